@@ -0,0 +1,64 @@
+package reedsolomon
+
+// Arithmetic in GF(2^8) with the reducing polynomial x^8+x^4+x^3+x^2+1 (0x11d)
+// and generator 2.
+
+const gfPolynomial = 0x11d
+
+var (
+	gfExp [510]byte
+	gfLog [256]int
+	// gfMulTable[a][b] = a*b, so that the inner loop of mulAdd is a single
+	// table lookup.
+	gfMulTable [256][256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= gfPolynomial
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			gfMulTable[a][b] = gfExp[gfLog[a]+gfLog[b]]
+		}
+	}
+}
+
+func gfMul(a byte, b byte) byte {
+	return gfMulTable[a][b]
+}
+
+// gfInv must not be called with 0.
+func gfInv(a byte) byte {
+	return gfExp[255-gfLog[a]]
+}
+
+func gfPow(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(gfLog[a]*n)%255]
+}
+
+// mulAdd sets dst[i] ^= c*src[i]. Addition in GF(2^8) is xor.
+func mulAdd(dst []byte, src []byte, c byte) {
+	if c == 0 {
+		return
+	}
+	table := &gfMulTable[c]
+	for i, s := range src[:len(dst)] {
+		dst[i] ^= table[s]
+	}
+}
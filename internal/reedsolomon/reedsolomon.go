@@ -0,0 +1,208 @@
+// Systematic Reed-Solomon erasure code over GF(2^8). Data is split into
+// dataShards equally sized shards, which are extended by parity shards to a
+// total of totalShards shards. Any dataShards of the shards suffice to
+// reconstruct the data.
+package reedsolomon
+
+import (
+	"fmt"
+)
+
+// MaxTotalShards is the maximum number of shards, bounded by the number of
+// distinct evaluation points in GF(2^8).
+const MaxTotalShards = 256
+
+type Code struct {
+	dataShards  int
+	totalShards int
+	// matrix is the totalShards x dataShards encoding matrix. Its top
+	// dataShards rows form the identity matrix, hence the code is systematic.
+	// Any dataShards rows of it are linearly independent.
+	matrix [][]byte
+}
+
+func New(dataShards int, totalShards int) (*Code, error) {
+	if !(0 < dataShards && dataShards <= totalShards && totalShards <= MaxTotalShards) {
+		return nil, fmt.Errorf("invalid shard counts, need 0 < dataShards (%d) <= totalShards (%d) <= %d", dataShards, totalShards, MaxTotalShards)
+	}
+
+	// Any dataShards rows of a Vandermonde matrix with distinct evaluation
+	// points are linearly independent. Multiplying by the inverse of the top
+	// square preserves this and makes the code systematic.
+	vandermonde := make([][]byte, totalShards)
+	for r := range vandermonde {
+		vandermonde[r] = make([]byte, dataShards)
+		for c := range vandermonde[r] {
+			vandermonde[r][c] = gfPow(byte(r), c)
+		}
+	}
+	topInverse, err := invertMatrix(vandermonde[:dataShards])
+	if err != nil {
+		return nil, fmt.Errorf("assumption violation: failed to invert vandermonde matrix: %w", err)
+	}
+	return &Code{dataShards, totalShards, multiplyMatrices(vandermonde, topInverse)}, nil
+}
+
+func (c *Code) DataShards() int {
+	return c.dataShards
+}
+
+func (c *Code) TotalShards() int {
+	return c.totalShards
+}
+
+// Encode computes the parity shards from the data shards. shards must contain
+// totalShards shards of equal length, the first dataShards of which hold the
+// data. The remaining shards are overwritten.
+func (c *Code) Encode(shards [][]byte) error {
+	shardLength, err := c.checkShards(shards, false)
+	if err != nil {
+		return err
+	}
+	for r := c.dataShards; r < c.totalShards; r++ {
+		clear(shards[r])
+		for i := 0; i < c.dataShards; i++ {
+			mulAdd(shards[r][:shardLength], shards[i], c.matrix[r][i])
+		}
+	}
+	return nil
+}
+
+// ReconstructData fills in missing data shards. Missing shards must be nil. At
+// least dataShards shards must be present, and all present shards must be of
+// equal length. Missing parity shards remain nil.
+func (c *Code) ReconstructData(shards [][]byte) error {
+	shardLength, err := c.checkShards(shards, true)
+	if err != nil {
+		return err
+	}
+
+	missingData := false
+	for i := 0; i < c.dataShards; i++ {
+		if shards[i] == nil {
+			missingData = true
+			break
+		}
+	}
+	if !missingData {
+		return nil
+	}
+
+	presentRows := make([]int, 0, c.dataShards)
+	for r := 0; r < c.totalShards && len(presentRows) < c.dataShards; r++ {
+		if shards[r] != nil {
+			presentRows = append(presentRows, r)
+		}
+	}
+	if len(presentRows) < c.dataShards {
+		return fmt.Errorf("too few shards present, have %d, need %d", len(presentRows), c.dataShards)
+	}
+
+	subMatrix := make([][]byte, 0, c.dataShards)
+	for _, r := range presentRows {
+		subMatrix = append(subMatrix, c.matrix[r])
+	}
+	decodeMatrix, err := invertMatrix(subMatrix)
+	if err != nil {
+		return fmt.Errorf("assumption violation: failed to invert encoding sub matrix: %w", err)
+	}
+
+	for i := 0; i < c.dataShards; i++ {
+		if shards[i] != nil {
+			continue
+		}
+		shard := make([]byte, shardLength)
+		for j, r := range presentRows {
+			mulAdd(shard, shards[r], decodeMatrix[i][j])
+		}
+		shards[i] = shard
+	}
+	return nil
+}
+
+func (c *Code) checkShards(shards [][]byte, allowMissing bool) (int, error) {
+	if len(shards) != c.totalShards {
+		return 0, fmt.Errorf("wrong number of shards, expected %d, got %d", c.totalShards, len(shards))
+	}
+	shardLength := -1
+	for i, shard := range shards {
+		if shard == nil {
+			if allowMissing || i >= c.dataShards {
+				continue
+			}
+			return 0, fmt.Errorf("data shard %d is missing", i)
+		}
+		if shardLength == -1 {
+			shardLength = len(shard)
+		} else if len(shard) != shardLength {
+			return 0, fmt.Errorf("shard %d has length %d, expected %d", i, len(shard), shardLength)
+		}
+	}
+	if !allowMissing {
+		for r := c.dataShards; r < c.totalShards; r++ {
+			if shards[r] == nil {
+				shards[r] = make([]byte, shardLength)
+			}
+		}
+	}
+	return max(shardLength, 0), nil
+}
+
+func multiplyMatrices(a [][]byte, b [][]byte) [][]byte {
+	result := make([][]byte, len(a))
+	for r := range a {
+		result[r] = make([]byte, len(b[0]))
+		for c := range result[r] {
+			var v byte
+			for i := range b {
+				v ^= gfMul(a[r][i], b[i][c])
+			}
+			result[r][c] = v
+		}
+	}
+	return result
+}
+
+// invertMatrix inverts a square matrix using Gauss-Jordan elimination.
+func invertMatrix(m [][]byte) ([][]byte, error) {
+	size := len(m)
+	// augmented matrix [m | I]
+	work := make([][]byte, size)
+	for r := range work {
+		work[r] = make([]byte, 2*size)
+		copy(work[r], m[r])
+		work[r][size+r] = 1
+	}
+
+	for col := 0; col < size; col++ {
+		pivot := -1
+		for r := col; r < size; r++ {
+			if work[r][col] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot == -1 {
+			return nil, fmt.Errorf("matrix is singular")
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+
+		if inv := gfInv(work[col][col]); inv != 1 {
+			for c := range work[col] {
+				work[col][c] = gfMul(work[col][c], inv)
+			}
+		}
+		for r := 0; r < size; r++ {
+			if r == col || work[r][col] == 0 {
+				continue
+			}
+			mulAdd(work[r], work[col], work[r][col])
+		}
+	}
+
+	inverse := make([][]byte, size)
+	for r := range inverse {
+		inverse[r] = work[r][size:]
+	}
+	return inverse, nil
+}
@@ -0,0 +1,334 @@
+// Package discovererdatabase provides persistent implementations of
+// [nettypes.DiscovererDatabase], backed either by a single file or by a Pebble
+// database.
+package discovererdatabase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/networking/ragedisco"
+	nettypes "github.com/smartcontractkit/libocr/networking/types"
+)
+
+const (
+	DefaultMaxAnnouncements             = 1000
+	DefaultGarbageCollectionGracePeriod = 24 * time.Hour
+)
+
+type Options struct {
+	// Maximum number of announcements stored. If the database is full,
+	// announcements of peers that are no longer in any group are evicted to
+	// make room for new ones. If there are none, storing fails. Defaults to
+	// DefaultMaxAnnouncements.
+	MaxAnnouncements int
+	// Announcements of peers that haven't been part of any group for this long
+	// are deleted. Defaults to DefaultGarbageCollectionGracePeriod.
+	GarbageCollectionGracePeriod time.Duration
+}
+
+func (o Options) getMaxAnnouncements() int {
+	if o.MaxAnnouncements <= 0 {
+		return DefaultMaxAnnouncements
+	}
+	return o.MaxAnnouncements
+}
+
+func (o Options) getGarbageCollectionGracePeriod() time.Duration {
+	if o.GarbageCollectionGracePeriod <= 0 {
+		return DefaultGarbageCollectionGracePeriod
+	}
+	return o.GarbageCollectionGracePeriod
+}
+
+// persistedRecord is what backends store for each peer ID.
+type persistedRecord struct {
+	Announcement []byte `json:"announcement"`
+	// Time at which the peer was first observed to not be part of any group,
+	// nil if it is part of a group.
+	UnreferencedSince *time.Time `json:"unreferencedSince,omitempty"`
+}
+
+type record struct {
+	persistedRecord
+	counter uint64
+}
+
+type backend interface {
+	load() (map[string]persistedRecord, error)
+	// persist must atomically apply the given upserts and deletions. all
+	// contains the complete contents of the database after the change.
+	persist(all map[string]persistedRecord, upserted map[string]persistedRecord, deleted []string) error
+	loadKeyRotations() ([]byte, error)
+	persistKeyRotations(keyRotations []byte) error
+	close() error
+}
+
+// DiscovererDatabase is a bounded, persistent [nettypes.DiscovererDatabase].
+// Only announcements that verify are stored, and announcements loaded from
+// the backend are verified again; invalid ones are dropped. An announcement
+// is never replaced by one with a lower counter.
+//
+// All its functions are thread-safe.
+type DiscovererDatabase struct {
+	backend backend
+	options Options
+	logger  commontypes.Logger
+
+	mutex   sync.Mutex
+	records map[string]record
+	closed  bool
+}
+
+var _ nettypes.DiscovererDatabase = (*DiscovererDatabase)(nil)
+var _ nettypes.DiscovererDatabaseGarbageCollector = (*DiscovererDatabase)(nil)
+var _ nettypes.DiscovererDatabaseKeyRotations = (*DiscovererDatabase)(nil)
+
+func newDiscovererDatabase(backend backend, options Options, logger commontypes.Logger) (*DiscovererDatabase, error) {
+	persisted, err := backend.load()
+	if err != nil {
+		_ = backend.close()
+		return nil, fmt.Errorf("failed to load announcements: %w", err)
+	}
+
+	records := make(map[string]record, len(persisted))
+	deleted := []string{}
+	for peerID, pr := range persisted {
+		counter, err := ragedisco.VerifySerializedAnnouncement(peerID, pr.Announcement)
+		if err != nil {
+			logger.Warn("DiscovererDatabase: dropping invalid stored announcement", commontypes.LogFields{
+				"peerID": peerID,
+				"error":  err,
+			})
+			deleted = append(deleted, peerID)
+			continue
+		}
+		records[peerID] = record{pr, counter}
+	}
+
+	d := &DiscovererDatabase{
+		backend,
+		options,
+		logger,
+		sync.Mutex{},
+		records,
+		false,
+	}
+
+	// The bound may have been lowered since the database was last written.
+	for len(d.records) > options.getMaxAnnouncements() {
+		deleted = append(deleted, d.lockedEvict(true))
+	}
+
+	if len(deleted) != 0 {
+		if err := d.lockedPersist(nil, deleted); err != nil {
+			_ = backend.close()
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (d *DiscovererDatabase) StoreAnnouncement(ctx context.Context, peerID string, ann []byte) error {
+	counter, err := ragedisco.VerifySerializedAnnouncement(peerID, ann)
+	if err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return fmt.Errorf("DiscovererDatabase is closed")
+	}
+
+	existing, exists := d.records[peerID]
+	if exists {
+		if counter < existing.counter || bytes.Equal(ann, existing.Announcement) {
+			return nil
+		}
+	}
+
+	snapshot := d.lockedSnapshot()
+	deleted := []string{}
+	if !exists && len(d.records) >= d.options.getMaxAnnouncements() {
+		evicted := d.lockedEvict(false)
+		if evicted == "" {
+			return fmt.Errorf("DiscovererDatabase is full (%v announcements) and no announcement can be evicted", len(d.records))
+		}
+		deleted = append(deleted, evicted)
+	}
+
+	r := record{persistedRecord{append([]byte(nil), ann...), existing.UnreferencedSince}, counter}
+	d.records[peerID] = r
+	if err := d.lockedPersist(map[string]persistedRecord{peerID: r.persistedRecord}, deleted); err != nil {
+		d.records = snapshot
+		return err
+	}
+	return nil
+}
+
+func (d *DiscovererDatabase) ReadAnnouncements(ctx context.Context, peerIDs []string) (map[string][]byte, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return nil, fmt.Errorf("DiscovererDatabase is closed")
+	}
+
+	result := map[string][]byte{}
+	for _, peerID := range peerIDs {
+		if r, ok := d.records[peerID]; ok {
+			result[peerID] = append([]byte(nil), r.Announcement...)
+		}
+	}
+	return result, nil
+}
+
+// CollectGarbage marks announcements of peers not in activePeerIDs as
+// unreferenced and deletes those that have been unreferenced for longer than
+// the GarbageCollectionGracePeriod.
+func (d *DiscovererDatabase) CollectGarbage(ctx context.Context, activePeerIDs []string) error {
+	active := make(map[string]struct{}, len(activePeerIDs))
+	for _, peerID := range activePeerIDs {
+		active[peerID] = struct{}{}
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return fmt.Errorf("DiscovererDatabase is closed")
+	}
+
+	snapshot := d.lockedSnapshot()
+	now := time.Now()
+	upserted := map[string]persistedRecord{}
+	deleted := []string{}
+	for peerID, r := range d.records {
+		_, isActive := active[peerID]
+		switch {
+		case isActive && r.UnreferencedSince != nil:
+			r.UnreferencedSince = nil
+		case isActive:
+			continue
+		case r.UnreferencedSince == nil:
+			r.UnreferencedSince = &now
+		case now.Sub(*r.UnreferencedSince) >= d.options.getGarbageCollectionGracePeriod():
+			delete(d.records, peerID)
+			deleted = append(deleted, peerID)
+			continue
+		default:
+			continue
+		}
+		d.records[peerID] = r
+		upserted[peerID] = r.persistedRecord
+	}
+
+	if len(upserted) == 0 && len(deleted) == 0 {
+		return nil
+	}
+	if err := d.lockedPersist(upserted, deleted); err != nil {
+		d.records = snapshot
+		return err
+	}
+	if len(deleted) != 0 {
+		d.logger.Info("DiscovererDatabase: deleted announcements of peers no longer in any group", commontypes.LogFields{
+			"peerIDs": deleted,
+		})
+	}
+	return nil
+}
+
+// StoreKeyRotations stores key rotations serialized by the discoverer. They
+// are verified again when they are loaded.
+func (d *DiscovererDatabase) StoreKeyRotations(ctx context.Context, keyRotations []byte) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return fmt.Errorf("DiscovererDatabase is closed")
+	}
+	if err := d.backend.persistKeyRotations(append([]byte(nil), keyRotations...)); err != nil {
+		return fmt.Errorf("failed to persist key rotations: %w", err)
+	}
+	return nil
+}
+
+func (d *DiscovererDatabase) ReadKeyRotations(ctx context.Context) ([]byte, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return nil, fmt.Errorf("DiscovererDatabase is closed")
+	}
+	return d.backend.loadKeyRotations()
+}
+
+func (d *DiscovererDatabase) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return fmt.Errorf("DiscovererDatabase already closed")
+	}
+	d.closed = true
+	return d.backend.close()
+}
+
+// lockedEvict removes the record of the peer that has been unreferenced the
+// longest and returns its peer ID. If force is set and no peer is
+// unreferenced, an arbitrary (but deterministic) peer is evicted instead.
+// Returns "" if nothing was evicted.
+func (d *DiscovererDatabase) lockedEvict(force bool) string {
+	peerIDs := make([]string, 0, len(d.records))
+	for peerID := range d.records {
+		peerIDs = append(peerIDs, peerID)
+	}
+	sort.Strings(peerIDs)
+
+	victim := ""
+	var victimSince *time.Time
+	for _, peerID := range peerIDs {
+		since := d.records[peerID].UnreferencedSince
+		if since == nil {
+			continue
+		}
+		if victimSince == nil || since.Before(*victimSince) {
+			victim, victimSince = peerID, since
+		}
+	}
+	if victim == "" && force && len(peerIDs) != 0 {
+		victim = peerIDs[0]
+	}
+	if victim != "" {
+		delete(d.records, victim)
+	}
+	return victim
+}
+
+// lockedSnapshot returns a copy of d.records that can be restored if
+// persisting a change fails.
+func (d *DiscovererDatabase) lockedSnapshot() map[string]record {
+	snapshot := make(map[string]record, len(d.records))
+	for peerID, r := range d.records {
+		snapshot[peerID] = r
+	}
+	return snapshot
+}
+
+func (d *DiscovererDatabase) lockedPersist(upserted map[string]persistedRecord, deleted []string) error {
+	all := make(map[string]persistedRecord, len(d.records))
+	for peerID, r := range d.records {
+		all[peerID] = r.persistedRecord
+	}
+	if err := d.backend.persist(all, upserted, deleted); err != nil {
+		return fmt.Errorf("failed to persist announcements: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package discovererdatabase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+)
+
+const fileFormatVersion = 1
+
+type fileContents struct {
+	Version       int                        `json:"version"`
+	Announcements map[string]persistedRecord `json:"announcements"`
+	KeyRotations  []byte                     `json:"keyRotations,omitempty"`
+}
+
+// NewFileDiscovererDatabase opens the DiscovererDatabase stored in the file at
+// path, creating it if it doesn't exist. The directory containing path must
+// exist and be writeable. The whole database is rewritten on every change,
+// using a temporary file and an atomic rename, so the file is never left in a
+// partially written state, even if the process crashes. The database requires
+// exclusive control of the file: external changes are forbidden.
+func NewFileDiscovererDatabase(path string, options Options, logger commontypes.Logger) (*DiscovererDatabase, error) {
+	return newDiscovererDatabase(&fileBackend{path, map[string]persistedRecord{}, nil}, options, logger)
+}
+
+type fileBackend struct {
+	path string
+
+	// The whole file is rewritten on every change, so we keep what we last
+	// wrote of either part.
+	announcements map[string]persistedRecord
+	keyRotations  []byte
+}
+
+var _ backend = &fileBackend{}
+
+func (f *fileBackend) load() (map[string]persistedRecord, error) {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]persistedRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var contents fileContents
+	if err := json.Unmarshal(raw, &contents); err != nil {
+		return nil, fmt.Errorf("could not parse %v: %w", f.path, err)
+	}
+	if contents.Version != fileFormatVersion {
+		return nil, fmt.Errorf("unsupported file format version %v in %v", contents.Version, f.path)
+	}
+	if contents.Announcements == nil {
+		contents.Announcements = map[string]persistedRecord{}
+	}
+	f.announcements = contents.Announcements
+	f.keyRotations = contents.KeyRotations
+	return contents.Announcements, nil
+}
+
+func (f *fileBackend) persist(all map[string]persistedRecord, _ map[string]persistedRecord, _ []string) error {
+	if err := f.write(fileContents{fileFormatVersion, all, f.keyRotations}); err != nil {
+		return err
+	}
+	f.announcements = all
+	return nil
+}
+
+func (f *fileBackend) loadKeyRotations() ([]byte, error) {
+	return f.keyRotations, nil
+}
+
+func (f *fileBackend) persistKeyRotations(keyRotations []byte) error {
+	if err := f.write(fileContents{fileFormatVersion, f.announcements, keyRotations}); err != nil {
+		return err
+	}
+	f.keyRotations = keyRotations
+	return nil
+}
+
+func (f *fileBackend) write(contents fileContents) error {
+	raw, err := json.Marshal(contents)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(raw); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return err
+	}
+	succeeded = true
+
+	// Make the rename durable.
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (f *fileBackend) close() error {
+	return nil
+}
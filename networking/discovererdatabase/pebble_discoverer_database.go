@@ -0,0 +1,110 @@
+package discovererdatabase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+)
+
+// NewPebbleDiscovererDatabase opens the DiscovererDatabase stored in the
+// Pebble database in directory dir, creating it if it doesn't exist. Changes
+// are written with fsync. The database requires exclusive control of the
+// directory: external changes are forbidden.
+func NewPebbleDiscovererDatabase(dir string, options Options, logger commontypes.Logger) (*DiscovererDatabase, error) {
+	db, err := pebble.Open(dir, &pebble.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return newDiscovererDatabase(&pebbleBackend{db}, options, logger)
+}
+
+// Peer IDs never start with a zero byte, so this key can't collide with an
+// announcement's.
+var pebbleKeyRotationsKey = []byte("\x00keyRotations")
+
+// Key rotations are wrapped in JSON like announcements, so that older versions
+// merely drop them as an invalid announcement.
+type pebbleKeyRotations struct {
+	KeyRotations []byte `json:"keyRotations"`
+}
+
+type pebbleBackend struct {
+	db *pebble.DB
+}
+
+var _ backend = &pebbleBackend{}
+
+func (p *pebbleBackend) load() (map[string]persistedRecord, error) {
+	iter, err := p.db.NewIter(&pebble.IterOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	records := map[string]persistedRecord{}
+	for iter.First(); iter.Valid(); iter.Next() {
+		if bytes.Equal(iter.Key(), pebbleKeyRotationsKey) {
+			continue
+		}
+		var pr persistedRecord
+		if err := json.Unmarshal(iter.Value(), &pr); err != nil {
+			return nil, fmt.Errorf("could not parse record for peer %v: %w", string(iter.Key()), err)
+		}
+		records[string(iter.Key())] = pr
+	}
+	return records, iter.Error()
+}
+
+func (p *pebbleBackend) persist(_ map[string]persistedRecord, upserted map[string]persistedRecord, deleted []string) error {
+	batch := p.db.NewBatch()
+	defer batch.Close()
+
+	for peerID, pr := range upserted {
+		value, err := json.Marshal(pr)
+		if err != nil {
+			return err
+		}
+		if err := batch.Set([]byte(peerID), value, nil); err != nil {
+			return err
+		}
+	}
+	for _, peerID := range deleted {
+		if err := batch.Delete([]byte(peerID), nil); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+func (p *pebbleBackend) loadKeyRotations() ([]byte, error) {
+	value, closer, err := p.db.Get(pebbleKeyRotationsKey)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	var pkr pebbleKeyRotations
+	if err := json.Unmarshal(value, &pkr); err != nil {
+		return nil, fmt.Errorf("could not parse key rotations: %w", err)
+	}
+	return pkr.KeyRotations, nil
+}
+
+func (p *pebbleBackend) persistKeyRotations(keyRotations []byte) error {
+	value, err := json.Marshal(pebbleKeyRotations{keyRotations})
+	if err != nil {
+		return err
+	}
+	return p.db.Set(pebbleKeyRotationsKey, value, pebble.Sync)
+}
+
+func (p *pebbleBackend) close() error {
+	return p.db.Close()
+}
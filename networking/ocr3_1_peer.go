@@ -1,8 +1,12 @@
 package networking
 
 import (
+	"time"
+
 	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	ragetypes "github.com/smartcontractkit/libocr/ragep2p/types"
 )
 
 var _ types.BinaryNetworkEndpoint2Factory = &ocr3_1BinaryNetworkEndpointFactory{}
@@ -26,3 +30,20 @@ func (o *ocr3_1BinaryNetworkEndpointFactory) NewEndpoint(
 		lowPriorityConfig,
 	)
 }
+
+var _ ocr3_1types.PeerLatencyHints = &ocr3_1PeerLatencyHints{}
+
+type ocr3_1PeerLatencyHints struct {
+	*concretePeerV2
+}
+
+// RoundTripLatency returns the latency measured by rageping. Peers are only
+// measured while an endpoint or bootstrapper for a config containing them
+// exists.
+func (o *ocr3_1PeerLatencyHints) RoundTripLatency(peerID string) (time.Duration, bool) {
+	var id ragetypes.PeerID
+	if err := id.UnmarshalText([]byte(peerID)); err != nil {
+		return 0, false
+	}
+	return o.latencyMetricsService.RoundTripLatency(id)
+}
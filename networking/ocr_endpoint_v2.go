@@ -99,7 +99,7 @@ func newOCREndpointV2(
 		peerMapping[commontypes.OracleID(i)] = peerID
 	}
 	reversedPeerMapping := reverseMappingV2(peerMapping)
-	ownOracleID, ok := reversedPeerMapping[peer.peerID]
+	ownOracleID, host, ok := peer.ownOracle(reversedPeerMapping)
 	if !ok {
 		return nil, fmt.Errorf("host peer ID %s is not present in given peerMapping", peer.PeerID())
 	}
@@ -126,7 +126,7 @@ func newOCREndpointV2(
 		peerIDs,
 		peerMapping,
 		reversedPeerMapping,
-		peer.host,
+		host,
 		configDigest,
 		ownOracleID,
 		chSendToSelf,
@@ -82,10 +82,14 @@ func newOCREndpointV3(
 		peerMapping[commontypes.OracleID(i)] = peerID
 	}
 	reversedPeerMapping := reverseMappingV2(peerMapping)
-	ownOracleID, ok := reversedPeerMapping[peer.peerID]
+	ownOracleID, ownHost, ok := peer.ownOracle(reversedPeerMapping)
 	if !ok {
 		return nil, fmt.Errorf("host peer ID %s is not present in given peerMapping", peer.PeerID())
 	}
+	// Key rotation only keeps a previous host for the legacy networking stack
+	if ownHost != peer.host {
+		return nil, fmt.Errorf("host peer ID %s is not present in given peerMapping, only the previous peer ID is, which OCREndpointV3 does not support", peer.PeerID())
+	}
 
 	chSendToSelf := make(chan ocr2types.InboundBinaryMessageWithSender, sendToSelfBufferSize)
 
@@ -168,6 +172,8 @@ func (o *ocrEndpointV3) start() error {
 					o.lowPriorityConfig.BytesRatePerOracle,
 					uint32(o.lowPriorityConfig.BytesCapacityPerOracle),
 				},
+				1,
+				ragep2pnew.CompressionNone,
 			},
 		)
 		if err != nil {
@@ -190,6 +196,8 @@ func (o *ocrEndpointV3) start() error {
 					o.defaultPriorityConfig.BytesRatePerOracle,
 					uint32(o.defaultPriorityConfig.BytesCapacityPerOracle),
 				},
+				1,
+				ragep2pnew.CompressionNone,
 			},
 		)
 		if err != nil {
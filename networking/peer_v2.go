@@ -4,6 +4,8 @@ import (
 	"crypto/ed25519"
 	"errors"
 	"fmt"
+	"net"
+	"slices"
 	"sync"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	ocr2types "github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 	"github.com/smartcontractkit/libocr/ragep2p"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew"
+	"github.com/smartcontractkit/libocr/ragep2p/relay"
 	ragetypes "github.com/smartcontractkit/libocr/ragep2p/types"
 )
 
@@ -51,8 +54,62 @@ type PeerConfig struct {
 	// Dial attempts will be at least V2DeltaDial apart.
 	V2DeltaDial time.Duration
 
+	// V2EnableQUIC makes the peer additionally accept QUIC connections on UDP
+	// at V2ListenAddresses, announce this through discovery, and prefer QUIC
+	// when dialing peers that announce it too. Other peers are dialed via TCP.
+	// Only supported by the experimental ragep2p stack, ignored otherwise.
+	V2EnableQUIC bool
+
+	// V2Dialer is used for outgoing connections, e.g. to connect through a
+	// SOCKS5 or HTTP CONNECT proxy (see package ragep2p/proxydialer). If nil,
+	// peers are dialed directly.
+	V2Dialer ragetypes.Dialer
+
+	// V2Relays are relays, e.g. bootstrappers, at which the peer holds
+	// reservations so that other peers can connect to it through them. Use this
+	// if the peer can't accept inbound connections. The peer announces the
+	// corresponding relay addresses in addition to its announce addresses.
+	// Peers running versions without relay support reject such announcements,
+	// so only set this once all peers of interest have been upgraded. Relays
+	// never see plaintext, connections remain end-to-end encrypted and
+	// authenticated.
+	V2Relays []ragetypes.PeerInfo
+
+	// V2RelayListenAddresses makes the peer act as a relay for other peers
+	// that list it in their V2Relays, listening on these addresses in
+	// <ip>:<port> form. They must differ from V2ListenAddresses.
+	V2RelayListenAddresses []string
+
+	// V2StreamPriorityWeights determines how the bandwidth to each peer is
+	// shared between stream priority classes. If nil,
+	// ragep2pnew.DefaultPriorityWeights() is used. Only supported by the
+	// experimental ragep2p stack, ignored otherwise.
+	V2StreamPriorityWeights ragep2pnew.PriorityWeights
+
+	// V2PreviousPeerKeyring is set while rotating the peer's key. It holds the
+	// old key, PeerKeyring holds the new one. Until
+	// V2PreviousPeerKeyringValidUntil, the peer announces a key rotation signed
+	// with both keys and remains reachable under both PeerIDs, and endpoints
+	// accept configs listing either PeerID for this peer. Other peers only
+	// accept the key rotation while one of their configs lists the old PeerID
+	// for this peer, and need to run a version with key rotation support to
+	// accept the new PeerID; the next config should list the new PeerID and
+	// take effect before the rotation expires. Only supported by the default
+	// ragep2p stack.
+	V2PreviousPeerKeyring           ragetypes.PeerKeyring
+	V2PreviousPeerKeyringValidUntil time.Time
+
 	V2DiscovererDatabase nettypes.DiscovererDatabase
 
+	// V2DiscoverySeeds are optional address hints for oracles, e.g. the
+	// addresses from the announcements of a previous config. They let the
+	// peer find the oracles of its configs if all bootstrappers are down and
+	// V2DiscovererDatabase doesn't know them either. Connecting to a single
+	// oracle suffices, since oracles exchange the announcements of all
+	// oracles of a config among each other. Hints are only used for oracles
+	// whose announcement is unknown.
+	V2DiscoverySeeds []ragetypes.PeerInfo
+
 	V2EndpointConfig EndpointConfigV2
 
 	MetricsRegisterer prometheus.Registerer
@@ -80,12 +137,16 @@ func (c *PeerConfig) keyring() (ragetypes.PeerKeyring, error) {
 // concretePeerV2 represents a ragep2p peer with one peer ID listening on one port
 type concretePeerV2 struct {
 	peerID                ragetypes.PeerID
+	previousPeerID        *ragetypes.PeerID // nil unless rotating our key
+	previousValidUntil    time.Time
 	host                  ragep2pwrapper.Host
+	previousHost          ragep2pwrapper.Host // nil unless rotating our key
 	discoverer            *ragedisco.Ragep2pDiscoverer
 	metricsRegisterer     prometheus.Registerer
 	logger                loghelper.LoggerWithContext
 	endpointConfig        EndpointConfigV2
 	latencyMetricsService rageping.LatencyMetricsService
+	relayServer           *relay.Server // nil unless the peer acts as a relay
 }
 
 // Users are expected to create (using the OCR*Factory() methods) and close endpoints and bootstrappers before calling
@@ -98,6 +159,15 @@ func NewPeer(c PeerConfig) (*concretePeerV2, error) {
 
 	peerID := ragetypes.PeerIDFromKeyring(keyring)
 
+	var previousPeerID *ragetypes.PeerID
+	if c.V2PreviousPeerKeyring != nil {
+		if c.EnableExperimentalRageP2P == DangerDangerEnableExperimentalRageP2P {
+			return nil, fmt.Errorf("V2PreviousPeerKeyring is not supported by the experimental ragep2p stack")
+		}
+		id := ragetypes.PeerIDFromKeyring(c.V2PreviousPeerKeyring)
+		previousPeerID = &id
+	}
+
 	logger := loghelper.MakeRootLoggerWithContext(c.Logger).MakeChild(commontypes.LogFields{
 		"id":     "PeerV2",
 		"peerID": peerID.String(),
@@ -114,13 +184,59 @@ func NewPeer(c PeerConfig) (*concretePeerV2, error) {
 		announceAddresses = c.V2ListenAddresses
 	}
 
+	succeeded := false
+
+	var relayServer *relay.Server
+	if len(c.V2RelayListenAddresses) != 0 {
+		relayServer, err = relay.NewServer(keyring, c.V2RelayListenAddresses, c.Logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct relay server: %w", err)
+		}
+		if err := relayServer.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start relay server: %w", err)
+		}
+		defer func() {
+			if !succeeded {
+				relayServer.Close()
+			}
+		}()
+	}
+
+	var relayListener *relay.Listener
+	if len(c.V2Relays) != 0 {
+		relayListener, err = relay.Listen(c.V2Relays, keyring, c.V2Dialer, c.Logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen via relays: %w", err)
+		}
+		defer func() {
+			if !succeeded {
+				relayListener.Close()
+			}
+		}()
+		// Relay addresses go first so that they aren't dropped if there are
+		// more announce addresses than fit into an announcement.
+		var relayAddresses []string
+		for _, a := range relayListener.Addresses() {
+			relayAddresses = append(relayAddresses, string(a))
+		}
+		announceAddresses = append(relayAddresses, announceAddresses...)
+	}
+
 	metricsRegistererWrapper := metricshelper.NewPrometheusRegistererWrapper(c.MetricsRegisterer, c.Logger)
 
-	discoverer := ragedisco.NewRagep2pDiscoverer(c.V2DeltaReconcile, announceAddresses, c.V2DiscovererDatabase, metricsRegistererWrapper)
-	var host ragep2pwrapper.Host
+	discoverer := ragedisco.NewRagep2pDiscoverer(
+		c.V2DeltaReconcile,
+		announceAddresses,
+		c.V2DiscoverySeeds,
+		c.V2PreviousPeerKeyring,
+		c.V2PreviousPeerKeyringValidUntil,
+		c.V2DiscovererDatabase,
+		metricsRegistererWrapper,
+	)
+	var host, previousHost ragep2pwrapper.Host
 	if c.EnableExperimentalRageP2P == DangerDangerEnableExperimentalRageP2P {
 		h, err := ragep2pnew.NewHost(
-			ragep2pnew.HostConfig{c.V2DeltaDial},
+			ragep2pnew.HostConfig{c.V2DeltaDial, c.V2EnableQUIC, c.V2Dialer, relayListenerOrNil(relayListener), c.V2StreamPriorityWeights},
 			keyring,
 			c.V2ListenAddresses,
 			discoverer,
@@ -133,7 +249,13 @@ func NewPeer(c PeerConfig) (*concretePeerV2, error) {
 		host = ragep2pnew.Wrapped(h)
 	} else {
 		h, err := ragep2p.NewHost(
-			ragep2p.HostConfig{c.V2DeltaDial},
+			ragep2p.HostConfig{
+				c.V2DeltaDial,
+				c.V2Dialer,
+				relayListenerOrNil(relayListener),
+				c.V2PreviousPeerKeyring,
+				c.V2PreviousPeerKeyringValidUntil,
+			},
 			keyring,
 			c.V2ListenAddresses,
 			discoverer,
@@ -144,6 +266,9 @@ func NewPeer(c PeerConfig) (*concretePeerV2, error) {
 			return nil, fmt.Errorf("failed to construct ragep2pnew host: %w", err)
 		}
 		host = ragep2p.Wrapped(h)
+		if previousPeerID != nil {
+			previousHost = ragep2p.WrappedPreviousIdentity(h)
+		}
 	}
 	err = host.Start()
 	if err != nil {
@@ -152,21 +277,35 @@ func NewPeer(c PeerConfig) (*concretePeerV2, error) {
 
 	logger.Info("PeerV2: ragep2p host booted", nil)
 
+	succeeded = true
+
 	latencyMetricsService := rageping.NewLatencyMetricsService(
 		host, metricsRegistererWrapper, logger, c.LatencyMetricsServiceConfigs,
 	)
 
 	return &concretePeerV2{
 		peerID,
+		previousPeerID,
+		c.V2PreviousPeerKeyringValidUntil,
 		host,
+		previousHost,
 		discoverer,
 		metricsRegistererWrapper,
 		logger,
 		c.V2EndpointConfig,
 		latencyMetricsService,
+		relayServer,
 	}, nil
 }
 
+// relayListenerOrNil avoids passing a typed nil to HostConfig.RelayListener.
+func relayListenerOrNil(l *relay.Listener) net.Listener {
+	if l == nil {
+		return nil
+	}
+	return l
+}
+
 // An endpointRegistration is held by an endpoint which services a particular configDigest. The invariant is that only
 // there can be at most a single active (ie. not closed) endpointRegistration for some configDigest, and thus only at
 // most one endpoint can service a particular configDigest at any given point in time. The endpoint is responsible for
@@ -198,7 +337,15 @@ func (p2 *concretePeerV2) register(configDigest ocr2types.ConfigDigest, oracles
 		bootstrappersIDs = append(bootstrappersIDs, b.ID)
 	}
 
-	p2.latencyMetricsService.RegisterPeers(oracles)
+	// The latency metrics service skips our PeerID, but not our previous one
+	pingedOracles := oracles
+	if p2.previousPeerID != nil {
+		pingedOracles = slices.DeleteFunc(slices.Clone(oracles), func(id ragetypes.PeerID) bool {
+			return id == *p2.previousPeerID
+		})
+	}
+
+	p2.latencyMetricsService.RegisterPeers(pingedOracles)
 	p2.latencyMetricsService.RegisterPeers(bootstrappersIDs)
 
 	return newEndpointRegistration(func() error {
@@ -206,7 +353,7 @@ func (p2 *concretePeerV2) register(configDigest ocr2types.ConfigDigest, oracles
 		// By the time concretePeerV2.Close() is called all endpoints/bootstrappers should have already been closed.
 		// Even if this weren't true, RemoveGroup() is a no-op if the discoverer is closed.
 
-		p2.latencyMetricsService.UnregisterPeers(oracles)
+		p2.latencyMetricsService.UnregisterPeers(pingedOracles)
 		p2.latencyMetricsService.UnregisterPeers(bootstrappersIDs)
 
 		return p2.discoverer.RemoveGroup(configDigest)
@@ -217,9 +364,28 @@ func (p2 *concretePeerV2) PeerID() string {
 	return p2.peerID.String()
 }
 
+// ownOracle looks up our oracle ID in a config. While our key rotation is
+// valid, the config may list our previous PeerID instead of our PeerID. The
+// returned host must be used for the streams of the config, so that we
+// identify ourselves by the PeerID the config lists.
+func (p2 *concretePeerV2) ownOracle(reversedPeerMapping map[ragetypes.PeerID]commontypes.OracleID) (commontypes.OracleID, ragep2pwrapper.Host, bool) {
+	if oid, ok := reversedPeerMapping[p2.peerID]; ok {
+		return oid, p2.host, true
+	}
+	if p2.previousPeerID != nil && time.Now().Before(p2.previousValidUntil) {
+		oid, ok := reversedPeerMapping[*p2.previousPeerID]
+		return oid, p2.previousHost, ok
+	}
+	return 0, nil, false
+}
+
 func (p2 *concretePeerV2) Close() error {
 	p2.latencyMetricsService.Close()
-	return p2.host.Close()
+	err := p2.host.Close()
+	if p2.relayServer != nil {
+		p2.relayServer.Close()
+	}
+	return err
 }
 func decodev2Bootstrappers(v2bootstrappers []commontypes.BootstrapperLocator) (infos []ragetypes.PeerInfo, err error) {
 	for _, b := range v2bootstrappers {
@@ -373,6 +539,16 @@ func (p2 *concretePeerV2) OCR3_1BinaryNetworkEndpointFactory() *ocr3_1BinaryNetw
 	return &ocr3_1BinaryNetworkEndpointFactory{p2}
 }
 
+func (p2 *concretePeerV2) OCR3_1PeerLatencyHints() *ocr3_1PeerLatencyHints {
+	return &ocr3_1PeerLatencyHints{p2}
+}
+
+// LatencyMatrix exposes the latencies that rageping measures to the peers of
+// all registered configs.
+func (p2 *concretePeerV2) LatencyMatrix() rageping.LatencyMatrix {
+	return p2.latencyMetricsService
+}
+
 func (p2 *concretePeerV2) OCR1BootstrapperFactory() *ocr1BootstrapperFactory {
 	return &ocr1BootstrapperFactory{p2}
 }
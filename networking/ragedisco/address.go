@@ -6,6 +6,7 @@ import (
 
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/networking/ragedisco/autodetect"
+	"github.com/smartcontractkit/libocr/ragep2p/relay"
 	ragetypes "github.com/smartcontractkit/libocr/ragep2p/types"
 )
 
@@ -19,6 +20,11 @@ func combinedAnnounceAddrs(logger commontypes.Logger, addrStrs []string, autodet
 	var addrs []ragetypes.Address
 	ifaceV4, ifaceV6, autodetectErr := autodetectFunc()
 	for _, addrStr := range addrStrs {
+		if relay.IsAddress(ragetypes.Address(addrStr)) {
+			// Relay addresses are announced as is, they are checked below
+			addrs = append(addrs, ragetypes.Address(addrStr))
+			continue
+		}
 		addrPort, err := parseAddrPortForAnnouncement(addrStr)
 		if err != nil {
 			logger.Critical("Invalid announce address provided", commontypes.LogFields{"address": addrStr, "error": err})
@@ -124,9 +130,17 @@ func parseAddrPortForAnnouncement(s string) (netip.AddrPort, error) {
 	return parseAddrPortForAnnouncementNoSizeLimit(s)
 }
 
-// isValidForAnnouncement checks that the provided address is in the form ip:port.
-// Hostnames or domain names are not allowed.
+// isValidForAnnouncement checks that the provided address is in the form ip:port,
+// or is a relay address whose relay is at ip:port. Hostnames or domain names are
+// not allowed.
 func isValidForAnnouncement(a ragetypes.Address) bool {
+	if relay.IsAddress(a) {
+		_, relayAddress, err := relay.ParseAddress(a)
+		if err != nil {
+			return false
+		}
+		a = ragetypes.Address(relayAddress)
+	}
 	_, err := parseAddrPortForAnnouncement(string(a))
 	return err == nil
 }
@@ -14,8 +14,9 @@ import (
 )
 
 type unsignedAnnouncement struct {
-	Addrs   []ragetypes.Address // addresses of a peer
-	Counter uint64              // counter
+	Addrs       []ragetypes.Address // addresses of a peer
+	Counter     uint64              // counter
+	AcceptsQUIC bool                // whether the peer accepts QUIC connections
 }
 
 // Announcement is a signed message in which a peer attests to their network
@@ -25,10 +26,12 @@ type Announcement struct {
 	unsignedAnnouncement
 	PublicKey ed25519.PublicKey // PublicKey used to verify Sig
 	Sig       []byte            // sig over unsignedAnnouncement
+	QUICSig   []byte            // sig over quicDigest(), nil unless AcceptsQUIC
 }
 
 type reconcile struct {
-	Anns []Announcement
+	Anns      []Announcement
+	Rotations []keyRotation
 }
 
 const (
@@ -36,13 +39,16 @@ const (
 	maxAddrsInAnnouncement = 10
 	// Domain separator for signatures
 	announcementDomainSeparator = "announcement for chainlink peer discovery v2.0.0"
+	// Domain separator for QUIC signatures
+	announcementQUICDomainSeparator = "quic announcement for chainlink peer discovery v1.0.0"
 	// Maximum message size over all message types. The worst case message is a
 	// reconcile message with the maximum number of announcements (one per
 	// oracle, capped at MaxOracles), with each announcement containing the
 	// maximum number (maxAddrsInAnnouncement) of the maximum length addresses
-	// (maxAddrPortValidForAnnouncementSize). We have a test which asserts this
-	// bound.
-	maxMessageLength = 110_000
+	// (maxAddrPortValidForAnnouncementSize) and a QUIC signature, and the
+	// maximum number of key rotations (again one per oracle). We have a test
+	// which asserts this bound.
+	maxMessageLength = 157_000
 )
 
 // Does NOT check if the announcement is well-formed.
@@ -66,6 +72,7 @@ func (ann Announcement) toProto() (*serialization.SignedAnnouncement, error) {
 		Counter:   ann.Counter,
 		PublicKey: ann.PublicKey,
 		Sig:       ann.Sig,
+		QuicSig:   ann.QUICSig,
 	}
 	return &pm, nil
 }
@@ -93,6 +100,9 @@ func (ann Announcement) checkWellFormed() error {
 	if ann.Sig == nil {
 		return fmt.Errorf("nil sig")
 	}
+	if ann.AcceptsQUIC != (ann.QUICSig != nil) {
+		return fmt.Errorf("QUIC sig must be present iff the announcement accepts QUIC")
+	}
 	return nil
 }
 
@@ -107,6 +117,14 @@ func (r reconcile) checkWellFormed() error {
 			return fmt.Errorf("failed to validate announcement %v with index %d in reconcile: %w", ann, i, err)
 		}
 	}
+	if len(r.Rotations) > MaxOracles {
+		return fmt.Errorf("unexpectedly many key rotations (expect at most %d, actual %d)", MaxOracles, len(r.Rotations))
+	}
+	for i, kr := range r.Rotations {
+		if err := kr.checkWellFormed(); err != nil {
+			return fmt.Errorf("failed to validate key rotation %v with index %d in reconcile: %w", kr, i, err)
+		}
+	}
 	return nil
 }
 
@@ -120,9 +138,11 @@ func signedAnnouncementFromProto(pm *serialization.SignedAnnouncement) (Announce
 		unsignedAnnouncement{
 			addrs,
 			pm.Counter,
+			len(pm.QuicSig) != 0,
 		},
 		pm.PublicKey,
 		pm.Sig,
+		pm.QuicSig,
 	}
 	return ann, nil
 }
@@ -136,6 +156,29 @@ func deserializeSignedAnnouncement(binary []byte) (Announcement, error) {
 	return signedAnnouncementFromProto(&pm)
 }
 
+// VerifySerializedAnnouncement checks that serializedAnnouncement is a
+// well-formed announcement carrying a valid signature by peerID and returns its
+// counter. Announcements with higher counters supersede those with lower ones.
+// This is meant for DiscovererDatabase implementations that verify what they
+// store.
+func VerifySerializedAnnouncement(peerID string, serializedAnnouncement []byte) (uint64, error) {
+	ann, err := deserializeSignedAnnouncement(serializedAnnouncement)
+	if err != nil {
+		return 0, fmt.Errorf("failed to deserialize announcement: %w", err)
+	}
+	if err := ann.verify(); err != nil {
+		return 0, fmt.Errorf("failed to verify announcement: %w", err)
+	}
+	annPeerID, err := ann.PeerID()
+	if err != nil {
+		return 0, err
+	}
+	if annPeerID.String() != peerID {
+		return 0, fmt.Errorf("announcement is by peer %s, expected %s", annPeerID, peerID)
+	}
+	return ann.Counter, nil
+}
+
 func (ann Announcement) PeerID() (ragetypes.PeerID, error) {
 	return ragetypes.PeerIDFromPublicKey(ann.PublicKey)
 }
@@ -147,15 +190,19 @@ func (ann Announcement) String() string {
 	} else {
 		identityPart = fmt.Sprintf("InvalidPublicKey:%x", ann.PublicKey)
 	}
-	return fmt.Sprintf("{%s Counter:%d Addrs:%s Sig:%s}",
+	return fmt.Sprintf("{%s Counter:%d Addrs:%s AcceptsQUIC:%t Sig:%s}",
 		identityPart,
 		ann.Counter,
 		ann.Addrs,
+		ann.AcceptsQUIC,
 		base64.StdEncoding.EncodeToString(ann.Sig))
 }
 
 func (r reconcile) String() string {
-	return fmt.Sprintf("%s", r.Anns)
+	if len(r.Rotations) == 0 {
+		return fmt.Sprintf("%s", r.Anns)
+	}
+	return fmt.Sprintf("%s %s", r.Anns, r.Rotations)
 }
 
 // digest returns a deterministic digest used for signing
@@ -193,6 +240,22 @@ func (uann unsignedAnnouncement) digest() ([]byte, error) {
 	return hasher.Sum(nil), nil
 }
 
+// quicDigest returns the digest signed by QUICSig. AcceptsQUIC is attested by a
+// separate signature rather than by including it in digest(), so that peers
+// that don't know about QUIC can still verify Sig. Stripping QUICSig from an
+// announcement only makes other peers dial via TCP.
+func (uann unsignedAnnouncement) quicDigest() ([]byte, error) {
+	digest, err := uann.digest()
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	hasher.Write([]byte(announcementQUICDomainSeparator))
+	hasher.Write(digest)
+	return hasher.Sum(nil), nil
+}
+
 func (uann unsignedAnnouncement) sign(keyring ragetypes.PeerKeyring) (Announcement, error) {
 	digest, err := uann.digest()
 	if err != nil {
@@ -204,12 +267,25 @@ func (uann unsignedAnnouncement) sign(keyring ragetypes.PeerKeyring) (Announceme
 		return Announcement{}, fmt.Errorf("keyring Sign failed: %w", err)
 	}
 
+	var quicSig []byte
+	if uann.AcceptsQUIC {
+		quicDigest, err := uann.quicDigest()
+		if err != nil {
+			return Announcement{}, err
+		}
+		quicSig, err = keyring.Sign(quicDigest)
+		if err != nil {
+			return Announcement{}, fmt.Errorf("keyring Sign failed: %w", err)
+		}
+	}
+
 	epk := ragetypes.Ed25519PublicKeyFromPeerPublicKey(keyring.PublicKey())
 
 	return Announcement{
 		uann,
 		epk,
 		sig,
+		quicSig,
 	}, nil
 }
 
@@ -226,6 +302,16 @@ func (ann Announcement) verify() error {
 		return fmt.Errorf("invalid signature")
 	}
 
+	if ann.AcceptsQUIC {
+		quicMsg, err := ann.quicDigest()
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(ann.PublicKey, quicMsg, ann.QUICSig) {
+			return fmt.Errorf("invalid QUIC signature")
+		}
+	}
+
 	return nil
 }
 
@@ -239,8 +325,14 @@ func (r reconcile) toProto() (*serialization.Reconcile, error) {
 		serAnns[i] = protoAnn
 	}
 
+	serRotations := make([]*serialization.SignedKeyRotation, len(r.Rotations))
+	for i, kr := range r.Rotations {
+		serRotations[i] = kr.toProto()
+	}
+
 	ser := serialization.Reconcile{
-		Anns: serAnns,
+		Anns:      serAnns,
+		Rotations: serRotations,
 	}
 	return &ser, nil
 }
@@ -264,7 +356,11 @@ func reconcileFromProto(pr *serialization.Reconcile) (*reconcile, error) {
 		}
 		anns[i] = ann
 	}
-	return &reconcile{Anns: anns}, nil
+	rotations := make([]keyRotation, len(pr.Rotations))
+	for i, protoRotation := range pr.Rotations {
+		rotations[i] = keyRotationFromProto(protoRotation)
+	}
+	return &reconcile{Anns: anns, Rotations: rotations}, nil
 }
 
 func (ann Announcement) toProtoWrapped() (*serialization.MessageWrapper, error) {
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -51,6 +52,7 @@ type discoveryProtocolLocked struct {
 	bootstrappers           map[ragetypes.PeerID]map[ragetypes.Address]int
 	numGroupsByOracle       map[ragetypes.PeerID]int
 	numGroupsByBootstrapper map[ragetypes.PeerID]int
+	retiredAnnouncements    map[ragetypes.PeerID]retiredAnnouncement
 }
 
 type discoveryProtocol struct {
@@ -65,10 +67,23 @@ type discoveryProtocol struct {
 	keyring            ragetypes.PeerKeyring
 	ownID              ragetypes.PeerID
 	ownAddrs           []ragetypes.Address
+	ownAcceptsQUIC     bool
+	previousKeyring    ragetypes.PeerKeyring // nil unless we are rotating our key
+	ownRotation        *keyRotation          // nil unless we are rotating our key
+
+	// address hints for oracles, see seed.go
+	seeds map[ragetypes.PeerID][]ragetypes.Address
 
 	lock   sync.RWMutex
 	locked discoveryProtocolLocked
 
+	// rotationsMu is never held while blocking, so that the host can look up
+	// key rotations at any time. If both lock and rotationsMu are needed, lock
+	// must be acquired first.
+	rotationsMu          sync.Mutex
+	rotations            map[ragetypes.PeerID]keyRotation // keyed by old PeerID
+	conflictingRotations map[ragetypes.PeerID]struct{}    // old PeerIDs
+
 	db nettypes.DiscovererDatabase
 
 	processes subprocesses.Subprocesses
@@ -92,13 +107,32 @@ func newDiscoveryProtocol(
 	chOutgoingMessages chan<- outgoingMessage,
 	chConnectivity chan<- connectivityMsg,
 	keyring ragetypes.PeerKeyring,
+	previousKeyring ragetypes.PeerKeyring,
+	previousKeyringValidUntil time.Time,
 	ownAddrs []ragetypes.Address,
+	ownAcceptsQUIC bool,
+	seeds []ragetypes.PeerInfo,
 	db nettypes.DiscovererDatabase,
 	logger loghelper.LoggerWithContext,
 	metricsRegisterer prometheus.Registerer,
 ) (*discoveryProtocol, error) {
 	ownID := ragetypes.PeerIDFromKeyring(keyring)
 
+	rotations := make(map[ragetypes.PeerID]keyRotation)
+	var ownRotation *keyRotation
+	if previousKeyring != nil {
+		kr, err := unsignedKeyRotation{
+			ragetypes.Ed25519PublicKeyFromPeerPublicKey(previousKeyring.PublicKey()),
+			ragetypes.Ed25519PublicKeyFromPeerPublicKey(keyring.PublicKey()),
+			uint64(previousKeyringValidUntil.Unix()),
+		}.sign(previousKeyring, keyring)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign own key rotation: %w", err)
+		}
+		ownRotation = &kr
+		rotations[ragetypes.PeerIDFromKeyring(previousKeyring)] = kr
+	}
+
 	ctx, ctxCancel := context.WithCancel(context.Background())
 	return &discoveryProtocol{
 		sync.Mutex{},
@@ -111,6 +145,10 @@ func newDiscoveryProtocol(
 		keyring,
 		ownID,
 		ownAddrs,
+		ownAcceptsQUIC,
+		previousKeyring,
+		ownRotation,
+		seedAddrsByPeer(seeds),
 		sync.RWMutex{},
 		discoveryProtocolLocked{
 			make(map[ragetypes.PeerID]Announcement),
@@ -118,7 +156,11 @@ func newDiscoveryProtocol(
 			make(map[ragetypes.PeerID]map[ragetypes.Address]int),
 			make(map[ragetypes.PeerID]int),
 			make(map[ragetypes.PeerID]int),
+			make(map[ragetypes.PeerID]retiredAnnouncement),
 		},
+		sync.Mutex{},
+		rotations,
+		make(map[ragetypes.PeerID]struct{}),
 		db,
 		subprocesses.Subprocesses{},
 		ctx,
@@ -145,9 +187,18 @@ func (p *discoveryProtocol) Start() error {
 
 	p.lock.Lock()
 	defer p.lock.Unlock()
-	_, _, err := p.lockedBumpOwnAnnouncement()
-	if err != nil {
-		return fmt.Errorf("failed to bump own announcement: %w", err)
+	if p.ownRotation != nil {
+		p.logger.Info("DiscoveryProtocol: Rotating our key", commontypes.LogFields{"keyRotation": *p.ownRotation})
+	}
+	if err := p.loadKeyRotationsFromDB(); err != nil {
+		// db-level errors are not prohibitive
+		p.logger.Warn("DiscoveryProtocol: Failed to load key rotations from db", reason(err))
+	}
+	for _, keyring := range p.ownKeyrings() {
+		_, _, err := p.lockedBumpOwnAnnouncement(keyring)
+		if err != nil {
+			return fmt.Errorf("failed to bump own announcement: %w", err)
+		}
 	}
 	p.processes.Go(p.recvLoop)
 	p.processes.Go(p.sendLoop)
@@ -209,17 +260,23 @@ func (p *discoveryProtocol) statusReportLoop() {
 	}
 }
 
-// Peer A is allowed to learn about an Announcement by peer B if B is an oracle node in
-// one of the groups A participates in.
 func (p *discoveryProtocol) lockedAllowedPeers(ann Announcement) (ps []ragetypes.PeerID) {
 	annPeerID, err := ann.PeerID()
 	if err != nil {
 		p.logger.Warn("Failed to obtain peer id from announcement", reason(err))
 		return
 	}
+	return p.lockedAllowedPeersForOracle(annPeerID)
+}
+
+// Peer A is allowed to learn about an Announcement or key rotation by peer B if
+// B is an oracle node in one of the groups A participates in. During key
+// rotations, B may be listed under either of its PeerIDs.
+func (p *discoveryProtocol) lockedAllowedPeersForOracle(oracle ragetypes.PeerID) (ps []ragetypes.PeerID) {
+	oracleIDs := p.peerIDsOf(oracle)
 	peers := make(map[ragetypes.PeerID]struct{})
 	for _, g := range p.locked.groups {
-		if !g.hasOracle(annPeerID) {
+		if !slices.ContainsFunc(oracleIDs, g.hasOracle) {
 			continue
 		}
 		for _, pid := range g.peerIDs() {
@@ -227,7 +284,7 @@ func (p *discoveryProtocol) lockedAllowedPeers(ann Announcement) (ps []ragetypes
 		}
 	}
 	for pid := range peers {
-		if pid == p.ownID {
+		if p.isOwnID(pid) {
 			continue
 		}
 		ps = append(ps, pid)
@@ -297,6 +354,7 @@ func (p *discoveryProtocol) addGroup(digest types.ConfigDigest, onodes []ragetyp
 		// db-level errors are not prohibitive
 		p.logger.Warn("DiscoveryProtocol: Failed to load announcements from db", commontypes.LogFields{"configDigest": digest, "error": err})
 	}
+	p.lockedLoadRetired(newOraclePeerIDs)
 	return nil
 }
 
@@ -392,7 +450,34 @@ func (p *discoveryProtocol) saveLoop() {
 		if err := p.saveToDB(); err != nil {
 			logger.Warn("Failed to save announcements to db", reason(err))
 		}
+		if err := p.saveKeyRotationsToDB(); err != nil {
+			logger.Warn("Failed to save key rotations to db", reason(err))
+		}
+		if err := p.collectGarbageInDB(); err != nil {
+			logger.Warn("Failed to collect garbage in db", reason(err))
+		}
+	}
+}
+
+func (p *discoveryProtocol) collectGarbageInDB() error {
+	gc, ok := p.db.(nettypes.DiscovererDatabaseGarbageCollector)
+	if !ok {
+		return nil
+	}
+	p.lock.RLock()
+	// Without any groups, we have no information on which peers are still
+	// relevant.
+	if len(p.locked.groups) == 0 {
+		p.lock.RUnlock()
+		return nil
 	}
+	activePeerIDs := make([]string, 0, len(p.locked.numGroupsByOracle))
+	for pid := range p.locked.numGroupsByOracle {
+		activePeerIDs = append(activePeerIDs, pid.String())
+	}
+	p.lock.RUnlock()
+
+	return gc.CollectGarbage(p.ctx, activePeerIDs)
 }
 
 func (p *discoveryProtocol) removeGroup(digest types.ConfigDigest) error {
@@ -418,7 +503,10 @@ func (p *discoveryProtocol) removeGroup(digest types.ConfigDigest) error {
 					p.logger.Warn("Failed to save announcement from removed group to DB", reason(err))
 				}
 			}
-			if oid != p.ownID {
+			if !p.isOwnID(oid) {
+				if ann, exists := p.locked.bestAnnouncement[oid]; exists {
+					p.lockedRetireAnnouncement(oid, ann)
+				}
 				delete(p.locked.bestAnnouncement, oid)
 			}
 			delete(p.locked.numGroupsByOracle, oid)
@@ -444,15 +532,20 @@ func (p *discoveryProtocol) removeGroup(digest types.ConfigDigest) error {
 
 	// Cleanup connections for peers we don't have in any group anymore.
 	for _, pid := range goneGroup.peerIDs() {
-		if p.locked.numGroupsByOracle[pid]+p.locked.numGroupsByBootstrapper[pid] == 0 {
-			select {
-			case p.chConnectivity <- connectivityMsg{connectivityRemove, pid}:
-			case <-p.ctx.Done():
-				return nil
+		// During key rotations, we may also be connected to pid's other PeerID
+		for _, id := range p.peerIDsOf(pid) {
+			if p.locked.numGroupsByOracle[id]+p.locked.numGroupsByBootstrapper[id] == 0 {
+				select {
+				case p.chConnectivity <- connectivityMsg{connectivityRemove, id}:
+				case <-p.ctx.Done():
+					return nil
+				}
 			}
 		}
 	}
 
+	p.lockedCollectRotationGarbage()
+
 	return nil
 }
 
@@ -460,20 +553,43 @@ func (p *discoveryProtocol) FindPeer(peer ragetypes.PeerID) ([]ragetypes.Address
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 	var addrs []ragetypes.Address
+	// If the peer is rotating its key, it can be found under both PeerIDs
+	peerIDs := p.peerIDsOf(peer)
 	// The addresses we know from local configuration take priority — useful for overriding addresses in disaster
 	// scenarios
-	if baddrs, ok := p.locked.bootstrappers[peer]; ok {
-		for baddr := range baddrs {
-			addrs = append(addrs, baddr)
+	for _, pid := range peerIDs {
+		if baddrs, ok := p.locked.bootstrappers[pid]; ok {
+			for baddr := range baddrs {
+				addrs = append(addrs, baddr)
+			}
 		}
 	}
 	// Followed by the addresses obtained by the best announcement
-	if ann, ok := p.locked.bestAnnouncement[peer]; ok {
-		addrs = append(addrs, ann.Addrs...)
+	for _, pid := range peerIDs {
+		if ann, ok := p.locked.bestAnnouncement[pid]; ok {
+			addrs = append(addrs, ann.Addrs...)
+		}
 	}
+	// Followed by seed hints, until we have an announcement. Only needed until
+	// we have connected to one oracle of the group, reconciles take it from
+	// there.
+	addrs = append(addrs, p.lockedSeedAddrs(peer)...)
 	return dedup(addrs), nil
 }
 
+// AcceptsQUIC reports whether the best announcement of peer says that it
+// accepts QUIC connections.
+func (p *discoveryProtocol) AcceptsQUIC(peer ragetypes.PeerID) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, pid := range p.peerIDsOf(peer) {
+		if ann, ok := p.locked.bestAnnouncement[pid]; ok && ann.AcceptsQUIC {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *discoveryProtocol) recvLoop() {
 	logger := p.logger.MakeChild(commontypes.LogFields{"in": "recvLoop"})
 	logger.Debug("Entering", nil)
@@ -505,6 +621,24 @@ func (p *discoveryProtocol) recvLoop() {
 					})
 					break // select
 				}
+				// Process key rotations first, so that we accept announcements
+				// for new keys.
+				rotationsChanged := false
+				for _, kr := range reconcile.Rotations {
+					changed, err := p.processKeyRotation(kr)
+					rotationsChanged = rotationsChanged || changed
+					if err != nil {
+						logger.Warn("Failed to process key rotation from reconcile", commontypes.LogFields{
+							"keyRotation": kr,
+							"error":       err,
+						})
+					}
+				}
+				if rotationsChanged {
+					if err := p.saveKeyRotationsToDB(); err != nil {
+						logger.Warn("Failed to save key rotations to db", reason(err))
+					}
+				}
 				for _, ann := range reconcile.Anns {
 					if err := p.processAnnouncement(ann); err != nil {
 
@@ -542,7 +676,7 @@ func (p *discoveryProtocol) lockedProcessAnnouncement(ann Announcement) error {
 		return fmt.Errorf("failed to obtain peer id: %w", err)
 	}
 
-	if p.locked.numGroupsByOracle[pid] == 0 {
+	if p.lockedNumGroupsByOracle(pid) == 0 {
 		return fmt.Errorf("peer %s is not an oracle in any of our jobs; perhaps whoever sent this is running a job that includes us and this peer, but we are not running that job", pid)
 	}
 
@@ -552,12 +686,17 @@ func (p *discoveryProtocol) lockedProcessAnnouncement(ann Announcement) error {
 	}
 
 	if localann, exists := p.locked.bestAnnouncement[pid]; !exists || localann.Counter <= ann.Counter {
-		if exists && pid != p.ownID && localann.Counter == ann.Counter {
+		if exists && !p.isOwnID(pid) && localann.Counter == ann.Counter {
 			return nil
 		}
-		p.locked.bestAnnouncement[pid] = ann
-		if pid == p.ownID {
-			bumpedann, better, err := p.lockedBumpOwnAnnouncement()
+		if p.isOwnID(pid) {
+			keyring, ok := p.ownKeyrings()[pid]
+			if !ok {
+				// our previous key has expired, we don't announce it anymore
+				return nil
+			}
+			p.locked.bestAnnouncement[pid] = ann
+			bumpedann, better, err := p.lockedBumpOwnAnnouncement(keyring)
 			if err != nil {
 				return fmt.Errorf("failed to bump own announcement: %w", err)
 			}
@@ -573,6 +712,7 @@ func (p *discoveryProtocol) lockedProcessAnnouncement(ann Announcement) error {
 				return nil
 			}
 		} else {
+			p.locked.bestAnnouncement[pid] = ann
 			logger.Info("Received better announcement for peer", nil)
 			select {
 			case p.chConnectivity <- connectivityMsg{connectivityAdd, pid}:
@@ -613,6 +753,11 @@ func (p *discoveryProtocol) sendLoop() {
 		case <-tick:
 			logger.Debug("Starting reconciliation", nil)
 			reconcileByPeer := make(map[ragetypes.PeerID]*reconcile)
+			func() {
+				p.lock.Lock()
+				defer p.lock.Unlock()
+				p.lockedCollectRotationGarbage()
+			}()
 			func() {
 				p.lock.RLock()
 				defer p.lock.RUnlock()
@@ -625,6 +770,15 @@ func (p *discoveryProtocol) sendLoop() {
 						r.Anns = append(r.Anns, ann)
 					}
 				}
+				for _, kr := range p.validRotations() {
+					for _, pid := range p.lockedAllowedPeersForOracle(kr.toKeyRotation().OldPeerID) {
+						if _, exists := reconcileByPeer[pid]; !exists {
+							reconcileByPeer[pid] = &reconcile{Anns: []Announcement{}}
+						}
+						r := reconcileByPeer[pid]
+						r.Rotations = append(r.Rotations, kr)
+					}
+				}
 			}()
 
 			for pid, rec := range reconcileByPeer {
@@ -640,31 +794,33 @@ func (p *discoveryProtocol) sendLoop() {
 	}
 }
 
-// lockedBumpOwnAnnouncement requires lock to be held by the caller.
-func (p *discoveryProtocol) lockedBumpOwnAnnouncement() (*Announcement, bool, error) {
+// lockedBumpOwnAnnouncement bumps the announcement for the identity of keyring,
+// which must be one of ownKeyrings(). Requires lock to be held by the caller.
+func (p *discoveryProtocol) lockedBumpOwnAnnouncement(keyring ragetypes.PeerKeyring) (*Announcement, bool, error) {
 	logger := p.logger.MakeChild(commontypes.LogFields{"in": "lockedBumpOwnAnnouncement"})
-	oldann, exists := p.locked.bestAnnouncement[p.ownID]
+	id := ragetypes.PeerIDFromKeyring(keyring)
+	oldann, exists := p.locked.bestAnnouncement[id]
 	newctr := uint64(0)
 
 	if exists {
-		if equalAddrs(oldann.Addrs, p.ownAddrs) {
+		if equalAddrs(oldann.Addrs, p.ownAddrs) && oldann.AcceptsQUIC == p.ownAcceptsQUIC {
 			return nil, false, nil
 		}
 		// Counter is uint64, and it only changes when a peer's
-		// addresses change. We assume a peer will not change addresses
-		// more than 2**64 times.
+		// addresses or QUIC support change. We assume a peer will not
+		// change these more than 2**64 times.
 		newctr = oldann.Counter + 1
 	}
-	newann := unsignedAnnouncement{Addrs: p.ownAddrs, Counter: newctr}
+	newann := unsignedAnnouncement{Addrs: p.ownAddrs, Counter: newctr, AcceptsQUIC: p.ownAcceptsQUIC}
 	if newctr > announcementVersionWarnThreshold {
 		logger.Warn("New announcement version too big!", commontypes.LogFields{"announcement": newann})
 	}
-	sann, err := newann.sign(p.keyring)
+	sann, err := newann.sign(keyring)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to sign own announcement: %w", err)
 	}
 	logger.Info("DiscoveryProtocol: Replacing our own announcement", commontypes.LogFields{"announcement": sann})
-	p.locked.bestAnnouncement[p.ownID] = sann
+	p.locked.bestAnnouncement[id] = sann
 	return &sann, true, nil
 }
 
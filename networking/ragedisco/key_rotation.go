@@ -0,0 +1,455 @@
+package ragedisco
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/networking/ragedisco/serialization"
+	nettypes "github.com/smartcontractkit/libocr/networking/types"
+	ragetypes "github.com/smartcontractkit/libocr/ragep2p/types"
+	"google.golang.org/protobuf/proto"
+)
+
+type unsignedKeyRotation struct {
+	OldPublicKey ed25519.PublicKey // key that is being rotated out
+	NewPublicKey ed25519.PublicKey // key that replaces OldPublicKey
+	ValidUntil   uint64            // unix timestamp (in seconds) at which the old key stops being valid
+}
+
+// keyRotation is a message in which a peer endorses a new key with its old key,
+// and the new key endorses the old one in turn. Until ValidUntil, other peers
+// accept either key for the peer, regardless of which PeerID their groups list
+// it under.
+type keyRotation struct {
+	unsignedKeyRotation
+	Sig    []byte // sig over unsignedKeyRotation by OldPublicKey
+	NewSig []byte // sig over unsignedKeyRotation by NewPublicKey
+}
+
+// Domain separator for key rotation signatures
+const keyRotationDomainSeparator = "key rotation for chainlink peer discovery v1.0.0"
+
+func (ukr unsignedKeyRotation) checkWellFormed() error {
+	if len(ukr.OldPublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("unknown old key size detected (expected %d, actual %d)", ed25519.PublicKeySize, len(ukr.OldPublicKey))
+	}
+	if len(ukr.NewPublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("unknown new key size detected (expected %d, actual %d)", ed25519.PublicKeySize, len(ukr.NewPublicKey))
+	}
+	if bytes.Equal(ukr.OldPublicKey, ukr.NewPublicKey) {
+		return fmt.Errorf("old and new key are identical")
+	}
+	return nil
+}
+
+func (kr keyRotation) checkWellFormed() error {
+	if err := kr.unsignedKeyRotation.checkWellFormed(); err != nil {
+		return err
+	}
+	if kr.Sig == nil {
+		return fmt.Errorf("nil sig")
+	}
+	if kr.NewSig == nil {
+		return fmt.Errorf("nil new sig")
+	}
+	return nil
+}
+
+// digest returns a deterministic digest used for signing
+// will return an error for an invalid unsignedKeyRotation
+func (ukr unsignedKeyRotation) digest() ([]byte, error) {
+	if err := ukr.checkWellFormed(); err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	hasher.Write([]byte(keyRotationDomainSeparator))
+	// keys have a fixed length, so we don't need to encode their lengths
+	hasher.Write(ukr.OldPublicKey)
+	hasher.Write(ukr.NewPublicKey)
+	err := binary.Write(hasher, binary.LittleEndian, ukr.ValidUntil)
+	if err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
+// sign must be called with the keyrings of the old and the new key.
+func (ukr unsignedKeyRotation) sign(oldKeyring ragetypes.PeerKeyring, newKeyring ragetypes.PeerKeyring) (keyRotation, error) {
+	if !bytes.Equal(ukr.OldPublicKey, ragetypes.Ed25519PublicKeyFromPeerPublicKey(oldKeyring.PublicKey())) {
+		return keyRotation{}, fmt.Errorf("keyring does not match old key")
+	}
+	if !bytes.Equal(ukr.NewPublicKey, ragetypes.Ed25519PublicKeyFromPeerPublicKey(newKeyring.PublicKey())) {
+		return keyRotation{}, fmt.Errorf("keyring does not match new key")
+	}
+	digest, err := ukr.digest()
+	if err != nil {
+		return keyRotation{}, err
+	}
+	sig, err := oldKeyring.Sign(digest)
+	if err != nil {
+		return keyRotation{}, fmt.Errorf("old keyring Sign failed: %w", err)
+	}
+	newSig, err := newKeyring.Sign(digest)
+	if err != nil {
+		return keyRotation{}, fmt.Errorf("new keyring Sign failed: %w", err)
+	}
+	return keyRotation{ukr, sig, newSig}, nil
+}
+
+func (kr keyRotation) verify() error {
+	if err := kr.checkWellFormed(); err != nil {
+		return err
+	}
+	msg, err := kr.digest()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(kr.OldPublicKey, msg, kr.Sig) {
+		return fmt.Errorf("invalid signature")
+	}
+	// Without the new key's consent, anyone could claim another peer's key
+	// as their new key and take over its identity.
+	if !ed25519.Verify(kr.NewPublicKey, msg, kr.NewSig) {
+		return fmt.Errorf("invalid new signature")
+	}
+	return nil
+}
+
+func (kr keyRotation) validUntil() time.Time {
+	return time.Unix(int64(kr.ValidUntil), 0)
+}
+
+func (kr keyRotation) expired(now time.Time) bool {
+	return !now.Before(kr.validUntil())
+}
+
+// Must only be called on well-formed key rotations.
+func (kr keyRotation) toKeyRotation() ragetypes.KeyRotation {
+	return ragetypes.KeyRotation{
+		ragetypes.PeerID(kr.OldPublicKey),
+		ragetypes.PeerID(kr.NewPublicKey),
+		kr.validUntil(),
+	}
+}
+
+func (kr keyRotation) equal(other keyRotation) bool {
+	return bytes.Equal(kr.OldPublicKey, other.OldPublicKey) &&
+		bytes.Equal(kr.NewPublicKey, other.NewPublicKey) &&
+		kr.ValidUntil == other.ValidUntil &&
+		bytes.Equal(kr.Sig, other.Sig) &&
+		bytes.Equal(kr.NewSig, other.NewSig)
+}
+
+func (kr keyRotation) String() string {
+	var oldPart, newPart string
+	if pid, err := ragetypes.PeerIDFromPublicKey(kr.OldPublicKey); err == nil {
+		oldPart = fmt.Sprintf("Old:%s", pid.String())
+	} else {
+		oldPart = fmt.Sprintf("InvalidOldPublicKey:%x", kr.OldPublicKey)
+	}
+	if pid, err := ragetypes.PeerIDFromPublicKey(kr.NewPublicKey); err == nil {
+		newPart = fmt.Sprintf("New:%s", pid.String())
+	} else {
+		newPart = fmt.Sprintf("InvalidNewPublicKey:%x", kr.NewPublicKey)
+	}
+	return fmt.Sprintf("{%s %s ValidUntil:%s Sig:%s NewSig:%s}",
+		oldPart,
+		newPart,
+		kr.validUntil().UTC().Format(time.RFC3339),
+		base64.StdEncoding.EncodeToString(kr.Sig),
+		base64.StdEncoding.EncodeToString(kr.NewSig))
+}
+
+func (kr keyRotation) toProto() *serialization.SignedKeyRotation {
+	return &serialization.SignedKeyRotation{
+		OldPublicKey: kr.OldPublicKey,
+		NewPublicKey: kr.NewPublicKey,
+		ValidUntil:   kr.ValidUntil,
+		Sig:          kr.Sig,
+		NewSig:       kr.NewSig,
+	}
+}
+
+func keyRotationFromProto(pm *serialization.SignedKeyRotation) keyRotation {
+	return keyRotation{
+		unsignedKeyRotation{
+			pm.OldPublicKey,
+			pm.NewPublicKey,
+			pm.ValidUntil,
+		},
+		pm.Sig,
+		pm.NewSig,
+	}
+}
+
+// previousID returns our previous PeerID while our own key rotation is valid.
+func (p *discoveryProtocol) previousID() (ragetypes.PeerID, bool) {
+	if p.ownRotation == nil || p.ownRotation.expired(time.Now()) {
+		return ragetypes.PeerID{}, false
+	}
+	return p.ownRotation.toKeyRotation().OldPeerID, true
+}
+
+// isOwnID returns whether pid is our PeerID or, if we are rotating our key, our
+// previous PeerID. The latter remains true after our key rotation has expired.
+func (p *discoveryProtocol) isOwnID(pid ragetypes.PeerID) bool {
+	return pid == p.ownID || (p.ownRotation != nil && pid == p.ownRotation.toKeyRotation().OldPeerID)
+}
+
+// ownKeyrings returns the keyrings of the identities we currently announce.
+func (p *discoveryProtocol) ownKeyrings() map[ragetypes.PeerID]ragetypes.PeerKeyring {
+	keyrings := map[ragetypes.PeerID]ragetypes.PeerKeyring{p.ownID: p.keyring}
+	if previousID, ok := p.previousID(); ok {
+		keyrings[previousID] = p.previousKeyring
+	}
+	return keyrings
+}
+
+// FindKeyRotation returns the valid key rotation that id is the old or new
+// PeerID of, if any.
+func (p *discoveryProtocol) FindKeyRotation(id ragetypes.PeerID) (ragetypes.KeyRotation, bool) {
+	kr, ok := p.findRotation(id)
+	if !ok {
+		return ragetypes.KeyRotation{}, false
+	}
+	return kr.toKeyRotation(), true
+}
+
+func (p *discoveryProtocol) findRotation(id ragetypes.PeerID) (keyRotation, bool) {
+	now := time.Now()
+	p.rotationsMu.Lock()
+	defer p.rotationsMu.Unlock()
+	if kr, ok := p.rotations[id]; ok && !kr.expired(now) {
+		return kr, true
+	}
+	for _, kr := range p.rotations {
+		if kr.toKeyRotation().NewPeerID == id && !kr.expired(now) {
+			return kr, true
+		}
+	}
+	return keyRotation{}, false
+}
+
+func (p *discoveryProtocol) validRotations() []keyRotation {
+	now := time.Now()
+	p.rotationsMu.Lock()
+	defer p.rotationsMu.Unlock()
+	rotations := make([]keyRotation, 0, len(p.rotations))
+	for _, kr := range p.rotations {
+		if !kr.expired(now) {
+			rotations = append(rotations, kr)
+		}
+	}
+	return rotations
+}
+
+// peerIDsOf returns pid and, if pid is part of a valid key rotation, the other
+// PeerID of the rotation.
+func (p *discoveryProtocol) peerIDsOf(pid ragetypes.PeerID) []ragetypes.PeerID {
+	kr, ok := p.findRotation(pid)
+	if !ok {
+		return []ragetypes.PeerID{pid}
+	}
+	rotation := kr.toKeyRotation()
+	return []ragetypes.PeerID{rotation.OldPeerID, rotation.NewPeerID}
+}
+
+// lockedNumGroupsByOracle counts the groups that pid is an oracle in under any
+// of its PeerIDs. Requires lock to be held.
+func (p *discoveryProtocol) lockedNumGroupsByOracle(pid ragetypes.PeerID) int {
+	n := 0
+	for _, id := range p.peerIDsOf(pid) {
+		n += p.locked.numGroupsByOracle[id]
+	}
+	return n
+}
+
+// processKeyRotation locks lock for its whole lifetime. It returns whether the
+// key rotations we persist have changed, also in case of an error.
+func (p *discoveryProtocol) processKeyRotation(kr keyRotation) (changed bool, err error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if err := kr.verify(); err != nil {
+		return false, fmt.Errorf("invalid key rotation: %w", err)
+	}
+	if kr.expired(time.Now()) {
+		return false, fmt.Errorf("key rotation has expired")
+	}
+	rotation := kr.toKeyRotation()
+	// Only a peer we already know may introduce a new key
+	if p.locked.numGroupsByOracle[rotation.OldPeerID] == 0 {
+		return false, fmt.Errorf("old peer %s is not an oracle in any of our jobs", rotation.OldPeerID)
+	}
+
+	p.rotationsMu.Lock()
+	defer p.rotationsMu.Unlock()
+
+	if _, conflicting := p.conflictingRotations[rotation.OldPeerID]; conflicting {
+		return false, fmt.Errorf("ignoring key rotation of peer %s which has conflicting key rotations", rotation.OldPeerID)
+	}
+	if existing, ok := p.rotations[rotation.OldPeerID]; ok {
+		if existing.equal(kr) {
+			return false, nil
+		}
+		if bytes.Equal(existing.NewPublicKey, kr.NewPublicKey) {
+			// The peer may extend its transition window
+			if kr.ValidUntil > existing.ValidUntil {
+				p.rotations[rotation.OldPeerID] = kr
+				p.logger.Info("DiscoveryProtocol: Key rotation was extended", commontypes.LogFields{"keyRotation": kr})
+				return true, nil
+			}
+			return false, nil
+		}
+		// Whoever signed the second rotation has the old key. We cannot tell
+		// which rotation is legitimate, so we honor neither.
+		delete(p.rotations, rotation.OldPeerID)
+		p.conflictingRotations[rotation.OldPeerID] = struct{}{}
+		return true, fmt.Errorf("conflicting key rotations %v and %v for peer %s, ignoring all key rotations for it", existing, kr, rotation.OldPeerID)
+	}
+	for _, other := range p.rotations {
+		otherRotation := other.toKeyRotation()
+		if otherRotation.NewPeerID == rotation.NewPeerID || otherRotation.NewPeerID == rotation.OldPeerID || otherRotation.OldPeerID == rotation.NewPeerID {
+			return false, fmt.Errorf("key rotation overlaps with key rotation %v", other)
+		}
+	}
+
+	p.rotations[rotation.OldPeerID] = kr
+	p.logger.Info("DiscoveryProtocol: Learned key rotation", commontypes.LogFields{"keyRotation": kr})
+	return true, nil
+}
+
+// lockedCollectRotationGarbage forgets key rotations that have expired or whose
+// old peers aren't oracles in any of our groups anymore, along with the
+// announcements and connections we only kept because of them. Without any
+// groups, e.g. right after a restart, only expired key rotations are
+// forgotten. Requires lock to be held.
+func (p *discoveryProtocol) lockedCollectRotationGarbage() {
+	var forgotten []ragetypes.PeerID
+	func() {
+		now := time.Now()
+		p.rotationsMu.Lock()
+		defer p.rotationsMu.Unlock()
+		for oldID, kr := range p.rotations {
+			newID := kr.toKeyRotation().NewPeerID
+			if !kr.expired(now) && (len(p.locked.groups) == 0 || p.locked.numGroupsByOracle[oldID] != 0) {
+				continue
+			}
+			delete(p.rotations, oldID)
+			if p.locked.numGroupsByOracle[newID] == 0 && !p.isOwnID(newID) {
+				delete(p.locked.bestAnnouncement, newID)
+				forgotten = append(forgotten, newID)
+			}
+		}
+	}()
+
+	for _, pid := range forgotten {
+		if p.locked.numGroupsByBootstrapper[pid] != 0 {
+			continue
+		}
+		select {
+		case p.chConnectivity <- connectivityMsg{connectivityRemove, pid}:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// serializeKeyRotations serializes the valid key rotations of other peers that
+// we have accepted, and the old PeerIDs with conflicting key rotations.
+func (p *discoveryProtocol) serializeKeyRotations() ([]byte, error) {
+	now := time.Now()
+	pm := &serialization.PersistedKeyRotations{}
+	func() {
+		p.rotationsMu.Lock()
+		defer p.rotationsMu.Unlock()
+		for oldID, kr := range p.rotations {
+			if kr.expired(now) || p.isOwnID(oldID) {
+				continue
+			}
+			pm.Rotations = append(pm.Rotations, kr.toProto())
+		}
+		for oldID := range p.conflictingRotations {
+			pm.ConflictingOldPublicKeys = append(pm.ConflictingOldPublicKeys, append([]byte(nil), oldID[:]...))
+		}
+	}()
+	return proto.Marshal(pm)
+}
+
+func (p *discoveryProtocol) saveKeyRotationsToDB() error {
+	db, ok := p.db.(nettypes.DiscovererDatabaseKeyRotations)
+	if !ok {
+		return nil
+	}
+	ser, err := p.serializeKeyRotations()
+	if err != nil {
+		return err
+	}
+	return db.StoreKeyRotations(p.ctx, ser)
+}
+
+// loadKeyRotationsFromDB restores the key rotations saved by
+// saveKeyRotationsToDB. Key rotations that don't verify or have expired are
+// dropped. Key rotations whose old peers aren't oracles in any of our groups
+// are forgotten once we have groups again.
+func (p *discoveryProtocol) loadKeyRotationsFromDB() error {
+	db, ok := p.db.(nettypes.DiscovererDatabaseKeyRotations)
+	if !ok {
+		return nil
+	}
+	raw, err := db.ReadKeyRotations(p.ctx)
+	if err != nil {
+		return err
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	var pm serialization.PersistedKeyRotations
+	if err := proto.Unmarshal(raw, &pm); err != nil {
+		return fmt.Errorf("failed to deserialize key rotations: %w", err)
+	}
+
+	now := time.Now()
+	p.rotationsMu.Lock()
+	defer p.rotationsMu.Unlock()
+
+	for _, oldPublicKey := range pm.ConflictingOldPublicKeys {
+		if len(oldPublicKey) != ed25519.PublicKeySize {
+			continue
+		}
+		oldID := ragetypes.PeerID(oldPublicKey)
+		if p.isOwnID(oldID) {
+			continue
+		}
+		delete(p.rotations, oldID)
+		p.conflictingRotations[oldID] = struct{}{}
+	}
+	loaded := 0
+	for _, pkr := range pm.Rotations {
+		kr := keyRotationFromProto(pkr)
+		if err := kr.verify(); err != nil || kr.expired(now) {
+			continue
+		}
+		oldID := kr.toKeyRotation().OldPeerID
+		if _, conflicting := p.conflictingRotations[oldID]; conflicting || p.isOwnID(oldID) {
+			continue
+		}
+		if _, ok := p.rotations[oldID]; ok {
+			continue
+		}
+		p.rotations[oldID] = kr
+		loaded++
+	}
+	p.logger.Info("Loaded key rotations from db", commontypes.LogFields{
+		"numLoaded":      loaded,
+		"numConflicting": len(p.conflictingRotations),
+	})
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 	nettypes "github.com/smartcontractkit/libocr/networking/types"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 	"github.com/smartcontractkit/libocr/ragep2p"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew"
 	ragetypes "github.com/smartcontractkit/libocr/ragep2p/types"
 	"github.com/smartcontractkit/libocr/subprocesses"
 )
@@ -33,15 +34,24 @@ type Ragep2pDiscoverer struct {
 	ctxCancel         context.CancelFunc
 	deltaReconcile    time.Duration
 	announceAddresses []string
+	seeds             []ragetypes.PeerInfo
 	db                nettypes.DiscovererDatabase
 	host              ragep2pwrapper.Host
 	proto             *discoveryProtocol
 
+	// previousKeyring and previousHost are nil unless we are rotating our key
+	previousKeyring           ragetypes.PeerKeyring
+	previousKeyringValidUntil time.Time
+	previousHost              ragep2pwrapper.Host
+
 	stateMu sync.Mutex
 	state   ragep2pDiscovererState
 
 	streamsMu sync.Mutex
 	streams   map[ragetypes.PeerID]ragep2pwrapper.Stream
+	// streams in which we identify ourselves by our previous PeerID, for
+	// peers that still know us by it
+	previousStreams map[ragetypes.PeerID]ragep2pwrapper.Stream
 
 	chIncomingMessages chan incomingMessage
 	chOutgoingMessages chan outgoingMessage
@@ -50,9 +60,16 @@ type Ragep2pDiscoverer struct {
 	metricsRegisterer prometheus.Registerer
 }
 
+// NewRagep2pDiscoverer creates a discoverer for a ragep2p host. If the host is
+// rotating its key, previousKeyring is the keyring of the old key, which must
+// remain usable until previousKeyringValidUntil. Otherwise, previousKeyring is
+// nil. seeds are optional address hints for oracles, see seed.go.
 func NewRagep2pDiscoverer(
 	deltaReconcile time.Duration,
 	announceAddresses []string,
+	seeds []ragetypes.PeerInfo,
+	previousKeyring ragetypes.PeerKeyring,
+	previousKeyringValidUntil time.Time,
 	db nettypes.DiscovererDatabase,
 	metricsRegisterer prometheus.Registerer,
 ) *Ragep2pDiscoverer {
@@ -64,13 +81,18 @@ func NewRagep2pDiscoverer(
 		ctxCancel,
 		deltaReconcile,
 		announceAddresses,
+		seeds,
 		db,
 		nil, // ragep2p host, filled on Start()
 		nil, // discovery protocol, filled on Start()
+		previousKeyring,
+		previousKeyringValidUntil,
+		nil, // previous identity host, filled on Start()
 		sync.Mutex{},
 		ragep2pDiscovererUnstarted,
 		sync.Mutex{},
 		make(map[ragetypes.PeerID]ragep2pwrapper.Stream),
+		make(map[ragetypes.PeerID]ragep2pwrapper.Stream),
 		make(chan incomingMessage),
 		make(chan outgoingMessage),
 		make(chan connectivityMsg),
@@ -94,17 +116,32 @@ func (r *Ragep2pDiscoverer) Start(host ragep2pwrapper.Host, keyring ragetypes.Pe
 	}
 	r.state = ragep2pDiscovererStarted
 	r.host = host
+	if r.previousKeyring != nil {
+		h, ok := host.RawWrappee().(*ragep2p.Host)
+		if !ok {
+			return fmt.Errorf("key rotation requires a wrapped ragep2p.Host")
+		}
+		r.previousHost = ragep2p.WrappedPreviousIdentity(h)
+	}
 	announceAddresses, ok := combinedAnnounceAddrsForDiscoverer(r.logger, r.announceAddresses)
 	if !ok {
 		return fmt.Errorf("failed to obtain announce addresses")
 	}
+	acceptsQUIC := false
+	if h, ok := host.RawWrappee().(*ragep2pnew.Host); ok {
+		acceptsQUIC = h.AcceptsQUIC()
+	}
 	proto, err := newDiscoveryProtocol(
 		r.deltaReconcile,
 		r.chIncomingMessages,
 		r.chOutgoingMessages,
 		r.chConnectivity,
 		keyring,
+		r.previousKeyring,
+		r.previousKeyringValidUntil,
 		announceAddresses,
+		acceptsQUIC,
+		r.seeds,
 		r.db,
 		logger,
 		r.metricsRegisterer,
@@ -133,80 +170,34 @@ func (r *Ragep2pDiscoverer) connectivityLoop() {
 			logger := r.logger.MakeChild(commontypes.LogFields{
 				"remotePeerID": c.peerID,
 			})
-			if c.peerID == r.host.ID() {
+			if r.proto.isOwnID(c.peerID) {
 				break
 			}
 			r.streamsMu.Lock()
 			if c.msgType == connectivityAdd {
-				if _, exists := r.streams[c.peerID]; exists {
-					r.streamsMu.Unlock()
-					break
-				}
-				// no point in keeping very large buffers, since only
-				// the latest messages matter anyways.
-				bufferSize := 2
-				messagesLimit := ragetypes.TokenBucketParams{
-					// we expect one message every deltaReconcile seconds, let's double it
-					// for good measure
-					2 / r.deltaReconcile.Seconds(),
-					// twice the buffer size should be plenty
-					2 * uint32(bufferSize),
-				}
-				// bytesLimit is messagesLimit * maxMessageLength
-				bytesLimit := ragetypes.TokenBucketParams{
-					messagesLimit.Rate * maxMessageLength,
-					messagesLimit.Capacity * maxMessageLength,
-				}
-				s, err := r.host.NewStream(
-					c.peerID,
-					"ragedisco/v1",
-					bufferSize,
-					bufferSize,
-					maxMessageLength,
-					messagesLimit,
-					bytesLimit,
-				)
-				if err != nil {
-					logger.Warn("NewStream failed!", reason(err))
-					r.streamsMu.Unlock()
-					break
+				r.lockedAddStream(&subs, r.host, r.streams, c.peerID, logger)
+				// Peers whose groups list our previous PeerID open their
+				// streams to it
+				if r.previousHost != nil && time.Now().Before(r.previousKeyringValidUntil) {
+					r.lockedAddStream(&subs, r.previousHost, r.previousStreams, c.peerID, logger)
 				}
-				r.streams[c.peerID] = s
 				r.streamsMu.Unlock()
-				pid := c.peerID
-				subs.Go(func() {
-					chDone := r.ctx.Done()
-					for {
-						select {
-						case m, ok := <-s.ReceiveMessages():
-							if !ok { // stream Close() will signal us when it's time to go
-								return
-							}
-							w, err := fromProtoWrappedBytes(m)
-							if err != nil {
-								logger.Warn("Failed to unwrap incoming message", reason(err))
-								break
-							}
-							select {
-							case r.chIncomingMessages <- incomingMessage{w, pid}:
-							case <-chDone:
-								return
-							}
-						case <-chDone:
-							return
-						}
-					}
-				})
 			} else {
-				if _, exists := r.streams[c.peerID]; !exists {
+				_, exists := r.streams[c.peerID]
+				_, previousExists := r.previousStreams[c.peerID]
+				if !exists && !previousExists {
 					logger.Warn("Asked to remove connectivity with peer we don't have a stream for", nil)
 					r.streamsMu.Unlock()
 					break
 				}
-				if err := r.streams[c.peerID].Close(); err != nil {
-					logger.Warn("Failed to close stream", reason(err))
+				for _, streams := range []map[ragetypes.PeerID]ragep2pwrapper.Stream{r.streams, r.previousStreams} {
+					if s, ok := streams[c.peerID]; ok {
+						if err := s.Close(); err != nil {
+							logger.Warn("Failed to close stream", reason(err))
+						}
+						delete(streams, c.peerID)
+					}
 				}
-				delete(r.streams, c.peerID)
 				r.streamsMu.Unlock()
 			}
 		case <-r.ctx.Done():
@@ -215,13 +206,81 @@ func (r *Ragep2pDiscoverer) connectivityLoop() {
 	}
 }
 
+// lockedAddStream opens a stream to pid through host, unless streams already
+// contains one, and forwards the messages received on it. Requires streamsMu
+// to be held.
+func (r *Ragep2pDiscoverer) lockedAddStream(
+	subs *subprocesses.Subprocesses,
+	host ragep2pwrapper.Host,
+	streams map[ragetypes.PeerID]ragep2pwrapper.Stream,
+	pid ragetypes.PeerID,
+	logger loghelper.LoggerWithContext,
+) {
+	if _, exists := streams[pid]; exists {
+		return
+	}
+	// no point in keeping very large buffers, since only
+	// the latest messages matter anyways.
+	bufferSize := 2
+	messagesLimit := ragetypes.TokenBucketParams{
+		// we expect one message every deltaReconcile seconds, let's double it
+		// for good measure
+		2 / r.deltaReconcile.Seconds(),
+		// twice the buffer size should be plenty
+		2 * uint32(bufferSize),
+	}
+	// bytesLimit is messagesLimit * maxMessageLength
+	bytesLimit := ragetypes.TokenBucketParams{
+		messagesLimit.Rate * maxMessageLength,
+		messagesLimit.Capacity * maxMessageLength,
+	}
+	s, err := host.NewStream(
+		pid,
+		"ragedisco/v1",
+		bufferSize,
+		bufferSize,
+		maxMessageLength,
+		messagesLimit,
+		bytesLimit,
+	)
+	if err != nil {
+		logger.Warn("NewStream failed!", reason(err))
+		return
+	}
+	streams[pid] = s
+	subs.Go(func() {
+		chDone := r.ctx.Done()
+		for {
+			select {
+			case m, ok := <-s.ReceiveMessages():
+				if !ok { // stream Close() will signal us when it's time to go
+					return
+				}
+				w, err := fromProtoWrappedBytes(m)
+				if err != nil {
+					logger.Warn("Failed to unwrap incoming message", reason(err))
+					break
+				}
+				select {
+				case r.chIncomingMessages <- incomingMessage{w, pid}:
+				case <-chDone:
+					return
+				}
+			case <-chDone:
+				return
+			}
+		}
+	})
+}
+
 func (r *Ragep2pDiscoverer) writeLoop() {
 	for {
 		select {
 		case m := <-r.chOutgoingMessages:
 			r.streamsMu.Lock()
 			s, exists := r.streams[m.to]
-			if !exists {
+			ps, previousExists := r.previousStreams[m.to]
+			if !exists && !previousExists {
 				r.logger.Warn("Write message to peer we don't have a stream open for", commontypes.LogFields{
 					"remotePeerID": m.to,
 				})
@@ -234,7 +293,12 @@ func (r *Ragep2pDiscoverer) writeLoop() {
 				r.logger.Warn("Failed to convert message to bytes", commontypes.LogFields{"message": m.payload})
 				break
 			}
-			s.SendMessage(bs)
+			if exists {
+				s.SendMessage(bs)
+			}
+			if previousExists {
+				ps.SendMessage(bs)
+			}
 		case <-r.ctx.Done():
 			return
 		}
@@ -276,4 +340,15 @@ func (r *Ragep2pDiscoverer) FindPeer(peer ragetypes.PeerID) ([]ragetypes.Address
 	return r.proto.FindPeer(peer)
 }
 
+func (r *Ragep2pDiscoverer) FindKeyRotation(peer ragetypes.PeerID) (ragetypes.KeyRotation, bool) {
+	return r.proto.FindKeyRotation(peer)
+}
+
+func (r *Ragep2pDiscoverer) AcceptsQUIC(peer ragetypes.PeerID) bool {
+	return r.proto.AcceptsQUIC(peer)
+}
+
 var _ ragep2p.Discoverer = &Ragep2pDiscoverer{}
+var _ ragep2p.KeyRotationDiscoverer = &Ragep2pDiscoverer{}
+var _ ragep2pnew.Discoverer = &Ragep2pDiscoverer{}
+var _ ragep2pnew.QUICDiscoverer = &Ragep2pDiscoverer{}
@@ -0,0 +1,94 @@
+package ragedisco
+
+import (
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	ragetypes "github.com/smartcontractkit/libocr/ragep2p/types"
+)
+
+// Seeds let oracles find each other when no bootstrapper is reachable. Once we
+// are connected to any oracle of a group, reconciles spread the announcements
+// of all other oracles of the group, so that bootstrappers are not needed
+// anymore. There are two kinds of seeds:
+//
+//   - operator-supplied address hints for oracles, used to dial an oracle
+//     until we know its announcement
+//   - retired announcements, i.e. announcements of oracles of groups we have
+//     removed, which are reused if a later group contains the same oracles.
+//     This makes reconfigurations independent of bootstrappers, even without
+//     a DiscovererDatabase.
+
+// Maximum number of retired announcements that we keep in memory. When
+// exceeded, the announcements that were retired first are dropped.
+const maxRetiredAnnouncements = MaxOracles
+
+type retiredAnnouncement struct {
+	announcement Announcement
+	retiredAt    time.Time
+}
+
+func seedAddrsByPeer(seeds []ragetypes.PeerInfo) map[ragetypes.PeerID][]ragetypes.Address {
+	addrs := make(map[ragetypes.PeerID][]ragetypes.Address, len(seeds))
+	for _, seed := range seeds {
+		addrs[seed.ID] = append(addrs[seed.ID], seed.Addrs...)
+	}
+	for pid := range addrs {
+		addrs[pid] = dedup(addrs[pid])
+	}
+	return addrs
+}
+
+// lockedSeedAddrs returns the address hints for peer, unless we know an
+// announcement for it. Requires lock to be held.
+func (p *discoveryProtocol) lockedSeedAddrs(peer ragetypes.PeerID) []ragetypes.Address {
+	var addrs []ragetypes.Address
+	for _, pid := range p.peerIDsOf(peer) {
+		if _, ok := p.locked.bestAnnouncement[pid]; ok {
+			// Signed announcements supersede hints, which may be stale
+			return nil
+		}
+		addrs = append(addrs, p.seeds[pid]...)
+	}
+	return addrs
+}
+
+// lockedRetireAnnouncement keeps the announcement of an oracle that isn't in
+// any of our groups anymore. Requires lock to be held.
+func (p *discoveryProtocol) lockedRetireAnnouncement(pid ragetypes.PeerID, ann Announcement) {
+	p.locked.retiredAnnouncements[pid] = retiredAnnouncement{ann, time.Now()}
+	for len(p.locked.retiredAnnouncements) > maxRetiredAnnouncements {
+		var oldestPeerID ragetypes.PeerID
+		var oldest *retiredAnnouncement
+		for id, retired := range p.locked.retiredAnnouncements {
+			if oldest == nil || retired.retiredAt.Before(oldest.retiredAt) {
+				oldestPeerID, oldest = id, &retired
+			}
+		}
+		delete(p.locked.retiredAnnouncements, oldestPeerID)
+	}
+}
+
+// lockedLoadRetired reinstates the retired announcements of the given peers,
+// which must be oracles in one of our groups. Requires lock to be held.
+func (p *discoveryProtocol) lockedLoadRetired(pids []ragetypes.PeerID) {
+	var loaded []ragetypes.PeerID
+	for _, pid := range pids {
+		retired, ok := p.locked.retiredAnnouncements[pid]
+		if !ok {
+			continue
+		}
+		delete(p.locked.retiredAnnouncements, pid)
+		if err := p.lockedProcessAnnouncement(retired.announcement); err != nil {
+			p.logger.Warn("Failed to process retired announcement", commontypes.LogFields{
+				"announcement": retired.announcement,
+				"error":        err,
+			})
+			continue
+		}
+		loaded = append(loaded, pid)
+	}
+	if len(loaded) != 0 {
+		p.logger.Info("Reinstated retired announcements", commontypes.LogFields{"peerIDs": loaded})
+	}
+}
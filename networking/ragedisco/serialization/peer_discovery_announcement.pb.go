@@ -29,6 +29,7 @@ type SignedAnnouncement struct {
 	Counter   uint64   `protobuf:"varint,2,opt,name=counter,proto3" json:"counter,omitempty"`
 	PublicKey []byte   `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
 	Sig       []byte   `protobuf:"bytes,4,opt,name=sig,proto3" json:"sig,omitempty"`
+	QuicSig   []byte   `protobuf:"bytes,5,opt,name=quic_sig,json=quicSig,proto3" json:"quic_sig,omitempty"`
 }
 
 func (x *SignedAnnouncement) Reset() {
@@ -91,12 +92,20 @@ func (x *SignedAnnouncement) GetSig() []byte {
 	return nil
 }
 
+func (x *SignedAnnouncement) GetQuicSig() []byte {
+	if x != nil {
+		return x.QuicSig
+	}
+	return nil
+}
+
 type Reconcile struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Anns []*SignedAnnouncement `protobuf:"bytes,1,rep,name=anns,proto3" json:"anns,omitempty"`
+	Anns      []*SignedAnnouncement `protobuf:"bytes,1,rep,name=anns,proto3" json:"anns,omitempty"`
+	Rotations []*SignedKeyRotation  `protobuf:"bytes,2,rep,name=rotations,proto3" json:"rotations,omitempty"`
 }
 
 func (x *Reconcile) Reset() {
@@ -138,6 +147,13 @@ func (x *Reconcile) GetAnns() []*SignedAnnouncement {
 	return nil
 }
 
+func (x *Reconcile) GetRotations() []*SignedKeyRotation {
+	if x != nil {
+		return x.Rotations
+	}
+	return nil
+}
+
 type MessageWrapper struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -219,39 +235,198 @@ func (*MessageWrapper_MessageSignedAnnouncement) isMessageWrapper_Msg() {}
 
 func (*MessageWrapper_MessageReconcile) isMessageWrapper_Msg() {}
 
+type SignedKeyRotation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OldPublicKey []byte `protobuf:"bytes,1,opt,name=old_public_key,json=oldPublicKey,proto3" json:"old_public_key,omitempty"`
+	NewPublicKey []byte `protobuf:"bytes,2,opt,name=new_public_key,json=newPublicKey,proto3" json:"new_public_key,omitempty"`
+	ValidUntil   uint64 `protobuf:"varint,3,opt,name=valid_until,json=validUntil,proto3" json:"valid_until,omitempty"`
+	Sig          []byte `protobuf:"bytes,4,opt,name=sig,proto3" json:"sig,omitempty"`
+	NewSig       []byte `protobuf:"bytes,5,opt,name=new_sig,json=newSig,proto3" json:"new_sig,omitempty"`
+}
+
+func (x *SignedKeyRotation) Reset() {
+	*x = SignedKeyRotation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_serialization_peer_discovery_announcement_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignedKeyRotation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedKeyRotation) ProtoMessage() {}
+
+func (x *SignedKeyRotation) ProtoReflect() protoreflect.Message {
+	mi := &file_serialization_peer_discovery_announcement_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedKeyRotation.ProtoReflect.Descriptor instead.
+func (*SignedKeyRotation) Descriptor() ([]byte, []int) {
+	return file_serialization_peer_discovery_announcement_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SignedKeyRotation) GetOldPublicKey() []byte {
+	if x != nil {
+		return x.OldPublicKey
+	}
+	return nil
+}
+
+func (x *SignedKeyRotation) GetNewPublicKey() []byte {
+	if x != nil {
+		return x.NewPublicKey
+	}
+	return nil
+}
+
+func (x *SignedKeyRotation) GetValidUntil() uint64 {
+	if x != nil {
+		return x.ValidUntil
+	}
+	return 0
+}
+
+func (x *SignedKeyRotation) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *SignedKeyRotation) GetNewSig() []byte {
+	if x != nil {
+		return x.NewSig
+	}
+	return nil
+}
+
+type PersistedKeyRotations struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rotations                []*SignedKeyRotation `protobuf:"bytes,1,rep,name=rotations,proto3" json:"rotations,omitempty"`
+	ConflictingOldPublicKeys [][]byte             `protobuf:"bytes,2,rep,name=conflicting_old_public_keys,json=conflictingOldPublicKeys,proto3" json:"conflicting_old_public_keys,omitempty"`
+}
+
+func (x *PersistedKeyRotations) Reset() {
+	*x = PersistedKeyRotations{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_serialization_peer_discovery_announcement_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PersistedKeyRotations) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PersistedKeyRotations) ProtoMessage() {}
+
+func (x *PersistedKeyRotations) ProtoReflect() protoreflect.Message {
+	mi := &file_serialization_peer_discovery_announcement_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PersistedKeyRotations.ProtoReflect.Descriptor instead.
+func (*PersistedKeyRotations) Descriptor() ([]byte, []int) {
+	return file_serialization_peer_discovery_announcement_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PersistedKeyRotations) GetRotations() []*SignedKeyRotation {
+	if x != nil {
+		return x.Rotations
+	}
+	return nil
+}
+
+func (x *PersistedKeyRotations) GetConflictingOldPublicKeys() [][]byte {
+	if x != nil {
+		return x.ConflictingOldPublicKeys
+	}
+	return nil
+}
+
 var File_serialization_peer_discovery_announcement_proto protoreflect.FileDescriptor
 
 var file_serialization_peer_discovery_announcement_proto_rawDesc = []byte{
 	0x0a, 0x2f, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2f,
 	0x70, 0x65, 0x65, 0x72, 0x5f, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x5f, 0x61,
 	0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x09, 0x72, 0x61, 0x67, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x22, 0x75, 0x0a, 0x12,
-	0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65,
-	0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
-	0x0c, 0x52, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e,
-	0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74,
-	0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65,
-	0x79, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
-	0x73, 0x69, 0x67, 0x22, 0x3e, 0x0a, 0x09, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c, 0x65,
-	0x12, 0x31, 0x0a, 0x04, 0x61, 0x6e, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d,
-	0x2e, 0x72, 0x61, 0x67, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65,
-	0x64, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x04, 0x61,
-	0x6e, 0x6e, 0x73, 0x22, 0xc0, 0x01, 0x0a, 0x0e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x57,
-	0x72, 0x61, 0x70, 0x70, 0x65, 0x72, 0x12, 0x5d, 0x0a, 0x19, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d,
-	0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x72, 0x61, 0x67, 0x65,
-	0x64, 0x69, 0x73, 0x63, 0x6f, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x41, 0x6e, 0x6e, 0x6f,
-	0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x19, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63,
-	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x42, 0x0a, 0x10, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x52, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x14, 0x2e, 0x72, 0x61, 0x67, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x2e, 0x52, 0x65, 0x63, 0x6f,
-	0x6e, 0x63, 0x69, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x10, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x52, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c, 0x65, 0x42, 0x05, 0x0a, 0x03, 0x6d, 0x73, 0x67,
-	0x4a, 0x04, 0x08, 0x01, 0x10, 0x02, 0x42, 0x12, 0x5a, 0x10, 0x2e, 0x2f, 0x3b, 0x73, 0x65, 0x72,
-	0x69, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x6f, 0x12, 0x09, 0x72, 0x61, 0x67, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x22, 0x90, 0x01, 0x0a,
+	0x12, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0c, 0x52, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65,
+	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b,
+	0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x03, 0x73, 0x69, 0x67, 0x12, 0x19, 0x0a, 0x08, 0x71, 0x75, 0x69, 0x63, 0x5f, 0x73, 0x69, 0x67,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x71, 0x75, 0x69, 0x63, 0x53, 0x69, 0x67, 0x22,
+	0x7a, 0x0a, 0x09, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c, 0x65, 0x12, 0x31, 0x0a, 0x04,
+	0x61, 0x6e, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x72, 0x61, 0x67,
+	0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x41, 0x6e, 0x6e,
+	0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x04, 0x61, 0x6e, 0x6e, 0x73, 0x12,
+	0x3a, 0x0a, 0x09, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x72, 0x61, 0x67, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x2e, 0x53,
+	0x69, 0x67, 0x6e, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x52, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x09, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xc0, 0x01, 0x0a, 0x0e,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x57, 0x72, 0x61, 0x70, 0x70, 0x65, 0x72, 0x12, 0x5d,
+	0x0a, 0x19, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x41,
+	0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1d, 0x2e, 0x72, 0x61, 0x67, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x2e, 0x53, 0x69,
+	0x67, 0x6e, 0x65, 0x64, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x48, 0x00, 0x52, 0x19, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x53, 0x69, 0x67, 0x6e, 0x65,
+	0x64, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x42, 0x0a,
+	0x10, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x61, 0x67, 0x65, 0x64, 0x69,
+	0x73, 0x63, 0x6f, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c, 0x65, 0x48, 0x00, 0x52,
+	0x10, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x63, 0x69, 0x6c,
+	0x65, 0x42, 0x05, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x4a, 0x04, 0x08, 0x01, 0x10, 0x02, 0x22, 0xab,
+	0x01, 0x0a, 0x11, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x52, 0x6f, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x24, 0x0a, 0x0e, 0x6f, 0x6c, 0x64, 0x5f, 0x70, 0x75, 0x62, 0x6c,
+	0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x6f, 0x6c,
+	0x64, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x24, 0x0a, 0x0e, 0x6e, 0x65,
+	0x77, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x0c, 0x6e, 0x65, 0x77, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79,
+	0x12, 0x1f, 0x0a, 0x0b, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x5f, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x6e, 0x74, 0x69,
+	0x6c, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
+	0x73, 0x69, 0x67, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x65, 0x77, 0x5f, 0x73, 0x69, 0x67, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x6e, 0x65, 0x77, 0x53, 0x69, 0x67, 0x22, 0x92, 0x01, 0x0a,
+	0x15, 0x50, 0x65, 0x72, 0x73, 0x69, 0x73, 0x74, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x52, 0x6f, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3a, 0x0a, 0x09, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x72, 0x61, 0x67, 0x65,
+	0x64, 0x69, 0x73, 0x63, 0x6f, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x52,
+	0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x3d, 0x0a, 0x1b, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x69, 0x6e,
+	0x67, 0x5f, 0x6f, 0x6c, 0x64, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x18, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63,
+	0x74, 0x69, 0x6e, 0x67, 0x4f, 0x6c, 0x64, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79,
+	0x73, 0x42, 0x12, 0x5a, 0x10, 0x2e, 0x2f, 0x3b, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -266,21 +441,25 @@ func file_serialization_peer_discovery_announcement_proto_rawDescGZIP() []byte {
 	return file_serialization_peer_discovery_announcement_proto_rawDescData
 }
 
-var file_serialization_peer_discovery_announcement_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_serialization_peer_discovery_announcement_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_serialization_peer_discovery_announcement_proto_goTypes = []interface{}{
-	(*SignedAnnouncement)(nil), // 0: ragedisco.SignedAnnouncement
-	(*Reconcile)(nil),          // 1: ragedisco.Reconcile
-	(*MessageWrapper)(nil),     // 2: ragedisco.MessageWrapper
+	(*SignedAnnouncement)(nil),    // 0: ragedisco.SignedAnnouncement
+	(*Reconcile)(nil),             // 1: ragedisco.Reconcile
+	(*MessageWrapper)(nil),        // 2: ragedisco.MessageWrapper
+	(*SignedKeyRotation)(nil),     // 3: ragedisco.SignedKeyRotation
+	(*PersistedKeyRotations)(nil), // 4: ragedisco.PersistedKeyRotations
 }
 var file_serialization_peer_discovery_announcement_proto_depIdxs = []int32{
 	0, // 0: ragedisco.Reconcile.anns:type_name -> ragedisco.SignedAnnouncement
-	0, // 1: ragedisco.MessageWrapper.messageSignedAnnouncement:type_name -> ragedisco.SignedAnnouncement
-	1, // 2: ragedisco.MessageWrapper.messageReconcile:type_name -> ragedisco.Reconcile
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	3, // 1: ragedisco.Reconcile.rotations:type_name -> ragedisco.SignedKeyRotation
+	0, // 2: ragedisco.MessageWrapper.messageSignedAnnouncement:type_name -> ragedisco.SignedAnnouncement
+	1, // 3: ragedisco.MessageWrapper.messageReconcile:type_name -> ragedisco.Reconcile
+	3, // 4: ragedisco.PersistedKeyRotations.rotations:type_name -> ragedisco.SignedKeyRotation
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_serialization_peer_discovery_announcement_proto_init() }
@@ -325,6 +504,30 @@ func file_serialization_peer_discovery_announcement_proto_init() {
 				return nil
 			}
 		}
+		file_serialization_peer_discovery_announcement_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SignedKeyRotation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_serialization_peer_discovery_announcement_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PersistedKeyRotations); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_serialization_peer_discovery_announcement_proto_msgTypes[2].OneofWrappers = []interface{}{
 		(*MessageWrapper_MessageSignedAnnouncement)(nil),
@@ -336,7 +539,7 @@ func file_serialization_peer_discovery_announcement_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_serialization_peer_discovery_announcement_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,98 @@
+package rageping
+
+import (
+	"time"
+
+	ragetypes "github.com/smartcontractkit/libocr/ragep2p/types"
+)
+
+// Weight of the most recent measurement in PeerLatency.AverageRoundTripLatency.
+const averageRoundTripLatencyWeight = 0.25
+
+func (s *latencyMetricsPeerState) observeLatency(latency time.Duration) {
+	s.latencyMu.Lock()
+	defer s.latencyMu.Unlock()
+
+	if s.latency.LastPongReceivedAt.IsZero() {
+		s.latency.AverageRoundTripLatency = latency
+	} else {
+		s.latency.AverageRoundTripLatency = time.Duration(
+			averageRoundTripLatencyWeight*float64(latency) +
+				(1-averageRoundTripLatencyWeight)*float64(s.latency.AverageRoundTripLatency),
+		)
+	}
+	s.latency.LastRoundTripLatency = latency
+	s.latency.LastPongReceivedAt = time.Now()
+	s.latency.ConsecutiveTimeouts = 0
+}
+
+func (s *latencyMetricsPeerState) observeTimeout() {
+	s.latencyMu.Lock()
+	defer s.latencyMu.Unlock()
+
+	s.latency.ConsecutiveTimeouts++
+}
+
+func (s *latencyMetricsPeerState) currentLatency() PeerLatency {
+	s.latencyMu.Lock()
+	defer s.latencyMu.Unlock()
+
+	return s.latency
+}
+
+func (s *latencyMetricsService) Latencies() []PeerLatency {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latencies := make([]PeerLatency, 0, len(s.peerStates))
+	for _, peerState := range s.peerStates {
+		latencies = append(latencies, peerState.currentLatency())
+	}
+	return latencies
+}
+
+func (s *latencyMetricsService) RoundTripLatency(peerID ragetypes.PeerID) (time.Duration, bool) {
+	latency, ok := s.peerLatency(peerID)
+	if !ok || !latency.Responsive() {
+		return 0, false
+	}
+	return latency.AverageRoundTripLatency, true
+}
+
+func (s *latencyMetricsService) peerLatency(peerID ragetypes.PeerID) (PeerLatency, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	peerState, ok := s.peerStates[peerID]
+	if !ok {
+		return PeerLatency{}, false
+	}
+	return peerState.currentLatency(), true
+}
+
+// Collect the measurements of all underlying service instances.
+func (sg *latencyMetricsServiceGroup) Latencies() []PeerLatency {
+	var latencies []PeerLatency
+	for _, instance := range sg.instances {
+		latencies = append(latencies, instance.Latencies()...)
+	}
+	return latencies
+}
+
+// Use the instance with the smallest PING size that the peer is registered with. Small PING messages are the least
+// affected by bandwidth, and are sent most frequently with the default configurations.
+func (sg *latencyMetricsServiceGroup) RoundTripLatency(peerID ragetypes.PeerID) (time.Duration, bool) {
+	var best *latencyMetricsService
+	for _, instance := range sg.instances {
+		if _, ok := instance.peerLatency(peerID); !ok {
+			continue
+		}
+		if best == nil || instance.config.PingSize < best.config.PingSize {
+			best = instance
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+	return best.RoundTripLatency(peerID)
+}
@@ -33,7 +33,7 @@ type latencyMetricsService struct {
 // A smaller wrapper which runs a LatencyMetricsService instead for each passed configuration.
 // Calls are are simply forwarded to the internal instances.
 type latencyMetricsServiceGroup struct {
-	instances []LatencyMetricsService
+	instances []*latencyMetricsService
 }
 
 var _ LatencyMetricsService = &latencyMetricsService{}
@@ -71,6 +71,11 @@ type latencyMetricsPeerState struct {
 
 	// Channel indicating when the main ping/pong protocol has terminated (after a shutdown was requested).
 	chDone chan struct{}
+
+	// Live latency measurements exposed through the LatencyMatrix interface. Written by the main ping/pong protocol,
+	// hence guarded by a separate mutex.
+	latencyMu sync.Mutex
+	latency   PeerLatency
 }
 
 func (s *latencyMetricsPeerState) Done() chan struct{} {
@@ -155,7 +160,14 @@ func (s *latencyMetricsService) RegisterPeers(peerIDs []ragetypes.PeerID) {
 
 		metrics := newLatencyMetrics(s.metricsRegisterer, s.logger, s.host.ID(), peerID, s.config)
 		refCount := 1
-		peerState := &latencyMetricsPeerState{metrics, stream, refCount, make(chan struct{})}
+		peerState := &latencyMetricsPeerState{
+			metrics,
+			stream,
+			refCount,
+			make(chan struct{}),
+			sync.Mutex{},
+			PeerLatency{peerID, s.config.PingSize, 0, 0, time.Time{}, 0},
+		}
 		s.peerStates[peerID] = peerState
 
 		go s.run(peerID, peerState)
@@ -374,6 +386,7 @@ func (s *latencyMetricsService) run(remotePeerID ragetypes.PeerID, peerState *la
 				//  3. Reschedule the ticker for sending a new PING message.
 				expectedPongMsg = nil
 				s.processTimedOutPing(remotePeerID, metrics)
+				peerState.observeTimeout()
 				ticker.Reset(s.getNextDelay())
 			}
 
@@ -397,7 +410,8 @@ func (s *latencyMetricsService) run(remotePeerID ragetypes.PeerID, peerState *la
 					break
 				}
 				if msgType == msgTypePong && len(msg) == pongSize {
-					if s.processIncomingPongMessage(msg, expectedPongMsg, lastPingSentAt, remotePeerID, metrics) {
+					if latency, ok := s.processIncomingPongMessage(msg, expectedPongMsg, lastPingSentAt, remotePeerID, metrics); ok {
+						peerState.observeLatency(latency)
 						expectedPongMsg = nil
 						ticker.Reset(s.getNextDelay())
 					}
@@ -491,7 +505,7 @@ func (s *latencyMetricsService) processIncomingPongMessage(
 	lastPingSentAt time.Time,
 	remotePeerID ragetypes.PeerID,
 	metrics *latencyMetrics,
-) bool {
+) (time.Duration, bool) {
 	// Some (valid or invalid) PONG message was received from the remote peer.
 	if bytes.Equal(pongMsg, expectedPongMsg) {
 		// The value matches the expected one, so the PONG message is valid and we compute the latency
@@ -504,7 +518,7 @@ func (s *latencyMetricsService) processIncomingPongMessage(
 			},
 		)
 		metrics.roundTripLatencySeconds.Observe(latency.Seconds())
-		return true
+		return latency, true
 	} else {
 		if expectedPongMsg != nil {
 			s.logger.Debug("invalid (conflicting) PONG received. The typical cause are restarts of the underlying network connection.", commontypes.LogFields{
@@ -516,6 +530,6 @@ func (s *latencyMetricsService) processIncomingPongMessage(
 			})
 		}
 		metrics.invalidMessagesReceivedTotal.Inc()
-		return false
+		return 0, false
 	}
 }
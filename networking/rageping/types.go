@@ -22,6 +22,43 @@ type LatencyMetricsService interface {
 
 	// Unregisters all peers (if any) and releases all resources.
 	Close()
+
+	LatencyMatrix
+}
+
+// LatencyMatrix exposes the live round-trip latency measurements of a LatencyMetricsService, i.e., the row of the
+// latency matrix between all peers that belongs to this host. All functions are thread safe.
+type LatencyMatrix interface {
+	// Returns the current measurements for each registered peer, one entry per peer and configuration.
+	Latencies() []PeerLatency
+
+	// Returns the average round-trip latency to the given peer, as measured by the configuration with the smallest
+	// PING size. The second return value is false if the peer is not registered, has not responded to any PING yet, or
+	// failed to respond to the latest PING in time.
+	RoundTripLatency(peerID ragetypes.PeerID) (time.Duration, bool)
+}
+
+// PeerLatency holds the latency measurements to a single remote peer for a single configuration.
+type PeerLatency struct {
+	PeerID   ragetypes.PeerID
+	PingSize int
+
+	// Round-trip latency of the most recent valid PING/PONG exchange. Zero if no valid PONG was received yet.
+	LastRoundTripLatency time.Duration
+
+	// Exponentially weighted moving average of the round-trip latencies. Zero if no valid PONG was received yet.
+	AverageRoundTripLatency time.Duration
+
+	// Time at which the most recent valid PONG message was received. Zero if no valid PONG was received yet.
+	LastPongReceivedAt time.Time
+
+	// The number of PING messages that timed out since the most recent valid PONG message was received.
+	ConsecutiveTimeouts int
+}
+
+// Whether the peer responded to the latest PING message in time.
+func (l PeerLatency) Responsive() bool {
+	return !l.LastPongReceivedAt.IsZero() && l.ConsecutiveTimeouts == 0
 }
 
 type LatencyMetricsServiceConfig struct {
@@ -93,7 +130,7 @@ func NewLatencyMetricsService(
 
 	// Create a latencyMetricsService instance per configuration and manage all of them using a
 	// latencyMetricsServiceGroup, i.e., a rapper which forwards all calls to the individual instances.
-	serviceGroup := latencyMetricsServiceGroup{make([]LatencyMetricsService, 0, len(configs))}
+	serviceGroup := latencyMetricsServiceGroup{make([]*latencyMetricsService, 0, len(configs))}
 	for _, config := range configs {
 		if config.PingSize < minPingSize {
 			logger.Error(
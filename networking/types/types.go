@@ -11,3 +11,27 @@ type DiscovererDatabase interface {
 	// keyed by each announcement's corresponding peer ID.
 	ReadAnnouncements(ctx context.Context, peerIDs []string) (map[string][]byte, error)
 }
+
+// DiscovererDatabaseGarbageCollector is an optional extension of
+// DiscovererDatabase. If the DiscovererDatabase implements it, the discoverer
+// periodically calls CollectGarbage while it is part of at least one group.
+type DiscovererDatabaseGarbageCollector interface {
+	// CollectGarbage receives the peer IDs of all oracles in any of the
+	// discoverer's current groups. Implementations may delete announcements of
+	// other peers. Since peers commonly leave all groups briefly during config
+	// changes, implementations should apply a grace period before deleting.
+	CollectGarbage(ctx context.Context, activePeerIDs []string) error
+}
+
+// DiscovererDatabaseKeyRotations is an optional extension of
+// DiscovererDatabase. If the DiscovererDatabase implements it, the discoverer
+// persists the key rotations of other peers it has accepted, so that it
+// doesn't forget them, or which of them conflict, when it restarts.
+type DiscovererDatabaseKeyRotations interface {
+	// StoreKeyRotations replaces the stored serialized key rotations.
+	StoreKeyRotations(ctx context.Context, keyRotations []byte) error
+
+	// ReadKeyRotations returns the stored serialized key rotations, or nil if
+	// there are none.
+	ReadKeyRotations(ctx context.Context) ([]byte, error)
+}
@@ -0,0 +1,434 @@
+// Package eventconfigtracker provides a types.ContractConfigTracker that is
+// driven by a stream of config-change events and chain heads, rather than by
+// polling a contract.
+//
+// Blockchain clients typically deliver config-change logs through a
+// subscription. On reorgs, logs from blocks that are no longer canonical are
+// re-delivered with a "removed" flag. EventDrivenContractConfigTracker keeps
+// track of these events together with the hashes of recent canonical blocks,
+// so that it only ever reports configs that are part of the canonical chain.
+package eventconfigtracker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// BlockHash identifies a block. For chains with shorter block identifiers,
+// zero-pad on the left.
+type BlockHash [32]byte
+
+// Head describes a block that has become the tip of the canonical chain.
+type Head struct {
+	Number     uint64
+	Hash       BlockHash
+	ParentHash BlockHash
+}
+
+// ConfigEvent describes a config-change log emitted by the contract.
+type ConfigEvent struct {
+	BlockNumber uint64
+	BlockHash   BlockHash
+	// Position of the log within its block. Used to order multiple config
+	// changes within the same block.
+	LogIndex uint
+	// Removed is set when the log has been reverted by a reorg.
+	Removed bool
+	Config  types.ContractConfig
+}
+
+// ConfigStatus describes a config that the tracker currently considers part of
+// the canonical chain.
+type ConfigStatus struct {
+	ConfigDigest types.ConfigDigest
+	BlockNumber  uint64
+	BlockHash    BlockHash
+	// Confirmations is the number of blocks (including the block containing
+	// the config change) that have been observed on top of the config change.
+	// It is at least one, since configs in blocks above the latest head are
+	// not considered canonical yet.
+	Confirmations uint64
+}
+
+type eventKey struct {
+	blockHash BlockHash
+	logIndex  uint
+}
+
+var _ types.ContractConfigTracker = (*EventDrivenContractConfigTracker)(nil)
+
+// EventDrivenContractConfigTracker adapts a stream of ConfigEvents and Heads
+// into a types.ContractConfigTracker. Callers forward their subscriptions to
+// HandleConfigEvent and HandleHead. Notify fires whenever the latest canonical
+// config changes and on every new head until the latest config has
+// notifyConfirmations confirmations, so that config changes are enacted as
+// soon as they are sufficiently confirmed.
+//
+// Events in blocks above the latest head are pending and only become
+// canonical once a head at or above their block confirms them. Heads and
+// events older than finalityDepth blocks below the latest head are considered
+// final and are pruned, except for the latest final config.
+//
+// All its functions are thread-safe.
+type EventDrivenContractConfigTracker struct {
+	logger              commontypes.Logger
+	finalityDepth       uint64
+	notifyConfirmations uint64
+
+	chNotify chan struct{}
+
+	mutex sync.Mutex
+	// heads observed within finalityDepth of the latest head, by hash
+	heads map[BlockHash]Head
+	// canonical block hashes by height, obtained by following parent hashes
+	// from the latest head through heads
+	canonical  map[uint64]BlockHash
+	latestHead *Head
+	events     map[eventKey]ConfigEvent
+	reorgs     uint64
+	// digest of the latest canonical config, used to detect changes
+	lastNotifiedDigest types.ConfigDigest
+}
+
+// NewEventDrivenContractConfigTracker returns a new tracker. finalityDepth
+// must be positive. notifyConfirmations should match the
+// ContractConfigConfirmations in the LocalConfig of the oracles using the
+// tracker.
+func NewEventDrivenContractConfigTracker(
+	logger commontypes.Logger,
+	finalityDepth uint64,
+	notifyConfirmations uint16,
+) (*EventDrivenContractConfigTracker, error) {
+	if finalityDepth == 0 {
+		return nil, fmt.Errorf("finalityDepth must be positive")
+	}
+	return &EventDrivenContractConfigTracker{
+		logger,
+		finalityDepth,
+		uint64(notifyConfirmations),
+
+		make(chan struct{}, 1),
+
+		sync.Mutex{},
+		map[BlockHash]Head{},
+		map[uint64]BlockHash{},
+		nil,
+		map[eventKey]ConfigEvent{},
+		0,
+		types.ConfigDigest{},
+	}, nil
+}
+
+// HandleConfigEvent must be called for every config-change log delivered by
+// the chain client, including logs with the Removed flag set. Events may be
+// delivered multiple times; duplicates are ignored.
+func (t *EventDrivenContractConfigTracker) HandleConfigEvent(event ConfigEvent) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	key := eventKey{event.BlockHash, event.LogIndex}
+	if event.Removed {
+		if _, ok := t.events[key]; ok {
+			delete(t.events, key)
+			t.logger.Info("EventDrivenContractConfigTracker: config event removed by reorg", commontypes.LogFields{
+				"blockNumber":  event.BlockNumber,
+				"blockHash":    fmt.Sprintf("%x", event.BlockHash),
+				"configDigest": event.Config.ConfigDigest,
+			})
+		}
+	} else {
+		if t.latestHead != nil && event.BlockNumber+t.finalityDepth <= t.latestHead.Number {
+			if hash, ok := t.canonical[event.BlockNumber]; !ok || hash != event.BlockHash {
+				// Too old to verify against our view of the canonical chain.
+				// This is fine if the event is a late delivery for a block
+				// we have already pruned, but we cannot tell the difference
+				// from a stale event.
+				t.logger.Warn("EventDrivenContractConfigTracker: received config event for block beyond finality depth", commontypes.LogFields{
+					"blockNumber":      event.BlockNumber,
+					"latestHeadNumber": t.latestHead.Number,
+					"finalityDepth":    t.finalityDepth,
+				})
+			}
+		}
+		t.events[key] = event
+	}
+
+	t.maybeNotify()
+}
+
+// HandleHead must be called for every new head of the canonical chain. Heads
+// may skip heights. On every head, the canonical chain is rebuilt by following
+// parent hashes from the head, and all tracked events are re-validated
+// against it. A head that conflicts with a previously observed canonical
+// block is interpreted as a reorg.
+func (t *EventDrivenContractConfigTracker) HandleHead(head Head) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.latestHead != nil && head.Number <= t.latestHead.Number {
+		if hash, ok := t.canonical[head.Number]; ok && hash == head.Hash {
+			// duplicate
+			return
+		}
+	}
+
+	previousCanonical := t.canonical
+	previousHead := t.latestHead
+	t.heads[head.Hash] = head
+	t.latestHead = &head
+	t.canonical = t.canonicalChain(previousCanonical)
+
+	// A head at or below the previous one replaces blocks we considered
+	// canonical. A head above it may still change blocks further down.
+	reorged := previousHead != nil && head.Number <= previousHead.Number
+	for number, hash := range t.canonical {
+		if previousHash, ok := previousCanonical[number]; ok && previousHash != hash {
+			reorged = true
+		}
+	}
+
+	if reorged {
+		t.reorgs++
+		t.logger.Info("EventDrivenContractConfigTracker: detected reorg", commontypes.LogFields{
+			"headNumber": head.Number,
+			"headHash":   fmt.Sprintf("%x", head.Hash),
+			"reorgs":     t.reorgs,
+		})
+	}
+
+	t.prune()
+
+	latest, ok := t.latestCanonicalEvent()
+	if ok && t.confirmations(latest.BlockNumber) <= t.notifyConfirmations {
+		t.notify()
+	}
+	t.maybeNotify()
+}
+
+// Notify fires when the latest canonical config changes and on every head
+// until the latest config has been sufficiently confirmed.
+func (t *EventDrivenContractConfigTracker) Notify() <-chan struct{} {
+	return t.chNotify
+}
+
+// LatestConfigDetails returns the block number and digest of the latest
+// canonical config change. If no config change has been observed, the zero
+// ConfigDigest is returned.
+func (t *EventDrivenContractConfigTracker) LatestConfigDetails(ctx context.Context) (changedInBlock uint64, configDigest types.ConfigDigest, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	event, ok := t.latestCanonicalEvent()
+	if !ok {
+		return 0, types.ConfigDigest{}, nil
+	}
+	return event.BlockNumber, event.Config.ConfigDigest, nil
+}
+
+// LatestConfig returns the latest canonical config changed in changedInBlock.
+func (t *EventDrivenContractConfigTracker) LatestConfig(ctx context.Context, changedInBlock uint64) (types.ContractConfig, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	events := t.canonicalEvents()
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].BlockNumber == changedInBlock {
+			return events[i].Config, nil
+		}
+	}
+	return types.ContractConfig{}, fmt.Errorf("no canonical config change in block %v", changedInBlock)
+}
+
+// LatestBlockHeight returns the number of the latest head. An error is
+// returned if no head has been observed yet.
+func (t *EventDrivenContractConfigTracker) LatestBlockHeight(ctx context.Context) (blockHeight uint64, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.latestHead == nil {
+		return 0, fmt.Errorf("no head observed yet")
+	}
+	return t.latestHead.Number, nil
+}
+
+// Configs returns the status of all config changes currently considered
+// canonical and tracked by t, ordered from oldest to newest.
+func (t *EventDrivenContractConfigTracker) Configs() []ConfigStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	events := t.canonicalEvents()
+	statuses := make([]ConfigStatus, 0, len(events))
+	for _, event := range events {
+		statuses = append(statuses, ConfigStatus{
+			event.Config.ConfigDigest,
+			event.BlockNumber,
+			event.BlockHash,
+			t.confirmations(event.BlockNumber),
+		})
+	}
+	return statuses
+}
+
+// Confirmations returns the confirmation depth of the config with the given
+// digest. ok is false if the config is not currently considered canonical.
+func (t *EventDrivenContractConfigTracker) Confirmations(configDigest types.ConfigDigest) (confirmations uint64, ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	events := t.canonicalEvents()
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Config.ConfigDigest == configDigest {
+			return t.confirmations(events[i].BlockNumber), true
+		}
+	}
+	return 0, false
+}
+
+// Reorgs returns the number of reorgs detected so far.
+func (t *EventDrivenContractConfigTracker) Reorgs() uint64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.reorgs
+}
+
+// Must be called with mutex held.
+func (t *EventDrivenContractConfigTracker) confirmations(blockNumber uint64) uint64 {
+	if t.latestHead == nil || t.latestHead.Number < blockNumber {
+		return 0
+	}
+	return t.latestHead.Number - blockNumber + 1
+}
+
+// Must be called with mutex held. Returns the canonical block hashes by
+// height, as far down as parent hashes can be followed through t.heads. If
+// the lowest block reached this way is also part of previous, the lower
+// heights of previous are carried over, since they are its ancestors.
+func (t *EventDrivenContractConfigTracker) canonicalChain(previous map[uint64]BlockHash) map[uint64]BlockHash {
+	canonical := map[uint64]BlockHash{}
+	if t.latestHead == nil {
+		return canonical
+	}
+	head := *t.latestHead
+	canonical[head.Number] = head.Hash
+	for head.Number > 0 {
+		canonical[head.Number-1] = head.ParentHash
+		parent, ok := t.heads[head.ParentHash]
+		if !ok || parent.Number+1 != head.Number {
+			break
+		}
+		head = parent
+	}
+
+	lowest := head.Number
+	if lowest > 0 {
+		lowest--
+	}
+	if hash, ok := previous[lowest]; ok && hash == canonical[lowest] {
+		for number, hash := range previous {
+			if number < lowest {
+				canonical[number] = hash
+			}
+		}
+	}
+	return canonical
+}
+
+// Must be called with mutex held.
+func (t *EventDrivenContractConfigTracker) isCanonical(event ConfigEvent) bool {
+	if t.latestHead == nil || event.BlockNumber > t.latestHead.Number {
+		// Events may arrive before the corresponding head. They are pending
+		// until a head at or above their block is observed, since they might
+		// be orphaned before that.
+		return false
+	}
+	hash, ok := t.canonical[event.BlockNumber]
+	// If we don't know the canonical hash at this height (e.g. because heads
+	// skipped it), we rely on the chain client to deliver a removal.
+	return !ok || hash == event.BlockHash
+}
+
+// Must be called with mutex held. Returns events ordered from oldest to
+// newest.
+func (t *EventDrivenContractConfigTracker) canonicalEvents() []ConfigEvent {
+	events := make([]ConfigEvent, 0, len(t.events))
+	for _, event := range t.events {
+		if t.isCanonical(event) {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].BlockNumber != events[j].BlockNumber {
+			return events[i].BlockNumber < events[j].BlockNumber
+		}
+		return events[i].LogIndex < events[j].LogIndex
+	})
+	return events
+}
+
+// Must be called with mutex held.
+func (t *EventDrivenContractConfigTracker) latestCanonicalEvent() (ConfigEvent, bool) {
+	events := t.canonicalEvents()
+	if len(events) == 0 {
+		return ConfigEvent{}, false
+	}
+	return events[len(events)-1], true
+}
+
+// Must be called with mutex held.
+func (t *EventDrivenContractConfigTracker) prune() {
+	if t.latestHead == nil || t.latestHead.Number < t.finalityDepth {
+		return
+	}
+	finalized := t.latestHead.Number - t.finalityDepth
+
+	// Keep the latest final canonical event, since it may still be the
+	// latest config.
+	var latestFinal *ConfigEvent
+	for _, event := range t.canonicalEvents() {
+		if event.BlockNumber <= finalized {
+			latestFinal = &event
+		}
+	}
+	for key, event := range t.events {
+		if event.BlockNumber > finalized {
+			continue
+		}
+		if latestFinal != nil && key == (eventKey{latestFinal.BlockHash, latestFinal.LogIndex}) {
+			continue
+		}
+		delete(t.events, key)
+	}
+	for hash, head := range t.heads {
+		if head.Number < finalized {
+			delete(t.heads, hash)
+		}
+	}
+	for number := range t.canonical {
+		if number < finalized {
+			delete(t.canonical, number)
+		}
+	}
+}
+
+// Must be called with mutex held.
+func (t *EventDrivenContractConfigTracker) maybeNotify() {
+	latest, _ := t.latestCanonicalEvent()
+	if latest.Config.ConfigDigest != t.lastNotifiedDigest {
+		t.lastNotifiedDigest = latest.Config.ConfigDigest
+		t.notify()
+	}
+}
+
+func (t *EventDrivenContractConfigTracker) notify() {
+	select {
+	case t.chNotify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,200 @@
+package signedconfig
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+var _ types.ContractConfigTracker = (*ContractConfigTracker)(nil)
+
+// ContractConfigTracker reads SignedConfigs from the local filesystem. Path
+// may either point to a single file or to a directory. In the latter case,
+// every file in the directory ending in ".json" is considered and the valid
+// config with the highest ConfigCount is used. Config files that fail to parse
+// or verify are logged and ignored.
+//
+// Since there is no blockchain, ConfigCount plays the role of the block
+// number: LatestConfigDetails reports changedInBlock = ConfigCount. Signed
+// configs are final, so LatestBlockHeight reports a height at which every
+// known config is confirmed regardless of ContractConfigConfirmations.
+//
+// The tracker never goes back to a config with a lower ConfigCount than one it
+// has returned before, even if the newer config file is removed. This prevents
+// rollbacks to older configs by anyone who can only delete files.
+//
+// All its functions are thread-safe.
+type ContractConfigTracker struct {
+	path            string
+	digester        OffchainConfigDigester
+	adminPublicKeys []ed25519.PublicKey
+	quorum          int
+	logger          commontypes.Logger
+
+	mutex  sync.Mutex
+	latest *types.ContractConfig
+}
+
+func NewContractConfigTracker(
+	path string,
+	digester OffchainConfigDigester,
+	adminPublicKeys []ed25519.PublicKey,
+	quorum int,
+	logger commontypes.Logger,
+) (*ContractConfigTracker, error) {
+	if err := checkAdminPublicKeys(adminPublicKeys, quorum); err != nil {
+		return nil, err
+	}
+	return &ContractConfigTracker{
+		path,
+		digester,
+		adminPublicKeys,
+		quorum,
+		logger,
+
+		sync.Mutex{},
+		nil,
+	}, nil
+}
+
+// Notify returns a nil channel. Config files are re-read whenever the oracle
+// polls, i.e. every ContractConfigTrackerPollInterval.
+func (t *ContractConfigTracker) Notify() <-chan struct{} {
+	return nil
+}
+
+func (t *ContractConfigTracker) LatestConfigDetails(ctx context.Context) (changedInBlock uint64, configDigest types.ConfigDigest, err error) {
+	latest, err := t.refresh()
+	if err != nil {
+		return 0, types.ConfigDigest{}, err
+	}
+	if latest == nil {
+		return 0, types.ConfigDigest{}, nil
+	}
+	return latest.ConfigCount, latest.ConfigDigest, nil
+}
+
+func (t *ContractConfigTracker) LatestConfig(ctx context.Context, changedInBlock uint64) (types.ContractConfig, error) {
+	latest, err := t.refresh()
+	if err != nil {
+		return types.ContractConfig{}, err
+	}
+	if latest == nil || latest.ConfigCount != changedInBlock {
+		return types.ContractConfig{}, fmt.Errorf("no config with ConfigCount %v", changedInBlock)
+	}
+	return *latest, nil
+}
+
+func (t *ContractConfigTracker) LatestBlockHeight(ctx context.Context) (blockHeight uint64, err error) {
+	latest, err := t.refresh()
+	if err != nil {
+		return 0, err
+	}
+	if latest == nil {
+		return 0, nil
+	}
+	// ContractConfigConfirmations is a uint16, so this is guaranteed to
+	// exceed changedInBlock+ContractConfigConfirmations-1.
+	if latest.ConfigCount > math.MaxUint64-math.MaxUint16 {
+		return math.MaxUint64, nil
+	}
+	return latest.ConfigCount + math.MaxUint16, nil
+}
+
+func (t *ContractConfigTracker) refresh() (*types.ContractConfig, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	paths, err := t.configPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidate *types.ContractConfig
+	for _, path := range paths {
+		config, err := t.readConfig(path)
+		if err != nil {
+			t.logger.Warn("SignedConfig: ignoring invalid config file", commontypes.LogFields{
+				"path":  path,
+				"error": err,
+			})
+			continue
+		}
+		if candidate != nil && candidate.ConfigCount == config.ConfigCount && candidate.ConfigDigest != config.ConfigDigest {
+			return nil, fmt.Errorf("found conflicting configs with ConfigCount %v: %v and %v", config.ConfigCount, candidate.ConfigDigest, config.ConfigDigest)
+		}
+		if candidate == nil || candidate.ConfigCount < config.ConfigCount {
+			candidate = &config
+		}
+	}
+
+	if candidate == nil {
+		return t.latest, nil
+	}
+	if t.latest != nil {
+		if candidate.ConfigCount < t.latest.ConfigCount {
+			t.logger.Warn("SignedConfig: latest config file has lower ConfigCount than previously seen config, sticking with previous config", commontypes.LogFields{
+				"configCount":         candidate.ConfigCount,
+				"previousConfigCount": t.latest.ConfigCount,
+			})
+			return t.latest, nil
+		}
+		if candidate.ConfigCount == t.latest.ConfigCount && candidate.ConfigDigest != t.latest.ConfigDigest {
+			return nil, fmt.Errorf("found config with ConfigCount %v that conflicts with previously seen config: %v vs %v", candidate.ConfigCount, candidate.ConfigDigest, t.latest.ConfigDigest)
+		}
+	}
+	t.latest = candidate
+	return t.latest, nil
+}
+
+func (t *ContractConfigTracker) configPaths() ([]string, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("could not stat config path: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{t.path}, nil
+	}
+
+	entries, err := os.ReadDir(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config directory: %w", err)
+	}
+	paths := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		paths = append(paths, filepath.Join(t.path, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (t *ContractConfigTracker) readConfig(path string) (types.ContractConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return types.ContractConfig{}, err
+	}
+	var sc SignedConfig
+	if err := json.Unmarshal(raw, &sc); err != nil {
+		return types.ContractConfig{}, fmt.Errorf("could not parse config: %w", err)
+	}
+	if sc.Config.ConfigCount == 0 {
+		return types.ContractConfig{}, fmt.Errorf("ConfigCount must be positive")
+	}
+	if err := sc.Verify(t.digester, t.adminPublicKeys, t.quorum); err != nil {
+		return types.ContractConfig{}, err
+	}
+	return sc.Config, nil
+}
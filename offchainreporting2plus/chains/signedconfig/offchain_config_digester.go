@@ -0,0 +1,72 @@
+// Package signedconfig allows running OCR instances without a blockchain.
+// Instead of being read from a contract, configs are distributed as files that
+// are signed by a quorum of admin keys.
+package signedconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+const configDigestDomainSeparator = "ocr SignedConfig ConfigDigest"
+
+var _ types.OffchainConfigDigester = OffchainConfigDigester{}
+
+// OffchainConfigDigester computes ConfigDigests for configs that are
+// distributed off-chain. InstanceID plays the role that chain id and contract
+// address play for on-chain configs: it provides domain separation between
+// different protocol instances. Every protocol instance must use a distinct
+// InstanceID.
+type OffchainConfigDigester struct {
+	InstanceID string
+}
+
+func (d OffchainConfigDigester) ConfigDigest(_ context.Context, cc types.ContractConfig) (types.ConfigDigest, error) {
+	if len(d.InstanceID) == 0 {
+		return types.ConfigDigest{}, fmt.Errorf("InstanceID must not be empty")
+	}
+
+	h := sha256.New()
+
+	_, _ = h.Write([]byte(configDigestDomainSeparator))
+
+	_ = binary.Write(h, binary.BigEndian, uint64(len(d.InstanceID)))
+	_, _ = h.Write([]byte(d.InstanceID))
+
+	_ = binary.Write(h, binary.BigEndian, cc.ConfigCount)
+
+	_ = binary.Write(h, binary.BigEndian, uint64(len(cc.Signers)))
+	for _, signer := range cc.Signers {
+		_ = binary.Write(h, binary.BigEndian, uint64(len(signer)))
+		_, _ = h.Write(signer)
+	}
+
+	_ = binary.Write(h, binary.BigEndian, uint64(len(cc.Transmitters)))
+	for _, transmitter := range cc.Transmitters {
+		_ = binary.Write(h, binary.BigEndian, uint64(len(transmitter)))
+		_, _ = h.Write([]byte(transmitter))
+	}
+
+	_ = binary.Write(h, binary.BigEndian, cc.F)
+
+	_ = binary.Write(h, binary.BigEndian, uint64(len(cc.OnchainConfig)))
+	_, _ = h.Write(cc.OnchainConfig)
+
+	_ = binary.Write(h, binary.BigEndian, cc.OffchainConfigVersion)
+
+	_ = binary.Write(h, binary.BigEndian, uint64(len(cc.OffchainConfig)))
+	_, _ = h.Write(cc.OffchainConfig)
+
+	var configDigest types.ConfigDigest
+	h.Sum(configDigest[:0])
+	binary.BigEndian.PutUint16(configDigest[:2], uint16(types.ConfigDigestPrefixSignedConfig))
+	return configDigest, nil
+}
+
+func (d OffchainConfigDigester) ConfigDigestPrefix(context.Context) (types.ConfigDigestPrefix, error) {
+	return types.ConfigDigestPrefixSignedConfig, nil
+}
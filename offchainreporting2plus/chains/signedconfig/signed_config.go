@@ -0,0 +1,245 @@
+package signedconfig
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+const adminSignatureDomainSeparator = "ocr SignedConfig AdminSignature"
+
+// AdminSignature is an Ed25519 signature by an admin key over the
+// ConfigDigest of a config.
+type AdminSignature struct {
+	AdminPublicKey ed25519.PublicKey
+	Signature      []byte
+}
+
+// SignedConfig is a config together with the admin signatures that authorize
+// it. Since the ConfigDigest commits to all fields of the config (and to the
+// InstanceID of the OffchainConfigDigester), admins only sign the
+// ConfigDigest.
+type SignedConfig struct {
+	Config     types.ContractConfig
+	Signatures []AdminSignature
+}
+
+// MakeUnsignedConfig assembles a config for the protocol instance identified
+// by digester and computes its ConfigDigest. The remaining arguments are
+// typically obtained from one of the confighelper packages.
+func MakeUnsignedConfig(
+	digester OffchainConfigDigester,
+	configCount uint64,
+	signers []types.OnchainPublicKey,
+	transmitters []types.Account,
+	f uint8,
+	onchainConfig []byte,
+	offchainConfigVersion uint64,
+	offchainConfig []byte,
+) (SignedConfig, error) {
+	if configCount == 0 {
+		return SignedConfig{}, fmt.Errorf("configCount must be positive")
+	}
+	config := types.ContractConfig{
+		types.ConfigDigest{},
+		configCount,
+		signers,
+		transmitters,
+		f,
+		onchainConfig,
+		offchainConfigVersion,
+		offchainConfig,
+	}
+	configDigest, err := digester.ConfigDigest(context.Background(), config)
+	if err != nil {
+		return SignedConfig{}, fmt.Errorf("could not compute ConfigDigest: %w", err)
+	}
+	config.ConfigDigest = configDigest
+	return SignedConfig{config, nil}, nil
+}
+
+func adminSignatureMsg(configDigest types.ConfigDigest) []byte {
+	h := sha256.New()
+	_, _ = h.Write([]byte(adminSignatureDomainSeparator))
+	_, _ = h.Write(configDigest[:])
+	return h.Sum(nil)
+}
+
+// Sign adds a signature by adminPrivateKey to sc. Any previous signature by
+// the same key is replaced.
+func (sc *SignedConfig) Sign(adminPrivateKey ed25519.PrivateKey) {
+	publicKey := adminPrivateKey.Public().(ed25519.PublicKey)
+	signature := ed25519.Sign(adminPrivateKey, adminSignatureMsg(sc.Config.ConfigDigest))
+
+	for i, sig := range sc.Signatures {
+		if sig.AdminPublicKey.Equal(publicKey) {
+			sc.Signatures[i].Signature = signature
+			return
+		}
+	}
+	sc.Signatures = append(sc.Signatures, AdminSignature{publicKey, signature})
+}
+
+// Verify checks that the ConfigDigest of sc was computed correctly by digester
+// and that sc carries valid signatures by at least quorum distinct keys from
+// adminPublicKeys, which must not contain duplicates. Signatures by unknown
+// keys are ignored.
+func (sc SignedConfig) Verify(digester OffchainConfigDigester, adminPublicKeys []ed25519.PublicKey, quorum int) error {
+	if err := checkAdminPublicKeys(adminPublicKeys, quorum); err != nil {
+		return err
+	}
+
+	configDigest, err := digester.ConfigDigest(context.Background(), sc.Config)
+	if err != nil {
+		return fmt.Errorf("could not compute ConfigDigest: %w", err)
+	}
+	if configDigest != sc.Config.ConfigDigest {
+		return fmt.Errorf("ConfigDigest mismatch, expected %v but config has %v", configDigest, sc.Config.ConfigDigest)
+	}
+
+	msg := adminSignatureMsg(sc.Config.ConfigDigest)
+	signed := make([]bool, len(adminPublicKeys))
+	signatures := 0
+	for _, sig := range sc.Signatures {
+		for i, adminPublicKey := range adminPublicKeys {
+			if signed[i] || !adminPublicKey.Equal(sig.AdminPublicKey) {
+				continue
+			}
+			if len(sig.AdminPublicKey) != ed25519.PublicKeySize || !ed25519.Verify(adminPublicKey, msg, sig.Signature) {
+				return fmt.Errorf("invalid signature by admin key %x", []byte(adminPublicKey))
+			}
+			signed[i] = true
+			signatures++
+		}
+	}
+	if signatures < quorum {
+		return fmt.Errorf("config has valid signatures by %v admin keys, but quorum is %v", signatures, quorum)
+	}
+	return nil
+}
+
+// checkAdminPublicKeys rejects duplicate admin keys, since a single admin's
+// signature would otherwise count once per duplicate towards quorum.
+func checkAdminPublicKeys(adminPublicKeys []ed25519.PublicKey, quorum int) error {
+	if quorum <= 0 || len(adminPublicKeys) < quorum {
+		return fmt.Errorf("quorum (%v) must be positive and at most the number of admin keys (%v)", quorum, len(adminPublicKeys))
+	}
+	for i, adminPublicKey := range adminPublicKeys {
+		if len(adminPublicKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("%v-th admin public key has wrong length %v", i, len(adminPublicKey))
+		}
+		for j := 0; j < i; j++ {
+			if adminPublicKeys[j].Equal(adminPublicKey) {
+				return fmt.Errorf("%v-th and %v-th admin public keys are identical: %x", j, i, []byte(adminPublicKey))
+			}
+		}
+	}
+	return nil
+}
+
+type adminSignatureJSON struct {
+	AdminPublicKey string `json:"adminPublicKey"`
+	Signature      string `json:"signature"`
+}
+
+type signedConfigJSON struct {
+	ConfigDigest          types.ConfigDigest   `json:"configDigest"`
+	ConfigCount           uint64               `json:"configCount"`
+	Signers               []string             `json:"signers"`
+	Transmitters          []types.Account      `json:"transmitters"`
+	F                     uint8                `json:"f"`
+	OnchainConfig         string               `json:"onchainConfig"`
+	OffchainConfigVersion uint64               `json:"offchainConfigVersion"`
+	OffchainConfig        string               `json:"offchainConfig"`
+	Signatures            []adminSignatureJSON `json:"signatures"`
+}
+
+var _ json.Marshaler = SignedConfig{}
+
+// MarshalJSON encodes sc as JSON with all binary fields hex-encoded, so that
+// config files can be reviewed by humans before signing.
+func (sc SignedConfig) MarshalJSON() ([]byte, error) {
+	signers := make([]string, 0, len(sc.Config.Signers))
+	for _, signer := range sc.Config.Signers {
+		signers = append(signers, hex.EncodeToString(signer))
+	}
+	signatures := make([]adminSignatureJSON, 0, len(sc.Signatures))
+	for _, sig := range sc.Signatures {
+		signatures = append(signatures, adminSignatureJSON{
+			hex.EncodeToString(sig.AdminPublicKey),
+			hex.EncodeToString(sig.Signature),
+		})
+	}
+	return json.Marshal(signedConfigJSON{
+		sc.Config.ConfigDigest,
+		sc.Config.ConfigCount,
+		signers,
+		sc.Config.Transmitters,
+		sc.Config.F,
+		hex.EncodeToString(sc.Config.OnchainConfig),
+		sc.Config.OffchainConfigVersion,
+		hex.EncodeToString(sc.Config.OffchainConfig),
+		signatures,
+	})
+}
+
+var _ json.Unmarshaler = &SignedConfig{}
+
+func (sc *SignedConfig) UnmarshalJSON(data []byte) error {
+	var raw signedConfigJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	signers := make([]types.OnchainPublicKey, 0, len(raw.Signers))
+	for i, signer := range raw.Signers {
+		b, err := hex.DecodeString(signer)
+		if err != nil {
+			return fmt.Errorf("could not decode %v-th signer: %w", i, err)
+		}
+		signers = append(signers, types.OnchainPublicKey(b))
+	}
+	onchainConfig, err := hex.DecodeString(raw.OnchainConfig)
+	if err != nil {
+		return fmt.Errorf("could not decode onchainConfig: %w", err)
+	}
+	offchainConfig, err := hex.DecodeString(raw.OffchainConfig)
+	if err != nil {
+		return fmt.Errorf("could not decode offchainConfig: %w", err)
+	}
+	signatures := make([]AdminSignature, 0, len(raw.Signatures))
+	for i, sig := range raw.Signatures {
+		publicKey, err := hex.DecodeString(sig.AdminPublicKey)
+		if err != nil {
+			return fmt.Errorf("could not decode admin public key of %v-th signature: %w", i, err)
+		}
+		if len(publicKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("admin public key of %v-th signature has wrong length %v", i, len(publicKey))
+		}
+		signature, err := hex.DecodeString(sig.Signature)
+		if err != nil {
+			return fmt.Errorf("could not decode %v-th signature: %w", i, err)
+		}
+		signatures = append(signatures, AdminSignature{publicKey, signature})
+	}
+
+	*sc = SignedConfig{
+		types.ContractConfig{
+			raw.ConfigDigest,
+			raw.ConfigCount,
+			signers,
+			raw.Transmitters,
+			raw.F,
+			onchainConfig,
+			raw.OffchainConfigVersion,
+			offchainConfig,
+		},
+		signatures,
+	}
+	return nil
+}
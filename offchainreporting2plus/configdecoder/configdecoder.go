@@ -0,0 +1,237 @@
+// Package configdecoder fully decodes OCR2, OCR3, and OCR3.1 configs outside
+// of a running oracle, including the shared secret, given the config
+// decryption key of one of the oracles. It is intended for auditing configs.
+//
+// When decoded with a decryption key, a DecodedConfig contains the shared
+// secret in plaintext, so handle it (and its JSON encoding) as secret.
+package configdecoder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr2config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"golang.org/x/crypto/curve25519"
+)
+
+// Oracle describes an oracle of a config. Binary fields are hex-encoded.
+type Oracle struct {
+	OracleID          commontypes.OracleID `json:"oracleID"`
+	OffchainPublicKey string               `json:"offchainPublicKey"`
+	OnchainPublicKey  string               `json:"onchainPublicKey"`
+	PeerID            string               `json:"peerID"`
+	TransmitAccount   types.Account        `json:"transmitAccount"`
+}
+
+// SharedSecret holds the SharedSecretEncryptions of a config and, if
+// decryption succeeded, the decrypted shared secret. Binary fields are
+// hex-encoded.
+type SharedSecret struct {
+	DiffieHellmanPoint string   `json:"diffieHellmanPoint"`
+	SharedSecretHash   string   `json:"sharedSecretHash"`
+	Encryptions        []string `json:"encryptions"`
+
+	// The oracle whose encryption was decrypted. nil if decryption wasn't
+	// attempted.
+	DecryptedBy *commontypes.OracleID `json:"decryptedBy,omitempty"`
+	// The decrypted shared secret. Its keccak256 hash has been checked against
+	// SharedSecretHash.
+	Decrypted string `json:"decrypted,omitempty"`
+}
+
+// DecodedConfig is the result of decoding a config. It can be serialized to
+// JSON.
+type DecodedConfig struct {
+	ConfigDigest          types.ConfigDigest `json:"configDigest"`
+	ConfigCount           uint64             `json:"configCount"`
+	OffchainConfigVersion uint64             `json:"offchainConfigVersion"`
+	F                     int                `json:"f"`
+	Oracles               []Oracle           `json:"oracles"`
+	OnchainConfig         string             `json:"onchainConfig"`
+	ReportingPluginConfig string             `json:"reportingPluginConfig"`
+
+	// All other fields of the version-specific public config. Durations are
+	// formatted as strings, binary values are hex-encoded, and unset optional
+	// fields are null.
+	Parameters map[string]interface{} `json:"parameters"`
+
+	SharedSecret SharedSecret `json:"sharedSecret"`
+
+	// Non-empty if the config fails the resource exhaustion or production
+	// sanity checks that oracles apply by default. Such configs are decoded
+	// nonetheless.
+	ChecksError string `json:"checksError,omitempty"`
+}
+
+// Decode decodes change without decrypting the shared secret.
+func Decode(change types.ContractConfig) (DecodedConfig, error) {
+	return decode(change, nil)
+}
+
+// DecodeWithOffchainKeyring decodes change and decrypts the shared secret
+// using offchainKeyring. It fails if none of the SharedSecretEncryptions
+// decrypts to a secret matching the published SharedSecretHash.
+func DecodeWithOffchainKeyring(change types.ContractConfig, offchainKeyring types.OffchainKeyring) (DecodedConfig, error) {
+	return decode(change, offchainKeyring.ConfigDiffieHellman)
+}
+
+// DecodeWithConfigEncryptionSecretKey is like DecodeWithOffchainKeyring, but
+// takes the X25519 secret key that corresponds to an oracle's
+// ConfigEncryptionPublicKey.
+func DecodeWithConfigEncryptionSecretKey(change types.ContractConfig, configEncryptionSecretKey [curve25519.ScalarSize]byte) (DecodedConfig, error) {
+	return decode(change, func(point [curve25519.PointSize]byte) ([curve25519.PointSize]byte, error) {
+		sharedPoint, err := curve25519.X25519(configEncryptionSecretKey[:], point[:])
+		if err != nil {
+			return [curve25519.PointSize]byte{}, err
+		}
+		var result [curve25519.PointSize]byte
+		copy(result[:], sharedPoint)
+		return result, nil
+	})
+}
+
+func decode(
+	change types.ContractConfig,
+	configDiffieHellman func(point [curve25519.PointSize]byte) ([curve25519.PointSize]byte, error),
+) (DecodedConfig, error) {
+	var (
+		publicConfigPtr         interface{}
+		identities              []config.OracleIdentity
+		f                       int
+		onchainConfig           []byte
+		reportingPluginConfig   []byte
+		sharedSecretEncryptions config.SharedSecretEncryptions
+		checksErr               error
+	)
+	switch change.OffchainConfigVersion {
+	case config.OCR2OffchainConfigVersion:
+		pc, sse, err := ocr2config.PublicConfigAndSharedSecretEncryptionsFromContractConfig(true, change)
+		if err != nil {
+			return DecodedConfig{}, err
+		}
+		_, checksErr = ocr2config.PublicConfigFromContractConfig(false, change)
+		publicConfigPtr, identities, f, onchainConfig, reportingPluginConfig, sharedSecretEncryptions =
+			&pc, pc.OracleIdentities, pc.F, pc.OnchainConfig, pc.ReportingPluginConfig, sse
+	case config.OCR3OffchainConfigVersion:
+		pc, sse, err := ocr3config.PublicConfigAndSharedSecretEncryptionsFromContractConfig(true, change)
+		if err != nil {
+			return DecodedConfig{}, err
+		}
+		_, checksErr = ocr3config.PublicConfigFromContractConfig(false, change)
+		publicConfigPtr, identities, f, onchainConfig, reportingPluginConfig, sharedSecretEncryptions =
+			&pc, pc.OracleIdentities, pc.F, pc.OnchainConfig, pc.ReportingPluginConfig, sse
+	case config.OCR3_1OffchainConfigVersion:
+		pc, sse, err := ocr3_1config.PublicConfigAndSharedSecretEncryptionsFromContractConfig(true, change)
+		if err != nil {
+			return DecodedConfig{}, err
+		}
+		_, checksErr = ocr3_1config.PublicConfigFromContractConfig(false, change)
+		publicConfigPtr, identities, f, onchainConfig, reportingPluginConfig, sharedSecretEncryptions =
+			&pc, pc.OracleIdentities, pc.F, pc.OnchainConfig, pc.ReportingPluginConfig, sse
+	default:
+		return DecodedConfig{}, fmt.Errorf("unsupported OffchainConfigVersion %v", change.OffchainConfigVersion)
+	}
+
+	oracles := make([]Oracle, 0, len(identities))
+	for i, identity := range identities {
+		oracles = append(oracles, Oracle{
+			commontypes.OracleID(i),
+			hex.EncodeToString(identity.OffchainPublicKey[:]),
+			hex.EncodeToString(identity.OnchainPublicKey),
+			identity.PeerID,
+			identity.TransmitAccount,
+		})
+	}
+
+	sharedSecret, err := decodeSharedSecret(sharedSecretEncryptions, configDiffieHellman)
+	if err != nil {
+		return DecodedConfig{}, err
+	}
+
+	var checksError string
+	if checksErr != nil {
+		checksError = checksErr.Error()
+	}
+
+	return DecodedConfig{
+		change.ConfigDigest,
+		change.ConfigCount,
+		change.OffchainConfigVersion,
+		f,
+		oracles,
+		hex.EncodeToString(onchainConfig),
+		hex.EncodeToString(reportingPluginConfig),
+		formatParameters(publicConfigPtr),
+		sharedSecret,
+		checksError,
+	}, nil
+}
+
+func decodeSharedSecret(
+	e config.SharedSecretEncryptions,
+	configDiffieHellman func(point [curve25519.PointSize]byte) ([curve25519.PointSize]byte, error),
+) (SharedSecret, error) {
+	encryptions := make([]string, 0, len(e.Encryptions))
+	for _, encryption := range e.Encryptions {
+		encryptions = append(encryptions, hex.EncodeToString(encryption[:]))
+	}
+	result := SharedSecret{
+		hex.EncodeToString(e.DiffieHellmanPoint[:]),
+		hex.EncodeToString(e.SharedSecretHash[:]),
+		encryptions,
+		nil,
+		"",
+	}
+	if configDiffieHellman == nil {
+		return result, nil
+	}
+
+	oid, sharedSecret, err := e.DecryptAny(configDiffieHellman)
+	if err != nil {
+		return SharedSecret{}, fmt.Errorf("could not decrypt shared secret: %w", err)
+	}
+	result.DecryptedBy = &oid
+	result.Decrypted = hex.EncodeToString(sharedSecret[:])
+	return result, nil
+}
+
+// Fields of the public configs that DecodedConfig holds outside of
+// Parameters.
+var nonParameterFields = map[string]bool{
+	"OracleIdentities":      true,
+	"ReportingPluginConfig": true,
+	"OnchainConfig":         true,
+	"ConfigDigest":          true,
+	"F":                     true,
+}
+
+func formatParameters(publicConfigPtr interface{}) map[string]interface{} {
+	parameters := map[string]interface{}{}
+	for _, parameter := range config.PublicConfigParameters(publicConfigPtr, nonParameterFields) {
+		if parameter.Value == nil {
+			parameters[parameter.Name] = nil
+			continue
+		}
+		parameters[parameter.Name] = formatParameter(parameter.Value)
+	}
+	return parameters
+}
+
+func formatParameter(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Duration:
+		return v.String()
+	case []byte:
+		return hex.EncodeToString(v)
+	case types.HistoryDigest:
+		return v.String()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,348 @@
+// Package configdiff compares two configs of an OCR protocol instance and
+// reports the impact of moving from the old to the new one. It is intended for
+// reviewing a config change before it is submitted to the contract.
+//
+// Diff works on public config data only and never decrypts the shared
+// secret, so it needs none of the oracles' keys.
+package configdiff
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+type WarningKind string
+
+const (
+	WarningKindVersionChanged          WarningKind = "VersionChanged"
+	WarningKindConfigCountNotIncreased WarningKind = "ConfigCountNotIncreased"
+	WarningKindBelowByzQuorum          WarningKind = "BelowByzQuorum"
+	WarningKindFDecreased              WarningKind = "FDecreased"
+	WarningKindFBelowMax               WarningKind = "FBelowMax"
+	WarningKindSSumNotN                WarningKind = "SSumNotN"
+	WarningKindProductionChecksFailed  WarningKind = "ProductionChecksFailed"
+	WarningKindStateNotCarriedOver     WarningKind = "StateNotCarriedOver"
+	WarningKindStateCarryOverInvalid   WarningKind = "StateCarryOverInvalid"
+)
+
+type Warning struct {
+	Kind    WarningKind `json:"kind"`
+	Message string      `json:"message"`
+}
+
+// Oracle describes an oracle of a config. Binary fields are hex-encoded.
+// Index is the position of the oracle in the config, i.e. its OracleID.
+type Oracle struct {
+	Index             int           `json:"index"`
+	OffchainPublicKey string        `json:"offchainPublicKey"`
+	OnchainPublicKey  string        `json:"onchainPublicKey"`
+	PeerID            string        `json:"peerID"`
+	TransmitAccount   types.Account `json:"transmitAccount"`
+}
+
+// ChangedOracle is an oracle that is part of both configs, identified by its
+// OffchainPublicKey, but whose index or other identity fields differ.
+type ChangedOracle struct {
+	Old Oracle `json:"old"`
+	New Oracle `json:"new"`
+}
+
+// ParameterChange describes a changed protocol parameter, e.g. DeltaProgress.
+// If a parameter only exists in one of the configs' versions, the other side
+// is "(absent)".
+type ParameterChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// BlobChange describes a change to an opaque binary config field. Hashes are
+// hex-encoded SHA-256 digests of the field contents.
+type BlobChange struct {
+	OldLength int    `json:"oldLength"`
+	NewLength int    `json:"newLength"`
+	OldSHA256 string `json:"oldSHA256"`
+	NewSHA256 string `json:"newSHA256"`
+}
+
+// StateCarryOver describes whether the new config continues the replicated
+// state of the old one. It is only reported when the new config is an OCR3.1
+// config.
+type StateCarryOver struct {
+	// Whether the new config sets PrevConfigDigest, PrevSeqNr, and
+	// PrevHistoryDigest.
+	PrevFieldsSet     bool                `json:"prevFieldsSet"`
+	PrevConfigDigest  *types.ConfigDigest `json:"prevConfigDigest,omitempty"`
+	PrevSeqNr         *uint64             `json:"prevSeqNr,omitempty"`
+	PrevHistoryDigest string              `json:"prevHistoryDigest,omitempty"`
+	// Whether PrevConfigDigest equals the ConfigDigest of the old config.
+	ContinuesOldConfig bool `json:"continuesOldConfig"`
+	// Number of oracles of the new config that were also part of the old
+	// config and can thus serve the old state.
+	OverlappingOracles int `json:"overlappingOracles"`
+}
+
+// Report is the result of Diff. It can be serialized to JSON.
+type Report struct {
+	OldConfigDigest          types.ConfigDigest `json:"oldConfigDigest"`
+	NewConfigDigest          types.ConfigDigest `json:"newConfigDigest"`
+	OldConfigCount           uint64             `json:"oldConfigCount"`
+	NewConfigCount           uint64             `json:"newConfigCount"`
+	OldOffchainConfigVersion uint64             `json:"oldOffchainConfigVersion"`
+	NewOffchainConfigVersion uint64             `json:"newOffchainConfigVersion"`
+
+	OldN             int `json:"oldN"`
+	NewN             int `json:"newN"`
+	OldF             int `json:"oldF"`
+	NewF             int `json:"newF"`
+	OldByzQuorumSize int `json:"oldByzQuorumSize"`
+	NewByzQuorumSize int `json:"newByzQuorumSize"`
+
+	AddedOracles    []Oracle        `json:"addedOracles"`
+	RemovedOracles  []Oracle        `json:"removedOracles"`
+	ChangedOracles  []ChangedOracle `json:"changedOracles"`
+	RetainedOracles int             `json:"retainedOracles"`
+
+	ParameterChanges []ParameterChange `json:"parameterChanges"`
+
+	// nil if the respective field is unchanged
+	ReportingPluginConfigChange *BlobChange `json:"reportingPluginConfigChange,omitempty"`
+	OnchainConfigChange         *BlobChange `json:"onchainConfigChange,omitempty"`
+
+	// nil unless the new config is an OCR3.1 config
+	StateCarryOver *StateCarryOver `json:"stateCarryOver,omitempty"`
+
+	Warnings []Warning `json:"warnings"`
+}
+
+// Diff decodes oldConfig and newConfig and reports the differences between
+// them along with warnings about changes that are likely mistakes or that
+// reduce the fault tolerance of the protocol instance. The configs may use any
+// supported OffchainConfigVersion, and need not use the same one.
+//
+// Configs are decoded without resource exhaustion and production sanity
+// checks, so that Diff can also be used on test configs. If a config fails
+// these checks, a warning is emitted instead. An error is only returned if a
+// config can't be decoded at all.
+func Diff(oldConfig types.ContractConfig, newConfig types.ContractConfig) (Report, error) {
+	oldDecoded, err := decode(oldConfig)
+	if err != nil {
+		return Report{}, fmt.Errorf("could not decode old config: %w", err)
+	}
+	newDecoded, err := decode(newConfig)
+	if err != nil {
+		return Report{}, fmt.Errorf("could not decode new config: %w", err)
+	}
+
+	r := Report{
+		oldConfig.ConfigDigest,
+		newConfig.ConfigDigest,
+		oldConfig.ConfigCount,
+		newConfig.ConfigCount,
+		oldConfig.OffchainConfigVersion,
+		newConfig.OffchainConfigVersion,
+
+		oldDecoded.n,
+		newDecoded.n,
+		oldDecoded.f,
+		newDecoded.f,
+		oldDecoded.byzQuorumSize,
+		newDecoded.byzQuorumSize,
+
+		[]Oracle{},
+		[]Oracle{},
+		[]ChangedOracle{},
+		0,
+
+		diffParameters(oldDecoded, newDecoded),
+
+		diffBlob(oldDecoded.reportingPluginConfig, newDecoded.reportingPluginConfig),
+		diffBlob(oldDecoded.onchainConfig, newDecoded.onchainConfig),
+
+		nil,
+
+		[]Warning{},
+	}
+
+	r.diffOracles(oldDecoded, newDecoded)
+	r.checkVersionAndCount()
+	r.checkFaultTolerance(oldDecoded, newDecoded)
+	r.checkProductionChecks(newDecoded)
+	if newConfig.OffchainConfigVersion == config.OCR3_1OffchainConfigVersion {
+		r.diffStateCarryOver(oldDecoded, newDecoded)
+	}
+
+	return r, nil
+}
+
+func (r *Report) warn(kind WarningKind, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, Warning{kind, fmt.Sprintf(format, args...)})
+}
+
+func makeOracle(index int, identity config.OracleIdentity) Oracle {
+	return Oracle{
+		index,
+		hex.EncodeToString(identity.OffchainPublicKey[:]),
+		hex.EncodeToString(identity.OnchainPublicKey),
+		identity.PeerID,
+		identity.TransmitAccount,
+	}
+}
+
+// diffOracles matches oracles across configs by their OffchainPublicKey, which
+// is what identifies an oracle in the protocol.
+func (r *Report) diffOracles(oldDecoded, newDecoded decodedConfig) {
+	oldIndices := map[types.OffchainPublicKey]int{}
+	for i, identity := range oldDecoded.identities {
+		oldIndices[identity.OffchainPublicKey] = i
+	}
+	newIndices := map[types.OffchainPublicKey]int{}
+	for i, identity := range newDecoded.identities {
+		newIndices[identity.OffchainPublicKey] = i
+	}
+
+	for i, identity := range oldDecoded.identities {
+		if _, ok := newIndices[identity.OffchainPublicKey]; !ok {
+			r.RemovedOracles = append(r.RemovedOracles, makeOracle(i, identity))
+		}
+	}
+	for i, identity := range newDecoded.identities {
+		oldIndex, ok := oldIndices[identity.OffchainPublicKey]
+		if !ok {
+			r.AddedOracles = append(r.AddedOracles, makeOracle(i, identity))
+			continue
+		}
+		r.RetainedOracles++
+		oldOracle := makeOracle(oldIndex, oldDecoded.identities[oldIndex])
+		newOracle := makeOracle(i, identity)
+		if oldOracle != newOracle {
+			r.ChangedOracles = append(r.ChangedOracles, ChangedOracle{oldOracle, newOracle})
+		}
+	}
+
+	if r.RetainedOracles < oldDecoded.byzQuorumSize {
+		r.warn(WarningKindBelowByzQuorum, "only %v oracles of the old config are retained, fewer than the old byzantine quorum size %v", r.RetainedOracles, oldDecoded.byzQuorumSize)
+	}
+}
+
+func diffParameters(oldDecoded, newDecoded decodedConfig) []ParameterChange {
+	const absent = "(absent)"
+
+	changes := []ParameterChange{}
+	for _, name := range oldDecoded.parameterNames {
+		newValue, ok := newDecoded.parameters[name]
+		if !ok {
+			newValue = absent
+		}
+		if oldValue := oldDecoded.parameters[name]; oldValue != newValue {
+			changes = append(changes, ParameterChange{name, oldValue, newValue})
+		}
+	}
+	for _, name := range newDecoded.parameterNames {
+		if _, ok := oldDecoded.parameters[name]; !ok {
+			changes = append(changes, ParameterChange{name, absent, newDecoded.parameters[name]})
+		}
+	}
+	return changes
+}
+
+func diffBlob(oldBlob, newBlob []byte) *BlobChange {
+	if bytes.Equal(oldBlob, newBlob) {
+		return nil
+	}
+	oldHash := sha256.Sum256(oldBlob)
+	newHash := sha256.Sum256(newBlob)
+	return &BlobChange{
+		len(oldBlob),
+		len(newBlob),
+		hex.EncodeToString(oldHash[:]),
+		hex.EncodeToString(newHash[:]),
+	}
+}
+
+func (r *Report) checkVersionAndCount() {
+	if r.OldOffchainConfigVersion != r.NewOffchainConfigVersion {
+		r.warn(WarningKindVersionChanged, "OffchainConfigVersion changes from %v to %v, oracles will switch protocols", r.OldOffchainConfigVersion, r.NewOffchainConfigVersion)
+	}
+	if r.NewConfigCount <= r.OldConfigCount {
+		r.warn(WarningKindConfigCountNotIncreased, "ConfigCount does not increase (%v -> %v)", r.OldConfigCount, r.NewConfigCount)
+	}
+}
+
+func sum(s []int) int {
+	total := 0
+	for _, x := range s {
+		total += x
+	}
+	return total
+}
+
+func (r *Report) checkFaultTolerance(oldDecoded, newDecoded decodedConfig) {
+	if newDecoded.f < oldDecoded.f {
+		r.warn(WarningKindFDecreased, "F decreases from %v to %v, fewer faulty oracles are tolerated", oldDecoded.f, newDecoded.f)
+	}
+	if maxF := (newDecoded.n - 1) / 3; newDecoded.f < maxF {
+		r.warn(WarningKindFBelowMax, "F (%v) is below the maximum of %v supported by N = %v", newDecoded.f, maxF, newDecoded.n)
+	}
+	if sum(newDecoded.s) != newDecoded.n {
+		if sum(oldDecoded.s) == oldDecoded.n {
+			r.warn(WarningKindSSumNotN, "sum(S) (%v) no longer equals N (%v), S = %v", sum(newDecoded.s), newDecoded.n, newDecoded.s)
+		} else {
+			r.warn(WarningKindSSumNotN, "sum(S) (%v) does not equal N (%v), S = %v", sum(newDecoded.s), newDecoded.n, newDecoded.s)
+		}
+	}
+}
+
+func (r *Report) checkProductionChecks(newDecoded decodedConfig) {
+	if newDecoded.productionChecksErr != nil {
+		r.warn(WarningKindProductionChecksFailed, "new config fails production checks: %v", newDecoded.productionChecksErr)
+	}
+}
+
+func (r *Report) diffStateCarryOver(oldDecoded, newDecoded decodedConfig) {
+	carryOver := StateCarryOver{
+		newDecoded.prevFields != nil,
+		nil,
+		nil,
+		"",
+		false,
+		r.RetainedOracles,
+	}
+	r.StateCarryOver = &carryOver
+
+	if newDecoded.prevFields == nil {
+		if r.OldOffchainConfigVersion == config.OCR3_1OffchainConfigVersion {
+			r.warn(WarningKindStateNotCarriedOver, "new config does not set PrevConfigDigest, PrevSeqNr, and PrevHistoryDigest, the new instance starts with empty state")
+		}
+		return
+	}
+
+	prevFields := *newDecoded.prevFields
+	carryOver.PrevConfigDigest = &prevFields.PrevConfigDigest
+	carryOver.PrevSeqNr = &prevFields.PrevSeqNr
+	carryOver.PrevHistoryDigest = prevFields.PrevHistoryDigest.String()
+	carryOver.ContinuesOldConfig = prevFields.PrevConfigDigest == r.OldConfigDigest
+
+	if !carryOver.ContinuesOldConfig {
+		r.warn(WarningKindStateCarryOverInvalid, "PrevConfigDigest (%v) does not match the old ConfigDigest (%v)", prevFields.PrevConfigDigest, r.OldConfigDigest)
+		return
+	}
+	if r.OldOffchainConfigVersion != config.OCR3_1OffchainConfigVersion {
+		r.warn(WarningKindStateCarryOverInvalid, "state can only be carried over from an OCR3.1 config, but old OffchainConfigVersion is %v", r.OldOffchainConfigVersion)
+		return
+	}
+	if r.RetainedOracles == 0 {
+		r.warn(WarningKindStateCarryOverInvalid, "no oracle of the old config is retained, so nobody can serve the old state")
+	}
+	if oldDecoded.snapshotInterval != 0 && prevFields.PrevSeqNr%oldDecoded.snapshotInterval != 0 {
+		r.warn(WarningKindStateCarryOverInvalid, "PrevSeqNr (%v) is not a multiple of the old SnapshotInterval (%v)", prevFields.PrevSeqNr, oldDecoded.snapshotInterval)
+	}
+	if oldDecoded.prevFields != nil && prevFields.PrevSeqNr <= oldDecoded.prevFields.PrevSeqNr {
+		r.warn(WarningKindStateCarryOverInvalid, "PrevSeqNr (%v) must exceed the old config's PrevSeqNr (%v)", prevFields.PrevSeqNr, oldDecoded.prevFields.PrevSeqNr)
+	}
+}
@@ -0,0 +1,175 @@
+package configdiff
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/internal/byzquorum"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr2config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// decodedConfig is the version-independent view of a config that Diff works
+// on.
+type decodedConfig struct {
+	contractConfig types.ContractConfig
+
+	n             int
+	f             int
+	byzQuorumSize int
+	s             []int
+	identities    []config.OracleIdentity
+
+	reportingPluginConfig []byte
+	onchainConfig         []byte
+
+	// parameters holds all remaining fields of the version-specific public
+	// config, formatted as strings. parameterNames lists them in declaration
+	// order.
+	parameterNames []string
+	parameters     map[string]string
+
+	// only set for OCR3.1 configs
+	prevFields       *ocr3_1config.PublicConfigPrevFields
+	snapshotInterval uint64
+
+	// error returned by the decoder when production checks are enabled, nil
+	// if the config passes them.
+	productionChecksErr error
+}
+
+// Fields that Diff reports on separately and that are hence excluded from
+// decodedConfig.parameters.
+var separatelyDiffedFields = map[string]bool{
+	"OracleIdentities":      true,
+	"ReportingPluginConfig": true,
+	"OnchainConfig":         true,
+	"ConfigDigest":          true,
+	"F":                     true,
+	"PrevConfigDigest":      true,
+	"PrevSeqNr":             true,
+	"PrevHistoryDigest":     true,
+}
+
+func decode(contractConfig types.ContractConfig) (decodedConfig, error) {
+	switch contractConfig.OffchainConfigVersion {
+	case config.OCR2OffchainConfigVersion:
+		publicConfig, err := ocr2config.PublicConfigFromContractConfig(true, contractConfig)
+		if err != nil {
+			return decodedConfig{}, err
+		}
+		_, productionChecksErr := ocr2config.PublicConfigFromContractConfig(false, contractConfig)
+		return makeDecodedConfig(
+			contractConfig,
+			&publicConfig,
+			publicConfig.F,
+			byzquorum.Size(publicConfig.N(), publicConfig.F),
+			publicConfig.S,
+			publicConfig.OracleIdentities,
+			publicConfig.ReportingPluginConfig,
+			publicConfig.OnchainConfig,
+			nil,
+			0,
+			productionChecksErr,
+		), nil
+	case config.OCR3OffchainConfigVersion:
+		publicConfig, err := ocr3config.PublicConfigFromContractConfig(true, contractConfig)
+		if err != nil {
+			return decodedConfig{}, err
+		}
+		_, productionChecksErr := ocr3config.PublicConfigFromContractConfig(false, contractConfig)
+		return makeDecodedConfig(
+			contractConfig,
+			&publicConfig,
+			publicConfig.F,
+			publicConfig.ByzQuorumSize(),
+			publicConfig.S,
+			publicConfig.OracleIdentities,
+			publicConfig.ReportingPluginConfig,
+			publicConfig.OnchainConfig,
+			nil,
+			0,
+			productionChecksErr,
+		), nil
+	case config.OCR3_1OffchainConfigVersion:
+		publicConfig, err := ocr3_1config.PublicConfigFromContractConfig(true, contractConfig)
+		if err != nil {
+			return decodedConfig{}, err
+		}
+		_, productionChecksErr := ocr3_1config.PublicConfigFromContractConfig(false, contractConfig)
+		var prevFields *ocr3_1config.PublicConfigPrevFields
+		if pf, ok := publicConfig.GetPrevFields(); ok {
+			prevFields = &pf
+		}
+		return makeDecodedConfig(
+			contractConfig,
+			&publicConfig,
+			publicConfig.F,
+			publicConfig.ByzQuorumSize(),
+			publicConfig.S,
+			publicConfig.OracleIdentities,
+			publicConfig.ReportingPluginConfig,
+			publicConfig.OnchainConfig,
+			prevFields,
+			publicConfig.GetSnapshotInterval(),
+			productionChecksErr,
+		), nil
+	default:
+		return decodedConfig{}, fmt.Errorf("unsupported OffchainConfigVersion %v", contractConfig.OffchainConfigVersion)
+	}
+}
+
+func makeDecodedConfig(
+	contractConfig types.ContractConfig,
+	publicConfig interface{},
+	f int,
+	byzQuorumSize int,
+	s []int,
+	identities []config.OracleIdentity,
+	reportingPluginConfig []byte,
+	onchainConfig []byte,
+	prevFields *ocr3_1config.PublicConfigPrevFields,
+	snapshotInterval uint64,
+	productionChecksErr error,
+) decodedConfig {
+	parameterNames, parameters := formatParameters(publicConfig)
+	return decodedConfig{
+		contractConfig,
+		len(identities),
+		f,
+		byzQuorumSize,
+		s,
+		identities,
+		reportingPluginConfig,
+		onchainConfig,
+		parameterNames,
+		parameters,
+		prevFields,
+		snapshotInterval,
+		productionChecksErr,
+	}
+}
+
+// formatParameters formats every field of the public config pointed to by
+// publicConfigPtr that isn't diffed separately. Unset optional fields are
+// rendered with the default value returned by the corresponding Get method,
+// if there is one.
+func formatParameters(publicConfigPtr interface{}) ([]string, map[string]string) {
+	names := []string{}
+	parameters := map[string]string{}
+	for _, parameter := range config.PublicConfigParameters(publicConfigPtr, separatelyDiffedFields) {
+		var formatted string
+		if parameter.Value != nil {
+			formatted = fmt.Sprint(parameter.Value)
+		} else if parameter.Default != nil {
+			formatted = fmt.Sprintf("%v (default)", parameter.Default)
+		} else {
+			formatted = "(unset)"
+		}
+		names = append(names, parameter.Name)
+		parameters[parameter.Name] = formatted
+	}
+	return names, parameters
+}
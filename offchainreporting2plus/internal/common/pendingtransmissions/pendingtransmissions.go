@@ -0,0 +1,227 @@
+// Package pendingtransmissions keeps track of the reports an OCR3 or OCR3.1
+// oracle has accepted and scheduled for transmission, and persists them so
+// that their transmission resumes after a restart.
+package pendingtransmissions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/loghelper"
+)
+
+// Key identifies a pending transmission.
+type Key struct {
+	SeqNr uint64
+	Index int
+}
+
+// PendingTransmission is implemented by the pending transmission types of the
+// protocols.
+type PendingTransmission interface {
+	Key() Key
+}
+
+// Database persists the pending transmissions of a single protocol instance.
+// Calls to WritePendingTransmission and DeletePendingTransmission are not
+// concurrent.
+type Database[PT PendingTransmission] interface {
+	ReadPendingTransmissions(ctx context.Context) ([]PT, error)
+	WritePendingTransmission(ctx context.Context, pendingTransmission PT) error
+	DeletePendingTransmission(ctx context.Context, key Key) error
+}
+
+// A pending transmission supersedes the pending transmissions with the same
+// Index and a lower SeqNr. Superseded pending transmissions are deleted from
+// the database and thus aren't resumed after a restart. Until then, they stay
+// pending and it is up to ShouldTransmitAcceptedReport whether they are
+// transmitted.
+func supersedes(key Key, other Key) bool {
+	return key.Index == other.Index && key.SeqNr > other.SeqNr
+}
+
+type operation[PT PendingTransmission] struct {
+	key   Key
+	write *PT // nil for deletions
+}
+
+// Tracker keeps track of pending transmissions. It is safe for concurrent use.
+// Database I/O happens outside of the lock protecting the tracked state, in
+// the order in which the state was changed.
+type Tracker[PT PendingTransmission] struct {
+	ctx       context.Context
+	logger    loghelper.LoggerWithContext
+	database  Database[PT]
+	dbTimeout time.Duration
+
+	// ioMu serializes database I/O. It is never acquired while mu is held.
+	ioMu sync.Mutex
+
+	mu sync.Mutex
+	// Maps to whether the pending transmission is persisted
+	pending map[Key]bool
+	queue   []operation[PT]
+}
+
+func NewTracker[PT PendingTransmission](
+	ctx context.Context,
+	logger loghelper.LoggerWithContext,
+	database Database[PT],
+	dbTimeout time.Duration,
+) *Tracker[PT] {
+	return &Tracker[PT]{
+		ctx,
+		logger,
+		database,
+		dbTimeout,
+
+		sync.Mutex{},
+
+		sync.Mutex{},
+		make(map[Key]bool),
+		nil,
+	}
+}
+
+// Add tracks pendingTransmission and, if persist is true and it hasn't been
+// superseded already, persists it. Add returns false if pendingTransmission
+// is already pending, e.g. because it was resumed after a restart.
+func (t *Tracker[PT]) Add(pendingTransmission PT, persist bool) bool {
+	key := pendingTransmission.Key()
+
+	t.mu.Lock()
+	if _, ok := t.pending[key]; ok {
+		t.mu.Unlock()
+		return false
+	}
+	for other, persisted := range t.pending {
+		if supersedes(other, key) {
+			persist = false
+		} else if supersedes(key, other) && persisted {
+			t.pending[other] = false
+			t.queue = append(t.queue, operation[PT]{other, nil})
+		}
+	}
+	t.pending[key] = persist
+	if persist {
+		t.queue = append(t.queue, operation[PT]{key, &pendingTransmission})
+	}
+	t.mu.Unlock()
+
+	t.flush()
+	return true
+}
+
+// Remove stops tracking the pending transmission identified by key and
+// deletes it from the database.
+func (t *Tracker[PT]) Remove(key Key) {
+	t.mu.Lock()
+	persisted, ok := t.pending[key]
+	delete(t.pending, key)
+	if ok && persisted {
+		t.queue = append(t.queue, operation[PT]{key, nil})
+	}
+	t.mu.Unlock()
+
+	t.flush()
+}
+
+// Resume reads the persisted pending transmissions, deletes those that have
+// been superseded, and tracks and returns the others. The caller is expected
+// to Remove those it doesn't reschedule.
+func (t *Tracker[PT]) Resume() ([]PT, error) {
+	readCtx, readCancel := context.WithTimeout(t.ctx, t.dbTimeout)
+	defer readCancel()
+	persisted, err := t.database.ReadPendingTransmissions(readCtx)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading pending transmissions: %w", err)
+	}
+
+	var resumed []PT
+	superseded := 0
+
+	t.mu.Lock()
+	for _, pt := range persisted {
+		key := pt.Key()
+		if _, ok := t.pending[key]; ok {
+			continue
+		}
+		if t.isSupersededLocked(key, persisted) {
+			t.queue = append(t.queue, operation[PT]{key, nil})
+			superseded++
+			continue
+		}
+		t.pending[key] = true
+		resumed = append(resumed, pt)
+	}
+	t.mu.Unlock()
+
+	t.flush()
+
+	if superseded != 0 {
+		t.logger.Info("pruned superseded pending transmissions", commontypes.LogFields{"pruned": superseded})
+	}
+	return resumed, nil
+}
+
+func (t *Tracker[PT]) isSupersededLocked(key Key, persisted []PT) bool {
+	for other := range t.pending {
+		if supersedes(other, key) {
+			return true
+		}
+	}
+	for _, pt := range persisted {
+		if supersedes(pt.Key(), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// flush applies the queued database operations. Since the queue is taken
+// while holding ioMu, operations are applied in the order they were queued.
+func (t *Tracker[PT]) flush() {
+	t.ioMu.Lock()
+	defer t.ioMu.Unlock()
+
+	t.mu.Lock()
+	queue := t.queue
+	t.queue = nil
+	t.mu.Unlock()
+
+	for _, op := range queue {
+		if op.write != nil {
+			t.write(*op.write)
+		} else {
+			t.delete(op.key)
+		}
+	}
+}
+
+func (t *Tracker[PT]) write(pendingTransmission PT) {
+	writeCtx, writeCancel := context.WithTimeout(t.ctx, t.dbTimeout)
+	defer writeCancel()
+	if err := t.database.WritePendingTransmission(writeCtx, pendingTransmission); err != nil {
+		key := pendingTransmission.Key()
+		t.logger.Warn("error while persisting pending transmission", commontypes.LogFields{
+			"seqNr": key.SeqNr,
+			"index": key.Index,
+			"error": err,
+		})
+	}
+}
+
+func (t *Tracker[PT]) delete(key Key) {
+	writeCtx, writeCancel := context.WithTimeout(t.ctx, t.dbTimeout)
+	defer writeCancel()
+	if err := t.database.DeletePendingTransmission(writeCtx, key); err != nil {
+		t.logger.Warn("error while deleting pending transmission", commontypes.LogFields{
+			"seqNr": key.SeqNr,
+			"index": key.Index,
+			"error": err,
+		})
+	}
+}
@@ -377,6 +377,13 @@ func PublicConfigFromContractConfig(skipInsaneForProductionChecks bool, change t
 	return pubcon, err
 }
 
+// PublicConfigAndSharedSecretEncryptionsFromContractConfig is like
+// PublicConfigFromContractConfig, but additionally returns the encrypted
+// shared secret. Meant for tooling that inspects configs outside of an oracle.
+func PublicConfigAndSharedSecretEncryptionsFromContractConfig(skipInsaneForProductionChecks bool, change types.ContractConfig) (PublicConfig, config.SharedSecretEncryptions, error) {
+	return publicConfigFromContractConfig(skipInsaneForProductionChecks, change)
+}
+
 func publicConfigFromContractConfig(skipInsaneForProductionChecks bool, change types.ContractConfig) (PublicConfig, config.SharedSecretEncryptions, error) {
 	if change.OffchainConfigVersion != config.OCR3_1OffchainConfigVersion {
 		return PublicConfig{}, config.SharedSecretEncryptions{}, fmt.Errorf("unsuppported OffchainConfigVersion %v, supported OffchainConfigVersion is %v", change.OffchainConfigVersion, config.OCR3_1OffchainConfigVersion)
@@ -27,19 +27,27 @@ type SharedConfig struct {
 }
 
 func (c *SharedConfig) LeaderSelectionKey() [16]byte {
+	return leaderSelectionKey(c.SharedSecret, c.ConfigDigest)
+}
+
+func (c *SharedConfig) TransmissionOrderKey() [16]byte {
+	return transmissionOrderKey(c.SharedSecret, c.ConfigDigest)
+}
+
+func leaderSelectionKey(sharedSecret *[config.SharedSecretSize]byte, configDigest types.ConfigDigest) [16]byte {
 	var result [16]byte
-	mac := hmac.New(sha256.New, c.SharedSecret[:])
+	mac := hmac.New(sha256.New, sharedSecret[:])
 	_, _ = mac.Write([]byte("chainlink offchain reporting v3 leader selection key"))
-	_, _ = mac.Write(c.ConfigDigest[:])
+	_, _ = mac.Write(configDigest[:])
 	_ = copy(result[:], mac.Sum(nil))
 	return result
 }
 
-func (c *SharedConfig) TransmissionOrderKey() [16]byte {
+func transmissionOrderKey(sharedSecret *[config.SharedSecretSize]byte, configDigest types.ConfigDigest) [16]byte {
 	var result [16]byte
-	mac := hmac.New(sha256.New, c.SharedSecret[:])
+	mac := hmac.New(sha256.New, sharedSecret[:])
 	_, _ = mac.Write([]byte("chainlink offchain reporting v3 transmission order key"))
-	_, _ = mac.Write(c.ConfigDigest[:])
+	_, _ = mac.Write(configDigest[:])
 	_ = copy(result[:], mac.Sum(nil))
 	return result
 }
@@ -0,0 +1,104 @@
+package ocr3_1config
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// SharedSecretRotation replaces the SharedSecret of a SharedConfig without
+// changing its ConfigDigest. Starting at ActivationEpoch, leader selection and
+// transmission order are derived from the rotated secret instead of the
+// original one.
+//
+// Like the original SharedSecretEncryptions, a SharedSecretRotation is created
+// by a dealer (typically the same party that calls setConfig) and is only
+// adopted once a byzantine quorum of oracles from the config has endorsed it.
+// RotationNumbers must strictly increase across rotations for the same
+// ConfigDigest.
+type SharedSecretRotation struct {
+	ConfigDigest            types.ConfigDigest
+	RotationNumber          uint64
+	ActivationEpoch         uint64
+	SharedSecretEncryptions config.SharedSecretEncryptions
+}
+
+type SharedSecretRotationDigest [32]byte
+
+const sharedSecretRotationDigestDomainSeparator = "ocr3.1/SharedSecretRotation/"
+
+func (r SharedSecretRotation) Digest() SharedSecretRotationDigest {
+	h := sha256.New()
+
+	_, _ = h.Write([]byte(sharedSecretRotationDigestDomainSeparator))
+
+	_, _ = h.Write(r.ConfigDigest[:])
+	_ = binary.Write(h, binary.BigEndian, r.RotationNumber)
+	_ = binary.Write(h, binary.BigEndian, r.ActivationEpoch)
+
+	_, _ = h.Write(r.SharedSecretEncryptions.DiffieHellmanPoint[:])
+	_, _ = h.Write(r.SharedSecretEncryptions.SharedSecretHash[:])
+	_ = binary.Write(h, binary.BigEndian, uint64(len(r.SharedSecretEncryptions.Encryptions)))
+	for _, encryption := range r.SharedSecretEncryptions.Encryptions {
+		_, _ = h.Write(encryption[:])
+	}
+
+	var result SharedSecretRotationDigest
+	h.Sum(result[:0])
+	return result
+}
+
+// Decrypt returns the rotated shared secret. It fails if the oracle's
+// encryption doesn't match SharedSecretHash.
+func (r SharedSecretRotation) Decrypt(oid commontypes.OracleID, k types.OffchainKeyring) (*[config.SharedSecretSize]byte, error) {
+	return r.SharedSecretEncryptions.Decrypt(oid, k)
+}
+
+// LeaderSelectionKey returns the leader selection key derived from the
+// rotated sharedSecret, using the same derivation as
+// SharedConfig.LeaderSelectionKey.
+func (r SharedSecretRotation) LeaderSelectionKey(sharedSecret *[config.SharedSecretSize]byte) [16]byte {
+	return leaderSelectionKey(sharedSecret, r.ConfigDigest)
+}
+
+// TransmissionOrderKey returns the transmission order key derived from the
+// rotated sharedSecret, using the same derivation as
+// SharedConfig.TransmissionOrderKey.
+func (r SharedSecretRotation) TransmissionOrderKey(sharedSecret *[config.SharedSecretSize]byte) [16]byte {
+	return transmissionOrderKey(sharedSecret, r.ConfigDigest)
+}
+
+// MakeSharedSecretRotation draws a fresh shared secret from rand and encrypts
+// it for the oracles of the config identified by configDigest.
+// sharedSecretEncryptionPublicKeys must be given in the same order as the
+// oracles in the config.
+func MakeSharedSecretRotation(
+	configDigest types.ConfigDigest,
+	rotationNumber uint64,
+	activationEpoch uint64,
+	sharedSecretEncryptionPublicKeys []types.ConfigEncryptionPublicKey,
+	rand io.Reader,
+) (SharedSecretRotation, error) {
+	if rotationNumber == 0 {
+		return SharedSecretRotation{}, fmt.Errorf("rotationNumber must be positive")
+	}
+	var sharedSecret [config.SharedSecretSize]byte
+	if _, err := io.ReadFull(rand, sharedSecret[:]); err != nil {
+		return SharedSecretRotation{}, fmt.Errorf("could not read enough randomness for shared secret: %w", err)
+	}
+	sharedSecretEncryptions, err := config.EncryptSharedSecret(sharedSecretEncryptionPublicKeys, &sharedSecret, rand)
+	if err != nil {
+		return SharedSecretRotation{}, err
+	}
+	return SharedSecretRotation{
+		configDigest,
+		rotationNumber,
+		activationEpoch,
+		sharedSecretEncryptions,
+	}, nil
+}
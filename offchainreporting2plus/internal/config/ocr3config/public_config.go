@@ -138,6 +138,13 @@ func PublicConfigFromContractConfig(skipResourceExhaustionChecks bool, change ty
 	return pubcon, err
 }
 
+// PublicConfigAndSharedSecretEncryptionsFromContractConfig is like
+// PublicConfigFromContractConfig, but additionally returns the encrypted
+// shared secret. Meant for tooling that inspects configs outside of an oracle.
+func PublicConfigAndSharedSecretEncryptionsFromContractConfig(skipResourceExhaustionChecks bool, change types.ContractConfig) (PublicConfig, config.SharedSecretEncryptions, error) {
+	return publicConfigFromContractConfig(skipResourceExhaustionChecks, change)
+}
+
 func publicConfigFromContractConfig(skipResourceExhaustionChecks bool, change types.ContractConfig) (PublicConfig, config.SharedSecretEncryptions, error) {
 	if change.OffchainConfigVersion != config.OCR3OffchainConfigVersion {
 		return PublicConfig{}, config.SharedSecretEncryptions{}, fmt.Errorf("unsuppported OffchainConfigVersion %v, supported OffchainConfigVersion is %v", change.OffchainConfigVersion, config.OCR3OffchainConfigVersion)
@@ -0,0 +1,41 @@
+package config
+
+import "reflect"
+
+// PublicConfigParameter is a field of a version-specific PublicConfig.
+type PublicConfigParameter struct {
+	Name string
+	// The field's value, dereferenced for optional fields. nil if the field
+	// is an unset optional field.
+	Value interface{}
+	// For unset optional fields, the value returned by the public config's
+	// Get<Name> method, if there is one. nil otherwise.
+	Default interface{}
+}
+
+// PublicConfigParameters returns the fields of the public config pointed to
+// by publicConfigPtr in declaration order, skipping the fields in excluded.
+func PublicConfigParameters(publicConfigPtr interface{}, excluded map[string]bool) []PublicConfigParameter {
+	ptr := reflect.ValueOf(publicConfigPtr)
+	v := ptr.Elem()
+	t := v.Type()
+
+	parameters := []PublicConfigParameter{}
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if excluded[name] {
+			continue
+		}
+		field := v.Field(i)
+		parameter := PublicConfigParameter{name, nil, nil}
+		if field.Kind() != reflect.Ptr {
+			parameter.Value = field.Interface()
+		} else if !field.IsNil() {
+			parameter.Value = field.Elem().Interface()
+		} else if getter := ptr.MethodByName("Get" + name); getter.IsValid() && getter.Type().NumIn() == 0 && getter.Type().NumOut() == 1 {
+			parameter.Default = getter.Call(nil)[0].Interface()
+		}
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
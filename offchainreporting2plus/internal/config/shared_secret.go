@@ -89,13 +89,43 @@ func (e SharedSecretEncryptions) Decrypt(oid commontypes.OracleID, k types.Offch
 		return nil, err
 	}
 
+	sharedSecret, ok := e.decryptWithDiffieHellmanPoint(oid, dhPoint)
+	if !ok {
+		return nil, errors.Errorf("decrypted sharedSecret has wrong hash")
+	}
+
+	return sharedSecret, nil
+}
+
+// DecryptAny is like Decrypt, but doesn't require knowledge of the oracle's
+// index. Instead, it tries every encryption and returns the index of the first
+// one that decrypts to a sharedSecret matching SharedSecretHash.
+// configDiffieHellman has the semantics of
+// types.OffchainKeyring.ConfigDiffieHellman.
+func (e SharedSecretEncryptions) DecryptAny(
+	configDiffieHellman func(point [curve25519.PointSize]byte) ([curve25519.PointSize]byte, error),
+) (commontypes.OracleID, *[SharedSecretSize]byte, error) {
+	dhPoint, err := configDiffieHellman(e.DiffieHellmanPoint)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for i := range e.Encryptions {
+		if sharedSecret, ok := e.decryptWithDiffieHellmanPoint(commontypes.OracleID(i), dhPoint); ok {
+			return commontypes.OracleID(i), sharedSecret, nil
+		}
+	}
+	return 0, nil, errors.Errorf("no encryption decrypts to a sharedSecret with the expected hash")
+}
+
+func (e SharedSecretEncryptions) decryptWithDiffieHellmanPoint(oid commontypes.OracleID, dhPoint [curve25519.PointSize]byte) (*[SharedSecretSize]byte, bool) {
 	key := crypto.Keccak256(dhPoint[:])[:16]
 
 	sharedSecret := aesDecryptBlock(key, e.Encryptions[int(oid)][:])
 
 	if common.BytesToHash(crypto.Keccak256(sharedSecret[:])) != e.SharedSecretHash {
-		return nil, errors.Errorf("decrypted sharedSecret has wrong hash")
+		return nil, false
 	}
 
-	return &sharedSecret, nil
+	return &sharedSecret, true
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/smartcontractkit/libocr/internal/jmt"
 	"github.com/smartcontractkit/libocr/internal/mt"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
@@ -18,26 +19,28 @@ import (
 )
 
 type OCR3_1SerializedLengthLimits struct {
-	MaxLenMsgNewEpoch                    int
-	MaxLenMsgEpochStartRequest           int
-	MaxLenMsgEpochStart                  int
-	MaxLenMsgRoundStart                  int
-	MaxLenMsgObservation                 int
-	MaxLenMsgProposal                    int
-	MaxLenMsgPrepare                     int
-	MaxLenMsgCommit                      int
-	MaxLenMsgReportSignatures            int
-	MaxLenMsgReportsPlusPrecursorRequest int
-	MaxLenMsgReportsPlusPrecursor        int
-	MaxLenMsgStateSyncSummary            int
-	MaxLenMsgBlockSyncRequest            int
-	MaxLenMsgBlockSyncResponse           int
-	MaxLenMsgTreeSyncChunkRequest        int
-	MaxLenMsgTreeSyncChunkResponse       int
-	MaxLenMsgBlobOffer                   int
-	MaxLenMsgBlobOfferResponse           int
-	MaxLenMsgBlobChunkRequest            int
-	MaxLenMsgBlobChunkResponse           int
+	MaxLenMsgNewEpoch                        int
+	MaxLenMsgEpochStartRequest               int
+	MaxLenMsgEpochStart                      int
+	MaxLenMsgRoundStart                      int
+	MaxLenMsgObservation                     int
+	MaxLenMsgProposal                        int
+	MaxLenMsgPrepare                         int
+	MaxLenMsgCommit                          int
+	MaxLenMsgReportSignatures                int
+	MaxLenMsgReportsPlusPrecursorRequest     int
+	MaxLenMsgReportsPlusPrecursor            int
+	MaxLenMsgStateSyncSummary                int
+	MaxLenMsgBlockSyncRequest                int
+	MaxLenMsgBlockSyncResponse               int
+	MaxLenMsgTreeSyncChunkRequest            int
+	MaxLenMsgTreeSyncChunkResponse           int
+	MaxLenMsgBlobOffer                       int
+	MaxLenMsgBlobOfferResponse               int
+	MaxLenMsgBlobChunkRequest                int
+	MaxLenMsgBlobChunkResponse               int
+	MaxLenMsgSharedSecretRotationEndorsement int
+	MaxLenMsgSharedSecretRotationCertificate int
 }
 
 func OCR3_1Limits(cfg ocr3_1config.PublicConfig, pluginLimits ocr3_1types.ReportingPluginLimits, maxSigLen int) (types.BinaryNetworkEndpointLimits, types.BinaryNetworkEndpointLimits, OCR3_1SerializedLengthLimits, error) {
@@ -138,14 +141,28 @@ func OCR3_1Limits(cfg ocr3_1config.PublicConfig, pluginLimits ocr3_1types.Report
 	// blob exchange messages
 	const blobDigestSize = len(protocol.BlobDigest{})
 	cfgBlobChunkSize := cfg.GetBlobChunkBytes()
-	maxNumBlobChunks := (pluginLimits.MaxBlobPayloadBytes + cfgBlobChunkSize - 1) / cfgBlobChunkSize
+	maxNumPlainBlobChunks := (pluginLimits.MaxBlobPayloadBytes + cfgBlobChunkSize - 1) / cfgBlobChunkSize
+	// erasure coded blobs have N shards of ceil(MaxBlobPayloadBytes/(F+1)) bytes each
+	maxBlobShardLength := (pluginLimits.MaxBlobPayloadBytes + cfg.F) / (cfg.F + 1)
+	maxNumErasureCodedBlobChunks := mul(cfg.N(), (maxBlobShardLength+cfgBlobChunkSize-1)/cfgBlobChunkSize)
+	maxNumBlobChunks := max(maxNumPlainBlobChunks, maxNumErasureCodedBlobChunks)
 	maxBlobChunksDigestProofElements := bits.Len(uint(maxNumBlobChunks)) + 1
-	maxLenMsgBlobOffer := add(blobDigestSize, overhead)
+	// offers for erasure coded blobs include all chunk digests
+	maxLenMsgBlobOffer := add(blobDigestSize, ocr3_1types.MaxBlobExpiryKeyBytes+overhead,
+		mul(maxNumErasureCodedBlobChunks, add(repeatedOverhead, len(protocol.BlobChunkDigest{}))), overhead)
 	maxLenMsgBlobChunkRequest := add(blobDigestSize, overhead)
 	maxLenMsgBlobChunkResponse := add(blobDigestSize, cfgBlobChunkSize,
 		mul(maxBlobChunksDigestProofElements, add(repeatedOverhead, len(mt.Digest{}))), overhead)
 	maxLenMsgBlobOfferResponse := add(blobDigestSize, ed25519.SignatureSize+sigOverhead, overhead)
 
+	// shared secret rotation messages
+	maxLenMsgSharedSecretRotationEndorsement := add(sha256.Size, ed25519.SignatureSize+sigOverhead, overhead)
+	maxLenMsgSharedSecretRotationCertificate := add(
+		mul(config.SharedSecretSize+repeatedOverhead, cfg.N()),
+		mul(ed25519.SignatureSize+sigOverhead, cfg.ByzQuorumSize()),
+		overhead,
+	)
+
 	maxDefaultPriorityMessageSize := max(
 		maxLenMsgNewEpoch,
 		maxLenMsgEpochStartRequest,
@@ -158,19 +175,26 @@ func OCR3_1Limits(cfg ocr3_1config.PublicConfig, pluginLimits ocr3_1types.Report
 		maxLenMsgReportsPlusPrecursorRequest,
 		maxLenMsgBlobOffer,
 		maxLenMsgBlobChunkRequest,
+		maxLenMsgSharedSecretRotationEndorsement,
+		maxLenMsgSharedSecretRotationCertificate,
 	)
 
+	// Bulk transfers (state sync and blob chunks) use low priority so that
+	// they don't delay consensus messages. Older oracles still request blob
+	// chunks at default priority, so they are accounted for in both priority
+	// classes.
 	maxLowPriorityMessageSize := max(
 		maxLenMsgStateSyncSummary,
 		maxLenMsgBlockSyncRequest,
 		maxLenMsgTreeSyncChunkRequest,
+		maxLenMsgBlobChunkRequest,
 	)
 
 	minRoundInterval := math.Max(float64(cfg.DeltaRound), float64(cfg.DeltaGrace))
 
 	minEpochInterval := math.Min(float64(cfg.DeltaProgress), math.Min(float64(cfg.GetDeltaInitial()), float64(cfg.RMax)*float64(minRoundInterval)))
 
-	defaultPriorityMessagesRate := (1.0*float64(time.Second)/float64(cfg.GetDeltaResend()) +
+	defaultPriorityMessagesRate := (3.0*float64(time.Second)/float64(cfg.GetDeltaResend()) +
 		3.0*float64(time.Second)/minEpochInterval +
 		6.0*float64(time.Second)/float64(minRoundInterval) +
 		2.0*float64(time.Second)/float64(cfg.GetDeltaBlobOfferMinRequestToSameOracleInterval()) +
@@ -178,9 +202,10 @@ func OCR3_1Limits(cfg ocr3_1config.PublicConfig, pluginLimits ocr3_1types.Report
 
 	lowPriorityMessagesRate := (1.0*float64(time.Second)/float64(cfg.GetDeltaBlockSyncMinRequestToSameOracleInterval()) +
 		1.0*float64(time.Second)/float64(cfg.GetDeltaTreeSyncMinRequestToSameOracleInterval()) +
-		1.0*float64(time.Second)/float64(cfg.GetDeltaStateSyncSummaryInterval())) * 1.2
+		1.0*float64(time.Second)/float64(cfg.GetDeltaStateSyncSummaryInterval()) +
+		1.0*float64(time.Second)/float64(cfg.GetDeltaBlobChunkMinRequestToSameOracleInterval())) * 1.2
 
-	defaultPriorityMessagesCapacity := mul(13, 3)
+	defaultPriorityMessagesCapacity := mul(15, 3)
 	lowPriorityMessagesCapacity := mul(3, 3)
 
 	// we don't multiply bytesRate by a safetyMargin since we already have a generous overhead on each message
@@ -196,11 +221,14 @@ func OCR3_1Limits(cfg ocr3_1config.PublicConfig, pluginLimits ocr3_1types.Report
 		float64(time.Second)/float64(minEpochInterval)*float64(maxLenMsgEpochStartRequest) +
 		float64(time.Second)/float64(minRoundInterval)*float64(maxLenMsgReportsPlusPrecursorRequest) +
 		float64(time.Second)/float64(cfg.GetDeltaBlobOfferMinRequestToSameOracleInterval())*float64(maxLenMsgBlobOffer) + // blob-related messages
-		float64(time.Second)/float64(cfg.GetDeltaBlobChunkMinRequestToSameOracleInterval())*float64(maxLenMsgBlobChunkRequest)
+		float64(time.Second)/float64(cfg.GetDeltaBlobChunkMinRequestToSameOracleInterval())*float64(maxLenMsgBlobChunkRequest) +
+		float64(time.Second)/float64(cfg.GetDeltaResend())*float64(maxLenMsgSharedSecretRotationEndorsement) + // shared secret rotation messages
+		float64(time.Second)/float64(cfg.GetDeltaResend())*float64(maxLenMsgSharedSecretRotationCertificate)
 
 	lowPriorityBytesRate := float64(time.Second)/float64(cfg.GetDeltaStateSyncSummaryInterval())*float64(maxLenMsgStateSyncSummary) +
 		float64(time.Second)/float64(cfg.GetDeltaBlockSyncMinRequestToSameOracleInterval())*float64(maxLenMsgBlockSyncRequest) +
-		float64(time.Second)/float64(cfg.GetDeltaTreeSyncMinRequestToSameOracleInterval())*float64(maxLenMsgTreeSyncChunkRequest)
+		float64(time.Second)/float64(cfg.GetDeltaTreeSyncMinRequestToSameOracleInterval())*float64(maxLenMsgTreeSyncChunkRequest) +
+		float64(time.Second)/float64(cfg.GetDeltaBlobChunkMinRequestToSameOracleInterval())*float64(maxLenMsgBlobChunkRequest)
 
 	defaultPriorityBytesCapacity := mul(add(
 		maxLenMsgNewEpoch,
@@ -215,12 +243,15 @@ func OCR3_1Limits(cfg ocr3_1config.PublicConfig, pluginLimits ocr3_1types.Report
 		maxLenMsgReportsPlusPrecursorRequest,
 		maxLenMsgBlobOffer,
 		maxLenMsgBlobChunkRequest,
+		maxLenMsgSharedSecretRotationEndorsement,
+		maxLenMsgSharedSecretRotationCertificate,
 	), 3)
 
 	lowPriorityBytesCapacity := mul(add(
 		maxLenMsgStateSyncSummary,
 		maxLenMsgBlockSyncRequest,
 		maxLenMsgTreeSyncChunkRequest,
+		maxLenMsgBlobChunkRequest,
 	), 3)
 
 	if overflow {
@@ -263,6 +294,8 @@ func OCR3_1Limits(cfg ocr3_1config.PublicConfig, pluginLimits ocr3_1types.Report
 			maxLenMsgBlobOfferResponse,
 			maxLenMsgBlobChunkRequest,
 			maxLenMsgBlobChunkResponse,
+			maxLenMsgSharedSecretRotationEndorsement,
+			maxLenMsgSharedSecretRotationCertificate,
 		},
 		nil
 }
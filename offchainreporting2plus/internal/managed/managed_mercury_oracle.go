@@ -230,6 +230,7 @@ func RunManagedMercuryOracle(
 				offchainKeyring,
 				ocr3OnchainKeyring,
 				shim.LimitCheckOCR3ReportingPlugin[mercuryshim.MercuryReportInfo]{reportingPlugin, reportingPluginLimits},
+				reportingPlugin,
 				shim.NewOCR3TelemetrySender(chTelemetrySend, childLogger, localConfig.EnableTransmissionTelemetry),
 			)
 
@@ -0,0 +1,235 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/internal/metricshelper"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/managed/limits"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/serialization"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/shim"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"github.com/smartcontractkit/libocr/subprocesses"
+)
+
+// RunManagedOCR3_1Observer runs a "managed" version of protocol.RunObserver.
+// It handles setting up telemetry, configuration updates, and translating from
+// types.BinaryNetworkEndpoint2 to protocol.NetworkEndpoint. Observers hold no
+// keys and never sign anything.
+func RunManagedOCR3_1Observer(
+	ctx context.Context,
+
+	v2bootstrappers []commontypes.BootstrapperLocator,
+	configTracker types.ContractConfigTracker,
+	database types.ConfigDatabase,
+	keyValueDatabaseFactory ocr3_1types.KeyValueDatabaseFactory,
+	localConfig types.LocalConfig,
+	logger loghelper.LoggerWithContext,
+	maxSigLen int,
+	metricsRegisterer prometheus.Registerer,
+	monitoringEndpoint commontypes.MonitoringEndpoint,
+	messageNetEndpointFactory types.BinaryNetworkEndpoint2Factory,
+	observerConsumer ocr3_1types.ObserverConsumer,
+	offchainConfigDigester types.OffchainConfigDigester,
+	peerLatencyHints ocr3_1types.PeerLatencyHints,
+	reportingPluginLimits ocr3_1types.ReportingPluginLimits,
+) {
+	subs := subprocesses.Subprocesses{}
+	defer subs.Wait()
+
+	var chTelemetrySend chan<- *serialization.TelemetryWrapper
+	{
+		chTelemetry := make(chan *serialization.TelemetryWrapper, 100)
+		chTelemetrySend = chTelemetry
+		subs.Go(func() {
+			forwardTelemetry(ctx, logger, monitoringEndpoint, chTelemetry)
+		})
+	}
+
+	metricsRegistererWrapper := metricshelper.NewPrometheusRegistererWrapper(metricsRegisterer, logger)
+
+	runWithContractConfig(
+		ctx,
+
+		configTracker,
+		database,
+		func(ctx context.Context, logger loghelper.LoggerWithContext, contractConfig types.ContractConfig) (err error, retry bool) {
+			skipInsaneForProductionChecks := localConfig.DevelopmentMode == types.EnableDangerousDevelopmentMode
+
+			publicConfig, err := ocr3_1config.PublicConfigFromContractConfig(skipInsaneForProductionChecks, contractConfig)
+			if err != nil {
+				return fmt.Errorf("ManagedOCR3_1Observer: error while decoding ContractConfig: %w", err), false
+			}
+
+			if err := validateOCR3_1ReportingPluginLimits(reportingPluginLimits); err != nil {
+				return fmt.Errorf("ManagedOCR3_1Observer: invalid ReportingPluginLimits: %w", err), false
+			}
+
+			peerIDs := []string{}
+			for _, identity := range publicConfig.OracleIdentities {
+				peerIDs = append(peerIDs, identity.PeerID)
+			}
+
+			ownPeerID := messageNetEndpointFactory.PeerID()
+			if slices.Contains(peerIDs, ownPeerID) {
+				return fmt.Errorf("ManagedOCR3_1Observer: peer ID %s belongs to an oracle, oracles cannot be observers", ownPeerID), false
+			}
+			// as far as the network is concerned, we are the oracle following the
+			// oracles
+			oid := commontypes.OracleID(publicConfig.N())
+			endpointPeerIDs := append(slices.Clone(peerIDs), ownPeerID)
+
+			registerer := prometheus.WrapRegistererWith(
+				prometheus.Labels{
+					// disambiguate different protocol instances by configDigest
+					"config_digest": publicConfig.ConfigDigest.String(),
+					// disambiguate different observer instances by peerID
+					"observer_peer_id": ownPeerID,
+				},
+				metricsRegistererWrapper,
+			)
+
+			childLogger := logger.MakeChild(commontypes.LogFields{
+				"oid":      oid,
+				"observer": true,
+			})
+
+			defaultLims, lowPriorityLimits, serializedLengthLimits, err := limits.OCR3_1Limits(publicConfig, reportingPluginLimits, maxSigLen)
+			if err != nil {
+				logger.Error("ManagedOCR3_1Observer: error during limits", commontypes.LogFields{
+					"error":                 err,
+					"publicConfig":          publicConfig,
+					"reportingPluginLimits": reportingPluginLimits,
+					"maxSigLen":             maxSigLen,
+				})
+				return fmt.Errorf("ManagedOCR3_1Observer: error during limits"), false
+			}
+
+			defaultPriorityConfig := types.BinaryNetworkEndpoint2Config{
+				defaultLims,
+				nil,
+				nil,
+			}
+			lowPriorityConfig := types.BinaryNetworkEndpoint2Config{
+				lowPriorityLimits,
+				nil,
+				nil,
+			}
+
+			binNetEndpoint, err := messageNetEndpointFactory.NewEndpoint(
+				publicConfig.ConfigDigest,
+				endpointPeerIDs,
+				v2bootstrappers,
+				defaultPriorityConfig,
+				lowPriorityConfig,
+			)
+			if err != nil {
+				logger.Error("ManagedOCR3_1Observer: error during NewEndpoint", commontypes.LogFields{
+					"error":           err,
+					"peerIDs":         endpointPeerIDs,
+					"v2bootstrappers": v2bootstrappers,
+				})
+				return fmt.Errorf("ManagedOCR3_1Observer: error during NewEndpoint"), true
+			}
+			defer loghelper.CloseLogError(
+				binNetEndpoint,
+				logger,
+				"ManagedOCR3_1Observer: error during BinaryNetworkEndpoint2.Close()",
+			)
+
+			netEndpoint := shim.NewOCR3_1SerializingEndpoint[struct{}](
+				chTelemetrySend,
+				publicConfig.ConfigDigest,
+				binNetEndpoint,
+				maxSigLen,
+				childLogger,
+				registerer,
+				1, // ourselves
+				reportingPluginLimits,
+				publicConfig,
+				serializedLengthLimits,
+			)
+			err = netEndpoint.Start()
+			if err != nil {
+				return fmt.Errorf("ManagedOCR3_1Observer: error during netEndpoint.Start(): %w", err), true
+			}
+			defer loghelper.CloseLogError(
+				netEndpoint,
+				logger,
+				"ManagedOCR3_1Observer: error during netEndpoint.Close()",
+			)
+
+			if prev, ok := publicConfig.GetPrevFields(); ok {
+				err := tryCopyFromPrevInstance(
+					ctx,
+					publicConfig,
+					logger,
+					&devNullRegisterer{},
+					registerer,
+					reportingPluginLimits,
+					keyValueDatabaseFactory,
+					prev.PrevConfigDigest,
+					prev.PrevSeqNr,
+					publicConfig.ConfigDigest,
+				)
+				if err != nil {
+					return fmt.Errorf("ManagedOCR3_1Observer: error during tryCopyFromPrevInstance: %w", err), true
+				}
+			}
+
+			keyValueDatabase, err := keyValueDatabaseFactory.NewKeyValueDatabase(publicConfig.ConfigDigest)
+			if err != nil {
+				return fmt.Errorf("ManagedOCR3_1Observer: error during NewKeyValueDatabase: %w", err), true
+			}
+			defer loghelper.CloseLogError(
+				keyValueDatabase,
+				logger,
+				"ManagedOCR3_1Observer: error during keyValueDatabase.Close()",
+			)
+			keyValueDatabaseWithMetrics := shim.NewKeyValueDatabaseWithMetrics(keyValueDatabase, registerer, logger)
+			defer loghelper.CloseLogError(
+				keyValueDatabaseWithMetrics,
+				logger,
+				"ManagedOCR3_1Observer: error during keyValueDatabaseWithMetrics.Close()",
+			)
+			semanticOCR3_1KeyValueDatabase, err := shim.NewSemanticOCR3_1KeyValueDatabase(keyValueDatabaseWithMetrics, reportingPluginLimits, publicConfig, logger, registerer)
+			if err != nil {
+				return fmt.Errorf("ManagedOCR3_1Observer: error during NewSemanticOCR3_1KeyValueDatabase: %w", err), true
+			}
+			defer loghelper.CloseLogError(
+				semanticOCR3_1KeyValueDatabase,
+				logger,
+				"ManagedOCR3_1Observer: error during semanticOCR3_1KeyValueDatabase.Close()",
+			)
+
+			var protocolLatencyHints protocol.OracleLatencyHints
+			if peerLatencyHints != nil {
+				protocolLatencyHints = &shim.OCR3_1OracleLatencyHints{peerLatencyHints, peerIDs}
+			}
+
+			protocol.RunObserver[struct{}](
+				ctx,
+				publicConfig,
+				observerConsumer,
+				oid,
+				semanticOCR3_1KeyValueDatabase,
+				protocolLatencyHints,
+				childLogger,
+				netEndpoint,
+			)
+
+			return nil, false
+		},
+		localConfig,
+		logger,
+		offchainConfigDigester,
+		defaultRetryParams(),
+	)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/common"
 
@@ -41,10 +42,13 @@ func RunManagedOCR3_1Oracle[RI any](
 	metricsRegisterer prometheus.Registerer,
 	monitoringEndpoint commontypes.MonitoringEndpoint,
 	messageNetEndpointFactory types.BinaryNetworkEndpoint2Factory,
+	observerPeerIDs []string,
 	offchainConfigDigester types.OffchainConfigDigester,
 	offchainKeyring types.OffchainKeyring,
 	onchainKeyring ocr3types.OnchainKeyring[RI],
+	peerLatencyHints ocr3_1types.PeerLatencyHints,
 	reportingPluginFactory ocr3_1types.ReportingPluginFactory[RI],
+	sharedSecretRotationSource ocr3_1types.SharedSecretRotationSource,
 ) {
 	subs := subprocesses.Subprocesses{}
 	defer subs.Wait()
@@ -101,11 +105,18 @@ func RunManagedOCR3_1Oracle[RI any](
 				peerIDs = append(peerIDs, identity.PeerID)
 			}
 
+			if err := checkOCR3_1ObserverPeerIDs(peerIDs, observerPeerIDs); err != nil {
+				return fmt.Errorf("ManagedOCR3_1Oracle: invalid observer peer IDs: %w", err), false
+			}
+			// observers get the OracleIDs N, N+1, ...
+			endpointPeerIDs := append(slices.Clone(peerIDs), observerPeerIDs...)
+
 			childLogger := logger.MakeChild(commontypes.LogFields{
 				"oid": oid,
 			})
 
 			blobEndpointWrapper := protocol.BlobEndpointWrapper{}
+			historicalKeyValueStateWrapper := protocol.HistoricalKeyValueStateWrapper{}
 
 			maxDurationInitialization := sharedConfig.MaxDurationInitialization
 			initCtx, initCancel := context.WithTimeout(ctx, maxDurationInitialization)
@@ -120,7 +131,7 @@ func RunManagedOCR3_1Oracle[RI any](
 				},
 			)
 
-			reportingPlugin, reportingPluginInfo_, err := reportingPluginFactory.NewReportingPlugin(initCtx, ocr3types.ReportingPluginConfig{
+			reportingPluginConfig := ocr3types.ReportingPluginConfig{
 				sharedConfig.ConfigDigest,
 				oid,
 				sharedConfig.N(),
@@ -132,7 +143,14 @@ func RunManagedOCR3_1Oracle[RI any](
 				sharedConfig.WarnDurationObservation,
 				sharedConfig.MaxDurationShouldAcceptAttestedReport,
 				sharedConfig.MaxDurationShouldTransmitAcceptedReport,
-			}, &blobEndpointWrapper)
+			}
+			var reportingPlugin ocr3_1types.ReportingPlugin[RI]
+			var reportingPluginInfo_ ocr3_1types.ReportingPluginInfo
+			if historicalReportingPluginFactory, ok := reportingPluginFactory.(ocr3_1types.ReportingPluginFactoryWithHistoricalKeyValueState[RI]); ok {
+				reportingPlugin, reportingPluginInfo_, err = historicalReportingPluginFactory.NewReportingPluginWithHistoricalKeyValueState(initCtx, reportingPluginConfig, &blobEndpointWrapper, &historicalKeyValueStateWrapper)
+			} else {
+				reportingPlugin, reportingPluginInfo_, err = reportingPluginFactory.NewReportingPlugin(initCtx, reportingPluginConfig, &blobEndpointWrapper)
+			}
 
 			ins.Stop()
 
@@ -184,7 +202,7 @@ func RunManagedOCR3_1Oracle[RI any](
 
 			binNetEndpoint, err := messageNetEndpointFactory.NewEndpoint(
 				sharedConfig.ConfigDigest,
-				peerIDs,
+				endpointPeerIDs,
 				v2bootstrappers,
 				defaultPriorityConfig,
 				lowPriorityConfig,
@@ -192,7 +210,7 @@ func RunManagedOCR3_1Oracle[RI any](
 			if err != nil {
 				logger.Error("ManagedOCR3_1Oracle: error during NewEndpoint", commontypes.LogFields{
 					"error":           err,
-					"peerIDs":         peerIDs,
+					"peerIDs":         endpointPeerIDs,
 					"v2bootstrappers": v2bootstrappers,
 				})
 				return fmt.Errorf("ManagedOCR3_1Oracle: error during NewEndpoint"), true
@@ -210,6 +228,7 @@ func RunManagedOCR3_1Oracle[RI any](
 				maxSigLen,
 				childLogger,
 				registerer,
+				len(observerPeerIDs),
 				reportingPluginInfo.Limits,
 				sharedConfig.PublicConfig,
 				serializedLengthLimits,
@@ -267,6 +286,21 @@ func RunManagedOCR3_1Oracle[RI any](
 				"ManagedOCR3_1Oracle: error during semanticOCR3_1KeyValueDatabase.Close()",
 			)
 
+			historicalKeyValueStateWrapper.SetHistoricalKeyValueState(protocol.NewHistoricalKeyValueState(semanticOCR3_1KeyValueDatabase, sharedConfig.PublicConfig))
+			defer historicalKeyValueStateWrapper.SetHistoricalKeyValueState(nil)
+
+			var protocolSharedSecretRotationSource protocol.SharedSecretRotationSource
+			if sharedSecretRotationSource != nil {
+				protocolSharedSecretRotationSource = &shim.SerializingOCR3_1SharedSecretRotationSource{sharedSecretRotationSource}
+			}
+
+			var protocolLatencyHints protocol.OracleLatencyHints
+			if peerLatencyHints != nil {
+				protocolLatencyHints = &shim.OCR3_1OracleLatencyHints{peerLatencyHints, peerIDs}
+			}
+
+			reportInfoCodec, _ := reportingPlugin.(ocr3types.ReportInfoCodec[RI])
+
 			protocol.RunOracle[RI](
 				ctx,
 				&blobEndpointWrapper,
@@ -275,6 +309,7 @@ func RunManagedOCR3_1Oracle[RI any](
 				&shim.SerializingOCR3_1Database{database},
 				oid,
 				semanticOCR3_1KeyValueDatabase,
+				protocolLatencyHints,
 				reportingPluginInfo.Limits,
 				localConfig,
 				childLogger,
@@ -283,6 +318,8 @@ func RunManagedOCR3_1Oracle[RI any](
 				offchainKeyring,
 				onchainKeyring,
 				shim.LimitCheckOCR3_1ReportingPlugin[RI]{reportingPlugin, reportingPluginInfo.Limits},
+				reportInfoCodec,
+				protocolSharedSecretRotationSource,
 				shim.NewOCR3_1TelemetrySender(chTelemetrySend, childLogger),
 			)
 
@@ -295,6 +332,20 @@ func RunManagedOCR3_1Oracle[RI any](
 	)
 }
 
+func checkOCR3_1ObserverPeerIDs(oraclePeerIDs []string, observerPeerIDs []string) error {
+	seen := make(map[string]struct{}, len(oraclePeerIDs)+len(observerPeerIDs))
+	for _, peerID := range oraclePeerIDs {
+		seen[peerID] = struct{}{}
+	}
+	for _, peerID := range observerPeerIDs {
+		if _, ok := seen[peerID]; ok {
+			return fmt.Errorf("peer ID %s is listed more than once or is also an oracle's peer ID", peerID)
+		}
+		seen[peerID] = struct{}{}
+	}
+	return nil
+}
+
 func validateOCR3_1ReportingPluginLimits(limits ocr3_1types.ReportingPluginLimits) error {
 	var err error
 	if !(0 <= limits.MaxQueryBytes && limits.MaxQueryBytes <= ocr3_1types.MaxMaxQueryBytes) {
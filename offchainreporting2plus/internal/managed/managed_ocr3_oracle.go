@@ -196,6 +196,8 @@ func RunManagedOCR3Oracle[RI any](
 				"ManagedOCR3Oracle: error during netEndpoint.Close()",
 			)
 
+			reportInfoCodec, _ := reportingPlugin.(ocr3types.ReportInfoCodec[RI])
+
 			protocol.RunOracle[RI](
 				ctx,
 				sharedConfig,
@@ -209,6 +211,7 @@ func RunManagedOCR3Oracle[RI any](
 				offchainKeyring,
 				onchainKeyring,
 				shim.LimitCheckOCR3ReportingPlugin[RI]{reportingPlugin, reportingPluginInfo.Limits},
+				reportInfoCodec,
 				shim.NewOCR3TelemetrySender(chTelemetrySend, childLogger, localConfig.EnableTransmissionTelemetry),
 			)
 
@@ -127,6 +127,7 @@ type MercuryReportingPlugin struct {
 }
 
 var _ ocr3types.ReportingPlugin[MercuryReportInfo] = &MercuryReportingPlugin{}
+var _ ocr3types.ReportInfoCodec[MercuryReportInfo] = &MercuryReportingPlugin{}
 
 type mercuryReportingPluginOutcome struct {
 	Epoch        uint32
@@ -257,6 +258,16 @@ func (p *MercuryReportingPlugin) ShouldTransmitAcceptedReport(context.Context, u
 	return true, nil
 }
 
+func (p *MercuryReportingPlugin) EncodeReportInfo(info MercuryReportInfo) ([]byte, error) {
+	return json.Marshal(info)
+}
+
+func (p *MercuryReportingPlugin) DecodeReportInfo(encoded []byte) (MercuryReportInfo, error) {
+	var info MercuryReportInfo
+	err := json.Unmarshal(encoded, &info)
+	return info, err
+}
+
 func (p *MercuryReportingPlugin) Close() error {
 	return p.Plugin.Close()
 }
@@ -2,7 +2,9 @@ package protocol
 
 import (
 	"context"
+	"time"
 
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/common/pendingtransmissions"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 )
 
@@ -11,6 +13,28 @@ type PacemakerState struct {
 	HighestSentNewEpochWish uint64
 }
 
+// PendingTransmission is an attested report that this oracle has accepted and
+// scheduled for transmission. Pending transmissions are persisted so that
+// transmission resumes after a restart.
+type PendingTransmission struct {
+	SeqNr       uint64
+	Index       int
+	ScheduledAt time.Time
+	// After a restart, the transmission is dropped once ExpiresAt has passed
+	ExpiresAt time.Time
+	Report    types.Report
+	// Encoded by the reporting plugin's ocr3types.ReportInfoCodec
+	ReportInfo           []byte
+	AttributedSignatures []types.AttributedOnchainSignature
+}
+
+// PendingTransmissionKey identifies a PendingTransmission.
+type PendingTransmissionKey = pendingtransmissions.Key
+
+func (pt PendingTransmission) Key() PendingTransmissionKey {
+	return PendingTransmissionKey{pt.SeqNr, pt.Index}
+}
+
 type Database interface {
 	types.ConfigDatabase
 
@@ -19,4 +43,13 @@ type Database interface {
 
 	ReadCert(ctx context.Context, configDigest types.ConfigDigest) (CertifiedPrepareOrCommit, error)
 	WriteCert(ctx context.Context, configDigest types.ConfigDigest, cert CertifiedPrepareOrCommit) error
+
+	// In case there are no pending transmissions, nil should be returned.
+	ReadPendingTransmissions(ctx context.Context, configDigest types.ConfigDigest) ([]PendingTransmission, error)
+	// Adds or replaces the pending transmission with the same SeqNr and Index.
+	// Calls to WritePendingTransmission and DeletePendingTransmission for the
+	// same configDigest are not concurrent.
+	WritePendingTransmission(ctx context.Context, configDigest types.ConfigDigest, pendingTransmission PendingTransmission) error
+	// Deleting a pending transmission that doesn't exist is not an error.
+	DeletePendingTransmission(ctx context.Context, configDigest types.ConfigDigest, seqNr uint64, index int) error
 }
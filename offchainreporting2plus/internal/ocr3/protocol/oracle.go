@@ -33,6 +33,8 @@ func RunOracle[RI any](
 	offchainKeyring types.OffchainKeyring,
 	onchainKeyring ocr3types.OnchainKeyring[RI],
 	reportingPlugin ocr3types.ReportingPlugin[RI],
+	// nil if pending transmissions should not survive restarts
+	reportInfoCodec ocr3types.ReportInfoCodec[RI],
 	telemetrySender TelemetrySender,
 ) {
 	o := oracleState[RI]{
@@ -49,6 +51,7 @@ func RunOracle[RI any](
 		offchainKeyring:     offchainKeyring,
 		onchainKeyring:      onchainKeyring,
 		reportingPlugin:     reportingPlugin,
+		reportInfoCodec:     reportInfoCodec,
 		telemetrySender:     telemetrySender,
 	}
 	o.run()
@@ -68,6 +71,7 @@ type oracleState[RI any] struct {
 	offchainKeyring     types.OffchainKeyring
 	onchainKeyring      ocr3types.OnchainKeyring[RI]
 	reportingPlugin     ocr3types.ReportingPlugin[RI]
+	reportInfoCodec     ocr3types.ReportInfoCodec[RI]
 	telemetrySender     TelemetrySender
 
 	chNetToPacemaker         chan<- MessageToPacemakerWithSender[RI]
@@ -229,10 +233,12 @@ func (o *oracleState[RI]) run() {
 			chReportAttestationToTransmission,
 			o.config,
 			o.contractTransmitter,
+			o.database,
 			o.id,
 			o.localConfig,
 			o.logger,
 			o.reportingPlugin,
+			o.reportInfoCodec,
 			o.telemetrySender,
 		)
 	})
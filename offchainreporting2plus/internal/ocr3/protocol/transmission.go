@@ -11,6 +11,7 @@ import (
 
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/common/pendingtransmissions"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/common/scheduler"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3config"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3types"
@@ -27,12 +28,16 @@ func RunTransmission[RI any](
 	chReportAttestationToTransmission <-chan EventToTransmission[RI],
 	config ocr3config.SharedConfig,
 	contractTransmitter ocr3types.ContractTransmitter[RI],
+	database Database,
 	id commontypes.OracleID,
 	localConfig types.LocalConfig,
 	logger loghelper.LoggerWithContext,
 	reportingPlugin ocr3types.ReportingPlugin[RI],
+	reportInfoCodec ocr3types.ReportInfoCodec[RI],
 	telemetrySender TelemetrySender,
 ) {
+	logger = logger.MakeUpdated(commontypes.LogFields{"proto": "transmission"})
+
 	sched := scheduler.NewScheduler[EventAttestedReport[RI]]()
 	defer sched.Close()
 
@@ -43,13 +48,21 @@ func RunTransmission[RI any](
 		chReportAttestationToTransmission,
 		config,
 		contractTransmitter,
+		database,
 		id,
 		localConfig,
-		logger.MakeUpdated(commontypes.LogFields{"proto": "transmission"}),
+		logger,
 		reportingPlugin,
+		reportInfoCodec,
 		telemetrySender,
 
 		sched,
+		pendingtransmissions.NewTracker[PendingTransmission](
+			ctx,
+			logger,
+			pendingTransmissionDatabase{database, config.ConfigDigest},
+			localConfig.DatabaseTimeout,
+		),
 	}
 	t.run()
 }
@@ -61,19 +74,25 @@ type transmissionState[RI any] struct {
 	chReportAttestationToTransmission <-chan EventToTransmission[RI]
 	config                            ocr3config.SharedConfig
 	contractTransmitter               ocr3types.ContractTransmitter[RI]
+	database                          Database
 	id                                commontypes.OracleID
 	localConfig                       types.LocalConfig
 	logger                            loghelper.LoggerWithContext
 	reportingPlugin                   ocr3types.ReportingPlugin[RI]
+	reportInfoCodec                   ocr3types.ReportInfoCodec[RI] // may be nil
 	telemetrySender                   TelemetrySender
 
 	scheduler *scheduler.Scheduler[EventAttestedReport[RI]]
+
+	pendingTransmissions *pendingtransmissions.Tracker[PendingTransmission]
 }
 
 // run runs the event loop for the local transmission protocol
 func (t *transmissionState[RI]) run() {
 	t.logger.Info("Transmission: running", nil)
 
+	t.resumePendingTransmissions()
+
 	chDone := t.ctx.Done()
 	for {
 		select {
@@ -164,7 +183,16 @@ func (t *transmissionState[RI]) backgroundEventAttestedReport(ctx context.Contex
 		"delay":                        delay.String(),
 		"transmissionScheduleOverride": ev.TransmissionScheduleOverride != nil,
 	})
-	t.scheduler.ScheduleDeadline(ev, start.Add(delay))
+	scheduledAt := start.Add(delay)
+	expiresAt := start.Add(t.lastStageDelay(ev.TransmissionScheduleOverride) + t.config.DeltaStage)
+	if !t.addPendingTransmission(ev, scheduledAt, expiresAt) {
+		t.logger.Debug("AttestedReport is already scheduled for transmission", commontypes.LogFields{
+			"seqNr": ev.SeqNr,
+			"index": ev.Index,
+		})
+		return
+	}
+	t.scheduler.ScheduleDeadline(ev, scheduledAt)
 }
 
 func (t *transmissionState[RI]) scheduled(ev EventAttestedReport[RI]) {
@@ -174,6 +202,14 @@ func (t *transmissionState[RI]) scheduled(ev EventAttestedReport[RI]) {
 }
 
 func (t *transmissionState[RI]) backgroundScheduled(ctx context.Context, ev EventAttestedReport[RI]) {
+	defer func() {
+		// If we are shutting down, the transmission remains pending so that
+		// it is resumed after a restart
+		if ctx.Err() == nil {
+			t.removePendingTransmission(ev.SeqNr, ev.Index)
+		}
+	}()
+
 	shouldTransmit, ok := common.CallPlugin[bool](
 		ctx,
 		t.logger,
@@ -75,6 +75,258 @@ func (x *PacemakerState) GetHighestSentNewEpochWish() uint64 {
 	return 0
 }
 
+type PendingTransmission struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SeqNr                uint64                                    `protobuf:"varint,1,opt,name=seq_nr,json=seqNr,proto3" json:"seq_nr,omitempty"`
+	Index                uint64                                    `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	ScheduledAtUnixNano  int64                                     `protobuf:"varint,3,opt,name=scheduled_at_unix_nano,json=scheduledAtUnixNano,proto3" json:"scheduled_at_unix_nano,omitempty"`
+	ExpiresAtUnixNano    int64                                     `protobuf:"varint,4,opt,name=expires_at_unix_nano,json=expiresAtUnixNano,proto3" json:"expires_at_unix_nano,omitempty"`
+	Report               []byte                                    `protobuf:"bytes,5,opt,name=report,proto3" json:"report,omitempty"`
+	ReportInfo           []byte                                    `protobuf:"bytes,6,opt,name=report_info,json=reportInfo,proto3" json:"report_info,omitempty"`
+	AttributedSignatures []*PendingTransmissionAttributedSignature `protobuf:"bytes,7,rep,name=attributed_signatures,json=attributedSignatures,proto3" json:"attributed_signatures,omitempty"`
+}
+
+func (x *PendingTransmission) Reset() {
+	*x = PendingTransmission{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_offchainreporting3_db_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PendingTransmission) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendingTransmission) ProtoMessage() {}
+
+func (x *PendingTransmission) ProtoReflect() protoreflect.Message {
+	mi := &file_offchainreporting3_db_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendingTransmission.ProtoReflect.Descriptor instead.
+func (*PendingTransmission) Descriptor() ([]byte, []int) {
+	return file_offchainreporting3_db_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PendingTransmission) GetSeqNr() uint64 {
+	if x != nil {
+		return x.SeqNr
+	}
+	return 0
+}
+
+func (x *PendingTransmission) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *PendingTransmission) GetScheduledAtUnixNano() int64 {
+	if x != nil {
+		return x.ScheduledAtUnixNano
+	}
+	return 0
+}
+
+func (x *PendingTransmission) GetExpiresAtUnixNano() int64 {
+	if x != nil {
+		return x.ExpiresAtUnixNano
+	}
+	return 0
+}
+
+func (x *PendingTransmission) GetReport() []byte {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+func (x *PendingTransmission) GetReportInfo() []byte {
+	if x != nil {
+		return x.ReportInfo
+	}
+	return nil
+}
+
+func (x *PendingTransmission) GetAttributedSignatures() []*PendingTransmissionAttributedSignature {
+	if x != nil {
+		return x.AttributedSignatures
+	}
+	return nil
+}
+
+type PendingTransmissionAttributedSignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Signature []byte `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+	Signer    uint32 `protobuf:"varint,2,opt,name=signer,proto3" json:"signer,omitempty"`
+}
+
+func (x *PendingTransmissionAttributedSignature) Reset() {
+	*x = PendingTransmissionAttributedSignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_offchainreporting3_db_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PendingTransmissionAttributedSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendingTransmissionAttributedSignature) ProtoMessage() {}
+
+func (x *PendingTransmissionAttributedSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_offchainreporting3_db_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendingTransmissionAttributedSignature.ProtoReflect.Descriptor instead.
+func (*PendingTransmissionAttributedSignature) Descriptor() ([]byte, []int) {
+	return file_offchainreporting3_db_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PendingTransmissionAttributedSignature) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *PendingTransmissionAttributedSignature) GetSigner() uint32 {
+	if x != nil {
+		return x.Signer
+	}
+	return 0
+}
+
+type PendingTransmissionKeys struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Keys []*PendingTransmissionKey `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+}
+
+func (x *PendingTransmissionKeys) Reset() {
+	*x = PendingTransmissionKeys{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_offchainreporting3_db_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PendingTransmissionKeys) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendingTransmissionKeys) ProtoMessage() {}
+
+func (x *PendingTransmissionKeys) ProtoReflect() protoreflect.Message {
+	mi := &file_offchainreporting3_db_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendingTransmissionKeys.ProtoReflect.Descriptor instead.
+func (*PendingTransmissionKeys) Descriptor() ([]byte, []int) {
+	return file_offchainreporting3_db_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PendingTransmissionKeys) GetKeys() []*PendingTransmissionKey {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type PendingTransmissionKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SeqNr uint64 `protobuf:"varint,1,opt,name=seq_nr,json=seqNr,proto3" json:"seq_nr,omitempty"`
+	Index uint64 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+}
+
+func (x *PendingTransmissionKey) Reset() {
+	*x = PendingTransmissionKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_offchainreporting3_db_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PendingTransmissionKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendingTransmissionKey) ProtoMessage() {}
+
+func (x *PendingTransmissionKey) ProtoReflect() protoreflect.Message {
+	mi := &file_offchainreporting3_db_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendingTransmissionKey.ProtoReflect.Descriptor instead.
+func (*PendingTransmissionKey) Descriptor() ([]byte, []int) {
+	return file_offchainreporting3_db_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PendingTransmissionKey) GetSeqNr() uint64 {
+	if x != nil {
+		return x.SeqNr
+	}
+	return 0
+}
+
+func (x *PendingTransmissionKey) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
 var File_offchainreporting3_db_proto protoreflect.FileDescriptor
 
 var file_offchainreporting3_db_proto_rawDesc = []byte{
@@ -87,9 +339,46 @@ var file_offchainreporting3_db_proto_rawDesc = []byte{
 	0x68, 0x65, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x6e, 0x74, 0x5f, 0x6e, 0x65, 0x77, 0x5f, 0x65, 0x70,
 	0x6f, 0x63, 0x68, 0x5f, 0x77, 0x69, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x17,
 	0x68, 0x69, 0x67, 0x68, 0x65, 0x73, 0x74, 0x53, 0x65, 0x6e, 0x74, 0x4e, 0x65, 0x77, 0x45, 0x70,
-	0x6f, 0x63, 0x68, 0x57, 0x69, 0x73, 0x68, 0x42, 0x11, 0x5a, 0x0f, 0x2e, 0x3b, 0x73, 0x65, 0x72,
-	0x69, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x6f, 0x63, 0x68, 0x57, 0x69, 0x73, 0x68, 0x22, 0xd2, 0x02, 0x0a, 0x13, 0x50, 0x65, 0x6e, 0x64,
+	0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x15, 0x0a, 0x06, 0x73, 0x65, 0x71, 0x5f, 0x6e, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x05, 0x73, 0x65, 0x71, 0x4e, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x33, 0x0a, 0x16,
+	0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x5f, 0x75, 0x6e, 0x69,
+	0x78, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13, 0x73, 0x63,
+	0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x64, 0x41, 0x74, 0x55, 0x6e, 0x69, 0x78, 0x4e, 0x61, 0x6e,
+	0x6f, 0x12, 0x2f, 0x0a, 0x14, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x5f,
+	0x75, 0x6e, 0x69, 0x78, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x11, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x55, 0x6e, 0x69, 0x78, 0x4e, 0x61,
+	0x6e, 0x6f, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x06, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65,
+	0x70, 0x6f, 0x72, 0x74, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0a, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x6f, 0x0a, 0x15, 0x61,
+	0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x5f, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x3a, 0x2e, 0x6f, 0x66, 0x66,
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6e, 0x67, 0x33, 0x2e,
+	0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x53, 0x69, 0x67,
+	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x14, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
+	0x65, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x22, 0x5e, 0x0a, 0x26,
+	0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x53, 0x69, 0x67,
+	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x22, 0x59, 0x0a, 0x17,
+	0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x3e, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x6f, 0x66, 0x66, 0x63, 0x68, 0x61, 0x69, 0x6e,
+	0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6e, 0x67, 0x33, 0x2e, 0x50, 0x65, 0x6e, 0x64, 0x69,
+	0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4b, 0x65,
+	0x79, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x22, 0x45, 0x0a, 0x16, 0x50, 0x65, 0x6e, 0x64, 0x69,
+	0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4b, 0x65,
+	0x79, 0x12, 0x15, 0x0a, 0x06, 0x73, 0x65, 0x71, 0x5f, 0x6e, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x05, 0x73, 0x65, 0x71, 0x4e, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x42, 0x11,
+	0x5a, 0x0f, 0x2e, 0x3b, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -104,16 +393,22 @@ func file_offchainreporting3_db_proto_rawDescGZIP() []byte {
 	return file_offchainreporting3_db_proto_rawDescData
 }
 
-var file_offchainreporting3_db_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_offchainreporting3_db_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_offchainreporting3_db_proto_goTypes = []interface{}{
-	(*PacemakerState)(nil), // 0: offchainreporting3.PacemakerState
+	(*PacemakerState)(nil),                         // 0: offchainreporting3.PacemakerState
+	(*PendingTransmission)(nil),                    // 1: offchainreporting3.PendingTransmission
+	(*PendingTransmissionAttributedSignature)(nil), // 2: offchainreporting3.PendingTransmissionAttributedSignature
+	(*PendingTransmissionKeys)(nil),                // 3: offchainreporting3.PendingTransmissionKeys
+	(*PendingTransmissionKey)(nil),                 // 4: offchainreporting3.PendingTransmissionKey
 }
 var file_offchainreporting3_db_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2, // 0: offchainreporting3.PendingTransmission.attributed_signatures:type_name -> offchainreporting3.PendingTransmissionAttributedSignature
+	4, // 1: offchainreporting3.PendingTransmissionKeys.keys:type_name -> offchainreporting3.PendingTransmissionKey
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_offchainreporting3_db_proto_init() }
@@ -134,6 +429,54 @@ func file_offchainreporting3_db_proto_init() {
 				return nil
 			}
 		}
+		file_offchainreporting3_db_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PendingTransmission); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_offchainreporting3_db_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PendingTransmissionAttributedSignature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_offchainreporting3_db_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PendingTransmissionKeys); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_offchainreporting3_db_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PendingTransmissionKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -141,7 +484,7 @@ func file_offchainreporting3_db_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_offchainreporting3_db_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
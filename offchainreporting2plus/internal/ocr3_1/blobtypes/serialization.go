@@ -30,6 +30,8 @@ func (lc LightCertifiedBlob) AppendBinary(b []byte) ([]byte, error) {
 		lc.ExpirySeqNr,
 		uint32(lc.Submitter),
 		pbSignatures,
+		lc.ErasureCoded,
+		lc.ExpiryKey,
 	)
 
 	opts := proto.MarshalOptions{}
@@ -71,7 +73,9 @@ func (lc *LightCertifiedBlob) UnmarshalBinary(data []byte) error {
 		chunkDigestsRoot,
 		pbLightCertifiedBlob.PayloadLength,
 		pbLightCertifiedBlob.ExpirySeqNr,
+		pbLightCertifiedBlob.ExpiryKey,
 		commontypes.OracleID(pbLightCertifiedBlob.Submitter),
+		pbLightCertifiedBlob.ErasureCoded,
 		signatures,
 	}
 	return nil
@@ -25,6 +25,8 @@ func NewLightCertifiedBlob(
 	expirySeqNr uint64,
 	submitter uint32,
 	attributedBlobAvailabilitySignatures []*AttributedBlobAvailabilitySignature,
+	erasureCoded bool,
+	expiryKey []byte,
 ) *LightCertifiedBlob {
 	return &LightCertifiedBlob{
 		// zero-initialize protobuf built-ins
@@ -37,5 +39,7 @@ func NewLightCertifiedBlob(
 		expirySeqNr,
 		submitter,
 		attributedBlobAvailabilitySignatures,
+		erasureCoded,
+		expiryKey,
 	}
 }
@@ -71,12 +71,16 @@ func (bd BlobDigest) String() string {
 	return fmt.Sprintf("%x", bd[:])
 }
 
+// MakeBlobDigest computes the digest of a blob. For erasure coded blobs,
+// chunkDigestsRoot commits to the fragments rather than to the payload.
 func MakeBlobDigest(
 	configDigest types.ConfigDigest,
 	chunkDigestsRoot mt.Digest,
 	payloadLength uint64,
 	expirySeqNr uint64,
+	expiryKey []byte,
 	submitter commontypes.OracleID,
+	erasureCoded bool,
 ) BlobDigest {
 	h := sha256.New()
 
@@ -90,6 +94,20 @@ func MakeBlobDigest(
 
 	_ = binary.Write(h, binary.BigEndian, uint64(submitter))
 
+	// Only hashed for erasure coded blobs, so that digests of other blobs are
+	// unaffected. The preimage lengths differ, so there is no ambiguity.
+	if erasureCoded {
+		_, _ = h.Write([]byte{1})
+	}
+
+	// Likewise, only hashed for blobs that expire with a key. The marker
+	// differs from the one above.
+	if len(expiryKey) != 0 {
+		_, _ = h.Write([]byte{2})
+		_ = binary.Write(h, binary.BigEndian, uint64(len(expiryKey)))
+		_, _ = h.Write(expiryKey)
+	}
+
 	var result BlobDigest
 	h.Sum(result[:0])
 	return result
@@ -145,7 +163,14 @@ type LightCertifiedBlob struct {
 	ChunkDigestsRoot mt.Digest
 	PayloadLength    uint64
 	ExpirySeqNr      uint64
-	Submitter        commontypes.OracleID
+	// ExpiryKey is non-empty for blobs that expire once it is deleted from the
+	// KeyValueState, see ocr3_1types.BlobExpirationHintKeyDeleted.
+	ExpiryKey []byte
+	Submitter commontypes.OracleID
+	// ErasureCoded blobs are disseminated as fragments, one per oracle. Every
+	// signer of the certificate holds its own fragment, and any F+1 fragments
+	// suffice to reconstruct the payload.
+	ErasureCoded bool
 
 	AttributedBlobAvailabilitySignatures []AttributedBlobAvailabilitySignature
 }
@@ -165,7 +190,9 @@ func (lc *LightCertifiedBlob) Verify(
 		lc.ChunkDigestsRoot,
 		lc.PayloadLength,
 		lc.ExpirySeqNr,
+		lc.ExpiryKey,
 		lc.Submitter,
+		lc.ErasureCoded,
 	)
 
 	seen := make(map[commontypes.OracleID]bool)
@@ -1,10 +1,15 @@
 package protocol
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"sync"
 
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/byzquorum"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/blobtypes"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
 )
@@ -39,6 +44,16 @@ func (bew *BlobEndpointWrapper) BroadcastBlob(ctx context.Context, payload []byt
 	return wrapped.BroadcastBlob(ctx, payload, expirationHint)
 }
 
+var _ ocr3_1types.BlobErasureCodedBroadcaster = &BlobEndpointWrapper{}
+
+func (bew *BlobEndpointWrapper) BroadcastBlobErasureCoded(ctx context.Context, payload []byte, expirationHint ocr3_1types.BlobExpirationHint) (ocr3_1types.BlobHandle, error) {
+	wrapped := bew.locked()
+	if wrapped == nil {
+		return ocr3_1types.BlobHandle{}, errBlobEndpointUnavailable
+	}
+	return wrapped.BroadcastBlobErasureCoded(ctx, payload, expirationHint)
+}
+
 var _ ocr3_1types.BlobFetcher = &BlobEndpointWrapper{}
 
 func (bew *BlobEndpointWrapper) FetchBlob(ctx context.Context, handle ocr3_1types.BlobHandle) ([]byte, error) {
@@ -49,6 +64,26 @@ func (bew *BlobEndpointWrapper) FetchBlob(ctx context.Context, handle ocr3_1type
 	return wrapped.FetchBlob(ctx, handle)
 }
 
+var _ ocr3_1types.BlobStreamBroadcaster = &BlobEndpointWrapper{}
+
+func (bew *BlobEndpointWrapper) BroadcastBlobReader(ctx context.Context, payloadLength uint64, payload io.Reader, expirationHint ocr3_1types.BlobExpirationHint) (ocr3_1types.BlobHandle, error) {
+	wrapped := bew.locked()
+	if wrapped == nil {
+		return ocr3_1types.BlobHandle{}, errBlobEndpointUnavailable
+	}
+	return wrapped.BroadcastBlobReader(ctx, payloadLength, payload, expirationHint)
+}
+
+var _ ocr3_1types.BlobStreamFetcher = &BlobEndpointWrapper{}
+
+func (bew *BlobEndpointWrapper) FetchBlobReader(ctx context.Context, handle ocr3_1types.BlobHandle) (io.ReadCloser, error) {
+	wrapped := bew.locked()
+	if wrapped == nil {
+		return nil, errBlobEndpointUnavailable
+	}
+	return wrapped.FetchBlobReader(ctx, handle)
+}
+
 func (bew *BlobEndpointWrapper) setBlobEndpoint(wrapped *BlobEndpoint) {
 	bew.mu.Lock()
 	bew.wrapped = wrapped
@@ -60,20 +95,95 @@ type BlobEndpoint struct {
 
 	chBlobBroadcastRequest chan<- blobBroadcastRequest
 	chBlobFetchRequest     chan<- blobFetchRequest
+
+	config ocr3_1config.SharedConfig
+	id     commontypes.OracleID
+	kv     KeyValueDatabase
+	limits ocr3_1types.ReportingPluginLimits
 }
 
 var errBlobEndpointUnavailable = fmt.Errorf("blob endpoint unavailable")
 
-func expirySeqNr(expirationHint ocr3_1types.BlobExpirationHint) uint64 {
+// expiry returns the expiry seqNr for the given hint, and the expiry key if
+// the blob also expires once a key is deleted from the KeyValueState.
+func (be *BlobEndpoint) expiry(expirationHint ocr3_1types.BlobExpirationHint) (uint64, []byte, error) {
 	switch beh := expirationHint.(type) {
 	case ocr3_1types.BlobExpirationHintSequenceNumber:
-		return beh.SeqNr
+		return beh.SeqNr, nil, nil
+	case ocr3_1types.BlobExpirationHintDuration:
+		if !(beh.Duration > 0) {
+			return 0, nil, fmt.Errorf("blob expiration hint duration must be positive, got %v", beh.Duration)
+		}
+		committedSeqNr, err := be.kv.HighestCommittedSeqNr()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read highest committed seq nr: %w", err)
+		}
+		// A zero MinRoundInterval gives us no way to convert the duration,
+		// so we treat it as a single round.
+		rounds := uint64(1)
+		if minRoundInterval := be.config.MinRoundInterval(); minRoundInterval > 0 {
+			rounds = uint64((beh.Duration + minRoundInterval - 1) / minRoundInterval)
+		}
+		if committedSeqNr+rounds < committedSeqNr {
+			return 0, nil, fmt.Errorf("expiry seq nr overflow")
+		}
+		return committedSeqNr + rounds, nil, nil
+	case ocr3_1types.BlobExpirationHintKeyDeleted:
+		if !(0 < len(beh.Key) && len(beh.Key) <= ocr3_1types.MaxBlobExpiryKeyBytes) {
+			return 0, nil, fmt.Errorf("blob expiration hint key must have between 1 and %d bytes, got %d", ocr3_1types.MaxBlobExpiryKeyBytes, len(beh.Key))
+		}
+		switch beh.Bound.(type) {
+		case ocr3_1types.BlobExpirationHintSequenceNumber, ocr3_1types.BlobExpirationHintDuration:
+		default:
+			return 0, nil, fmt.Errorf("blob expiration hint bound must be a sequence number or duration hint, got %T", beh.Bound)
+		}
+		expirySeqNr, _, err := be.expiry(beh.Bound)
+		if err != nil {
+			return 0, nil, err
+		}
+		return expirySeqNr, bytes.Clone(beh.Key), nil
 	default:
 		panic(fmt.Sprintf("unexpected blob expiration hint type %T", beh))
 	}
 }
 
 func (be *BlobEndpoint) BroadcastBlob(ctx context.Context, payload []byte, expirationHint ocr3_1types.BlobExpirationHint) (ocr3_1types.BlobHandle, error) {
+	expirySeqNr, expiryKey, err := be.expiry(expirationHint)
+	if err != nil {
+		return ocr3_1types.BlobHandle{}, err
+	}
+	return be.broadcast(ctx, payload, nil, uint64(len(payload)), expirySeqNr, expiryKey, false)
+}
+
+var _ ocr3_1types.BlobErasureCodedBroadcaster = &BlobEndpoint{}
+
+func (be *BlobEndpoint) BroadcastBlobErasureCoded(ctx context.Context, payload []byte, expirationHint ocr3_1types.BlobExpirationHint) (ocr3_1types.BlobHandle, error) {
+	expirySeqNr, expiryKey, err := be.expiry(expirationHint)
+	if err != nil {
+		return ocr3_1types.BlobHandle{}, err
+	}
+	return be.broadcast(ctx, payload, nil, uint64(len(payload)), expirySeqNr, expiryKey, true)
+}
+
+var _ ocr3_1types.BlobStreamBroadcaster = &BlobEndpoint{}
+
+func (be *BlobEndpoint) BroadcastBlobReader(ctx context.Context, payloadLength uint64, payload io.Reader, expirationHint ocr3_1types.BlobExpirationHint) (ocr3_1types.BlobHandle, error) {
+	if payloadLength > uint64(be.limits.MaxBlobPayloadBytes) {
+		return ocr3_1types.BlobHandle{}, fmt.Errorf("blob payload length %d exceeds maximum allowed length %d",
+			payloadLength, be.limits.MaxBlobPayloadBytes)
+	}
+	expirySeqNr, expiryKey, err := be.expiry(expirationHint)
+	if err != nil {
+		return ocr3_1types.BlobHandle{}, err
+	}
+	chunkDigests, err := writeBlobPayloadFromReader(ctx, be.kv, be.config, be.id, payloadLength, payload, expirySeqNr, expiryKey)
+	if err != nil {
+		return ocr3_1types.BlobHandle{}, fmt.Errorf("failed to write blob: %w", err)
+	}
+	return be.broadcast(ctx, nil, chunkDigests, payloadLength, expirySeqNr, expiryKey, false)
+}
+
+func (be *BlobEndpoint) broadcast(ctx context.Context, payload []byte, chunkDigests []BlobChunkDigest, payloadLength uint64, expirySeqNr uint64, expiryKey []byte, erasureCoded bool) (ocr3_1types.BlobHandle, error) {
 	chRequestDone := ctx.Done()
 	chEndpointDone := be.ctx.Done()
 
@@ -83,7 +193,11 @@ func (be *BlobEndpoint) BroadcastBlob(ctx context.Context, payload []byte, expir
 
 	request := blobBroadcastRequest{
 		payload,
-		expirySeqNr(expirationHint),
+		chunkDigests,
+		payloadLength,
+		expirySeqNr,
+		expiryKey,
+		erasureCoded,
 		chResponse,
 		chDone,
 	}
@@ -111,6 +225,39 @@ func (be *BlobEndpoint) BroadcastBlob(ctx context.Context, payload []byte, expir
 var _ ocr3_1types.BlobBroadcaster = &BlobEndpoint{}
 
 func (be *BlobEndpoint) FetchBlob(ctx context.Context, handle ocr3_1types.BlobHandle) ([]byte, error) {
+	return be.fetch(ctx, handle, false)
+}
+
+var _ ocr3_1types.BlobStreamFetcher = &BlobEndpoint{}
+
+func (be *BlobEndpoint) FetchBlobReader(ctx context.Context, handle ocr3_1types.BlobHandle) (io.ReadCloser, error) {
+	blobHandleSumType := blobtypes.ExtractBlobHandleSumType(handle)
+	cert, ok := blobHandleSumType.(*LightCertifiedBlob)
+	if !ok || cert == nil {
+		return nil, fmt.Errorf("zero value blob handle provided")
+	}
+	if _, err := be.fetch(ctx, handle, true); err != nil {
+		return nil, err
+	}
+	blobDigest := blobtypes.MakeBlobDigest(
+		be.config.ConfigDigest,
+		cert.ChunkDigestsRoot,
+		cert.PayloadLength,
+		cert.ExpirySeqNr,
+		cert.ExpiryKey,
+		cert.Submitter,
+		cert.ErasureCoded,
+	)
+	reader, err := newBlobPayloadReader(be.kv, be.config, blobDigest, cert.ChunkDigestsRoot, cert.PayloadLength, cert.ErasureCoded)
+	if err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// fetch makes sure the blob is available in local storage. Unless
+// availableOnly is set, it also reads and returns the payload.
+func (be *BlobEndpoint) fetch(ctx context.Context, handle ocr3_1types.BlobHandle, availableOnly bool) ([]byte, error) {
 	chRequestDone := ctx.Done()
 	chEndpointDone := be.ctx.Done()
 
@@ -131,6 +278,7 @@ func (be *BlobEndpoint) FetchBlob(ctx context.Context, handle ocr3_1types.BlobHa
 
 		request := blobFetchRequest{
 			*handle,
+			availableOnly,
 			chResponse,
 			chDone,
 		}
@@ -179,14 +327,146 @@ func (r *RoundBlobBroadcastFetcher) BroadcastBlob(ctx context.Context, payload [
 }
 
 func (r *RoundBlobBroadcastFetcher) FetchBlob(ctx context.Context, handle ocr3_1types.BlobHandle) ([]byte, error) {
+	if err := r.checkNotExpired(handle); err != nil {
+		return nil, err
+	}
+	return r.blobBroadcastFetcher.FetchBlob(ctx, handle)
+}
+
+func (r *RoundBlobBroadcastFetcher) checkNotExpired(handle ocr3_1types.BlobHandle) error {
 	blobHandleSumType := blobtypes.ExtractBlobHandleSumType(handle)
 	switch cert := blobHandleSumType.(type) {
 	case *blobtypes.LightCertifiedBlob:
 		if cert != nil && cert.ExpirySeqNr < r.seqNr {
-			return nil, fmt.Errorf("blob expired")
+			return fmt.Errorf("blob expired")
 		}
-		return r.blobBroadcastFetcher.FetchBlob(ctx, handle)
+		return nil
 	default:
 		panic(fmt.Sprintf("unexpected blob handle type %T", handle))
 	}
 }
+
+var _ ocr3_1types.BlobStreamBroadcaster = &RoundBlobBroadcastFetcher{}
+
+func (r *RoundBlobBroadcastFetcher) BroadcastBlobReader(ctx context.Context, payloadLength uint64, payload io.Reader, expirationHint ocr3_1types.BlobExpirationHint) (ocr3_1types.BlobHandle, error) {
+	streamBroadcaster, ok := r.blobBroadcastFetcher.(ocr3_1types.BlobStreamBroadcaster)
+	if !ok {
+		return ocr3_1types.BlobHandle{}, fmt.Errorf("streaming blob broadcasts are not supported")
+	}
+	return streamBroadcaster.BroadcastBlobReader(ctx, payloadLength, payload, expirationHint)
+}
+
+var _ ocr3_1types.BlobErasureCodedBroadcaster = &RoundBlobBroadcastFetcher{}
+
+func (r *RoundBlobBroadcastFetcher) BroadcastBlobErasureCoded(ctx context.Context, payload []byte, expirationHint ocr3_1types.BlobExpirationHint) (ocr3_1types.BlobHandle, error) {
+	erasureCodedBroadcaster, ok := r.blobBroadcastFetcher.(ocr3_1types.BlobErasureCodedBroadcaster)
+	if !ok {
+		return ocr3_1types.BlobHandle{}, fmt.Errorf("erasure coded blob broadcasts are not supported")
+	}
+	return erasureCodedBroadcaster.BroadcastBlobErasureCoded(ctx, payload, expirationHint)
+}
+
+var _ ocr3_1types.BlobStreamFetcher = &RoundBlobBroadcastFetcher{}
+
+func (r *RoundBlobBroadcastFetcher) FetchBlobReader(ctx context.Context, handle ocr3_1types.BlobHandle) (io.ReadCloser, error) {
+	streamFetcher, ok := r.blobBroadcastFetcher.(ocr3_1types.BlobStreamFetcher)
+	if !ok {
+		return nil, fmt.Errorf("streaming blob fetches are not supported")
+	}
+	if err := r.checkNotExpired(handle); err != nil {
+		return nil, err
+	}
+	return streamFetcher.FetchBlobReader(ctx, handle)
+}
+
+// StateTransitionBlobFetcher is the blob fetcher passed to StateTransition. In
+// addition to what RoundBlobBroadcastFetcher does, it allows the plugin to
+// release blobs, and enforces blob releases and expiry keys on fetches.
+// Releases are recorded in the KeyValueState, so that they take effect on all
+// oracles alike once the seqNr is committed.
+type StateTransitionBlobFetcher struct {
+	*RoundBlobBroadcastFetcher
+	config   ocr3_1config.SharedConfig
+	releases *stateTransitionBlobReleases
+}
+
+func NewStateTransitionBlobFetcher(
+	seqNr uint64,
+	blobBroadcastFetcher ocr3_1types.BlobBroadcastFetcher,
+	config ocr3_1config.SharedConfig,
+	releases *stateTransitionBlobReleases,
+) *StateTransitionBlobFetcher {
+	return &StateTransitionBlobFetcher{
+		NewRoundBlobBroadcastFetcher(seqNr, blobBroadcastFetcher),
+		config,
+		releases,
+	}
+}
+
+var _ ocr3_1types.BlobReleaser = &StateTransitionBlobFetcher{}
+
+func (s *StateTransitionBlobFetcher) verifiedCertAndDigest(handle ocr3_1types.BlobHandle) (*LightCertifiedBlob, BlobDigest, error) {
+	blobHandleSumType := blobtypes.ExtractBlobHandleSumType(handle)
+	switch cert := blobHandleSumType.(type) {
+	case *LightCertifiedBlob:
+		if cert == nil {
+			return nil, BlobDigest{}, fmt.Errorf("zero value blob handle provided")
+		}
+		if err := cert.Verify(s.config.ConfigDigest, s.config.OracleIdentities, byzquorum.Size(s.config.N(), s.config.F), s.config.N()); err != nil {
+			return nil, BlobDigest{}, fmt.Errorf("invalid blob handle: %w", err)
+		}
+		blobDigest := blobtypes.MakeBlobDigest(
+			s.config.ConfigDigest,
+			cert.ChunkDigestsRoot,
+			cert.PayloadLength,
+			cert.ExpirySeqNr,
+			cert.ExpiryKey,
+			cert.Submitter,
+			cert.ErasureCoded,
+		)
+		return cert, blobDigest, nil
+	case nil:
+		return nil, BlobDigest{}, fmt.Errorf("zero value blob handle provided")
+	default:
+		panic(fmt.Sprintf("unexpected blob handle type %T", cert))
+	}
+}
+
+// beforeFetch checks that the blob may be fetched as of the KeyValueState and
+// links it to its expiry key. Its outcome, and what it writes, only depend on
+// the KeyValueState, never on whether the fetch succeeds locally.
+func (s *StateTransitionBlobFetcher) beforeFetch(handle ocr3_1types.BlobHandle) error {
+	cert, blobDigest, err := s.verifiedCertAndDigest(handle)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNotExpired(handle); err != nil {
+		return err
+	}
+	if err := s.releases.checkFetch(cert, blobDigest); err != nil {
+		return err
+	}
+	return s.releases.link(cert, blobDigest)
+}
+
+func (s *StateTransitionBlobFetcher) FetchBlob(ctx context.Context, handle ocr3_1types.BlobHandle) ([]byte, error) {
+	if err := s.beforeFetch(handle); err != nil {
+		return nil, err
+	}
+	return s.RoundBlobBroadcastFetcher.FetchBlob(ctx, handle)
+}
+
+func (s *StateTransitionBlobFetcher) FetchBlobReader(ctx context.Context, handle ocr3_1types.BlobHandle) (io.ReadCloser, error) {
+	if err := s.beforeFetch(handle); err != nil {
+		return nil, err
+	}
+	return s.RoundBlobBroadcastFetcher.FetchBlobReader(ctx, handle)
+}
+
+func (s *StateTransitionBlobFetcher) ReleaseBlob(handle ocr3_1types.BlobHandle) error {
+	cert, blobDigest, err := s.verifiedCertAndDigest(handle)
+	if err != nil {
+		return err
+	}
+	return s.releases.release(ReleasedBlob{cert.ExpirySeqNr, blobDigest})
+}
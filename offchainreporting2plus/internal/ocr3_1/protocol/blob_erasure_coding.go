@@ -0,0 +1,281 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/smartcontractkit/libocr/internal/mt"
+	"github.com/smartcontractkit/libocr/internal/reedsolomon"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/blobtypes"
+)
+
+// Erasure coded blobs
+//
+// The payload of an erasure coded blob is split into F+1 data shards of equal
+// length (the last one zero padded), which are extended to N shards with a
+// Reed-Solomon code. Shard i is the fragment of oracle i. Each shard is split
+// into chunks just like the payload of a regular blob, and the chunks of all
+// shards (in shard order) make up the chunks of the blob. The chunk digests
+// root thus commits to all fragments.
+//
+// The submitter offers each oracle only its own fragment, and an oracle signs
+// the availability of the blob once it holds its fragment. A certificate thus
+// implies that at least F+1 honest oracles hold their fragments, which suffices
+// to reconstruct the payload. This reduces the upstream bandwidth of the
+// submitter from N times to roughly N/(F+1) times the payload length.
+//
+// A malicious submitter could commit to fragments that are not a codeword.
+// After reconstruction, we therefore re-encode the payload and check the chunk
+// digests root. If the check fails for one set of F+1 fragments, it fails for
+// all, so all honest oracles agree that the blob is invalid.
+
+var errBlobInconsistentlyErasureCoded = errors.New("blob fragments are inconsistently erasure coded")
+
+type blobErasureCodingLayout struct {
+	payloadLength  uint64
+	dataShards     int
+	totalShards    int
+	shardLength    uint64
+	chunksPerShard uint64
+	chunkSize      int
+}
+
+func makeBlobErasureCodingLayout(payloadLength uint64, config ocr3_1config.PublicConfig) *blobErasureCodingLayout {
+	dataShards := config.F + 1
+	shardLength := (payloadLength + uint64(dataShards) - 1) / uint64(dataShards)
+	cfgBlobChunkSize := config.GetBlobChunkBytes()
+	return &blobErasureCodingLayout{
+		payloadLength,
+		dataShards,
+		config.N(),
+		shardLength,
+		numChunks(shardLength, cfgBlobChunkSize),
+		cfgBlobChunkSize,
+	}
+}
+
+// blobNumChunks returns the number of chunks of a blob, whether erasure coded
+// or not.
+func blobNumChunks(payloadLength uint64, erasureCoded bool, config ocr3_1config.SharedConfig) uint64 {
+	if erasureCoded {
+		return makeBlobErasureCodingLayout(payloadLength, config.PublicConfig).numChunks()
+	}
+	return numChunks(payloadLength, config.GetBlobChunkBytes())
+}
+
+func (l *blobErasureCodingLayout) numChunks() uint64 {
+	return uint64(l.totalShards) * l.chunksPerShard
+}
+
+// numDataChunks returns the number of chunks that make up the data shards.
+// The payload is the concatenation of these chunks, truncated to the payload
+// length.
+func (l *blobErasureCodingLayout) numDataChunks() uint64 {
+	return uint64(l.dataShards) * l.chunksPerShard
+}
+
+func (l *blobErasureCodingLayout) shardOfChunk(chunkIndex uint64) int {
+	return int(chunkIndex / l.chunksPerShard)
+}
+
+// shardChunks returns the range [lo, hi) of chunk indices of a shard.
+func (l *blobErasureCodingLayout) shardChunks(shard int) (uint64, uint64) {
+	return uint64(shard) * l.chunksPerShard, uint64(shard+1) * l.chunksPerShard
+}
+
+func (l *blobErasureCodingLayout) chunkBytes(chunkIndex uint64) (uint64, bool) {
+	if !(chunkIndex < l.numChunks()) {
+		return 0, false
+	}
+	return chunkBytes(l.shardLength, chunkIndex%l.chunksPerShard, l.chunkSize)
+}
+
+func (l *blobErasureCodingLayout) haveShard(shard int, chunkHaves []bool) bool {
+	lo, hi := l.shardChunks(shard)
+	return !slices.Contains(chunkHaves[lo:hi], false)
+}
+
+func (l *blobErasureCodingLayout) completeShards(chunkHaves []bool) []int {
+	var shards []int
+	for shard := 0; shard < l.totalShards; shard++ {
+		if l.haveShard(shard, chunkHaves) {
+			shards = append(shards, shard)
+		}
+	}
+	return shards
+}
+
+func (l *blobErasureCodingLayout) code() (*reedsolomon.Code, error) {
+	return reedsolomon.New(l.dataShards, l.totalShards)
+}
+
+// haveAllChunkDigests reports whether all chunk digests are known. Unknown
+// chunk digests are zero.
+func haveAllChunkDigests(chunkDigests []BlobChunkDigest) bool {
+	return !slices.Contains(chunkDigests, BlobChunkDigest{})
+}
+
+// haveErasureCodedPayload reports whether we hold the data shards of an
+// erasure coded blob, which make up its payload, and have verified all chunk
+// digests against the chunk digests root.
+func haveErasureCodedPayload(layout *blobErasureCodingLayout, chunkHaves []bool, chunkDigests []BlobChunkDigest, chunkDigestsRoot mt.Digest) bool {
+	return !slices.Contains(chunkHaves[:layout.numDataChunks()], false) &&
+		haveAllChunkDigests(chunkDigests) &&
+		blobtypes.MakeBlobChunkDigestsRoot(chunkDigests) == chunkDigestsRoot
+}
+
+// erasureCodeBlobPayload returns the chunks of all shards of the payload.
+func erasureCodeBlobPayload(payload []byte, layout *blobErasureCodingLayout) ([][]byte, error) {
+	code, err := layout.code()
+	if err != nil {
+		return nil, err
+	}
+	shards := make([][]byte, layout.totalShards)
+	for i := range shards {
+		shards[i] = make([]byte, layout.shardLength)
+	}
+	for i := 0; i < layout.dataShards; i++ {
+		lo := min(uint64(i)*layout.shardLength, uint64(len(payload)))
+		hi := min(lo+layout.shardLength, uint64(len(payload)))
+		copy(shards[i], payload[lo:hi])
+	}
+	if err := code.Encode(shards); err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	chunks := make([][]byte, 0, layout.numChunks())
+	for _, shard := range shards {
+		for _, chunk := range chunkPayload(shard, layout.chunkSize) {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+// reconstructErasureCodedBlob reconstructs the data shards of an erasure coded
+// blob from any F+1 complete shards in local storage, and verifies that all
+// shards are consistent with the chunk digests root. It then writes the
+// missing data chunks and returns the updated chunk haves and digests. We
+// proceed one chunk position at a time to bound memory usage. If the
+// fragments are inconsistent, we return an error wrapping
+// errBlobInconsistentlyErasureCoded and write nothing.
+func reconstructErasureCodedBlob(
+	kv KeyValueDatabase,
+	blobDigest BlobDigest,
+	layout *blobErasureCodingLayout,
+	chunkDigestsRoot mt.Digest,
+) ([]bool, []BlobChunkDigest, error) {
+	code, err := layout.code()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := kv.NewUnserializedReadWriteTransactionUnchecked()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create read/write transaction: %w", err)
+	}
+	defer tx.Discard()
+
+	blobMeta, err := tx.ReadBlobMeta(blobDigest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read blob meta: %w", err)
+	}
+	if blobMeta == nil {
+		return nil, nil, fmt.Errorf("blob meta is missing, blob might have been reaped")
+	}
+	if !(blobMeta.ErasureCoded && uint64(len(blobMeta.ChunkHaves)) == layout.numChunks()) {
+		return nil, nil, fmt.Errorf("blob meta does not match erasure coding layout")
+	}
+
+	// completeShards is sorted, so we prefer data shards, which need no
+	// reconstruction
+	completeShards := layout.completeShards(blobMeta.ChunkHaves)
+	if len(completeShards) < layout.dataShards {
+		return nil, nil, fmt.Errorf("too few complete shards, have %d, need %d", len(completeShards), layout.dataShards)
+	}
+	sourceShards := completeShards[:layout.dataShards]
+
+	chunkHaves := slices.Clone(blobMeta.ChunkHaves)
+	chunkDigests := make([]BlobChunkDigest, layout.numChunks())
+	type dataChunk struct {
+		chunkIndex uint64
+		chunk      []byte
+	}
+	var reconstructedDataChunks []dataChunk
+
+	for position := uint64(0); position < layout.chunksPerShard; position++ {
+		shards := make([][]byte, layout.totalShards)
+		for _, shard := range sourceShards {
+			chunkIndex := uint64(shard)*layout.chunksPerShard + position
+			chunk, err := tx.ReadBlobChunk(blobDigest, chunkIndex)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read blob chunk %d: %w", chunkIndex, err)
+			}
+			if chunk == nil {
+				return nil, nil, fmt.Errorf("blob chunk %d is missing", chunkIndex)
+			}
+			shards[shard] = chunk
+		}
+		present := slices.Clone(shards)
+		if err := code.ReconstructData(shards); err != nil {
+			return nil, nil, fmt.Errorf("failed to reconstruct data at chunk position %d: %w", position, err)
+		}
+		for i := layout.dataShards; i < layout.totalShards; i++ {
+			shards[i] = nil
+		}
+		if err := code.Encode(shards); err != nil {
+			return nil, nil, fmt.Errorf("failed to re-encode data at chunk position %d: %w", position, err)
+		}
+		for shard, chunk := range shards {
+			chunkIndex := uint64(shard)*layout.chunksPerShard + position
+			chunkDigests[chunkIndex] = blobtypes.MakeBlobChunkDigest(chunk)
+			if shard < layout.dataShards && present[shard] == nil {
+				reconstructedDataChunks = append(reconstructedDataChunks, dataChunk{chunkIndex, chunk})
+			}
+		}
+	}
+
+	if blobtypes.MakeBlobChunkDigestsRoot(chunkDigests) != chunkDigestsRoot {
+		return nil, nil, errBlobInconsistentlyErasureCoded
+	}
+
+	for _, dc := range reconstructedDataChunks {
+		if err := tx.WriteBlobChunk(blobDigest, dc.chunkIndex, dc.chunk); err != nil {
+			return nil, nil, fmt.Errorf("failed to write blob chunk %d: %w", dc.chunkIndex, err)
+		}
+		chunkHaves[dc.chunkIndex] = true
+	}
+	blobMeta.ChunkHaves = chunkHaves
+	blobMeta.ChunkDigests = chunkDigests
+	if err := tx.WriteBlobMeta(blobDigest, *blobMeta); err != nil {
+		return nil, nil, fmt.Errorf("failed to write blob meta: %w", err)
+	}
+	if err := tx.WriteStaleBlobIndex(staleBlob(blobMeta.ExpirySeqNr, blobDigest)); err != nil {
+		return nil, nil, fmt.Errorf("failed to write stale blob index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return chunkHaves, chunkDigests, nil
+}
+
+// readErasureCodedBlobPayload reads the payload of an erasure coded blob from
+// its data chunks.
+func readErasureCodedBlobPayload(tx KeyValueDatabaseReadTransaction, blobDigest BlobDigest, layout *blobErasureCodingLayout) ([]byte, error) {
+	payload := make([]byte, 0, layout.payloadLength)
+	for chunkIndex := uint64(0); chunkIndex < layout.numDataChunks() && uint64(len(payload)) < layout.payloadLength; chunkIndex++ {
+		chunk, err := tx.ReadBlobChunk(blobDigest, chunkIndex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blob chunk %d: %w", chunkIndex, err)
+		}
+		if chunk == nil {
+			return nil, fmt.Errorf("blob chunk %d is missing", chunkIndex)
+		}
+		payload = append(payload, chunk[:min(uint64(len(chunk)), layout.payloadLength-uint64(len(payload)))]...)
+	}
+	if uint64(len(payload)) != layout.payloadLength {
+		return nil, fmt.Errorf("payload length mismatch: read %d != expected %d", len(payload), layout.payloadLength)
+	}
+	return payload, nil
+}
@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"iter"
 	"slices"
@@ -33,6 +34,7 @@ func RunBlobExchange[RI any](
 	config ocr3_1config.SharedConfig,
 	kv KeyValueDatabase,
 	id commontypes.OracleID,
+	latencyHints OracleLatencyHints,
 	limits ocr3_1types.ReportingPluginLimits,
 	localConfig types.LocalConfig,
 	logger loghelper.LoggerWithContext,
@@ -49,7 +51,7 @@ func RunBlobExchange[RI any](
 		chOutcomeGenerationToBlobExchange,
 		chBlobBroadcastRequest, chBlobFetchRequest,
 		config, kv,
-		id, limits, localConfig, logger, metricsRegisterer, netSender, offchainKeyring,
+		id, latencyHints, limits, localConfig, logger, metricsRegisterer, netSender, offchainKeyring,
 		telemetrySender,
 		broadcastGraceTimeoutScheduler,
 	)
@@ -68,6 +70,7 @@ func makeBlobExchangeState[RI any](
 	config ocr3_1config.SharedConfig,
 	kv KeyValueDatabase,
 	id commontypes.OracleID,
+	latencyHints OracleLatencyHints,
 	limits ocr3_1types.ReportingPluginLimits,
 	localConfig types.LocalConfig,
 	logger loghelper.LoggerWithContext,
@@ -120,6 +123,7 @@ func makeBlobExchangeState[RI any](
 		bex.trySendBlobOffer,
 		bex.getPendingBlobOffers,
 		bex.getBlobOfferSeeders,
+		latencyFn(latencyHints),
 	)
 	bex.offerRequesterGadget = offerRequesterGadget
 
@@ -129,6 +133,7 @@ func makeBlobExchangeState[RI any](
 		bex.trySendBlobChunkRequest,
 		bex.getPendingBlobChunks,
 		bex.getBlobChunkSeeders,
+		latencyFn(latencyHints),
 	)
 	bex.chunkRequesterGadget = chunkRequesterGadget
 
@@ -199,6 +204,10 @@ func (bex *blobExchangeState[RI]) getPendingBlobChunks() []blobChunkId {
 		if fetch.expired {
 			continue
 		}
+		if blob.erasureCoding != nil {
+			pending = append(pending, bex.getPendingErasureCodedBlobChunks(blobDigest, blob)...)
+			continue
+		}
 		for chunkIndex := range blob.chunkDigests {
 			if blob.chunkHaves[chunkIndex] {
 				continue
@@ -217,9 +226,89 @@ func (bex *blobExchangeState[RI]) getBlobChunkSeeders(id blobChunkId) map[common
 	if blob.fetch == nil {
 		return nil
 	}
+	if blob.erasureCoding != nil {
+		return bex.getErasureCodedBlobChunkSeeders(id, blob)
+	}
 	return blob.fetch.seeders
 }
 
+// getPendingErasureCodedBlobChunks returns the chunks of an erasure coded blob
+// that we still need: those of our own fragment while we owe the submitter an
+// offer response, and those of the targeted fragments while a local fetch is
+// waiting for the payload.
+func (bex *blobExchangeState[RI]) getPendingErasureCodedBlobChunks(blobDigest BlobDigest, blob *blob) []blobChunkId {
+	layout := blob.erasureCoding
+	fetch := blob.fetch
+
+	var shards []int
+	if blob.weOweOfferResponse() {
+		shards = append(shards, int(bex.id))
+	}
+	if fetch.waiters > 0 && !fetch.invalid && !blob.haveAllChunks() {
+		for _, shard := range bex.targetErasureCodedBlobShards(blob) {
+			if !slices.Contains(shards, shard) {
+				shards = append(shards, shard)
+			}
+		}
+	}
+
+	var pending []blobChunkId
+	for _, shard := range shards {
+		lo, hi := layout.shardChunks(shard)
+		for chunkIndex := lo; chunkIndex < hi; chunkIndex++ {
+			if blob.chunkHaves[chunkIndex] {
+				continue
+			}
+			pending = append(pending, blobChunkId{blobDigest, chunkIndex})
+		}
+	}
+	return pending
+}
+
+// targetErasureCodedBlobShards returns the shards we fetch to reconstruct an
+// erasure coded blob. We target F+1 shards, preferring those we already hold
+// and then those whose holders signed the cert, starting from our own to
+// spread the load. For every DeltaBlobChunkResponseTimeout that passes since
+// the fetch was requested, we target one more shard, so that unresponsive
+// holders cannot stall us.
+func (bex *blobExchangeState[RI]) targetErasureCodedBlobShards(blob *blob) []int {
+	layout := blob.erasureCoding
+	fetch := blob.fetch
+
+	extraShards := 0
+	if timeout := bex.config.GetDeltaBlobChunkResponseTimeout(); timeout > 0 && !fetch.timeWhenRequested.IsZero() {
+		extraShards = int(time.Since(fetch.timeWhenRequested) / timeout)
+	}
+	targetShards := min(layout.dataShards+extraShards, layout.totalShards)
+
+	var completeShards, signedShards, otherShards []int
+	for i := 0; i < layout.totalShards; i++ {
+		shard := (int(bex.id) + i) % layout.totalShards
+		if layout.haveShard(shard, blob.chunkHaves) {
+			completeShards = append(completeShards, shard)
+		} else if _, ok := fetch.seeders[commontypes.OracleID(shard)]; ok {
+			signedShards = append(signedShards, shard)
+		} else {
+			otherShards = append(otherShards, shard)
+		}
+	}
+	return slices.Concat(completeShards, signedShards, otherShards)[:targetShards]
+}
+
+// getErasureCodedBlobChunkSeeders returns the holder of the chunk's fragment
+// if it signed the cert, and the submitter otherwise.
+func (bex *blobExchangeState[RI]) getErasureCodedBlobChunkSeeders(id blobChunkId, blob *blob) map[commontypes.OracleID]struct{} {
+	holder := commontypes.OracleID(blob.erasureCoding.shardOfChunk(id.chunkIndex))
+	if _, ok := blob.fetch.seeders[holder]; ok && holder != bex.id {
+		return map[commontypes.OracleID]struct{}{
+			holder: {},
+		}
+	}
+	return map[commontypes.OracleID]struct{}{
+		blob.submitter: {},
+	}
+}
+
 func (bex *blobExchangeState[RI]) trySendBlobOffer(item blobOfferItem, seeder commontypes.OracleID) (*requestergadget.RequestInfo, bool) {
 	blob, ok := bex.blobs[item.blobDigest]
 	if !ok {
@@ -240,10 +329,16 @@ func (bex *blobExchangeState[RI]) trySendBlobOffer(item blobOfferItem, seeder co
 		"chunkDigestsRoot": blob.chunkDigestsRoot,
 		"payloadLength":    blob.payloadLength,
 		"expirySeqNr":      blob.expirySeqNr,
+		"erasureCoded":     blob.erasureCoding != nil,
 		"timeout":          timeout,
 		"to":               seeder,
 	})
 
+	var chunkDigests []BlobChunkDigest
+	if blob.erasureCoding != nil {
+		chunkDigests = blob.chunkDigests
+	}
+
 	requestInfo := &types.RequestInfo{
 		time.Now().Add(timeout),
 	}
@@ -253,6 +348,9 @@ func (bex *blobExchangeState[RI]) trySendBlobOffer(item blobOfferItem, seeder co
 		blob.chunkDigestsRoot,
 		blob.payloadLength,
 		blob.expirySeqNr,
+		blob.expiryKey,
+		blob.erasureCoding != nil,
+		chunkDigests,
 	}, seeder)
 
 	return requestInfo, true
@@ -298,10 +396,16 @@ const (
 )
 
 type blobBroadcastRequest struct {
-	payload     []byte
-	expirySeqNr uint64
-	chResponse  chan blobBroadcastResponse
-	chDone      <-chan struct{}
+	payload []byte
+	// If chunkDigests is non-nil, the payload has already been written to
+	// local storage by the endpoint, and payload is nil.
+	chunkDigests  []BlobChunkDigest
+	payloadLength uint64
+	expirySeqNr   uint64
+	expiryKey     []byte
+	erasureCoded  bool
+	chResponse    chan blobBroadcastResponse
+	chDone        <-chan struct{}
 }
 
 func (req *blobBroadcastRequest) respond(ctx context.Context, resp blobBroadcastResponse) {
@@ -318,9 +422,12 @@ type blobBroadcastResponse struct {
 }
 
 type blobFetchRequest struct {
-	cert       LightCertifiedBlob
-	chResponse chan blobFetchResponse
-	chDone     <-chan struct{}
+	cert LightCertifiedBlob
+	// If availableOnly is set, we respond once the payload is available in
+	// local storage without reading it.
+	availableOnly bool
+	chResponse    chan blobFetchResponse
+	chDone        <-chan struct{}
 }
 
 func (req *blobFetchRequest) respond(ctx context.Context, resp blobFetchResponse) {
@@ -412,6 +519,12 @@ type blobFetchMeta struct {
 	exchange *blobExchangeMeta
 	seeders  map[commontypes.OracleID]struct{}
 	expired  bool
+	// invalid is set if the fragments of an erasure coded blob turned out to
+	// be inconsistent.
+	invalid bool
+	// timeWhenRequested is the time when the first local fetch request for
+	// the blob arrived, zero if there is none.
+	timeWhenRequested time.Time
 }
 
 func (bifm *blobFetchMeta) weServiced() {
@@ -497,17 +610,32 @@ type blob struct {
 
 	payloadLength uint64
 	expirySeqNr   uint64
+	expiryKey     []byte
 	submitter     commontypes.OracleID
+	// erasureCoding is nil unless the blob is erasure coded.
+	erasureCoding *blobErasureCodingLayout
 }
 
 func (b *blob) weOweOfferResponse() bool {
 	return b.fetch != nil && b.fetch.exchange != nil && !b.fetch.exchange.weSentOfferResponse
 }
 
+// haveAllChunks reports whether the payload is available in local storage. For
+// erasure coded blobs, the chunks of the data shards suffice.
 func (b *blob) haveAllChunks() bool {
+	if b.erasureCoding != nil {
+		return haveErasureCodedPayload(b.erasureCoding, b.chunkHaves, b.chunkDigests, b.chunkDigestsRoot)
+	}
 	return haveAllChunks(b.chunkHaves, b.chunkDigests, b.chunkDigestsRoot)
 }
 
+func (b *blob) chunkBytes(chunkIndex uint64, cfgBlobChunkSize int) (uint64, bool) {
+	if b.erasureCoding != nil {
+		return b.erasureCoding.chunkBytes(chunkIndex)
+	}
+	return chunkBytes(b.payloadLength, chunkIndex, cfgBlobChunkSize)
+}
+
 func haveAllChunks(chunkHaves []bool, chunkDigests []BlobChunkDigest, chunkDigestsRoot mt.Digest) bool {
 	return !slices.Contains(chunkHaves, false) && blobtypes.MakeBlobChunkDigestsRoot(chunkDigests) == chunkDigestsRoot
 }
@@ -593,11 +721,23 @@ func (bex *blobExchangeState[RI]) eventTStopExpiredBlobBroadcastOrFetch() {
 
 	for blobDigest, blob := range bex.blobs {
 		if !hasBlobExpired(blob.expirySeqNr, highestCommittedSeqNr) {
-			continue
+			// Blobs released by a committed StateTransition can no longer
+			// be fetched in StateTransitions either.
+			released, err := isBlobReleased(tx, ReleasedBlob{blob.expirySeqNr, blobDigest})
+			if err != nil {
+				bex.logger.Error("failed to read blob release for eventTStopExpiredBlobBroadcastOrFetch", commontypes.LogFields{
+					"blobDigest": blobDigest,
+					"error":      err,
+				})
+				continue
+			}
+			if !released {
+				continue
+			}
 		}
 
 		broadcastPending := blob.broadcast != nil && blob.broadcast.phase == blobBroadcastPhaseOffering
-		fetchPending := blob.fetch != nil && !blob.fetch.expired && !blob.haveAllChunks()
+		fetchPending := blob.fetch != nil && !blob.fetch.expired && !blob.fetch.invalid && !blob.haveAllChunks()
 
 		if !(broadcastPending || fetchPending) {
 			continue
@@ -636,6 +776,15 @@ func (bex *blobExchangeState[RI]) eventTStopExpiredBlobBroadcastOrFetch() {
 	}
 }
 
+func (bex *blobExchangeState[RI]) isBlobReleased(releasedBlob ReleasedBlob) (bool, error) {
+	tx, err := bex.kv.NewReadTransactionUnchecked()
+	if err != nil {
+		return false, fmt.Errorf("failed to create read transaction: %w", err)
+	}
+	defer tx.Discard()
+	return isBlobReleased(tx, releasedBlob)
+}
+
 func (bex *blobExchangeState[RI]) allowBlobOfferBasedOnOwedOfferResponsesBudget(sender commontypes.OracleID) error {
 	countOwedOfferResponses := 0
 	for _, blob := range bex.blobs {
@@ -721,10 +870,32 @@ func (bex *blobExchangeState[RI]) messageBlobOffer(msg MessageBlobOffer[RI], sen
 		msg.ChunkDigestsRoot,
 		msg.PayloadLength,
 		msg.ExpirySeqNr,
+		msg.ExpiryKey,
 		submitter,
+		msg.ErasureCoded,
 	)
 
-	chunkDigests, chunkHaves, err := bex.loadChunkDigestsAndHaves(blobDigest, msg.PayloadLength)
+	var erasureCoding *blobErasureCodingLayout
+	if msg.ErasureCoded {
+		erasureCoding = makeBlobErasureCodingLayout(msg.PayloadLength, bex.config.PublicConfig)
+		if !(uint64(len(msg.ChunkDigests)) == erasureCoding.numChunks() &&
+			blobtypes.MakeBlobChunkDigestsRoot(msg.ChunkDigests) == msg.ChunkDigestsRoot) {
+			bex.logger.Warn("dropping MessageBlobOffer, chunk digests do not match chunk digests root", commontypes.LogFields{
+				"blobDigest":      blobDigest,
+				"sender":          sender,
+				"chunkDigestsLen": len(msg.ChunkDigests),
+			})
+			return
+		}
+	} else if len(msg.ChunkDigests) != 0 {
+		bex.logger.Warn("dropping MessageBlobOffer, unexpected chunk digests for blob that is not erasure coded", commontypes.LogFields{
+			"blobDigest": blobDigest,
+			"sender":     sender,
+		})
+		return
+	}
+
+	chunkDigests, chunkHaves, err := bex.loadChunkDigestsAndHaves(blobDigest, msg.PayloadLength, msg.ErasureCoded)
 	if err != nil {
 		bex.logger.Warn("dropping MessageBlobOffer, failed to check if we already know of it", commontypes.LogFields{
 			"blobDigest": blobDigest,
@@ -734,8 +905,31 @@ func (bex *blobExchangeState[RI]) messageBlobOffer(msg MessageBlobOffer[RI], sen
 		return
 	}
 
-	// check if we maybe already have this blob in full
-	if haveAllChunks(chunkHaves, chunkDigests, msg.ChunkDigestsRoot) {
+	if erasureCoding != nil {
+		// check if we maybe already have our fragment in full
+		if erasureCoding.haveShard(int(bex.id), chunkHaves) {
+			if !haveAllChunkDigests(chunkDigests) {
+				if err := bex.writeBlobChunkDigests(blobDigest, msg.ChunkDigests); err != nil {
+					bex.logger.Error("failed to write blob chunk digests for MessageBlobOffer", commontypes.LogFields{
+						"blobDigest": blobDigest,
+						"sender":     sender,
+						"error":      err,
+					})
+					return
+				}
+			}
+			bex.logger.Debug("received MessageBlobOffer for which we already have our fragment", commontypes.LogFields{
+				"blobDigest": blobDigest,
+				"sender":     sender,
+			})
+			bex.sendBlobOfferResponseAccepting(blobDigest, submitter, msg.RequestHandle)
+			return
+		}
+		// the offer's chunk digests are verified, we persist them along with
+		// the first chunk we receive
+		chunkDigests = slices.Clone(msg.ChunkDigests)
+	} else if haveAllChunks(chunkHaves, chunkDigests, msg.ChunkDigestsRoot) {
+		// we already have this blob in full
 		bex.logger.Debug("received MessageBlobOffer for which we already have the payload", commontypes.LogFields{
 			"blobDigest": blobDigest,
 			"sender":     sender,
@@ -794,6 +988,28 @@ func (bex *blobExchangeState[RI]) messageBlobOffer(msg MessageBlobOffer[RI], sen
 		return
 	}
 
+	// Reject if blob has already been released
+	released, err := bex.isBlobReleased(ReleasedBlob{msg.ExpirySeqNr, blobDigest})
+	if err != nil {
+		bex.logger.Error("failed to read blob release for MessageBlobOffer", commontypes.LogFields{
+			"blobDigest": blobDigest,
+			"error":      err,
+		})
+		return
+	}
+	if released {
+		offerLogTaper.Trigger(func(consecutiveRejectedOffers uint64) {
+			bex.logger.Warn("received MessageBlobOffer for already released blob, rejecting", commontypes.LogFields{
+				"blobDigest":                blobDigest,
+				"submitter":                 submitter,
+				"expirySeqNr":               msg.ExpirySeqNr,
+				"consecutiveRejectedOffers": consecutiveRejectedOffers,
+			})
+		})
+		bex.sendBlobOfferResponseRejecting(blobDigest, submitter, msg.RequestHandle)
+		return
+	}
+
 	if err := bex.allowBlobOffer(msg, submitter); err != nil {
 
 		offerLogTaper.Trigger(func(consecutiveRejectedOffers uint64) {
@@ -821,13 +1037,14 @@ func (bex *blobExchangeState[RI]) messageBlobOffer(msg MessageBlobOffer[RI], sen
 		"chunkDigestsRoot": msg.ChunkDigestsRoot,
 		"payloadLength":    msg.PayloadLength,
 		"expirySeqNr":      msg.ExpirySeqNr,
+		"erasureCoded":     msg.ErasureCoded,
 	})
 
 	seeders := map[commontypes.OracleID]struct{}{
 		submitter: {},
 	}
 
-	if err := bex.createOrUpdateBlobMetaAndQuotaStats(blobDigest, msg.PayloadLength, msg.ExpirySeqNr, submitter); err != nil {
+	if err := bex.createOrUpdateBlobMetaAndQuotaStats(blobDigest, msg.PayloadLength, msg.ExpirySeqNr, submitter, msg.ErasureCoded); err != nil {
 		bex.logger.Error("failed to create or update blob meta and quota stats for MessageBlobOffer", commontypes.LogFields{
 			"blobDigest": blobDigest,
 			"submitter":  submitter,
@@ -849,6 +1066,8 @@ func (bex *blobExchangeState[RI]) messageBlobOffer(msg MessageBlobOffer[RI], sen
 			},
 			seeders,
 			false,
+			false,
+			time.Time{},
 		},
 
 		msg.ChunkDigestsRoot,
@@ -856,7 +1075,9 @@ func (bex *blobExchangeState[RI]) messageBlobOffer(msg MessageBlobOffer[RI], sen
 		chunkHaves,
 		msg.PayloadLength,
 		msg.ExpirySeqNr,
+		msg.ExpiryKey,
 		submitter,
+		erasureCoding,
 	}
 	bex.chunkRequesterGadget.PleaseRecheckPendingItems()
 }
@@ -1037,7 +1258,9 @@ func (bex *blobExchangeState[RI]) eventBlobBroadcastGraceTimeout(ev EventBlobBro
 		blob.chunkDigestsRoot,
 		blob.payloadLength,
 		blob.expirySeqNr,
+		blob.expiryKey,
 		blob.submitter,
+		blob.erasureCoding != nil,
 		abass,
 	}
 
@@ -1103,6 +1326,14 @@ func (bex *blobExchangeState[RI]) readBlobPayload(blobDigest BlobDigest) ([]byte
 	}
 	defer tx.Discard()
 
+	meta, err := tx.ReadBlobMeta(blobDigest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob meta: %w", err)
+	}
+	if meta != nil && meta.ErasureCoded {
+		return readErasureCodedBlobPayload(tx, blobDigest, makeBlobErasureCodingLayout(meta.PayloadLength, bex.config.PublicConfig))
+	}
+
 	payload, err := tx.ReadBlobPayload(blobDigest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read blob payload: %w", err)
@@ -1146,7 +1377,18 @@ func (bex *blobExchangeState[RI]) messageBlobChunkRequest(msg MessageBlobChunkRe
 		return
 	}
 
-	if slices.Contains(meta.ChunkHaves, false) {
+	if meta.ErasureCoded {
+		// We serve the chunks we have, but need all chunk digests for the
+		// proof. A missing chunk results in a go away response below.
+		if !haveAllChunkDigests(meta.ChunkDigests) {
+			bex.logger.Debug("dropping MessageBlobChunkRequest, we do not have all chunk digests", commontypes.LogFields{
+				"blobDigest": msg.BlobDigest,
+				"sender":     sender,
+				"chunkIndex": chunkIndex,
+			})
+			return
+		}
+	} else if slices.Contains(meta.ChunkHaves, false) {
 		bex.logger.Debug("dropping MessageBlobChunkRequest, we do not have all chunks", commontypes.LogFields{
 			"blobDigest": msg.BlobDigest,
 			"sender":     sender,
@@ -1242,6 +1484,13 @@ func (bex *blobExchangeState[RI]) messageBlobChunkResponse(msg MessageBlobChunkR
 		})
 		return
 	}
+	if fetch.invalid {
+		bex.logger.Debug("dropping MessageBlobChunkResponse for inconsistently erasure coded blob", commontypes.LogFields{
+			"blobDigest": msg.BlobDigest,
+			"sender":     sender,
+		})
+		return
+	}
 
 	chunkIndex := msg.ChunkIndex
 
@@ -1256,7 +1505,7 @@ func (bex *blobExchangeState[RI]) messageBlobChunkResponse(msg MessageBlobChunkR
 		return
 	}
 
-	expectedChunkBytes, ok := chunkBytes(blob.payloadLength, chunkIndex, bex.config.GetBlobChunkBytes())
+	expectedChunkBytes, ok := blob.chunkBytes(chunkIndex, bex.config.GetBlobChunkBytes())
 
 	if !ok || uint64(len(msg.Chunk)) != expectedChunkBytes {
 		bex.logger.Warn("dropping MessageBlobChunkResponse, incorrectly sized chunk", commontypes.LogFields{
@@ -1334,6 +1583,7 @@ func (bex *blobExchangeState[RI]) messageBlobChunkResponse(msg MessageBlobChunkR
 		chunkDigests,
 		blob.expirySeqNr,
 		blob.submitter,
+		blob.erasureCoding != nil,
 	}
 	err = tx.WriteBlobMeta(msg.BlobDigest, blobMeta)
 	if err != nil {
@@ -1369,6 +1619,11 @@ func (bex *blobExchangeState[RI]) messageBlobChunkResponse(msg MessageBlobChunkR
 	blob.chunkHaves[chunkIndex] = true
 	blob.chunkDigests[chunkIndex] = actualChunkDigest
 
+	if blob.erasureCoding != nil {
+		bex.progressErasureCodedBlob(msg.BlobDigest, blob)
+		return
+	}
+
 	if !blob.haveAllChunks() {
 		return
 	}
@@ -1389,33 +1644,115 @@ func (bex *blobExchangeState[RI]) messageBlobChunkResponse(msg MessageBlobChunkR
 	}
 }
 
+// progressErasureCodedBlob accepts the offer for an erasure coded blob once we
+// hold our own fragment, and reconstructs the payload once we hold enough
+// fragments and a local fetch is waiting for it.
+func (bex *blobExchangeState[RI]) progressErasureCodedBlob(blobDigest BlobDigest, blob *blob) {
+	layout := blob.erasureCoding
+	fetch := blob.fetch
+
+	if blob.weOweOfferResponse() && layout.haveShard(int(bex.id), blob.chunkHaves) && haveAllChunkDigests(blob.chunkDigests) {
+		bex.logger.Debug("own blob fragment fully received", commontypes.LogFields{
+			"blobDigest":    blobDigest,
+			"payloadLength": blob.payloadLength,
+		})
+		bex.sendBlobOfferResponseAccepting(blobDigest, blob.submitter, fetch.exchange.latestOfferRequestHandle)
+		fetch.exchange.weServiced()
+	}
+
+	if fetch.waiters > 0 && !fetch.expired && !fetch.invalid && !blob.haveAllChunks() &&
+		len(layout.completeShards(blob.chunkHaves)) >= layout.dataShards {
+		chunkHaves, chunkDigests, err := reconstructErasureCodedBlob(bex.kv, blobDigest, layout, blob.chunkDigestsRoot)
+		if errors.Is(err, errBlobInconsistentlyErasureCoded) {
+			bex.logger.Warn("blob fragments are inconsistently erasure coded, submitter is faulty", commontypes.LogFields{
+				"blobDigest": blobDigest,
+				"submitter":  blob.submitter,
+			})
+			fetch.invalid = true
+			close(fetch.chNotify)
+		} else if err != nil {
+			bex.logger.Error("failed to reconstruct erasure coded blob", commontypes.LogFields{
+				"blobDigest": blobDigest,
+				"error":      err,
+			})
+		} else {
+			bex.logger.Debug("blob reconstructed from fragments", commontypes.LogFields{
+				"blobDigest":    blobDigest,
+				"payloadLength": blob.payloadLength,
+			})
+			blob.chunkHaves = chunkHaves
+			blob.chunkDigests = chunkDigests
+			close(fetch.chNotify)
+		}
+	}
+
+	if blob.prunable() {
+		bex.metrics.blobsInProgress.Dec()
+		delete(bex.blobs, blobDigest)
+	}
+}
+
 func (bex *blobExchangeState[RI]) processBlobBroadcastRequest(req blobBroadcastRequest) {
-	if len(req.payload) > bex.limits.MaxBlobPayloadBytes {
+	if req.payloadLength > uint64(bex.limits.MaxBlobPayloadBytes) {
 		req.respond(bex.ctx, blobBroadcastResponse{
 			LightCertifiedBlob{},
 			fmt.Errorf("blob payload length %d exceeds maximum allowed length %d",
-				len(req.payload), bex.limits.MaxBlobPayloadBytes),
+				req.payloadLength, bex.limits.MaxBlobPayloadBytes),
 		})
 		return
 	}
 
 	payload := req.payload
-	payloadLength := uint64(len(payload))
+	payloadLength := req.payloadLength
 	cfgBlobChunkSize := bex.config.GetBlobChunkBytes()
 
-	chunkDigests := make([]BlobChunkDigest, 0, numChunks(payloadLength, cfgBlobChunkSize))
-	chunkHaves := make([]bool, 0, numChunks(payloadLength, cfgBlobChunkSize))
+	chunkDigests := req.chunkDigests
+	payloadWritten := chunkDigests != nil
+
+	var erasureCoding *blobErasureCodingLayout
+	if req.erasureCoded {
+		if payloadWritten {
+			req.respond(bex.ctx, blobBroadcastResponse{
+				LightCertifiedBlob{},
+				fmt.Errorf("assumption violation: erasure coded blob payload must not be written by endpoint"),
+			})
+			return
+		}
+		erasureCoding = makeBlobErasureCodingLayout(payloadLength, bex.config.PublicConfig)
+	}
+
+	var chunks [][]byte
+	if !payloadWritten {
+		if erasureCoding != nil {
+			var err error
+			chunks, err = erasureCodeBlobPayload(payload, erasureCoding)
+			if err != nil {
+				req.respond(bex.ctx, blobBroadcastResponse{
+					LightCertifiedBlob{},
+					fmt.Errorf("failed to erasure code blob payload: %w", err),
+				})
+				return
+			}
+		} else {
+			chunks = slices.Collect(slices.Chunk(payload, cfgBlobChunkSize))
+		}
 
-	for _, payloadChunk := range chunkPayload(payload, cfgBlobChunkSize) {
-		// prepare for offer
-		chunkDigest := blobtypes.MakeBlobChunkDigest(payloadChunk)
-		chunkDigests = append(chunkDigests, chunkDigest)
+		chunkDigests = make([]BlobChunkDigest, 0, len(chunks))
+		for _, chunk := range chunks {
+			// prepare for offer
+			chunkDigest := blobtypes.MakeBlobChunkDigest(chunk)
+			chunkDigests = append(chunkDigests, chunkDigest)
+		}
+	}
 
-		// for local accounting
-		chunkHaves = append(chunkHaves, true)
+	// for local accounting
+	chunkHaves := make([]bool, len(chunkDigests))
+	for i := range chunkHaves {
+		chunkHaves[i] = true
 	}
 
 	expirySeqNr := req.expirySeqNr
+	expiryKey := req.expiryKey
 	submitter := bex.id
 
 	chunkDigestsRoot := blobtypes.MakeBlobChunkDigestsRoot(chunkDigests)
@@ -1425,10 +1762,12 @@ func (bex *blobExchangeState[RI]) processBlobBroadcastRequest(req blobBroadcastR
 		chunkDigestsRoot,
 		payloadLength,
 		expirySeqNr,
+		expiryKey,
 		submitter,
+		req.erasureCoded,
 	)
 
-	bex.logger.Debug("processing BlobBroadcastRequest", commontypes.LogFields{"blobDigest": blobDigest})
+	bex.logger.Debug("processing BlobBroadcastRequest", commontypes.LogFields{"blobDigest": blobDigest, "erasureCoded": req.erasureCoded})
 
 	var chNotifyCertAvailable chan struct{}
 	if existingBlob, ok := bex.blobs[blobDigest]; ok {
@@ -1447,12 +1786,14 @@ func (bex *blobExchangeState[RI]) processBlobBroadcastRequest(req blobBroadcastR
 	} else {
 		// if we haven't written the chunks to kv, we can't serve requests
 
-		if err := bex.writeBlobBeforeBroadcast(blobDigest, payloadLength, payload, expirySeqNr); err != nil {
-			req.respond(bex.ctx, blobBroadcastResponse{
-				LightCertifiedBlob{},
-				fmt.Errorf("failed to write blob: %w", err),
-			})
-			return
+		if !payloadWritten {
+			if err := bex.writeBlobBeforeBroadcast(blobDigest, payloadLength, chunks, expirySeqNr, req.erasureCoded); err != nil {
+				req.respond(bex.ctx, blobBroadcastResponse{
+					LightCertifiedBlob{},
+					fmt.Errorf("failed to write blob: %w", err),
+				})
+				return
+			}
 		}
 
 		// write in-memory state
@@ -1475,7 +1816,9 @@ func (bex *blobExchangeState[RI]) processBlobBroadcastRequest(req blobBroadcastR
 			chunkHaves,
 			payloadLength,
 			expirySeqNr,
+			expiryKey,
 			submitter,
+			erasureCoding,
 		}
 	}
 
@@ -1559,23 +1902,27 @@ func (bex *blobExchangeState[RI]) eventBlobBroadcastRequestDone(ev EventBlobBroa
 	}
 }
 
-func (bex *blobExchangeState[RI]) writeBlobBeforeBroadcast(blobDigest BlobDigest, payloadLength uint64, payload []byte, expirySeqNr uint64) error {
-	cfgBlobChunkSize := bex.config.GetBlobChunkBytes()
+// writeBlobBeforeBroadcast writes all chunks of a blob we are about to
+// broadcast. For erasure coded blobs, these are the chunks of all fragments.
+func (bex *blobExchangeState[RI]) writeBlobBeforeBroadcast(blobDigest BlobDigest, payloadLength uint64, chunks [][]byte, expirySeqNr uint64, erasureCoded bool) error {
 	tx, err := bex.kv.NewUnserializedReadWriteTransactionUnchecked()
 	if err != nil {
 		return fmt.Errorf("failed to create read/write transaction: %w", err)
 	}
 	defer tx.Discard()
 
-	numChunks := numChunks(payloadLength, cfgBlobChunkSize)
+	numChunks := blobNumChunks(payloadLength, erasureCoded, bex.config)
+	if uint64(len(chunks)) != numChunks {
+		return fmt.Errorf("assumption violation: have %d chunks, expected %d", len(chunks), numChunks)
+	}
 
 	chunkHaves := make([]bool, numChunks)
 	chunkDigests := make([]BlobChunkDigest, numChunks)
-	for chunkIdx, payloadChunk := range chunkPayload(payload, cfgBlobChunkSize) {
-		if err := tx.WriteBlobChunk(blobDigest, chunkIdx, payloadChunk); err != nil {
+	for chunkIdx, chunk := range chunks {
+		if err := tx.WriteBlobChunk(blobDigest, uint64(chunkIdx), chunk); err != nil {
 			return fmt.Errorf("failed to write local blob chunk: %w", err)
 		}
-		chunkDigests[chunkIdx] = blobtypes.MakeBlobChunkDigest(payloadChunk)
+		chunkDigests[chunkIdx] = blobtypes.MakeBlobChunkDigest(chunk)
 		chunkHaves[chunkIdx] = true // mark all chunks as present since we're writing the full blob
 	}
 
@@ -1585,6 +1932,7 @@ func (bex *blobExchangeState[RI]) writeBlobBeforeBroadcast(blobDigest BlobDigest
 		chunkDigests,
 		expirySeqNr,
 		bex.id,
+		erasureCoded,
 	}
 	if err := tx.WriteBlobMeta(blobDigest, blobMeta); err != nil {
 		return fmt.Errorf("failed to write local blob meta: %w", err)
@@ -1614,10 +1962,12 @@ func (bex *blobExchangeState[RI]) processBlobFetchRequest(req blobFetchRequest)
 		cert.ChunkDigestsRoot,
 		cert.PayloadLength,
 		cert.ExpirySeqNr,
+		cert.ExpiryKey,
 		cert.Submitter,
+		cert.ErasureCoded,
 	)
 
-	bex.logger.Debug("processing BlobFetchRequest", commontypes.LogFields{"blobDigest": blobDigest})
+	bex.logger.Debug("processing BlobFetchRequest", commontypes.LogFields{"blobDigest": blobDigest, "erasureCoded": cert.ErasureCoded})
 
 	seeders := make(map[commontypes.OracleID]struct{}, len(cert.AttributedBlobAvailabilitySignatures))
 	for _, abs := range cert.AttributedBlobAvailabilitySignatures {
@@ -1636,6 +1986,8 @@ func (bex *blobExchangeState[RI]) processBlobFetchRequest(req blobFetchRequest)
 				nil,
 				seeders,
 				false,
+				false,
+				time.Now(),
 			}
 			if existingBlob.haveAllChunks() {
 				close(chNotifyPayloadAvailable)
@@ -1646,23 +1998,37 @@ func (bex *blobExchangeState[RI]) processBlobFetchRequest(req blobFetchRequest)
 			}
 
 			existingBlob.fetch.waiters++
+			if existingBlob.fetch.timeWhenRequested.IsZero() {
+				existingBlob.fetch.timeWhenRequested = time.Now()
+			}
 
 			chNotifyPayloadAvailable = existingBlob.fetch.chNotify
+
+			if existingBlob.erasureCoding != nil {
+				// we might already hold enough fragments, e.g. if we
+				// received offers for the blob earlier
+				bex.progressErasureCodedBlob(blobDigest, existingBlob)
+			}
 		}
 	} else {
 		chNotifyPayloadAvailable = make(chan struct{})
 
-		if err := bex.createOrUpdateBlobMetaAndQuotaStats(blobDigest, cert.PayloadLength, cert.ExpirySeqNr, cert.Submitter); err != nil {
+		if err := bex.createOrUpdateBlobMetaAndQuotaStats(blobDigest, cert.PayloadLength, cert.ExpirySeqNr, cert.Submitter, cert.ErasureCoded); err != nil {
 			req.respond(bex.ctx, blobFetchResponse{nil, fmt.Errorf("failed to create or update blob meta and quota stats: %w", err)})
 			return
 		}
 
-		chunkDigests, chunkHaves, err := bex.loadChunkDigestsAndHaves(blobDigest, cert.PayloadLength)
+		chunkDigests, chunkHaves, err := bex.loadChunkDigestsAndHaves(blobDigest, cert.PayloadLength, cert.ErasureCoded)
 		if err != nil {
 			req.respond(bex.ctx, blobFetchResponse{nil, fmt.Errorf("failed to import blob chunk haves from disk: %w", err)})
 			return
 		}
 
+		var erasureCoding *blobErasureCodingLayout
+		if cert.ErasureCoded {
+			erasureCoding = makeBlobErasureCodingLayout(cert.PayloadLength, bex.config.PublicConfig)
+		}
+
 		newBlob := &blob{
 			time.Now(),
 			nil,
@@ -1672,6 +2038,8 @@ func (bex *blobExchangeState[RI]) processBlobFetchRequest(req blobFetchRequest)
 				nil,
 				seeders,
 				false,
+				false,
+				time.Now(),
 			},
 
 			cert.ChunkDigestsRoot,
@@ -1679,7 +2047,9 @@ func (bex *blobExchangeState[RI]) processBlobFetchRequest(req blobFetchRequest)
 			chunkHaves,
 			cert.PayloadLength,
 			cert.ExpirySeqNr,
+			cert.ExpiryKey,
 			cert.Submitter,
+			erasureCoding,
 		}
 
 		bex.metrics.blobsInProgress.Inc()
@@ -1687,6 +2057,8 @@ func (bex *blobExchangeState[RI]) processBlobFetchRequest(req blobFetchRequest)
 
 		if newBlob.haveAllChunks() {
 			close(chNotifyPayloadAvailable)
+		} else if newBlob.erasureCoding != nil {
+			bex.progressErasureCodedBlob(blobDigest, newBlob)
 		}
 	}
 
@@ -1727,6 +2099,7 @@ func (bex *blobExchangeState[RI]) createOrUpdateBlobMetaAndQuotaStats(
 	payloadLength uint64,
 	expirySeqNr uint64,
 	submitter commontypes.OracleID,
+	erasureCoded bool,
 ) error {
 	tx, err := bex.kv.NewUnserializedReadWriteTransactionUnchecked()
 	if err != nil {
@@ -1762,15 +2135,16 @@ func (bex *blobExchangeState[RI]) createOrUpdateBlobMetaAndQuotaStats(
 		return fmt.Errorf("failed to write blob quota stats: %w", err)
 	}
 
-	cfgBlobChunkSize := bex.config.GetBlobChunkBytes()
+	numChunks := blobNumChunks(payloadLength, erasureCoded, bex.config)
 
 	// and now write the meta
 	blobMeta := BlobMeta{
 		payloadLength,
-		make([]bool, numChunks(payloadLength, cfgBlobChunkSize)),
-		make([]BlobChunkDigest, numChunks(payloadLength, cfgBlobChunkSize)),
+		make([]bool, numChunks),
+		make([]BlobChunkDigest, numChunks),
 		expirySeqNr,
 		submitter,
+		erasureCoded,
 	}
 	err = tx.WriteBlobMeta(blobDigest, blobMeta)
 	if err != nil {
@@ -1791,9 +2165,11 @@ func (bex *blobExchangeState[RI]) eventBlobFetchRequestRespond(ev EventBlobFetch
 		err     error
 	)
 	blob, ok := bex.blobs[ev.BlobDigest]
-	if ok && blob != nil && blob.fetch != nil && blob.fetch.expired {
+	if ok && blob != nil && blob.fetch != nil && blob.fetch.invalid {
+		err = errBlobInconsistentlyErasureCoded
+	} else if ok && blob != nil && blob.fetch != nil && blob.fetch.expired {
 		err = fmt.Errorf("blob expired during fetching")
-	} else {
+	} else if !ev.Request.availableOnly {
 		payload, err = bex.readBlobPayload(ev.BlobDigest)
 		if payload == nil && err == nil {
 			err = fmt.Errorf("blob payload is unexpectedly nil")
@@ -1817,7 +2193,7 @@ func (bex *blobExchangeState[RI]) eventBlobFetchRequestDone(ev EventBlobFetchReq
 	}
 }
 
-func (bex *blobExchangeState[RI]) loadChunkDigestsAndHaves(blobDigest BlobDigest, payloadLength uint64) ([]BlobChunkDigest, []bool, error) {
+func (bex *blobExchangeState[RI]) loadChunkDigestsAndHaves(blobDigest BlobDigest, payloadLength uint64, erasureCoded bool) ([]BlobChunkDigest, []bool, error) {
 	tx, err := bex.kv.NewReadTransactionUnchecked()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create read transaction")
@@ -1828,15 +2204,46 @@ func (bex *blobExchangeState[RI]) loadChunkDigestsAndHaves(blobDigest BlobDigest
 		return nil, nil, fmt.Errorf("failed to read blob meta: %w", err)
 	}
 	if blobMeta == nil {
-		numChunks := numChunks(payloadLength, bex.config.GetBlobChunkBytes())
+		numChunks := blobNumChunks(payloadLength, erasureCoded, bex.config)
 		return make([]BlobChunkDigest, numChunks), make([]bool, numChunks), nil
 	}
 	if blobMeta.PayloadLength != payloadLength {
 		return nil, nil, fmt.Errorf("payload length mismatch: disk %d != mem %d", blobMeta.PayloadLength, payloadLength)
 	}
+	if blobMeta.ErasureCoded != erasureCoded {
+		return nil, nil, fmt.Errorf("erasure coding mismatch: disk %v != mem %v", blobMeta.ErasureCoded, erasureCoded)
+	}
 	return blobMeta.ChunkDigests, blobMeta.ChunkHaves, nil
 }
 
+// writeBlobChunkDigests fills in the chunk digests of an existing blob meta.
+func (bex *blobExchangeState[RI]) writeBlobChunkDigests(blobDigest BlobDigest, chunkDigests []BlobChunkDigest) error {
+	tx, err := bex.kv.NewUnserializedReadWriteTransactionUnchecked()
+	if err != nil {
+		return fmt.Errorf("failed to create read/write transaction: %w", err)
+	}
+	defer tx.Discard()
+
+	blobMeta, err := tx.ReadBlobMeta(blobDigest)
+	if err != nil {
+		return fmt.Errorf("failed to read blob meta: %w", err)
+	}
+	if blobMeta == nil {
+		return fmt.Errorf("blob meta is missing, blob might have been reaped")
+	}
+	if len(blobMeta.ChunkDigests) != len(chunkDigests) {
+		return fmt.Errorf("chunk digests length mismatch: disk %d != mem %d", len(blobMeta.ChunkDigests), len(chunkDigests))
+	}
+	blobMeta.ChunkDigests = chunkDigests
+	if err := tx.WriteBlobMeta(blobDigest, *blobMeta); err != nil {
+		return fmt.Errorf("failed to write blob meta: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 func (bex *blobExchangeState[RI]) minRejectors() int {
 	return bex.config.F + 1
 }
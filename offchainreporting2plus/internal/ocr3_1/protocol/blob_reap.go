@@ -12,6 +12,10 @@ import (
 const (
 	blobReapInterval                  = 3 * time.Second
 	maxBlobsToReapInSingleTransaction = 100
+
+	// Each expiry seqNr holds up to maxReleasedBlobsPerExpirySeqNr released
+	// blobs.
+	maxReleasedBlobExpirySeqNrsToReapInSingleTransaction = 4
 )
 
 func reapBlobs(ctx context.Context, kvDb KeyValueDatabase) (done bool, err error) {
@@ -34,7 +38,9 @@ func reapBlobs(ctx context.Context, kvDb KeyValueDatabase) (done bool, err error
 	}
 
 	if len(staleBlobs) == 0 {
-
+		if err := tx.Commit(); err != nil {
+			return false, fmt.Errorf("failed to commit transaction: %w", err)
+		}
 		return true, nil
 	}
 
@@ -109,6 +115,77 @@ func reapSingleBlob(tx KeyValueDatabaseReadWriteTransaction, staleBlob StaleBlob
 	return nil
 }
 
+// releasedBlobReapCursor tracks our progress scanning the blob releases
+// recorded in the KeyValueState. Releases can be committed for any unexpired
+// blob, so we rescan all of them whenever the committed seqNr advances.
+type releasedBlobReapCursor struct {
+	scanning       bool
+	committedSeqNr uint64
+	minExpirySeqNr uint64
+}
+
+// reapReleasedBlobs reaps the blobs that committed StateTransitions released
+// before they expired. Blobs we haven't got are skipped. Should we fetch them
+// later, they are kept until they expire.
+func reapReleasedBlobs(ctx context.Context, kvDb KeyValueDatabase, cursor *releasedBlobReapCursor) (done bool, err error) {
+	chDone := ctx.Done()
+
+	tx, err := kvDb.NewUnserializedReadWriteTransactionUnchecked()
+	if err != nil {
+		return false, fmt.Errorf("failed to create read/write transaction: %w", err)
+	}
+	defer tx.Discard()
+
+	committedSeqNr, err := tx.ReadHighestCommittedSeqNr()
+	if err != nil {
+		return false, fmt.Errorf("failed to read highest committed seq nr: %w", err)
+	}
+
+	if !cursor.scanning {
+		if cursor.committedSeqNr == committedSeqNr {
+			return true, nil
+		}
+		*cursor = releasedBlobReapCursor{true, committedSeqNr, 0}
+	}
+
+	// releases of expired blobs are left to reapBlobs
+	minExpirySeqNr := max(cursor.minExpirySeqNr, committedSeqNr+1)
+	releasedBlobs, more, err := tx.ReadReleasedBlobs(minExpirySeqNr, maxReleasedBlobExpirySeqNrsToReapInSingleTransaction)
+	if err != nil {
+		return false, fmt.Errorf("failed to read released blobs: %w", err)
+	}
+
+	for _, releasedBlob := range releasedBlobs {
+		select {
+		case <-chDone:
+			return true, ctx.Err()
+		default:
+		}
+
+		meta, err := tx.ReadBlobMeta(releasedBlob.BlobDigest)
+		if err != nil {
+			return false, fmt.Errorf("failed to read blob meta: %w", err)
+		}
+		if meta == nil {
+			continue
+		}
+		if err := reapSingleBlob(tx, staleBlob(meta.ExpirySeqNr, releasedBlob.BlobDigest)); err != nil {
+			return false, fmt.Errorf("failed to reap single released blob: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if more && len(releasedBlobs) > 0 && releasedBlobs[len(releasedBlobs)-1].ExpirySeqNr+1 != 0 {
+		cursor.minExpirySeqNr = releasedBlobs[len(releasedBlobs)-1].ExpirySeqNr + 1
+		return false, nil
+	}
+	cursor.scanning = false
+	return true, nil
+}
+
 func RunBlobReap(
 	ctx context.Context,
 	logger loghelper.LoggerWithContext,
@@ -117,6 +194,8 @@ func RunBlobReap(
 	chDone := ctx.Done()
 	chTick := time.After(0)
 
+	var releasedCursor releasedBlobReapCursor
+
 	for {
 		select {
 		case <-chTick:
@@ -130,7 +209,15 @@ func RunBlobReap(
 				"error": err,
 			})
 		}
-		if done {
+
+		releasedDone, err := reapReleasedBlobs(ctx, kvDb, &releasedCursor)
+		if err != nil {
+			logger.Warn("BlobReap: failed to reap released blobs", commontypes.LogFields{
+				"error": err,
+			})
+		}
+
+		if done && releasedDone {
 			chTick = time.After(blobReapInterval)
 		} else {
 			chTick = time.After(0)
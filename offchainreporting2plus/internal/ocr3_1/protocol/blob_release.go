@@ -0,0 +1,299 @@
+package protocol
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// Blob releases are recorded in the KeyValueState under
+// ocr3_1types.ReservedKeyPrefix, so that they take effect on all oracles alike,
+// including those that catch up through state sync. Released blobs are grouped
+// by expiry seqNr: a single read tells whether a blob has been released, and a
+// single deletion prunes all releases of blobs once they have expired. Only
+// point reads and writes are used, so that the determinism checker can replay
+// them on top of the tree.
+const (
+	releasedBlobsKeyPrefix  = ocr3_1types.ReservedKeyPrefix + "BR|"
+	expiryKeyLinksKeyPrefix = ocr3_1types.ReservedKeyPrefix + "BK|"
+
+	maxReleasedBlobsPerExpirySeqNr = 128
+	maxBlobsLinkedToExpiryKey      = 64
+)
+
+// ReleasedBlob identifies a blob released by a committed StateTransition.
+type ReleasedBlob struct {
+	ExpirySeqNr uint64
+	BlobDigest  BlobDigest
+}
+
+// ReleasedBlobsKey returns the key in the KeyValueState under which the
+// releases of blobs expiring at expirySeqNr are recorded.
+func ReleasedBlobsKey(expirySeqNr uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte(releasedBlobsKeyPrefix), expirySeqNr)
+}
+
+// ParseReleasedBlobs decodes a key-value pair written under a ReleasedBlobsKey.
+func ParseReleasedBlobs(key []byte, value []byte) ([]ReleasedBlob, error) {
+	if !(len(key) == len(releasedBlobsKeyPrefix)+8 && bytes.HasPrefix(key, []byte(releasedBlobsKeyPrefix))) {
+		return nil, fmt.Errorf("invalid released blobs key %x", key)
+	}
+	expirySeqNr := binary.BigEndian.Uint64(key[len(releasedBlobsKeyPrefix):])
+	blobDigests, err := decodeReleasedBlobDigests(value)
+	if err != nil {
+		return nil, err
+	}
+	releasedBlobs := make([]ReleasedBlob, 0, len(blobDigests))
+	for _, blobDigest := range blobDigests {
+		releasedBlobs = append(releasedBlobs, ReleasedBlob{expirySeqNr, blobDigest})
+	}
+	return releasedBlobs, nil
+}
+
+func decodeReleasedBlobDigests(value []byte) ([]BlobDigest, error) {
+	if len(value)%len(BlobDigest{}) != 0 {
+		return nil, fmt.Errorf("released blobs value has invalid length %d", len(value))
+	}
+	blobDigests := make([]BlobDigest, 0, len(value)/len(BlobDigest{}))
+	for len(value) > 0 {
+		blobDigests = append(blobDigests, BlobDigest(value[:len(BlobDigest{})]))
+		value = value[len(BlobDigest{}):]
+	}
+	return blobDigests, nil
+}
+
+func readReleasedBlobDigests(r ocr3_1types.KeyValueStateReader, expirySeqNr uint64) ([]BlobDigest, error) {
+	value, err := r.Read(ReleasedBlobsKey(expirySeqNr))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read released blobs: %w", err)
+	}
+	return decodeReleasedBlobDigests(value)
+}
+
+func isBlobReleased(r ocr3_1types.KeyValueStateReader, releasedBlob ReleasedBlob) (bool, error) {
+	blobDigests, err := readReleasedBlobDigests(r, releasedBlob.ExpirySeqNr)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(blobDigests, releasedBlob.BlobDigest), nil
+}
+
+func expiryKeyLinksKey(expiryKey []byte) []byte {
+	expiryKeyDigest := sha256.Sum256(expiryKey)
+	return append([]byte(expiryKeyLinksKeyPrefix), expiryKeyDigest[:]...)
+}
+
+func readExpiryKeyLinks(r ocr3_1types.KeyValueStateReader, expiryKey []byte) ([]ReleasedBlob, error) {
+	value, err := r.Read(expiryKeyLinksKey(expiryKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expiry key links: %w", err)
+	}
+	const linkLength = 8 + len(BlobDigest{})
+	if len(value)%linkLength != 0 {
+		return nil, fmt.Errorf("expiry key links value has invalid length %d", len(value))
+	}
+	links := make([]ReleasedBlob, 0, len(value)/linkLength)
+	for len(value) > 0 {
+		links = append(links, ReleasedBlob{
+			binary.BigEndian.Uint64(value[:8]),
+			BlobDigest(value[8:linkLength]),
+		})
+		value = value[linkLength:]
+	}
+	return links, nil
+}
+
+// stateTransitionBlobReleases records the blob releases of a single
+// StateTransition. Plugins may call into it concurrently, so it serializes the
+// read-modify-write cycles on the release records.
+type stateTransitionBlobReleases struct {
+	mu    sync.Mutex
+	seqNr uint64
+	rw    ocr3_1types.KeyValueStateReadWriter
+}
+
+func newStateTransitionBlobReleases(seqNr uint64, rw ocr3_1types.KeyValueStateReadWriter) *stateTransitionBlobReleases {
+	return &stateTransitionBlobReleases{sync.Mutex{}, seqNr, rw}
+}
+
+// prune deletes the releases of blobs that expired at seqNr-1, which can no
+// longer be fetched anyway. It must be called before the plugin runs, so that
+// releases of earlier expiring blobs never pile up.
+func (r *stateTransitionBlobReleases) prune() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := ReleasedBlobsKey(r.seqNr - 1)
+	value, err := r.rw.Read(key)
+	if err != nil {
+		return fmt.Errorf("failed to read released blobs: %w", err)
+	}
+	if value == nil {
+		return nil
+	}
+	if err := r.rw.Delete(key); err != nil {
+		return fmt.Errorf("failed to delete released blobs: %w", err)
+	}
+	return nil
+}
+
+func (r *stateTransitionBlobReleases) release(releasedBlob ReleasedBlob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ok, err := r.releaseLocked(releasedBlob)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("cannot release more than %d blobs expiring at seq nr %d", maxReleasedBlobsPerExpirySeqNr, releasedBlob.ExpirySeqNr)
+	}
+	return nil
+}
+
+// releaseLocked returns false if too many blobs with the same expiry seqNr
+// have been released already.
+func (r *stateTransitionBlobReleases) releaseLocked(releasedBlob ReleasedBlob) (bool, error) {
+	if hasBlobExpired(releasedBlob.ExpirySeqNr, r.seqNr) {
+		return true, nil
+	}
+	blobDigests, err := readReleasedBlobDigests(r.rw, releasedBlob.ExpirySeqNr)
+	if err != nil {
+		return false, err
+	}
+	if slices.Contains(blobDigests, releasedBlob.BlobDigest) {
+		return true, nil
+	}
+	if len(blobDigests) >= maxReleasedBlobsPerExpirySeqNr {
+		return false, nil
+	}
+	value := make([]byte, 0, (len(blobDigests)+1)*len(BlobDigest{}))
+	for _, blobDigest := range append(blobDigests, releasedBlob.BlobDigest) {
+		value = append(value, blobDigest[:]...)
+	}
+	if err := r.rw.Write(ReleasedBlobsKey(releasedBlob.ExpirySeqNr), value); err != nil {
+		return false, fmt.Errorf("failed to write released blobs: %w", err)
+	}
+	return true, nil
+}
+
+// checkFetch fails for blobs that have been released, and for blobs whose
+// expiry key is absent from the KeyValueState.
+func (r *stateTransitionBlobReleases) checkFetch(cert *LightCertifiedBlob, blobDigest BlobDigest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	released, err := isBlobReleased(r.rw, ReleasedBlob{cert.ExpirySeqNr, blobDigest})
+	if err != nil {
+		return err
+	}
+	if released {
+		return fmt.Errorf("blob has been released")
+	}
+	if len(cert.ExpiryKey) != 0 {
+		value, err := r.rw.Read(cert.ExpiryKey)
+		if err != nil {
+			return fmt.Errorf("failed to read expiry key: %w", err)
+		}
+		if value == nil {
+			return fmt.Errorf("blob expired, its expiry key is absent")
+		}
+	}
+	return nil
+}
+
+// link links a successfully fetched blob to its expiry key, if it has one, so
+// that deleting the key releases it. Blobs beyond maxBlobsLinkedToExpiryKey
+// are not linked and expire at their expiry seqNr.
+func (r *stateTransitionBlobReleases) link(cert *LightCertifiedBlob, blobDigest BlobDigest) error {
+	if len(cert.ExpiryKey) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	links, err := readExpiryKeyLinks(r.rw, cert.ExpiryKey)
+	if err != nil {
+		return err
+	}
+	releasedBlob := ReleasedBlob{cert.ExpirySeqNr, blobDigest}
+	if slices.Contains(links, releasedBlob) {
+		return nil
+	}
+	links = slices.DeleteFunc(links, func(link ReleasedBlob) bool {
+		return hasBlobExpired(link.ExpirySeqNr, r.seqNr)
+	})
+	if len(links) >= maxBlobsLinkedToExpiryKey {
+		return nil
+	}
+	value := make([]byte, 0, (len(links)+1)*(8+len(BlobDigest{})))
+	for _, link := range append(links, releasedBlob) {
+		value = binary.BigEndian.AppendUint64(value, link.ExpirySeqNr)
+		value = append(value, link.BlobDigest[:]...)
+	}
+	if err := r.rw.Write(expiryKeyLinksKey(cert.ExpiryKey), value); err != nil {
+		return fmt.Errorf("failed to write expiry key links: %w", err)
+	}
+	return nil
+}
+
+// releaseLinked releases the blobs linked to a key that has been deleted.
+func (r *stateTransitionBlobReleases) releaseLinked(expiryKey []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	links, err := readExpiryKeyLinks(r.rw, expiryKey)
+	if err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	for _, link := range links {
+		// Blobs that can't be released because too many others with the
+		// same expiry seqNr have been, simply expire then.
+		if _, err := r.releaseLocked(link); err != nil {
+			return err
+		}
+	}
+	if err := r.rw.Delete(expiryKeyLinksKey(expiryKey)); err != nil {
+		return fmt.Errorf("failed to delete expiry key links: %w", err)
+	}
+	return nil
+}
+
+// stateTransitionKeyValueState is the KeyValueStateReadWriter passed to
+// StateTransition. It keeps the plugin from modifying reserved keys and
+// releases the blobs linked to the keys the plugin deletes.
+type stateTransitionKeyValueState struct {
+	rw       ocr3_1types.KeyValueStateReadWriter
+	releases *stateTransitionBlobReleases
+}
+
+var _ ocr3_1types.KeyValueStateReadWriter = &stateTransitionKeyValueState{}
+
+func (s *stateTransitionKeyValueState) Read(key []byte) ([]byte, error) {
+	return s.rw.Read(key)
+}
+
+func (s *stateTransitionKeyValueState) Write(key []byte, value []byte) error {
+	if bytes.HasPrefix(key, []byte(ocr3_1types.ReservedKeyPrefix)) {
+		return fmt.Errorf("key %x is reserved", key)
+	}
+	return s.rw.Write(key, value)
+}
+
+func (s *stateTransitionKeyValueState) Delete(key []byte) error {
+	if bytes.HasPrefix(key, []byte(ocr3_1types.ReservedKeyPrefix)) {
+		return fmt.Errorf("key %x is reserved", key)
+	}
+	if err := s.rw.Delete(key); err != nil {
+		return err
+	}
+	return s.releases.releaseLinked(key)
+}
@@ -0,0 +1,306 @@
+package protocol
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/mt"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/blobtypes"
+)
+
+// writeBlobPayloadFromReader writes a payload of payloadLength bytes read from
+// r to local storage, one chunk at a time, and returns the chunk digests. The
+// blob digest depends on all chunks, so we can only compute it once we have
+// read the entire payload. We thus first stage the chunks under a random
+// digest and then copy them over. The staged blob has meta and a stale blob
+// index entry like any other blob, so that blob reaping cleans it up should we
+// fail to remove it ourselves.
+func writeBlobPayloadFromReader(
+	ctx context.Context,
+	kv KeyValueDatabase,
+	config ocr3_1config.SharedConfig,
+	submitter commontypes.OracleID,
+	payloadLength uint64,
+	r io.Reader,
+	expirySeqNr uint64,
+	expiryKey []byte,
+) ([]BlobChunkDigest, error) {
+	cfgBlobChunkSize := config.GetBlobChunkBytes()
+	numChunks := numChunks(payloadLength, cfgBlobChunkSize)
+
+	chunkHaves := make([]bool, numChunks)
+	for i := range chunkHaves {
+		chunkHaves[i] = true
+	}
+
+	var stagingDigest BlobDigest
+	if _, err := rand.Read(stagingDigest[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate staging blob digest: %w", err)
+	}
+	stagingMeta := BlobMeta{
+		payloadLength,
+		chunkHaves,
+		make([]BlobChunkDigest, numChunks),
+		expirySeqNr,
+		submitter,
+		false,
+	}
+	if err := writeBlobMetaAndStaleBlobIndex(kv, stagingDigest, stagingMeta); err != nil {
+		return nil, fmt.Errorf("failed to write staging blob meta: %w", err)
+	}
+	// best effort, blob reaping takes care of the staged blob otherwise
+	defer func() { _ = deleteBlob(kv, stagingDigest, stagingMeta) }()
+
+	chunkDigests := make([]BlobChunkDigest, 0, numChunks)
+	for chunkIndex := uint64(0); chunkIndex < numChunks; chunkIndex++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chunkLength, _ := chunkBytes(payloadLength, chunkIndex, cfgBlobChunkSize)
+		chunk := make([]byte, chunkLength)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, fmt.Errorf("failed to read chunk %d of payload: %w", chunkIndex, err)
+		}
+		chunkDigests = append(chunkDigests, blobtypes.MakeBlobChunkDigest(chunk))
+		if err := writeBlobChunk(kv, stagingDigest, chunkIndex, chunk); err != nil {
+			return nil, fmt.Errorf("failed to write staging blob chunk %d: %w", chunkIndex, err)
+		}
+	}
+
+	var excess [1]byte
+	if _, err := io.ReadFull(r, excess[:]); err == nil {
+		return nil, fmt.Errorf("payload is longer than payload length %d", payloadLength)
+	} else if !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to check for end of payload: %w", err)
+	}
+
+	blobDigest := blobtypes.MakeBlobDigest(
+		config.ConfigDigest,
+		blobtypes.MakeBlobChunkDigestsRoot(chunkDigests),
+		payloadLength,
+		expirySeqNr,
+		expiryKey,
+		submitter,
+		false,
+	)
+
+	// No one knows about blobDigest before we offer it, so we can write the
+	// meta ahead of the chunks. This way, blob reaping also covers a partial
+	// copy.
+	if err := writeBlobMetaAndStaleBlobIndex(kv, blobDigest, BlobMeta{
+		payloadLength,
+		chunkHaves,
+		chunkDigests,
+		expirySeqNr,
+		submitter,
+		false,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write blob meta: %w", err)
+	}
+	for chunkIndex := uint64(0); chunkIndex < numChunks; chunkIndex++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := copyBlobChunk(kv, stagingDigest, blobDigest, chunkIndex); err != nil {
+			return nil, fmt.Errorf("failed to copy blob chunk %d: %w", chunkIndex, err)
+		}
+	}
+
+	return chunkDigests, nil
+}
+
+func writeBlobMetaAndStaleBlobIndex(kv KeyValueDatabase, blobDigest BlobDigest, blobMeta BlobMeta) error {
+	tx, err := kv.NewUnserializedReadWriteTransactionUnchecked()
+	if err != nil {
+		return fmt.Errorf("failed to create read/write transaction: %w", err)
+	}
+	defer tx.Discard()
+	if err := tx.WriteBlobMeta(blobDigest, blobMeta); err != nil {
+		return fmt.Errorf("failed to write blob meta: %w", err)
+	}
+	if err := tx.WriteStaleBlobIndex(staleBlob(blobMeta.ExpirySeqNr, blobDigest)); err != nil {
+		return fmt.Errorf("failed to write stale blob index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func writeBlobChunk(kv KeyValueDatabase, blobDigest BlobDigest, chunkIndex uint64, chunk []byte) error {
+	tx, err := kv.NewUnserializedReadWriteTransactionUnchecked()
+	if err != nil {
+		return fmt.Errorf("failed to create read/write transaction: %w", err)
+	}
+	defer tx.Discard()
+	if err := tx.WriteBlobChunk(blobDigest, chunkIndex, chunk); err != nil {
+		return fmt.Errorf("failed to write blob chunk: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func copyBlobChunk(kv KeyValueDatabase, fromBlobDigest BlobDigest, toBlobDigest BlobDigest, chunkIndex uint64) error {
+	tx, err := kv.NewUnserializedReadWriteTransactionUnchecked()
+	if err != nil {
+		return fmt.Errorf("failed to create read/write transaction: %w", err)
+	}
+	defer tx.Discard()
+	chunk, err := tx.ReadBlobChunk(fromBlobDigest, chunkIndex)
+	if err != nil {
+		return fmt.Errorf("failed to read blob chunk: %w", err)
+	}
+	if chunk == nil {
+		return fmt.Errorf("blob chunk is missing")
+	}
+	if err := tx.WriteBlobChunk(toBlobDigest, chunkIndex, chunk); err != nil {
+		return fmt.Errorf("failed to write blob chunk: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// deleteBlob deletes a blob without touching the quota stats. Only to be used
+// for blobs that never counted towards any quota.
+func deleteBlob(kv KeyValueDatabase, blobDigest BlobDigest, blobMeta BlobMeta) error {
+	tx, err := kv.NewUnserializedReadWriteTransactionUnchecked()
+	if err != nil {
+		return fmt.Errorf("failed to create read/write transaction: %w", err)
+	}
+	defer tx.Discard()
+	for chunkIndex := range blobMeta.ChunkHaves {
+		if err := tx.DeleteBlobChunk(blobDigest, uint64(chunkIndex)); err != nil {
+			return fmt.Errorf("failed to delete blob chunk: %w", err)
+		}
+	}
+	if err := tx.DeleteBlobMeta(blobDigest); err != nil {
+		return fmt.Errorf("failed to delete blob meta: %w", err)
+	}
+	if err := tx.DeleteStaleBlobIndex(staleBlob(blobMeta.ExpirySeqNr, blobDigest)); err != nil {
+		return fmt.Errorf("failed to delete stale blob index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// blobPayloadReader streams the payload of a locally available blob chunk by
+// chunk, verifying each chunk against the chunk digests root. For erasure coded
+// blobs, the payload is made up of the chunks of the data shards, truncated to
+// the payload length.
+type blobPayloadReader struct {
+	kv             KeyValueDatabase
+	blobDigest     BlobDigest
+	chunkDigests   []BlobChunkDigest
+	remainingBytes uint64
+	nextChunkIndex uint64
+	buffered       []byte
+	closed         bool
+}
+
+var _ io.ReadCloser = &blobPayloadReader{}
+
+func newBlobPayloadReader(kv KeyValueDatabase, config ocr3_1config.SharedConfig, blobDigest BlobDigest, chunkDigestsRoot mt.Digest, payloadLength uint64, erasureCoded bool) (*blobPayloadReader, error) {
+	tx, err := kv.NewReadTransactionUnchecked()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create read transaction: %w", err)
+	}
+	defer tx.Discard()
+
+	meta, err := tx.ReadBlobMeta(blobDigest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob meta: %w", err)
+	}
+	if meta == nil {
+		return nil, fmt.Errorf("blob meta is missing, blob might have been reaped")
+	}
+	if meta.PayloadLength != payloadLength {
+		return nil, fmt.Errorf("payload length mismatch: disk %d != cert %d", meta.PayloadLength, payloadLength)
+	}
+	if meta.ErasureCoded != erasureCoded {
+		return nil, fmt.Errorf("erasure coding mismatch: disk %v != cert %v", meta.ErasureCoded, erasureCoded)
+	}
+	if uint64(len(meta.ChunkHaves)) != blobNumChunks(payloadLength, erasureCoded, config) {
+		return nil, fmt.Errorf("chunk count mismatch: disk %d != expected %d", len(meta.ChunkHaves), blobNumChunks(payloadLength, erasureCoded, config))
+	}
+	var complete bool
+	if erasureCoded {
+		complete = haveErasureCodedPayload(makeBlobErasureCodingLayout(payloadLength, config.PublicConfig), meta.ChunkHaves, meta.ChunkDigests, chunkDigestsRoot)
+	} else {
+		complete = haveAllChunks(meta.ChunkHaves, meta.ChunkDigests, chunkDigestsRoot)
+	}
+	if !complete {
+		return nil, fmt.Errorf("blob is incomplete or its chunk digests do not match the chunk digests root")
+	}
+	return &blobPayloadReader{
+		kv,
+		blobDigest,
+		meta.ChunkDigests,
+		payloadLength,
+		0,
+		nil,
+		false,
+	}, nil
+}
+
+func (r *blobPayloadReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, fmt.Errorf("blob payload reader has been closed")
+	}
+	if len(r.buffered) == 0 {
+		if r.remainingBytes == 0 {
+			return 0, io.EOF
+		}
+		if r.nextChunkIndex == uint64(len(r.chunkDigests)) {
+			return 0, fmt.Errorf("blob chunks are shorter than the payload length")
+		}
+		chunk, err := r.readChunk(r.nextChunkIndex)
+		if err != nil {
+			return 0, err
+		}
+		// the last data shard of an erasure coded blob is zero padded
+		chunk = chunk[:min(uint64(len(chunk)), r.remainingBytes)]
+		r.remainingBytes -= uint64(len(chunk))
+		r.buffered = chunk
+		r.nextChunkIndex++
+	}
+	n := copy(p, r.buffered)
+	r.buffered = r.buffered[n:]
+	return n, nil
+}
+
+func (r *blobPayloadReader) readChunk(chunkIndex uint64) ([]byte, error) {
+	tx, err := r.kv.NewReadTransactionUnchecked()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create read transaction: %w", err)
+	}
+	defer tx.Discard()
+
+	chunk, err := tx.ReadBlobChunk(r.blobDigest, chunkIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob chunk %d: %w", chunkIndex, err)
+	}
+	if chunk == nil {
+		return nil, fmt.Errorf("blob chunk %d is missing, blob might have been reaped", chunkIndex)
+	}
+	if blobtypes.MakeBlobChunkDigest(chunk) != r.chunkDigests[chunkIndex] {
+		return nil, fmt.Errorf("blob chunk %d does not match its digest", chunkIndex)
+	}
+	return chunk, nil
+}
+
+func (r *blobPayloadReader) Close() error {
+	r.closed = true
+	r.buffered = nil
+	return nil
+}
@@ -2,7 +2,10 @@ package protocol
 
 import (
 	"context"
+	"time"
 
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/common/pendingtransmissions"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 )
 
@@ -11,6 +14,40 @@ type PacemakerState struct {
 	HighestSentNewEpochWish uint64
 }
 
+// PendingTransmission is an attested report that this oracle has accepted and
+// scheduled for transmission. Pending transmissions are persisted so that
+// transmission resumes after a restart.
+type PendingTransmission struct {
+	SeqNr uint64
+	// Epoch in which the report was committed, determines the transmission
+	// order
+	Epoch       uint64
+	Index       int
+	ScheduledAt time.Time
+	// After a restart, the transmission is dropped once ExpiresAt has passed
+	ExpiresAt time.Time
+	Report    types.Report
+	// Encoded by the reporting plugin's ocr3types.ReportInfoCodec
+	ReportInfo           []byte
+	AttributedSignatures []types.AttributedOnchainSignature
+}
+
+// PendingTransmissionKey identifies a PendingTransmission.
+type PendingTransmissionKey = pendingtransmissions.Key
+
+func (pt PendingTransmission) Key() PendingTransmissionKey {
+	return PendingTransmissionKey{pt.SeqNr, pt.Index}
+}
+
+// SharedSecretRotationEndorsement is this oracle's endorsement of a shared
+// secret rotation. It is persisted before it is broadcast, so that the oracle
+// doesn't endorse a different rotation with the same RotationNumber after a
+// restart.
+type SharedSecretRotationEndorsement struct {
+	SharedSecretRotation ocr3_1config.SharedSecretRotation
+	Signature            SharedSecretRotationEndorsementSignature
+}
+
 type Database interface {
 	types.ConfigDatabase
 
@@ -19,4 +56,25 @@ type Database interface {
 
 	ReadCert(ctx context.Context, configDigest types.ConfigDigest) (CertifiedPrepareOrCommit, error)
 	WriteCert(ctx context.Context, configDigest types.ConfigDigest, cert CertifiedPrepareOrCommit) error
+
+	// In case there are no pending transmissions, nil should be returned.
+	ReadPendingTransmissions(ctx context.Context, configDigest types.ConfigDigest) ([]PendingTransmission, error)
+	// Adds or replaces the pending transmission with the same SeqNr and Index.
+	// Calls to WritePendingTransmission and DeletePendingTransmission for the
+	// same configDigest are not concurrent.
+	WritePendingTransmission(ctx context.Context, configDigest types.ConfigDigest, pendingTransmission PendingTransmission) error
+	// Deleting a pending transmission that doesn't exist is not an error.
+	DeletePendingTransmission(ctx context.Context, configDigest types.ConfigDigest, seqNr uint64, index int) error
+
+	// In case no shared secret rotation has been adopted, nil should be
+	// returned.
+	ReadSharedSecretRotations(ctx context.Context, configDigest types.ConfigDigest) ([]CertifiedSharedSecretRotation, error)
+	// Replaces all adopted shared secret rotations for configDigest.
+	WriteSharedSecretRotations(ctx context.Context, configDigest types.ConfigDigest, rotations []CertifiedSharedSecretRotation) error
+
+	// In case this oracle hasn't endorsed a shared secret rotation, nil
+	// should be returned.
+	ReadSharedSecretRotationEndorsement(ctx context.Context, configDigest types.ConfigDigest) (*SharedSecretRotationEndorsement, error)
+	// Replaces this oracle's latest endorsement for configDigest.
+	WriteSharedSecretRotationEndorsement(ctx context.Context, configDigest types.ConfigDigest, endorsement SharedSecretRotationEndorsement) error
 }
@@ -31,7 +31,8 @@ type EventToOutcomeGeneration[RI any] interface {
 }
 
 type EventNewEpochStart[RI any] struct {
-	Epoch uint64
+	Epoch  uint64
+	Leader commontypes.OracleID
 }
 
 var _ EventToOutcomeGeneration[struct{}] = EventNewEpochStart[struct{}]{}
@@ -131,6 +132,7 @@ type EventToTransmission[RI any] interface {
 
 type EventNewCertifiedCommit[RI any] struct {
 	SeqNr                      uint64
+	Epoch                      uint64
 	ReportsPlusPrecursorDigest ReportsPlusPrecursorDigest
 }
 
@@ -150,6 +152,16 @@ func (ev EventMissingReportsPlusPrecursor[RI]) processReportAttestation(repatt *
 	repatt.eventMissingReportsPlusPrecursor(ev)
 }
 
+type EventMissingCommitEpoch[RI any] struct {
+	SeqNr uint64
+}
+
+var _ EventToReportAttestation[struct{}] = EventMissingCommitEpoch[struct{}]{} // implements EventToReportAttestation
+
+func (ev EventMissingCommitEpoch[RI]) processReportAttestation(repatt *reportAttestationState[RI]) {
+	repatt.eventMissingCommitEpoch(ev)
+}
+
 type EventComputedReports[RI any] struct {
 	SeqNr       uint64
 	ReportsPlus []ocr3types.ReportPlus[RI]
@@ -162,7 +174,9 @@ func (ev EventComputedReports[RI]) processReportAttestation(repatt *reportAttest
 }
 
 type EventAttestedReport[RI any] struct {
-	SeqNr                        uint64
+	SeqNr uint64
+	// Epoch in which SeqNr was committed, determines the transmission order
+	Epoch                        uint64
 	Index                        int
 	AttestedReport               AttestedReportMany[RI]
 	TransmissionScheduleOverride *ocr3types.TransmissionSchedule
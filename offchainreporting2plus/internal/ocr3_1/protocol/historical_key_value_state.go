@@ -0,0 +1,300 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/smartcontractkit/libocr/internal/jmt"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+const maxHistoricalReadRangeChunkKeys = 1024
+
+type KeyValueDatabaseReadTransactionFactory interface {
+	NewReadTransactionUnchecked() (KeyValueDatabaseReadTransaction, error)
+}
+
+// historicalKeyValueState serves reads of the KeyValueState as of past
+// sequence numbers. We only keep JMT roots for complete snapshots (and the
+// snapshot currently being built), so the state as of an arbitrary seqNr is
+// obtained by reading from the highest complete snapshot not above seqNr and
+// overlaying the write sets of the retained blocks that follow it.
+type historicalKeyValueState struct {
+	kvDb   KeyValueDatabaseReadTransactionFactory
+	config ocr3_1config.PublicConfig
+}
+
+var _ ocr3_1types.HistoricalKeyValueState = &historicalKeyValueState{}
+
+func NewHistoricalKeyValueState(kvDb KeyValueDatabaseReadTransactionFactory, config ocr3_1config.PublicConfig) ocr3_1types.HistoricalKeyValueState {
+	return &historicalKeyValueState{kvDb, config}
+}
+
+func (hkvs *historicalKeyValueState) RetainedSeqNrs() (lowest uint64, highest uint64, err error) {
+	tx, err := hkvs.kvDb.NewReadTransactionUnchecked()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create read transaction: %w", err)
+	}
+	defer tx.Discard()
+	return retainedSeqNrs(tx, hkvs.config)
+}
+
+func retainedSeqNrs(tx KeyValueDatabaseReadTransaction, config ocr3_1config.PublicConfig) (lowest uint64, highest uint64, err error) {
+	if err := checkTreeSyncInactive(tx); err != nil {
+		return 0, 0, err
+	}
+	highest, err = tx.ReadHighestCommittedSeqNr()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read highest committed seq nr: %w", err)
+	}
+	lowestPersisted, err := tx.ReadLowestPersistedSeqNr()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read lowest persisted seq nr: %w", err)
+	}
+	lowest = max(lowestPersisted, genesisSeqNr(config))
+	if lowest > highest {
+		return 0, 0, fmt.Errorf("no state retained yet: lowest retained seq nr %d > highest committed seq nr %d", lowest, highest)
+	}
+	return lowest, highest, nil
+}
+
+func checkTreeSyncInactive(tx KeyValueDatabaseReadTransaction) error {
+	treeSyncStatus, err := tx.ReadTreeSyncStatus()
+	if err != nil {
+		return fmt.Errorf("failed to read tree sync status: %w", err)
+	}
+	if treeSyncStatus.Phase != TreeSyncPhaseInactive {
+		return fmt.Errorf("tree sync is in progress, historical state is unavailable")
+	}
+	return nil
+}
+
+func (hkvs *historicalKeyValueState) NewHistoricalKeyValueStateReader(seqNr uint64) (ocr3_1types.HistoricalKeyValueStateReader, error) {
+	tx, err := hkvs.kvDb.NewReadTransactionUnchecked()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create read transaction: %w", err)
+	}
+	lowest, highest, err := retainedSeqNrs(tx, hkvs.config)
+	if err != nil {
+		tx.Discard()
+		return nil, err
+	}
+	if seqNr < lowest {
+		tx.Discard()
+		return nil, fmt.Errorf("seq nr %d is below lowest retained seq nr %d: %w", seqNr, lowest, ocr3_1types.ErrKeyValueStateSeqNrPruned)
+	}
+	if seqNr > highest {
+		tx.Discard()
+		return nil, fmt.Errorf("seq nr %d is above highest committed seq nr %d: %w", seqNr, highest, ocr3_1types.ErrKeyValueStateSeqNrNotCommitted)
+	}
+
+	snapshotSeqNr, ok := highestCompleteSnapshotSeqNrNotAbove(seqNr, hkvs.config)
+	if !ok {
+		// Only the genesis state precedes seqNr. With a zero genesis, version 0
+		// has no root and thus represents the empty tree.
+		snapshotSeqNr = genesisSeqNr(hkvs.config)
+	}
+	return &historicalKeyValueStateReader{
+		sync.Mutex{},
+		tx,
+		hkvs.config,
+		seqNr,
+		highest,
+		snapshotSeqNr,
+		false,
+	}, nil
+}
+
+type historicalKeyValueStateReader struct {
+	mu            sync.Mutex
+	tx            KeyValueDatabaseReadTransaction
+	config        ocr3_1config.PublicConfig
+	seqNr         uint64
+	highestSeqNr  uint64
+	snapshotSeqNr uint64
+	discarded     bool
+}
+
+var _ ocr3_1types.HistoricalKeyValueStateReader = &historicalKeyValueStateReader{}
+
+func (r *historicalKeyValueStateReader) SeqNr() uint64 {
+	return r.seqNr
+}
+
+func (r *historicalKeyValueStateReader) Discard() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.discarded {
+		return
+	}
+	r.discarded = true
+	r.tx.Discard()
+}
+
+func (r *historicalKeyValueStateReader) Read(key []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.discarded {
+		return nil, fmt.Errorf("historical key value state reader has been discarded")
+	}
+	if !(len(key) <= ocr3_1types.MaxMaxKeyValueKeyBytes) {
+		return nil, fmt.Errorf("key length %d exceeds maximum %d", len(key), ocr3_1types.MaxMaxKeyValueKeyBytes)
+	}
+
+	if r.seqNr == r.highestSeqNr {
+		// the flat plugin keyspace reflects the highest committed state
+		return r.tx.Read(key)
+	}
+
+	// the most recent write at or below seqNr wins
+	for blockSeqNr := r.seqNr; blockSeqNr > r.snapshotSeqNr; blockSeqNr-- {
+		writeSet, err := r.readWriteSet(blockSeqNr)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(writeSet) - 1; i >= 0; i-- {
+			if bytes.Equal(writeSet[i].Key, key) {
+				if writeSet[i].Deleted {
+					return nil, nil
+				}
+				return writeSet[i].Value, nil
+			}
+		}
+	}
+
+	value, err := jmt.Read(r.tx, r.tx, RootVersion(r.snapshotSeqNr, r.config), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key from snapshot at seq nr %d: %w", r.snapshotSeqNr, err)
+	}
+	return value, nil
+}
+
+// ReadRange has to scan the entire snapshot since the tree is ordered by key
+// digest rather than by key.
+func (r *historicalKeyValueStateReader) ReadRange(loKey []byte, hiKeyExcl []byte) ([]ocr3_1types.KeyValuePair, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.discarded {
+		return nil, fmt.Errorf("historical key value state reader has been discarded")
+	}
+
+	inRange := func(key []byte) bool {
+		return bytes.Compare(loKey, key) <= 0 && (hiKeyExcl == nil || bytes.Compare(key, hiKeyExcl) < 0)
+	}
+
+	state := map[string][]byte{}
+	startIndex := jmt.MinDigest
+	for {
+		keyValues, truncated, err := jmt.ReadRange(
+			r.tx,
+			r.tx,
+			RootVersion(r.snapshotSeqNr, r.config),
+			startIndex,
+			jmt.MaxDigest,
+			math.MaxInt,
+			maxHistoricalReadRangeChunkKeys,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read range from snapshot at seq nr %d: %w", r.snapshotSeqNr, err)
+		}
+		for _, kv := range keyValues {
+			if inRange(kv.Key) {
+				state[string(kv.Key)] = kv.Value
+			}
+		}
+		if !truncated || len(keyValues) == 0 {
+			break
+		}
+		var ok bool
+		startIndex, ok = jmt.IncrementDigest(jmt.DigestKey(keyValues[len(keyValues)-1].Key))
+		if !ok {
+			break
+		}
+	}
+
+	for blockSeqNr := r.snapshotSeqNr + 1; blockSeqNr <= r.seqNr; blockSeqNr++ {
+		writeSet, err := r.readWriteSet(blockSeqNr)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range writeSet {
+			if !inRange(kv.Key) {
+				continue
+			}
+			if kv.Deleted {
+				delete(state, string(kv.Key))
+			} else {
+				state[string(kv.Key)] = kv.Value
+			}
+		}
+	}
+
+	keyValues := make([]ocr3_1types.KeyValuePair, 0, len(state))
+	for key, value := range state {
+		keyValues = append(keyValues, ocr3_1types.KeyValuePair{[]byte(key), value})
+	}
+	sort.Slice(keyValues, func(i, j int) bool {
+		return bytes.Compare(keyValues[i].Key, keyValues[j].Key) < 0
+	})
+	return keyValues, nil
+}
+
+func (r *historicalKeyValueStateReader) readWriteSet(seqNr uint64) ([]KeyValuePairWithDeletions, error) {
+	astb, err := r.tx.ReadAttestedStateTransitionBlock(seqNr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attested state transition block %d: %w", seqNr, err)
+	}
+	if astb.StateTransitionBlock.SeqNr() != seqNr {
+		return nil, fmt.Errorf("attested state transition block %d is missing, cannot reconstruct state at seq nr %d", seqNr, r.seqNr)
+	}
+	return astb.StateTransitionBlock.StateWriteSet.Entries, nil
+}
+
+// HistoricalKeyValueStateWrapper enables deferred initialization of a
+// HistoricalKeyValueState, analogous to BlobEndpointWrapper: the plugin is
+// constructed before the KeyValueDatabase it reads from is available. All
+// methods will error until the wrapper is initialized with
+// SetHistoricalKeyValueState.
+type HistoricalKeyValueStateWrapper struct {
+	mu      sync.Mutex
+	wrapped ocr3_1types.HistoricalKeyValueState
+}
+
+var _ ocr3_1types.HistoricalKeyValueState = &HistoricalKeyValueStateWrapper{}
+
+var errHistoricalKeyValueStateUnavailable = fmt.Errorf("historical key value state unavailable")
+
+func (hw *HistoricalKeyValueStateWrapper) locked() ocr3_1types.HistoricalKeyValueState {
+	hw.mu.Lock()
+	wrapped := hw.wrapped
+	hw.mu.Unlock()
+	return wrapped
+}
+
+func (hw *HistoricalKeyValueStateWrapper) RetainedSeqNrs() (lowest uint64, highest uint64, err error) {
+	wrapped := hw.locked()
+	if wrapped == nil {
+		return 0, 0, errHistoricalKeyValueStateUnavailable
+	}
+	return wrapped.RetainedSeqNrs()
+}
+
+func (hw *HistoricalKeyValueStateWrapper) NewHistoricalKeyValueStateReader(seqNr uint64) (ocr3_1types.HistoricalKeyValueStateReader, error) {
+	wrapped := hw.locked()
+	if wrapped == nil {
+		return nil, errHistoricalKeyValueStateUnavailable
+	}
+	return wrapped.NewHistoricalKeyValueStateReader(seqNr)
+}
+
+// SetHistoricalKeyValueState initializes the wrapper. Passing nil makes the
+// wrapper unavailable again, e.g. after the underlying database was closed.
+func (hw *HistoricalKeyValueStateWrapper) SetHistoricalKeyValueState(wrapped ocr3_1types.HistoricalKeyValueState) {
+	hw.mu.Lock()
+	hw.wrapped = wrapped
+	hw.mu.Unlock()
+}
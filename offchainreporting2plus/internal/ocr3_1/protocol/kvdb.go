@@ -56,6 +56,10 @@ type KeyValueDatabaseSemanticRead interface {
 	ReadBlobQuotaStats(blobQuotaStatsType BlobQuotaStatsType, submitter commontypes.OracleID) (BlobQuotaStats, error)
 	ReadBlobChunk(BlobDigest, uint64) ([]byte, error)
 	ReadStaleBlobIndex(maxStaleSinceSeqNr uint64, limit int) ([]StaleBlob, error)
+	// ReadReleasedBlobs returns the blobs released in the KeyValueState that
+	// expire at or after minExpirySeqNr, reading at most maxExpirySeqNrs
+	// distinct expiry seqNrs. more is true if there may be further ones.
+	ReadReleasedBlobs(minExpirySeqNr uint64, maxExpirySeqNrs int) (released []ReleasedBlob, more bool, err error)
 
 	ReadReportsPlusPrecursor(seqNr uint64, reportsPlusPrecursorDigest ReportsPlusPrecursorDigest) (*ocr3_1types.ReportsPlusPrecursor, error)
 
@@ -160,6 +164,9 @@ type BlobMeta struct {
 	ChunkDigests  []BlobChunkDigest
 	ExpirySeqNr   uint64
 	Submitter     commontypes.OracleID
+	// ErasureCoded indicates that the chunks are the erasure coded fragments
+	// of the payload rather than the payload itself.
+	ErasureCoded bool
 }
 
 type BlobQuotaStatsType string
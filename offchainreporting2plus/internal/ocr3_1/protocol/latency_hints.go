@@ -0,0 +1,25 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+)
+
+// OracleLatencyHints estimates the network latency to other oracles. State
+// sync and blob exchange use it to request items from the closest responsive
+// oracles first.
+type OracleLatencyHints interface {
+	// RoundTripLatency returns false if the latency to the oracle is unknown,
+	// or if the oracle appears to be unresponsive.
+	RoundTripLatency(oracleID commontypes.OracleID) (time.Duration, bool)
+}
+
+// latencyFn adapts latencyHints for requestergadget. It returns nil if
+// latencyHints is nil.
+func latencyFn(latencyHints OracleLatencyHints) func(commontypes.OracleID) (time.Duration, bool) {
+	if latencyHints == nil {
+		return nil
+	}
+	return latencyHints.RoundTripLatency
+}
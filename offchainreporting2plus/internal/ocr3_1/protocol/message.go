@@ -89,6 +89,17 @@ type MessageToBlobExchangeWithSender[RI any] struct {
 	sender commontypes.OracleID
 }
 
+type MessageToSharedSecretRotation[RI any] interface {
+	Message[RI]
+
+	processSharedSecretRotation(ssr *sharedSecretRotationState[RI], sender commontypes.OracleID)
+}
+
+type MessageToSharedSecretRotationWithSender[RI any] struct {
+	msg    MessageToSharedSecretRotation[RI]
+	sender commontypes.OracleID
+}
+
 type MessageNewEpochWish[RI any] struct {
 	Epoch uint64
 }
@@ -374,6 +385,14 @@ func (msg MessageReportsPlusPrecursorRequest[RI]) processReportAttestation(repat
 	repatt.messageReportsPlusPrecursorRequest(msg, sender)
 }
 
+func (msg MessageReportsPlusPrecursorRequest[RI]) processFromObserver(o *oracleState[RI], observer commontypes.OracleID) {
+	o.chObserverToReportAttestation <- MessageToReportAttestationFromObserverWithSender[RI]{msg, observer}
+}
+
+func (msg MessageReportsPlusPrecursorRequest[RI]) processReportAttestationFromObserver(repatt *reportAttestationState[RI], observer commontypes.OracleID) {
+	repatt.messageReportsPlusPrecursorRequestFromObserver(msg, observer)
+}
+
 type MessageReportsPlusPrecursor[RI any] struct {
 	RequestHandle        types.RequestHandle // actual handle for outbound message, sentinel for inbound
 	SeqNr                uint64
@@ -419,6 +438,16 @@ func (msg MessageBlockSyncRequest[RI]) processStateSync(stasy *stateSyncState[RI
 	stasy.messageBlockSyncRequest(msg, sender)
 }
 
+func (msg MessageBlockSyncRequest[RI]) processFromObserver(o *oracleState[RI], observer commontypes.OracleID) {
+	o.chObserverToStateSync <- MessageToStateSyncFromObserverWithSender[RI]{msg, observer}
+}
+
+// messageBlockSyncRequest only responds to the sender, so we can handle
+// requests from observers alike.
+func (msg MessageBlockSyncRequest[RI]) processStateSyncFromObserver(stasy *stateSyncState[RI], observer commontypes.OracleID) {
+	stasy.messageBlockSyncRequest(msg, observer)
+}
+
 type MessageStateSyncSummary[RI any] struct {
 	LowestPersistedSeqNr  uint64
 	HighestCommittedSeqNr uint64
@@ -492,6 +521,16 @@ func (msg MessageTreeSyncChunkRequest[RI]) processStateSync(stasy *stateSyncStat
 	stasy.messageTreeSyncChunkRequest(msg, sender)
 }
 
+func (msg MessageTreeSyncChunkRequest[RI]) processFromObserver(o *oracleState[RI], observer commontypes.OracleID) {
+	o.chObserverToStateSync <- MessageToStateSyncFromObserverWithSender[RI]{msg, observer}
+}
+
+// messageTreeSyncChunkRequest only responds to the sender, so we can handle
+// requests from observers alike.
+func (msg MessageTreeSyncChunkRequest[RI]) processStateSyncFromObserver(stasy *stateSyncState[RI], observer commontypes.OracleID) {
+	stasy.messageTreeSyncChunkRequest(msg, observer)
+}
+
 type MessageTreeSyncChunkResponse[RI any] struct {
 	RequestHandle       types.RequestHandle // actual handle for outbound message, sentinel for inbound
 	ToSeqNr             uint64
@@ -547,12 +586,19 @@ type MessageBlobOffer[RI any] struct {
 	ChunkDigestsRoot mt.Digest
 	PayloadLength    uint64
 	ExpirySeqNr      uint64
+	// ExpiryKey is only set for blobs that expire once it is deleted from the
+	// KeyValueState.
+	ExpiryKey    []byte
+	ErasureCoded bool
+	// ChunkDigests is only set for erasure coded blobs. Every recipient needs
+	// all chunk digests to serve its fragment to others.
+	ChunkDigests []BlobChunkDigest
 }
 
 var _ MessageToBlobExchange[struct{}] = MessageBlobOffer[struct{}]{}
 
 func (msg MessageBlobOffer[RI]) CheckSize(n int, f int, limits ocr3_1types.ReportingPluginLimits, _ int, config ocr3_1config.PublicConfig) bool {
-	return true
+	return len(msg.ExpiryKey) <= ocr3_1types.MaxBlobExpiryKeyBytes
 }
 
 func (msg MessageBlobOffer[RI]) process(o *oracleState[RI], sender commontypes.OracleID) {
@@ -635,3 +681,41 @@ func (msg MessageBlobChunkResponse[RI]) process(o *oracleState[RI], sender commo
 func (msg MessageBlobChunkResponse[RI]) processBlobExchange(bex *blobExchangeState[RI], sender commontypes.OracleID) {
 	bex.messageBlobChunkResponse(msg, sender)
 }
+
+type MessageSharedSecretRotationEndorsement[RI any] struct {
+	RotationNumber uint64
+	RotationDigest ocr3_1config.SharedSecretRotationDigest
+	Signature      SharedSecretRotationEndorsementSignature
+}
+
+var _ MessageToSharedSecretRotation[struct{}] = MessageSharedSecretRotationEndorsement[struct{}]{}
+
+func (msg MessageSharedSecretRotationEndorsement[RI]) CheckSize(n int, f int, _ ocr3_1types.ReportingPluginLimits, _ int, _ ocr3_1config.PublicConfig) bool {
+	return len(msg.Signature) == ed25519.SignatureSize
+}
+
+func (msg MessageSharedSecretRotationEndorsement[RI]) process(o *oracleState[RI], sender commontypes.OracleID) {
+	o.chNetToSharedSecretRotation <- MessageToSharedSecretRotationWithSender[RI]{msg, sender}
+}
+
+func (msg MessageSharedSecretRotationEndorsement[RI]) processSharedSecretRotation(ssr *sharedSecretRotationState[RI], sender commontypes.OracleID) {
+	ssr.messageSharedSecretRotationEndorsement(msg, sender)
+}
+
+type MessageSharedSecretRotationCertificate[RI any] struct {
+	CertifiedSharedSecretRotation CertifiedSharedSecretRotation
+}
+
+var _ MessageToSharedSecretRotation[struct{}] = MessageSharedSecretRotationCertificate[struct{}]{}
+
+func (msg MessageSharedSecretRotationCertificate[RI]) CheckSize(n int, f int, _ ocr3_1types.ReportingPluginLimits, _ int, _ ocr3_1config.PublicConfig) bool {
+	return msg.CertifiedSharedSecretRotation.CheckSize(n, f)
+}
+
+func (msg MessageSharedSecretRotationCertificate[RI]) process(o *oracleState[RI], sender commontypes.OracleID) {
+	o.chNetToSharedSecretRotation <- MessageToSharedSecretRotationWithSender[RI]{msg, sender}
+}
+
+func (msg MessageSharedSecretRotationCertificate[RI]) processSharedSecretRotation(ssr *sharedSecretRotationState[RI], sender commontypes.OracleID) {
+	ssr.messageSharedSecretRotationCertificate(msg, sender)
+}
@@ -0,0 +1,470 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol/requestergadget"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"github.com/smartcontractkit/libocr/subprocesses"
+)
+
+// Observers are peers outside of the oracle set that follow the committed
+// state and reports of a protocol instance. Oracles address observers by
+// OracleIDs starting at N. Observers never sign anything and never count
+// towards any quorum.
+
+const (
+	observerPollInterval = 250 * time.Millisecond
+
+	observerReportsPlusPrecursorMinRequestToSameOracleInterval = 10 * time.Millisecond
+
+	// oracles only retain ReportsPlusPrecursors for a few rounds, there is
+	// no point in requesting older ones
+	observerMaxReportsPlusPrecursorLag = uint64(expiryMaxRounds)
+
+	observerReportsPlusPrecursorBufferSize = 16
+)
+
+// MessageFromObserver is implemented by the messages that oracles accept from
+// observers. Observers only ever request committed state and reports. Oracles
+// route these requests through separate channels, so that the handlers for
+// messages from oracles never see senders >= N.
+type MessageFromObserver[RI any] interface {
+	Message[RI]
+
+	processFromObserver(o *oracleState[RI], observer commontypes.OracleID)
+}
+
+type MessageToStateSyncFromObserver[RI any] interface {
+	MessageFromObserver[RI]
+
+	processStateSyncFromObserver(stasy *stateSyncState[RI], observer commontypes.OracleID)
+}
+
+type MessageToStateSyncFromObserverWithSender[RI any] struct {
+	msg      MessageToStateSyncFromObserver[RI]
+	observer commontypes.OracleID
+}
+
+type MessageToReportAttestationFromObserver[RI any] interface {
+	MessageFromObserver[RI]
+
+	processReportAttestationFromObserver(repatt *reportAttestationState[RI], observer commontypes.OracleID)
+}
+
+type MessageToReportAttestationFromObserverWithSender[RI any] struct {
+	msg      MessageToReportAttestationFromObserver[RI]
+	observer commontypes.OracleID
+}
+
+// IsObserverRequest returns true iff msg may be processed when sent by an
+// observer.
+func IsObserverRequest[RI any](msg Message[RI]) bool {
+	_, ok := msg.(MessageFromObserver[RI])
+	return ok
+}
+
+// IsBroadcastToObservers returns true iff msg is sent to observers in
+// addition to oracles when broadcast. Observers learn how far the oracles have
+// progressed from their state sync summaries.
+func IsBroadcastToObservers[RI any](msg Message[RI]) bool {
+	_, ok := msg.(MessageStateSyncSummary[RI])
+	return ok
+}
+
+// RunObserver follows the protocol instance described by config as observer
+// id (>= config.N()). It replicates the committed KeyValueState into kvDb
+// using state sync, which verifies the attestations of all blocks against the
+// signer set in config. If consumer is not nil, the ReportsPlusPrecursors of
+// committed sequence numbers are fetched from the oracles and passed to it.
+func RunObserver[RI any](
+	ctx context.Context,
+
+	config ocr3_1config.PublicConfig,
+	consumer ocr3_1types.ObserverConsumer,
+	id commontypes.OracleID,
+	kvDb KeyValueDatabase,
+	latencyHints OracleLatencyHints,
+	logger loghelper.LoggerWithContext,
+	netEndpoint NetworkEndpoint[RI],
+) {
+	subs := subprocesses.Subprocesses{}
+	defer subs.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chNetToStateSync := make(chan MessageToStateSyncWithSender[RI])
+	chNetToObserverReports := make(chan observerReportsPlusPrecursorWithSender[RI], observerReportsPlusPrecursorBufferSize)
+
+	subs.Go(func() {
+		RunStateSync[RI](
+			ctx,
+
+			chNetToStateSync,
+			nil, // observers don't serve observers
+			nil, // observers don't generate outcomes
+			nil, // observers don't attest reports
+			ocr3_1config.SharedConfig{config, nil},
+			nil, // unused by state sync
+			id,
+			kvDb,
+			latencyHints,
+			logger,
+			observerNetSender[RI]{netEndpoint},
+			nil, // unused by state sync
+		)
+	})
+
+	if consumer != nil {
+		subs.Go(func() {
+			newObserverReportsState[RI](
+				ctx,
+				chNetToObserverReports,
+				config,
+				consumer,
+				kvDb,
+				latencyHints,
+				logger,
+				netEndpoint,
+			).run()
+		})
+	}
+
+	chNet := netEndpoint.Receive()
+
+	chDone := ctx.Done()
+	for {
+		select {
+		case msg := <-chNet:
+			if !(0 <= int(msg.Sender) && int(msg.Sender) < config.N()) {
+				logger.Critical("msg.Sender out of bounds. This should *never* happen.", commontypes.LogFields{
+					"sender": msg.Sender,
+					"n":      config.N(),
+				})
+				break
+			}
+			switch m := msg.Msg.(type) {
+			case MessageReportsPlusPrecursor[RI]:
+				select {
+				case chNetToObserverReports <- observerReportsPlusPrecursorWithSender[RI]{m, msg.Sender}:
+				default:
+					logger.Debug("Observer: dropping MessageReportsPlusPrecursor, buffer full", commontypes.LogFields{
+						"sender":   msg.Sender,
+						"msgSeqNr": m.SeqNr,
+					})
+				}
+			case MessageToStateSync[RI]:
+				select {
+				case chNetToStateSync <- MessageToStateSyncWithSender[RI]{m, msg.Sender}:
+				case <-chDone:
+				}
+			default:
+				logger.Debug("Observer: dropping message not meant for observers", commontypes.LogFields{
+					"sender": msg.Sender,
+				})
+			}
+		case <-chDone:
+		}
+
+		// ensure prompt exit
+		select {
+		case <-chDone:
+			logger.Debug("Observer: winding down", nil)
+			cancel()
+			subs.Wait()
+			logger.Debug("Observer: exiting", nil)
+			return
+		default:
+		}
+	}
+}
+
+// observerNetSender prevents observers from broadcasting. In particular,
+// observers don't send state sync summaries, so oracles never consider them
+// for state sync requests.
+type observerNetSender[RI any] struct {
+	netSender NetworkSender[RI]
+}
+
+var _ NetworkSender[struct{}] = observerNetSender[struct{}]{}
+
+func (s observerNetSender[RI]) SendTo(msg Message[RI], to commontypes.OracleID) {
+	s.netSender.SendTo(msg, to)
+}
+
+func (s observerNetSender[RI]) Broadcast(Message[RI]) {}
+
+type observerReportsPlusPrecursorWithSender[RI any] struct {
+	msg    MessageReportsPlusPrecursor[RI]
+	sender commontypes.OracleID
+}
+
+type observerReportsState[RI any] struct {
+	ctx context.Context
+
+	chNetToObserverReports <-chan observerReportsPlusPrecursorWithSender[RI]
+	config                 ocr3_1config.PublicConfig
+	consumer               ocr3_1types.ObserverConsumer
+	keyValueState          ocr3_1types.HistoricalKeyValueState
+	kvDb                   KeyValueDatabase
+	logger                 loghelper.LoggerWithContext
+	netSender              NetworkSender[RI]
+
+	// nextSeqNr is the next sequence number to pass to the consumer
+	nextSeqNr             uint64
+	highestCommittedSeqNr uint64
+	reportsPlusPrecursors map[uint64]ocr3_1types.ReportsPlusPrecursor
+
+	requesterGadget *requestergadget.RequesterGadget[uint64]
+
+	tPoll <-chan time.Time
+}
+
+func newObserverReportsState[RI any](
+	ctx context.Context,
+	chNetToObserverReports <-chan observerReportsPlusPrecursorWithSender[RI],
+	config ocr3_1config.PublicConfig,
+	consumer ocr3_1types.ObserverConsumer,
+	kvDb KeyValueDatabase,
+	latencyHints OracleLatencyHints,
+	logger loghelper.LoggerWithContext,
+	netSender NetworkSender[RI],
+) *observerReportsState[RI] {
+	obs := &observerReportsState[RI]{
+		ctx,
+
+		chNetToObserverReports,
+		config,
+		consumer,
+		NewHistoricalKeyValueState(kvDb, config),
+		kvDb,
+		logger.MakeUpdated(commontypes.LogFields{"proto": "observer/reports"}),
+		netSender,
+
+		0,
+		0,
+		map[uint64]ocr3_1types.ReportsPlusPrecursor{},
+
+		nil, // defined right below
+
+		time.After(0),
+	}
+	obs.requesterGadget = requestergadget.NewRequesterGadget[uint64](
+		config.N(),
+		observerReportsPlusPrecursorMinRequestToSameOracleInterval,
+		obs.sendReportsPlusPrecursorRequest,
+		obs.getPendingReportsPlusPrecursors,
+		obs.getReportsPlusPrecursorSeeders,
+		latencyFn(latencyHints),
+	)
+	return obs
+}
+
+func (obs *observerReportsState[RI]) run() {
+	for {
+		select {
+		case msg := <-obs.chNetToObserverReports:
+			obs.messageReportsPlusPrecursor(msg.msg, msg.sender)
+		case <-obs.tPoll:
+			obs.eventTPollTimeout()
+		case <-obs.requesterGadget.Ticker():
+			obs.requesterGadget.Tick()
+		case <-obs.ctx.Done():
+		}
+
+		// ensure prompt exit
+		select {
+		case <-obs.ctx.Done():
+			obs.logger.Info("ObserverReports: exiting", nil)
+			return
+		default:
+		}
+	}
+}
+
+func (obs *observerReportsState[RI]) eventTPollTimeout() {
+	defer func() {
+		obs.tPoll = time.After(observerPollInterval)
+	}()
+
+	tx, err := obs.kvDb.NewReadTransactionUnchecked()
+	if err != nil {
+		obs.logger.Warn("failed to create read transaction", commontypes.LogFields{
+			"error": err,
+		})
+		return
+	}
+	lowestRetainedSeqNr, highestCommittedSeqNr, err := retainedSeqNrs(tx, obs.config)
+	tx.Discard()
+	if err != nil {
+		obs.logger.Debug("committed state is not available yet", commontypes.LogFields{
+			"error": err,
+		})
+		return
+	}
+
+	if highestCommittedSeqNr <= obs.highestCommittedSeqNr {
+		return
+	}
+	obs.highestCommittedSeqNr = highestCommittedSeqNr
+
+	// the genesis block belongs to the previous instance
+	minSeqNr := max(lowestRetainedSeqNr, genesisSeqNr(obs.config)+1)
+	if highestCommittedSeqNr >= observerMaxReportsPlusPrecursorLag {
+		minSeqNr = max(minSeqNr, highestCommittedSeqNr-observerMaxReportsPlusPrecursorLag+1)
+	}
+	if obs.nextSeqNr < minSeqNr {
+		if obs.nextSeqNr != 0 {
+			obs.logger.Warn("skipping ReportsPlusPrecursors that oracles no longer retain", commontypes.LogFields{
+				"fromSeqNr": obs.nextSeqNr,
+				"toSeqNr":   minSeqNr - 1,
+			})
+		}
+		for seqNr := range obs.reportsPlusPrecursors {
+			if seqNr < minSeqNr {
+				delete(obs.reportsPlusPrecursors, seqNr)
+			}
+		}
+		obs.nextSeqNr = minSeqNr
+	}
+
+	obs.requesterGadget.PleaseRecheckPendingItems()
+	obs.tryDeliver()
+}
+
+func (obs *observerReportsState[RI]) getPendingReportsPlusPrecursors() []uint64 {
+	var pending []uint64
+	for seqNr := obs.nextSeqNr; obs.nextSeqNr != 0 && seqNr <= obs.highestCommittedSeqNr; seqNr++ {
+		if _, ok := obs.reportsPlusPrecursors[seqNr]; !ok {
+			pending = append(pending, seqNr)
+		}
+	}
+	return pending
+}
+
+func (obs *observerReportsState[RI]) getReportsPlusPrecursorSeeders(uint64) map[commontypes.OracleID]struct{} {
+	seeders := make(map[commontypes.OracleID]struct{})
+	for i := 0; i < obs.config.N(); i++ {
+		seeders[commontypes.OracleID(i)] = struct{}{}
+	}
+	return seeders
+}
+
+func (obs *observerReportsState[RI]) sendReportsPlusPrecursorRequest(seqNr uint64, target commontypes.OracleID) (*requestergadget.RequestInfo, bool) {
+	obs.logger.Debug("sending MessageReportsPlusPrecursorRequest", commontypes.LogFields{
+		"seqNr":  seqNr,
+		"target": target,
+	})
+	obs.netSender.SendTo(MessageReportsPlusPrecursorRequest[RI]{
+		types.EmptyRequestHandleForOutboundRequest,
+		seqNr,
+	}, target)
+	// matches the expiry of the request in the serializing endpoint
+	return &requestergadget.RequestInfo{
+		time.Now().Add(3 * obs.config.GetDeltaReportsPlusPrecursorRequest()),
+	}, true
+}
+
+func (obs *observerReportsState[RI]) messageReportsPlusPrecursor(msg MessageReportsPlusPrecursor[RI], sender commontypes.OracleID) {
+	if !obs.requesterGadget.CheckAndMarkResponse(msg.SeqNr, sender) {
+		obs.logger.Debug("dropping unexpected MessageReportsPlusPrecursor", commontypes.LogFields{
+			"sender":   sender,
+			"msgSeqNr": msg.SeqNr,
+		})
+		return
+	}
+
+	if _, ok := obs.reportsPlusPrecursors[msg.SeqNr]; ok || msg.SeqNr < obs.nextSeqNr {
+		obs.requesterGadget.MarkGoodResponse(msg.SeqNr, sender)
+		return
+	}
+
+	reportsPlusPrecursorDigest, ok := obs.readReportsPlusPrecursorDigest(msg.SeqNr)
+	if !ok {
+		return
+	}
+
+	actualReportsPlusPrecursorDigest := MakeReportsPlusPrecursorDigest(
+		obs.config.ConfigDigest,
+		msg.SeqNr,
+		msg.ReportsPlusPrecursor,
+	)
+	if reportsPlusPrecursorDigest != actualReportsPlusPrecursorDigest {
+		obs.logger.Warn("dropping MessageReportsPlusPrecursor with mismatching digest", commontypes.LogFields{
+			"sender":                             sender,
+			"msgSeqNr":                           msg.SeqNr,
+			"expectedReportsPlusPrecursorDigest": reportsPlusPrecursorDigest,
+			"actualReportsPlusPrecursorDigest":   actualReportsPlusPrecursorDigest,
+		})
+		obs.requesterGadget.MarkBadResponse(msg.SeqNr, sender)
+		return
+	}
+
+	obs.requesterGadget.MarkGoodResponse(msg.SeqNr, sender)
+	obs.reportsPlusPrecursors[msg.SeqNr] = msg.ReportsPlusPrecursor
+	obs.tryDeliver()
+}
+
+// readReportsPlusPrecursorDigest reads the ReportsPlusPrecursorDigest from the
+// attested block for seqNr, which state sync has already verified.
+func (obs *observerReportsState[RI]) readReportsPlusPrecursorDigest(seqNr uint64) (ReportsPlusPrecursorDigest, bool) {
+	tx, err := obs.kvDb.NewReadTransactionUnchecked()
+	if err != nil {
+		obs.logger.Warn("failed to create read transaction", commontypes.LogFields{
+			"error": err,
+		})
+		return ReportsPlusPrecursorDigest{}, false
+	}
+	defer tx.Discard()
+
+	astb, err := tx.ReadAttestedStateTransitionBlock(seqNr)
+	if err != nil {
+		obs.logger.Warn("failed to read attested state transition block", commontypes.LogFields{
+			"seqNr": seqNr,
+			"error": err,
+		})
+		return ReportsPlusPrecursorDigest{}, false
+	}
+	if astb.StateTransitionBlock.SeqNr() != seqNr {
+		obs.logger.Warn("attested state transition block is not persisted, cannot check ReportsPlusPrecursor", commontypes.LogFields{
+			"seqNr": seqNr,
+		})
+		return ReportsPlusPrecursorDigest{}, false
+	}
+	return astb.StateTransitionBlock.ReportsPlusPrecursorDigest, true
+}
+
+func (obs *observerReportsState[RI]) tryDeliver() {
+	for {
+		reportsPlusPrecursor, ok := obs.reportsPlusPrecursors[obs.nextSeqNr]
+		if !ok {
+			return
+		}
+		delete(obs.reportsPlusPrecursors, obs.nextSeqNr)
+
+		err := obs.consumer.ObservedReportsPlusPrecursor(
+			obs.ctx,
+			obs.config.ConfigDigest,
+			obs.nextSeqNr,
+			reportsPlusPrecursor,
+			obs.keyValueState,
+		)
+		if err != nil {
+			obs.logger.Warn("ObserverConsumer.ObservedReportsPlusPrecursor returned error", commontypes.LogFields{
+				"seqNr": obs.nextSeqNr,
+				"error": err,
+			})
+		}
+		obs.nextSeqNr++
+
+		if obs.ctx.Err() != nil {
+			return
+		}
+	}
+}
@@ -30,6 +30,7 @@ func RunOracle[RI any](
 	database Database,
 	id commontypes.OracleID,
 	kvDb KeyValueDatabase,
+	latencyHints OracleLatencyHints,
 	limits ocr3_1types.ReportingPluginLimits,
 	localConfig types.LocalConfig,
 	logger loghelper.LoggerWithContext,
@@ -38,26 +39,32 @@ func RunOracle[RI any](
 	offchainKeyring types.OffchainKeyring,
 	onchainKeyring ocr3types.OnchainKeyring[RI],
 	reportingPlugin ocr3_1types.ReportingPlugin[RI],
+	// nil if pending transmissions should not survive restarts
+	reportInfoCodec ocr3types.ReportInfoCodec[RI],
+	sharedSecretRotationSource SharedSecretRotationSource,
 	telemetrySender TelemetrySender,
 ) {
 	o := oracleState[RI]{
 		ctx: ctx,
 
-		blobEndpointWrapper: blobEndpointWrapper,
-		config:              config,
-		contractTransmitter: contractTransmitter,
-		database:            database,
-		id:                  id,
-		kvDb:                kvDb,
-		limits:              limits,
-		localConfig:         localConfig,
-		logger:              logger,
-		metricsRegisterer:   metricsRegisterer,
-		netEndpoint:         netEndpoint,
-		offchainKeyring:     offchainKeyring,
-		onchainKeyring:      onchainKeyring,
-		reportingPlugin:     reportingPlugin,
-		telemetrySender:     telemetrySender,
+		blobEndpointWrapper:        blobEndpointWrapper,
+		config:                     config,
+		contractTransmitter:        contractTransmitter,
+		database:                   database,
+		id:                         id,
+		kvDb:                       kvDb,
+		latencyHints:               latencyHints,
+		limits:                     limits,
+		localConfig:                localConfig,
+		logger:                     logger,
+		metricsRegisterer:          metricsRegisterer,
+		netEndpoint:                netEndpoint,
+		offchainKeyring:            offchainKeyring,
+		onchainKeyring:             onchainKeyring,
+		reportingPlugin:            reportingPlugin,
+		reportInfoCodec:            reportInfoCodec,
+		sharedSecretRotationSource: sharedSecretRotationSource,
+		telemetrySender:            telemetrySender,
 	}
 	o.run()
 }
@@ -65,35 +72,42 @@ func RunOracle[RI any](
 type oracleState[RI any] struct {
 	ctx context.Context
 
-	blobEndpointWrapper *BlobEndpointWrapper
-	config              ocr3_1config.SharedConfig
-	contractTransmitter ocr3types.ContractTransmitter[RI]
-	database            Database
-	id                  commontypes.OracleID
-	kvDb                KeyValueDatabase
-	limits              ocr3_1types.ReportingPluginLimits
-	localConfig         types.LocalConfig
-	logger              loghelper.LoggerWithContext
-	metricsRegisterer   prometheus.Registerer
-	netEndpoint         NetworkEndpoint[RI]
-	offchainKeyring     types.OffchainKeyring
-	onchainKeyring      ocr3types.OnchainKeyring[RI]
-	reportingPlugin     ocr3_1types.ReportingPlugin[RI]
-	telemetrySender     TelemetrySender
-
-	chNetToPacemaker         chan<- MessageToPacemakerWithSender[RI]
-	chNetToOutcomeGeneration chan<- MessageToOutcomeGenerationWithSender[RI]
-	chNetToReportAttestation chan<- MessageToReportAttestationWithSender[RI]
-	chNetToStateSync         chan<- MessageToStateSyncWithSender[RI]
-	chNetToBlobExchange      chan<- MessageToBlobExchangeWithSender[RI]
-	childCancel              context.CancelFunc
-	childCtx                 context.Context
-	epoch                    uint64
-	subprocesses             subprocesses.Subprocesses
+	blobEndpointWrapper        *BlobEndpointWrapper
+	config                     ocr3_1config.SharedConfig
+	contractTransmitter        ocr3types.ContractTransmitter[RI]
+	database                   Database
+	id                         commontypes.OracleID
+	kvDb                       KeyValueDatabase
+	latencyHints               OracleLatencyHints
+	limits                     ocr3_1types.ReportingPluginLimits
+	localConfig                types.LocalConfig
+	logger                     loghelper.LoggerWithContext
+	metricsRegisterer          prometheus.Registerer
+	netEndpoint                NetworkEndpoint[RI]
+	offchainKeyring            types.OffchainKeyring
+	onchainKeyring             ocr3types.OnchainKeyring[RI]
+	reportingPlugin            ocr3_1types.ReportingPlugin[RI]
+	reportInfoCodec            ocr3types.ReportInfoCodec[RI]
+	sharedSecretRotationSource SharedSecretRotationSource
+	telemetrySender            TelemetrySender
+
+	chNetToPacemaker              chan<- MessageToPacemakerWithSender[RI]
+	chNetToOutcomeGeneration      chan<- MessageToOutcomeGenerationWithSender[RI]
+	chNetToReportAttestation      chan<- MessageToReportAttestationWithSender[RI]
+	chNetToStateSync              chan<- MessageToStateSyncWithSender[RI]
+	chObserverToReportAttestation chan<- MessageToReportAttestationFromObserverWithSender[RI]
+	chObserverToStateSync         chan<- MessageToStateSyncFromObserverWithSender[RI]
+	chNetToBlobExchange           chan<- MessageToBlobExchangeWithSender[RI]
+	chNetToSharedSecretRotation   chan<- MessageToSharedSecretRotationWithSender[RI]
+	childCancel                   context.CancelFunc
+	childCtx                      context.Context
+	epoch                         uint64
+	subprocesses                  subprocesses.Subprocesses
 }
 
 // run ensures safe shutdown of the Oracle's "child routines",
-// (Pacemaker, OutcomeGeneration, Attestation, State, Transmission, and BlobExchange) upon
+// (Pacemaker, OutcomeGeneration, Attestation, State, Transmission, BlobExchange, and
+// SharedSecretRotation) upon
 // o.ctx.Done()
 //
 // Here is a graph of the various channels involved and what they
@@ -163,6 +177,9 @@ func (o *oracleState[RI]) run() {
 	chNetToReportAttestation := make(chan MessageToReportAttestationWithSender[RI])
 	o.chNetToReportAttestation = chNetToReportAttestation
 
+	chObserverToReportAttestation := make(chan MessageToReportAttestationFromObserverWithSender[RI])
+	o.chObserverToReportAttestation = chObserverToReportAttestation
+
 	chOutcomeGenerationToReportAttestation := make(chan EventToReportAttestation[RI])
 
 	chReportAttestationToTransmission := make(chan EventToTransmission[RI])
@@ -170,6 +187,9 @@ func (o *oracleState[RI]) run() {
 	chNetToStateSync := make(chan MessageToStateSyncWithSender[RI])
 	o.chNetToStateSync = chNetToStateSync
 
+	chObserverToStateSync := make(chan MessageToStateSyncFromObserverWithSender[RI])
+	o.chObserverToStateSync = chObserverToStateSync
+
 	chOutcomeGenerationToStateSync := make(chan EventToStateSync[RI])
 	chReportAttestationToStateSync := make(chan EventToStateSync[RI])
 
@@ -178,6 +198,9 @@ func (o *oracleState[RI]) run() {
 
 	chOutcomeGenerationToBlobExchange := make(chan EventToBlobExchange[RI])
 
+	chNetToSharedSecretRotation := make(chan MessageToSharedSecretRotationWithSender[RI])
+	o.chNetToSharedSecretRotation = chNetToSharedSecretRotation
+
 	// communication between blob exchange and blob endpoint
 	chBlobBroadcastRequest := make(chan blobBroadcastRequest)
 	chBlobFetchRequest := make(chan blobFetchRequest)
@@ -189,7 +212,7 @@ func (o *oracleState[RI]) run() {
 
 	defer o.kvDb.Close()
 
-	paceState, cert, err := o.restoreFromDatabase()
+	paceState, cert, sharedSecretRotations, sharedSecretRotationEndorsement, err := o.restoreFromDatabase()
 	if err != nil {
 		o.logger.Error("restoreFromDatabase returned an error, exiting oracle", commontypes.LogFields{
 			"error": err,
@@ -202,9 +225,17 @@ func (o *oracleState[RI]) run() {
 
 		chBlobBroadcastRequest,
 		chBlobFetchRequest,
+
+		o.config,
+		o.id,
+		o.kvDb,
+		o.limits,
 	}
 	o.blobEndpointWrapper.setBlobEndpoint(&blobEndpoint) // pass through to plugin
 
+	sharedSecretSchedule := NewSharedSecretSchedule(&o.config)
+	sharedSecretSchedule.restore(sharedSecretRotations, o.id, o.offchainKeyring, o.logger)
+
 	o.subprocesses.Go(func() {
 		RunPacemaker[RI](
 			o.childCtx,
@@ -220,6 +251,7 @@ func (o *oracleState[RI]) run() {
 			o.metricsRegisterer,
 			o.netEndpoint,
 			o.offchainKeyring,
+			sharedSecretSchedule,
 			o.telemetrySender,
 
 			paceState,
@@ -256,6 +288,7 @@ func (o *oracleState[RI]) run() {
 			o.childCtx,
 
 			chNetToReportAttestation,
+			chObserverToReportAttestation,
 			chOutcomeGenerationToReportAttestation,
 			chReportAttestationToStateSync,
 			chReportAttestationToTransmission,
@@ -274,12 +307,14 @@ func (o *oracleState[RI]) run() {
 			o.childCtx,
 
 			chNetToStateSync,
+			chObserverToStateSync,
 			chOutcomeGenerationToStateSync,
 			chReportAttestationToStateSync,
 			o.config,
 			o.database,
 			o.id,
 			o.kvDb,
+			o.latencyHints,
 			o.logger,
 			o.netEndpoint,
 			o.reportingPlugin,
@@ -293,10 +328,31 @@ func (o *oracleState[RI]) run() {
 			chReportAttestationToTransmission,
 			o.config,
 			o.contractTransmitter,
+			o.database,
 			o.id,
 			o.localConfig,
 			o.logger,
 			o.reportingPlugin,
+			o.reportInfoCodec,
+			sharedSecretSchedule,
+		)
+	})
+
+	o.subprocesses.Go(func() {
+		RunSharedSecretRotation[RI](
+			o.childCtx,
+
+			chNetToSharedSecretRotation,
+			o.config,
+			o.database,
+			o.id,
+			o.localConfig,
+			o.logger,
+			o.netEndpoint,
+			o.offchainKeyring,
+			o.sharedSecretRotationSource,
+			sharedSecretRotationEndorsement,
+			sharedSecretSchedule,
 		)
 	})
 
@@ -313,6 +369,7 @@ func (o *oracleState[RI]) run() {
 			o.config,
 			o.kvDb,
 			o.id,
+			o.latencyHints,
 			o.limits,
 			o.localConfig,
 			o.logger,
@@ -334,9 +391,13 @@ func (o *oracleState[RI]) run() {
 		case msg := <-chNet:
 			// This bounds check should never trigger since it's the netEndpoint's
 			// responsibility to only provide valid senders. We perform it for
-			// defense-in-depth.
+			// defense-in-depth. Observers (OracleIDs >= N) may only send
+			// requests for committed state and reports, which never reach the
+			// handlers for messages from oracles.
 			if 0 <= int(msg.Sender) && int(msg.Sender) < o.config.N() {
 				msg.Msg.process(o, msg.Sender)
+			} else if observerMsg, ok := msg.Msg.(MessageFromObserver[RI]); ok && int(msg.Sender) >= o.config.N() {
+				observerMsg.processFromObserver(o, msg.Sender)
 			} else {
 				o.logger.Critical("msg.Sender out of bounds. This should *never* happen.", commontypes.LogFields{
 					"sender": msg.Sender,
@@ -385,7 +446,7 @@ func tryUntilSuccess[T any](ctx context.Context, logger commontypes.Logger, retr
 	}
 }
 
-func (o *oracleState[RI]) restoreFromDatabase() (PacemakerState, CertifiedPrepareOrCommit, error) {
+func (o *oracleState[RI]) restoreFromDatabase() (PacemakerState, CertifiedPrepareOrCommit, []CertifiedSharedSecretRotation, *SharedSecretRotationEndorsement, error) {
 	const retryPeriod = 5 * time.Second
 
 	paceState, err := tryUntilSuccess[PacemakerState](
@@ -399,7 +460,7 @@ func (o *oracleState[RI]) restoreFromDatabase() (PacemakerState, CertifiedPrepar
 		},
 	)
 	if err != nil {
-		return PacemakerState{}, nil, err
+		return PacemakerState{}, nil, nil, nil, err
 	}
 
 	o.logger.Info("restoreFromDatabase: successfully restored pacemaker state", commontypes.LogFields{
@@ -417,7 +478,7 @@ func (o *oracleState[RI]) restoreFromDatabase() (PacemakerState, CertifiedPrepar
 		},
 	)
 	if err != nil {
-		return PacemakerState{}, nil, err
+		return PacemakerState{}, nil, nil, nil, err
 	}
 
 	if cert != nil {
@@ -429,5 +490,33 @@ func (o *oracleState[RI]) restoreFromDatabase() (PacemakerState, CertifiedPrepar
 		cert = GenesisCertifiedPrepareOrCommit(o.config.PublicConfig)
 	}
 
-	return paceState, cert, nil
+	sharedSecretRotations, err := tryUntilSuccess[[]CertifiedSharedSecretRotation](
+		o.ctx,
+		o.logger,
+		retryPeriod,
+		o.localConfig.DatabaseTimeout,
+		"Database.ReadSharedSecretRotations",
+		func(ctx context.Context) ([]CertifiedSharedSecretRotation, error) {
+			return o.database.ReadSharedSecretRotations(ctx, o.config.ConfigDigest)
+		},
+	)
+	if err != nil {
+		return PacemakerState{}, nil, nil, nil, err
+	}
+
+	sharedSecretRotationEndorsement, err := tryUntilSuccess[*SharedSecretRotationEndorsement](
+		o.ctx,
+		o.logger,
+		retryPeriod,
+		o.localConfig.DatabaseTimeout,
+		"Database.ReadSharedSecretRotationEndorsement",
+		func(ctx context.Context) (*SharedSecretRotationEndorsement, error) {
+			return o.database.ReadSharedSecretRotationEndorsement(ctx, o.config.ConfigDigest)
+		},
+	)
+	if err != nil {
+		return PacemakerState{}, nil, nil, nil, err
+	}
+
+	return paceState, cert, sharedSecretRotations, sharedSecretRotationEndorsement, nil
 }
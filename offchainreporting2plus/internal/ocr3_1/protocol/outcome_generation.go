@@ -369,7 +369,7 @@ func (outgen *outcomeGenerationState[RI]) eventNewEpochStart(ev EventNewEpochSta
 	outgen.epochCtx, outgen.epochCtxCancel = context.WithCancel(outgen.ctx)
 
 	outgen.sharedState.e = ev.Epoch
-	outgen.sharedState.l = Leader(outgen.sharedState.e, outgen.config.N(), outgen.config.LeaderSelectionKey())
+	outgen.sharedState.l = ev.Leader
 
 	outgen.logger = outgen.logger.MakeUpdated(commontypes.LogFields{
 		"e": outgen.sharedState.e,
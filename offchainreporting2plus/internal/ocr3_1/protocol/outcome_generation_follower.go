@@ -483,6 +483,14 @@ func (outgen *outcomeGenerationState[RI]) backgroundProposalStateTransition(
 	if !ok {
 		return
 	}
+	blobReleases := newStateTransitionBlobReleases(roundCtx.SeqNr, kvReadWriteTxn)
+	if err := blobReleases.prune(); err != nil {
+		logger.Warn("failed to prune blob releases", commontypes.LogFields{
+			"seqNr": roundCtx.SeqNr,
+			"error": err,
+		})
+		return
+	}
 	reportsPlusPrecursor, ok := callPluginFromOutcomeGenerationBackground[ocr3_1types.ReportsPlusPrecursor](
 		ctx,
 		logger,
@@ -495,10 +503,12 @@ func (outgen *outcomeGenerationState[RI]) backgroundProposalStateTransition(
 				roundCtx.SeqNr,
 				aq,
 				aos,
-				kvReadWriteTxn,
-				NewRoundBlobBroadcastFetcher(
+				&stateTransitionKeyValueState{kvReadWriteTxn, blobReleases},
+				NewStateTransitionBlobFetcher(
 					roundCtx.SeqNr,
 					outgen.blobBroadcastFetcher,
+					outgen.config,
+					blobReleases,
 				),
 			)
 		},
@@ -1145,6 +1155,7 @@ func (outgen *outcomeGenerationState[RI]) commit(commit CertifiedCommit) bool {
 		select {
 		case outgen.chOutcomeGenerationToReportAttestation <- EventNewCertifiedCommit[RI]{
 			commit.SeqNr(),
+			commit.CommitEpoch,
 			commit.ReportsPlusPrecursorDigest,
 		}:
 		case <-outgen.ctx.Done():
@@ -31,6 +31,7 @@ func RunPacemaker[RI any](
 	metricsRegisterer prometheus.Registerer,
 	netSender NetworkSender[RI],
 	offchainKeyring types.OffchainKeyring,
+	sharedSecretSchedule *SharedSecretSchedule,
 	telemetrySender TelemetrySender,
 
 	restoredState PacemakerState,
@@ -40,7 +41,7 @@ func RunPacemaker[RI any](
 		chPacemakerToOutcomeGeneration, chOutcomeGenerationToPacemaker,
 		config, database,
 		id, localConfig, logger, metricsRegisterer, netSender, offchainKeyring,
-		telemetrySender,
+		sharedSecretSchedule, telemetrySender,
 	)
 	pace.run(restoredState)
 }
@@ -57,6 +58,7 @@ func makePacemakerState[RI any](
 	metricsRegisterer prometheus.Registerer,
 	netSender NetworkSender[RI],
 	offchainKeyring types.OffchainKeyring,
+	sharedSecretSchedule *SharedSecretSchedule,
 	telemetrySender TelemetrySender,
 ) pacemakerState[RI] {
 	return pacemakerState[RI]{
@@ -73,6 +75,7 @@ func makePacemakerState[RI any](
 		metrics:                        newPacemakerMetrics(metricsRegisterer, logger),
 		netSender:                      netSender,
 		offchainKeyring:                offchainKeyring,
+		sharedSecretSchedule:           sharedSecretSchedule,
 		telemetrySender:                telemetrySender,
 
 		newEpochWishes: make([]uint64, config.N()),
@@ -93,6 +96,7 @@ type pacemakerState[RI any] struct {
 	metrics                        *pacemakerMetrics
 	netSender                      NetworkSender[RI]
 	offchainKeyring                types.OffchainKeyring
+	sharedSecretSchedule           *SharedSecretSchedule
 	telemetrySender                TelemetrySender
 	// Test use only: send testBlocker an event to halt the pacemaker event loop,
 	// send testUnblocker an event to resume it.
@@ -137,7 +141,7 @@ func (pace *pacemakerState[RI]) run(restoredState PacemakerState) {
 		pace.ne = restoredState.HighestSentNewEpochWish
 		pace.e = restoredState.Epoch
 	}
-	pace.l = Leader(pace.e, pace.config.N(), pace.config.LeaderSelectionKey())
+	pace.l = Leader(pace.e, pace.config.N(), pace.sharedSecretSchedule.StartEpoch(pace.e))
 
 	pace.tProgress = time.After(pace.config.DeltaProgress)
 
@@ -161,7 +165,7 @@ func (pace *pacemakerState[RI]) run(restoredState PacemakerState) {
 		}
 
 		select {
-		case nilOrChPacemakerToOutcomeGeneration <- EventNewEpochStart[RI]{pace.e}:
+		case nilOrChPacemakerToOutcomeGeneration <- EventNewEpochStart[RI]{pace.e, pace.l}:
 			pace.notifyOutcomeGenerationOfNewEpoch = false
 		case msg := <-pace.chNetToPacemaker:
 			msg.msg.processPacemaker(pace, msg.sender)
@@ -171,6 +175,8 @@ func (pace *pacemakerState[RI]) run(restoredState PacemakerState) {
 			pace.eventTResendTimeout()
 		case <-pace.tProgress:
 			pace.eventTProgressTimeout()
+		case <-pace.sharedSecretSchedule.lateAdoption():
+			pace.eventLateSharedSecretRotation()
 		case <-pace.testBlocker:
 			<-pace.testUnblocker
 		case <-chDone:
@@ -208,6 +214,24 @@ func (pace *pacemakerState[RI]) eventTProgressTimeout() {
 	pace.eventNewEpochRequest()
 }
 
+// eventLateSharedSecretRotation handles the adoption of a shared secret
+// rotation that is already active in the current epoch. Switching to a
+// different leader within an epoch could make us sign messages of two leaders
+// in the same epoch, so we instead move on to the next epoch, whose leader is
+// selected with the rotated secret.
+func (pace *pacemakerState[RI]) eventLateSharedSecretRotation() {
+	l := Leader(pace.e, pace.config.N(), pace.sharedSecretSchedule.StartEpoch(pace.e))
+	if l == pace.l {
+		return
+	}
+	pace.logger.Warn("adopted shared secret rotation changes leader of current epoch, requesting new epoch", commontypes.LogFields{
+		"epoch":     pace.e,
+		"leader":    pace.l,
+		"newLeader": l,
+	})
+	pace.eventNewEpochRequest()
+}
+
 func (pace *pacemakerState[RI]) eventNewEpochRequest() {
 	pace.tProgress = nil
 	epochPlusOne := pace.e + 1
@@ -302,7 +326,7 @@ func (pace *pacemakerState[RI]) messageNewEpochWish(msg MessageNewEpochWish[RI],
 		pace.logger.Debug("moving to new epoch", commontypes.LogFields{
 			"newEpoch": switchToEpoch,
 		})
-		l := Leader(switchToEpoch, pace.config.N(), pace.config.LeaderSelectionKey())
+		l := Leader(switchToEpoch, pace.config.N(), pace.sharedSecretSchedule.StartEpoch(switchToEpoch))
 		pace.e, pace.l = switchToEpoch, l // (e, l) ← (ē, leader(ē))
 		if pace.ne < pace.e {             // ne ← max{ne, e}
 			pace.ne = pace.e
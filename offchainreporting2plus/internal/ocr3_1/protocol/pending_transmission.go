@@ -0,0 +1,138 @@
+package protocol
+
+import (
+	"context"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/common/pendingtransmissions"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// If the reporting plugin implements ocr3types.ReportInfoCodec, pending
+// transmissions are persisted after ShouldAcceptAttestedReport has accepted a
+// report, and removed once the report has been handled at its scheduled time.
+// A restart in between thus doesn't drop the report: it is rescheduled at its
+// original time, unless it has expired or has been superseded by a report
+// with the same index and a higher seqNr.
+
+// pendingTransmissionDatabase adapts Database to a single config digest.
+type pendingTransmissionDatabase struct {
+	database     Database
+	configDigest types.ConfigDigest
+}
+
+var _ pendingtransmissions.Database[PendingTransmission] = pendingTransmissionDatabase{}
+
+func (db pendingTransmissionDatabase) ReadPendingTransmissions(ctx context.Context) ([]PendingTransmission, error) {
+	return db.database.ReadPendingTransmissions(ctx, db.configDigest)
+}
+
+func (db pendingTransmissionDatabase) WritePendingTransmission(ctx context.Context, pendingTransmission PendingTransmission) error {
+	return db.database.WritePendingTransmission(ctx, db.configDigest, pendingTransmission)
+}
+
+func (db pendingTransmissionDatabase) DeletePendingTransmission(ctx context.Context, key PendingTransmissionKey) error {
+	return db.database.DeletePendingTransmission(ctx, db.configDigest, key.SeqNr, key.Index)
+}
+
+// lastStageDelay returns the delay of the last oracle in the transmission
+// schedule of a report.
+func (t *transmissionState[RI]) lastStageDelay(transmissionScheduleOverride *ocr3types.TransmissionSchedule) time.Duration {
+	if transmissionScheduleOverride != nil {
+		var last time.Duration
+		for _, delay := range transmissionScheduleOverride.TransmissionDelays {
+			last = max(last, delay)
+		}
+		return last
+	}
+	return time.Duration(max(len(t.config.S)-1, 0)) * t.config.DeltaStage
+}
+
+// addPendingTransmission tracks and persists the pending transmission of ev.
+// It returns false if ev is already pending, e.g. because it was resumed after
+// a restart.
+func (t *transmissionState[RI]) addPendingTransmission(ev EventAttestedReport[RI], scheduledAt time.Time, expiresAt time.Time) bool {
+	persist := t.reportInfoCodec != nil
+	var reportInfo []byte
+	if persist {
+		var err error
+		reportInfo, err = t.reportInfoCodec.EncodeReportInfo(ev.AttestedReport.ReportWithInfo.Info)
+		if err != nil {
+			t.logger.Warn("failed to encode report info, transmission will not survive a restart", commontypes.LogFields{
+				"seqNr": ev.SeqNr,
+				"index": ev.Index,
+				"error": err,
+			})
+			persist = false
+		}
+	}
+
+	return t.pendingTransmissions.Add(PendingTransmission{
+		ev.SeqNr,
+		ev.Epoch,
+		ev.Index,
+		scheduledAt,
+		expiresAt,
+		ev.AttestedReport.ReportWithInfo.Report,
+		reportInfo,
+		ev.AttestedReport.AttributedSignatures,
+	}, persist)
+}
+
+func (t *transmissionState[RI]) removePendingTransmission(seqNr uint64, index int) {
+	t.pendingTransmissions.Remove(PendingTransmissionKey{seqNr, index})
+}
+
+// resumePendingTransmissions schedules the persisted pending transmissions,
+// pruning those that have expired or can't be decoded. Without a
+// ReportInfoCodec, all persisted pending transmissions are pruned.
+func (t *transmissionState[RI]) resumePendingTransmissions() {
+	pendingTransmissions, err := t.pendingTransmissions.Resume()
+	if err != nil {
+		t.logger.Error("failed to read pending transmissions", commontypes.LogFields{"error": err})
+		return
+	}
+
+	now := time.Now()
+	pruned := 0
+
+	for _, pt := range pendingTransmissions {
+		if t.reportInfoCodec == nil || pt.ExpiresAt.Before(now) {
+			t.pendingTransmissions.Remove(pt.Key())
+			pruned++
+			continue
+		}
+		info, err := t.reportInfoCodec.DecodeReportInfo(pt.ReportInfo)
+		if err != nil {
+			t.logger.Error("failed to decode report info of pending transmission, dropping it", commontypes.LogFields{
+				"seqNr": pt.SeqNr,
+				"index": pt.Index,
+				"error": err,
+			})
+			t.pendingTransmissions.Remove(pt.Key())
+			pruned++
+			continue
+		}
+		t.scheduler.ScheduleDeadline(EventAttestedReport[RI]{
+			pt.SeqNr,
+			pt.Epoch,
+			pt.Index,
+			AttestedReportMany[RI]{
+				ocr3types.ReportWithInfo[RI]{pt.Report, info},
+				pt.AttributedSignatures,
+			},
+			nil,
+		}, pt.ScheduledAt)
+		t.logger.Info("resuming pending transmission", commontypes.LogFields{
+			"seqNr":       pt.SeqNr,
+			"index":       pt.Index,
+			"scheduledAt": pt.ScheduledAt,
+		})
+	}
+
+	if pruned != 0 {
+		t.logger.Info("pruned pending transmissions", commontypes.LogFields{"pruned": pruned})
+	}
+}
@@ -26,6 +26,7 @@ func RunReportAttestation[RI any](
 	ctx context.Context,
 
 	chNetToReportAttestation <-chan MessageToReportAttestationWithSender[RI],
+	chObserverToReportAttestation <-chan MessageToReportAttestationFromObserverWithSender[RI],
 	chOutcomeGenerationToReportAttestation <-chan EventToReportAttestation[RI],
 	chReportAttestationToStateSync chan<- EventToStateSync[RI],
 	chReportAttestationToTransmission chan<- EventToTransmission[RI],
@@ -37,11 +38,11 @@ func RunReportAttestation[RI any](
 	onchainKeyring ocr3types.OnchainKeyring[RI],
 	reportingPlugin ocr3_1types.ReportingPlugin[RI],
 ) {
-	sched := scheduler.NewScheduler[EventMissingReportsPlusPrecursor[RI]]()
+	sched := scheduler.NewScheduler[EventToReportAttestation[RI]]()
 	defer sched.Close()
 
 	newReportAttestationState(ctx, chNetToReportAttestation,
-		chOutcomeGenerationToReportAttestation,
+		chObserverToReportAttestation, chOutcomeGenerationToReportAttestation,
 		chReportAttestationToStateSync, chReportAttestationToTransmission,
 		config, contractTransmitter, kvDb, logger, netSender, onchainKeyring,
 		reportingPlugin, sched).run()
@@ -62,6 +63,7 @@ type reportAttestationState[RI any] struct {
 	subs subprocesses.Subprocesses
 
 	chNetToReportAttestation               <-chan MessageToReportAttestationWithSender[RI]
+	chObserverToReportAttestation          <-chan MessageToReportAttestationFromObserverWithSender[RI]
 	chOutcomeGenerationToReportAttestation <-chan EventToReportAttestation[RI]
 	chReportAttestationToStateSync         chan<- EventToStateSync[RI]
 	chReportAttestationToTransmission      chan<- EventToTransmission[RI]
@@ -73,7 +75,7 @@ type reportAttestationState[RI any] struct {
 	onchainKeyring                         ocr3types.OnchainKeyring[RI]
 	reportingPlugin                        ocr3_1types.ReportingPlugin[RI]
 
-	scheduler    *scheduler.Scheduler[EventMissingReportsPlusPrecursor[RI]]
+	scheduler    *scheduler.Scheduler[EventToReportAttestation[RI]]
 	chLocalEvent chan EventComputedReports[RI]
 	// reap() is used to prevent unbounded state growth of rounds.
 
@@ -90,11 +92,13 @@ type round[RI any] struct {
 	ctx                                 context.Context                   // should always be initialized when a round[RI] is initiated
 	ctxCancel                           context.CancelFunc                // should always be initialized when a round[RI] is initiated
 	certifiedReportsPlusPrecursorDigest *ReportsPlusPrecursorDigest       // only stores ReportsPlusPrecursorDigest when supplied as committed by outgen, or from f+1 matching MessageReportSignatures
+	epoch                               *uint64                           // epoch in which seqNr was committed, from EventNewCertifiedCommit or the attested block in kv
 	certifiedReportsPlusPrecursor       *ocr3_1types.ReportsPlusPrecursor // only stores ReportsPlusPrecursor when it is the valid preimage of certifiedReportsPlusPrecursorDigest
 	reportsPlus                         *[]ocr3types.ReportPlus[RI]       // cache result of ReportingPlugin.Reports(seqNr, certifiedReportsPlusPrecursor)
 	oracles                             []oracle                          // always initialized to be of length n
 	startedFetch                        bool
 	successfullyCheckedKV               bool
+	scheduledEpochRead                  bool
 	complete                            bool
 }
 
@@ -118,6 +122,8 @@ func (repatt *reportAttestationState[RI]) run() {
 			ev.processReportAttestation(repatt)
 		case msg := <-repatt.chNetToReportAttestation:
 			msg.msg.processReportAttestation(repatt, msg.sender)
+		case msg := <-repatt.chObserverToReportAttestation:
+			msg.msg.processReportAttestationFromObserver(repatt, msg.observer)
 		case ev := <-repatt.chOutcomeGenerationToReportAttestation:
 			ev.processReportAttestation(repatt)
 		case ev := <-repatt.scheduler.Scheduled():
@@ -139,11 +145,18 @@ func (repatt *reportAttestationState[RI]) run() {
 }
 
 func (repatt *reportAttestationState[RI]) eventNewCertifiedCommit(ev EventNewCertifiedCommit[RI]) {
-	repatt.receivedCertifiedReportsPlusPrecursorDigest(ev.SeqNr, ev.ReportsPlusPrecursorDigest)
+	repatt.receivedCertifiedReportsPlusPrecursorDigest(ev.SeqNr, ev.Epoch, ev.ReportsPlusPrecursorDigest)
 }
 
-func (repatt *reportAttestationState[RI]) receivedCertifiedReportsPlusPrecursorDigest(seqNr uint64, reportsPlusPrecursorDigest ReportsPlusPrecursorDigest) {
+func (repatt *reportAttestationState[RI]) receivedCertifiedReportsPlusPrecursorDigest(seqNr uint64, epoch uint64, reportsPlusPrecursorDigest ReportsPlusPrecursorDigest) {
 	if repatt.rounds[seqNr] != nil && repatt.rounds[seqNr].certifiedReportsPlusPrecursorDigest != nil {
+		if repatt.rounds[seqNr].epoch == nil && *repatt.rounds[seqNr].certifiedReportsPlusPrecursorDigest == reportsPlusPrecursorDigest {
+			// we learned the digest through f+1 MessageReportSignatures and
+			// were still waiting for the epoch
+			repatt.rounds[seqNr].epoch = &epoch
+			repatt.tryComplete(seqNr)
+			return
+		}
 		repatt.logger.Debug("dropping redundant ReportsPlusPrecursorDigest", commontypes.LogFields{
 			"seqNr": seqNr,
 		})
@@ -158,14 +171,17 @@ func (repatt *reportAttestationState[RI]) receivedCertifiedReportsPlusPrecursorD
 			nil,
 			nil,
 			nil,
+			nil,
 			make([]oracle, repatt.config.N()),
 			false,
 			false,
 			false,
+			false,
 		}
 	}
 
 	repatt.rounds[seqNr].certifiedReportsPlusPrecursorDigest = &reportsPlusPrecursorDigest
+	repatt.rounds[seqNr].epoch = &epoch
 
 	repatt.tryComplete(seqNr)
 }
@@ -205,10 +221,12 @@ func (repatt *reportAttestationState[RI]) messageReportSignatures(
 			nil,
 			nil,
 			nil,
+			nil,
 			make([]oracle, repatt.config.N()),
 			false,
 			false,
 			false,
+			false,
 		}
 	}
 
@@ -261,6 +279,42 @@ func (repatt *reportAttestationState[RI]) messageReportsPlusPrecursorRequest(msg
 		"msgSeqNr": msg.SeqNr,
 	})
 
+	certifiedReportsPlusPrecursor, ok := repatt.certifiedReportsPlusPrecursorForRequest(msg, sender)
+	if !ok {
+		return
+	}
+
+	if repatt.rounds[msg.SeqNr].oracles[sender].weServiced {
+		repatt.logger.Warn("dropping duplicate MessageReportsPlusPrecursorRequest", commontypes.LogFields{
+			"msgSeqNr": msg.SeqNr,
+			"sender":   sender,
+		})
+		return
+	}
+
+	repatt.rounds[msg.SeqNr].oracles[sender].weServiced = true
+
+	repatt.sendReportsPlusPrecursor(msg, certifiedReportsPlusPrecursor, sender)
+}
+
+// messageReportsPlusPrecursorRequestFromObserver handles requests from
+// observers (OracleIDs >= N). We don't track observers, their requests are
+// only bounded by the network layer's rate limits.
+func (repatt *reportAttestationState[RI]) messageReportsPlusPrecursorRequestFromObserver(msg MessageReportsPlusPrecursorRequest[RI], observer commontypes.OracleID) {
+	repatt.logger.Debug("received MessageReportsPlusPrecursorRequest from observer", commontypes.LogFields{
+		"observer": observer,
+		"msgSeqNr": msg.SeqNr,
+	})
+
+	certifiedReportsPlusPrecursor, ok := repatt.certifiedReportsPlusPrecursorForRequest(msg, observer)
+	if !ok {
+		return
+	}
+
+	repatt.sendReportsPlusPrecursor(msg, certifiedReportsPlusPrecursor, observer)
+}
+
+func (repatt *reportAttestationState[RI]) certifiedReportsPlusPrecursorForRequest(msg MessageReportsPlusPrecursorRequest[RI], sender commontypes.OracleID) (ocr3_1types.ReportsPlusPrecursor, bool) {
 	if repatt.rounds[msg.SeqNr] == nil {
 		repatt.logger.Debug("dropping MessageReportsPlusPrecursorRequest for unknown seqNr", commontypes.LogFields{
 			"msgSeqNr":      msg.SeqNr,
@@ -268,7 +322,7 @@ func (repatt *reportAttestationState[RI]) messageReportsPlusPrecursorRequest(msg
 			"highWaterMark": repatt.highWaterMark,
 			"expiryRounds":  repatt.expiryRounds(),
 		})
-		return
+		return nil, false
 	}
 
 	if repatt.rounds[msg.SeqNr].certifiedReportsPlusPrecursor == nil {
@@ -276,28 +330,22 @@ func (repatt *reportAttestationState[RI]) messageReportsPlusPrecursorRequest(msg
 			"msgSeqNr": msg.SeqNr,
 			"sender":   sender,
 		})
-		return
-	}
-
-	if repatt.rounds[msg.SeqNr].oracles[sender].weServiced {
-		repatt.logger.Warn("dropping duplicate MessageReportsPlusPrecursorRequest", commontypes.LogFields{
-			"msgSeqNr": msg.SeqNr,
-			"sender":   sender,
-		})
-		return
+		return nil, false
 	}
 
-	repatt.rounds[msg.SeqNr].oracles[sender].weServiced = true
+	return *repatt.rounds[msg.SeqNr].certifiedReportsPlusPrecursor, true
+}
 
+func (repatt *reportAttestationState[RI]) sendReportsPlusPrecursor(msg MessageReportsPlusPrecursorRequest[RI], reportsPlusPrecursor ocr3_1types.ReportsPlusPrecursor, to commontypes.OracleID) {
 	repatt.logger.Debug("sending MessageReportsPlusPrecursor", commontypes.LogFields{
 		"msgSeqNr": msg.SeqNr,
-		"to":       sender,
+		"to":       to,
 	})
 	repatt.netSender.SendTo(MessageReportsPlusPrecursor[RI]{
 		msg.RequestHandle,
 		msg.SeqNr,
-		*repatt.rounds[msg.SeqNr].certifiedReportsPlusPrecursor,
-	}, sender)
+		reportsPlusPrecursor,
+	}, to)
 }
 
 func (repatt *reportAttestationState[RI]) messageReportsPlusPrecursor(msg MessageReportsPlusPrecursor[RI], sender commontypes.OracleID) {
@@ -520,6 +568,17 @@ func (repatt *reportAttestationState[RI]) tryComplete(seqNr uint64) {
 		return
 	}
 
+	if repatt.rounds[seqNr].epoch == nil && !repatt.tryReadEpoch(seqNr) {
+		if !repatt.rounds[seqNr].scheduledEpochRead {
+			repatt.logger.Debug("cannot complete, epoch in which seqNr was committed is unknown", commontypes.LogFields{
+				"seqNr": seqNr,
+			})
+			repatt.rounds[seqNr].scheduledEpochRead = true
+			repatt.scheduler.ScheduleDelay(EventMissingCommitEpoch[RI]{seqNr}, repatt.config.GetDeltaReportsPlusPrecursorRequest())
+		}
+		return
+	}
+
 	repatt.rounds[seqNr].complete = true
 
 	repatt.logger.Debug("sending attested reports to transmission protocol", commontypes.LogFields{
@@ -531,6 +590,7 @@ func (repatt *reportAttestationState[RI]) tryComplete(seqNr uint64) {
 		select {
 		case repatt.chReportAttestationToTransmission <- EventAttestedReport[RI]{
 			seqNr,
+			*repatt.rounds[seqNr].epoch,
 			i,
 			AttestedReportMany[RI]{
 				reportsPlus[i].ReportWithInfo,
@@ -602,10 +662,12 @@ func (repatt *reportAttestationState[RI]) receivedCertifiedReportsPlusPrecursor(
 			nil,
 			nil,
 			nil,
+			nil,
 			make([]oracle, repatt.config.N()),
 			false,
 			false,
 			false,
+			false,
 		}
 	}
 
@@ -735,6 +797,53 @@ func (repatt *reportAttestationState[RI]) readReportsPlusPrecursor(seqNr uint64,
 	return reportsPlusPrecursor, nil
 }
 
+func (repatt *reportAttestationState[RI]) eventMissingCommitEpoch(ev EventMissingCommitEpoch[RI]) {
+	if repatt.rounds[ev.SeqNr] == nil || repatt.rounds[ev.SeqNr].complete {
+		return
+	}
+
+	repatt.rounds[ev.SeqNr].scheduledEpochRead = false
+	repatt.tryComplete(ev.SeqNr)
+}
+
+// tryReadEpoch takes the epoch in which seqNr was committed from the attested
+// block in kv. Until state sync has fetched that block, the epoch is unknown.
+func (repatt *reportAttestationState[RI]) tryReadEpoch(seqNr uint64) bool {
+	epoch, err := repatt.readEpoch(seqNr, *repatt.rounds[seqNr].certifiedReportsPlusPrecursorDigest)
+	if err != nil {
+		repatt.logger.Warn("error reading attested state transition block from kv", commontypes.LogFields{
+			"seqNr": seqNr,
+			"error": err,
+		})
+		return false
+	}
+	if epoch == nil {
+		return false
+	}
+	repatt.rounds[seqNr].epoch = epoch
+	return true
+}
+
+func (repatt *reportAttestationState[RI]) readEpoch(seqNr uint64, reportsPlusPrecursorDigest ReportsPlusPrecursorDigest) (*uint64, error) {
+	tx, err := repatt.kvDb.NewReadTransactionUnchecked()
+	if err != nil {
+		return nil, fmt.Errorf("error creating read transaction: %w", err)
+	}
+	defer tx.Discard()
+	astb, err := tx.ReadAttestedStateTransitionBlock(seqNr)
+	if err != nil {
+		return nil, fmt.Errorf("error reading attested state transition block: %w", err)
+	}
+	if astb.StateTransitionBlock.SeqNr() != seqNr {
+		return nil, nil
+	}
+	if astb.StateTransitionBlock.ReportsPlusPrecursorDigest != reportsPlusPrecursorDigest {
+		return nil, fmt.Errorf("attested state transition block has ReportsPlusPrecursorDigest %s, but %s was certified", astb.StateTransitionBlock.ReportsPlusPrecursorDigest, reportsPlusPrecursorDigest)
+	}
+	epoch := astb.StateTransitionBlock.Epoch
+	return &epoch, nil
+}
+
 // reap expired rounds if there is a new high water mark
 func (repatt *reportAttestationState[RI]) tryReap(seqNr uint64, sender commontypes.OracleID) {
 	if repatt.highestReportSignaturesSeqNr[sender] >= seqNr {
@@ -881,6 +990,7 @@ func newReportAttestationState[RI any](
 	ctx context.Context,
 
 	chNetToReportAttestation <-chan MessageToReportAttestationWithSender[RI],
+	chObserverToReportAttestation <-chan MessageToReportAttestationFromObserverWithSender[RI],
 	chOutcomeGenerationToReportAttestation <-chan EventToReportAttestation[RI],
 	chReportAttestationToStateSync chan<- EventToStateSync[RI],
 	chReportAttestationToTransmission chan<- EventToTransmission[RI],
@@ -891,13 +1001,14 @@ func newReportAttestationState[RI any](
 	netSender NetworkSender[RI],
 	onchainKeyring ocr3types.OnchainKeyring[RI],
 	reportingPlugin ocr3_1types.ReportingPlugin[RI],
-	sched *scheduler.Scheduler[EventMissingReportsPlusPrecursor[RI]],
+	sched *scheduler.Scheduler[EventToReportAttestation[RI]],
 ) *reportAttestationState[RI] {
 	return &reportAttestationState[RI]{
 		ctx,
 		subprocesses.Subprocesses{},
 
 		chNetToReportAttestation,
+		chObserverToReportAttestation,
 		chOutcomeGenerationToReportAttestation,
 		chReportAttestationToStateSync,
 		chReportAttestationToTransmission,
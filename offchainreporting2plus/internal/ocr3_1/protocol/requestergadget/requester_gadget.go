@@ -18,6 +18,7 @@ func NewRequesterGadget[Item comparable](
 	sendRequestFn func(Item, commontypes.OracleID) (*RequestInfo, bool), // Invoked by the RequesterGadget to send a request for the given item to the given seeder.
 	getPendingItemsFn func() []Item, // Invoked by the RequesterGadget to get the list of items that should be requested. RequesterGadget will attempt to request items earlier in the list first.
 	getSeedersFn func(Item) map[commontypes.OracleID]struct{}, // Invoked by the RequesterGadget to get the list of seeders that can serve the given item.
+	getLatencyFn func(commontypes.OracleID) (time.Duration, bool), // Optional, may be nil. Invoked by the RequesterGadget to prefer seeders that are closer among those with equal score. Returns false if the seeder's latency is unknown or the seeder is unresponsive.
 ) *RequesterGadget[Item] {
 	oracles := make(map[commontypes.OracleID]*oracleState, n)
 	for i := range n {
@@ -34,6 +35,7 @@ func NewRequesterGadget[Item comparable](
 		sendRequestFn,
 		getPendingItemsFn,
 		getSeedersFn,
+		getLatencyFn,
 	}
 }
 
@@ -100,8 +102,10 @@ func (rg *RequesterGadget[Item]) markTimedOutResponse(item Item, sender commonty
 
 func (rg *RequesterGadget[Item]) rankedSeeders(seeders map[commontypes.OracleID]struct{}, excluded map[commontypes.OracleID]struct{}) []commontypes.OracleID {
 	type scoredSeeder struct {
-		seeder commontypes.OracleID
-		score  uint64
+		seeder     commontypes.OracleID
+		score      uint64
+		latency    time.Duration
+		responsive bool
 	}
 	scoredSeeders := make([]scoredSeeder, 0, len(seeders))
 	for seeder := range seeders {
@@ -111,14 +115,31 @@ func (rg *RequesterGadget[Item]) rankedSeeders(seeders map[commontypes.OracleID]
 		if _, ok := excluded[seeder]; ok {
 			continue
 		}
+		var latency time.Duration
+		var responsive bool
+		if rg.getLatencyFn != nil {
+			latency, responsive = rg.getLatencyFn(seeder)
+		}
 		scoredSeeders = append(scoredSeeders, scoredSeeder{
 			seeder,
 			rg.oracles[seeder].score,
+			latency,
+			responsive,
 		})
 	}
 	slices.SortFunc(scoredSeeders, func(a, b scoredSeeder) int {
 		// higher score goes first
-		return cmp.Compare(b.score, a.score)
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		// among equal scores, responsive seeders with lower latency go first
+		if a.responsive != b.responsive {
+			if a.responsive {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.latency, b.latency)
 	})
 
 	ranks := make([]commontypes.OracleID, 0, len(scoredSeeders))
@@ -253,6 +274,7 @@ type RequesterGadget[Item comparable] struct {
 	sendRequestFn     func(Item, commontypes.OracleID) (*RequestInfo, bool)
 	getPendingItemsFn func() []Item
 	getSeedersFn      func(Item) map[commontypes.OracleID]struct{}
+	getLatencyFn      func(commontypes.OracleID) (time.Duration, bool)
 }
 
 type pendingItemState struct {
@@ -0,0 +1,552 @@
+package protocol
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// SharedSecretRotationSource supplies this oracle with shared secret rotations
+// approved by its operator.
+type SharedSecretRotationSource interface {
+	// LatestSharedSecretRotation returns the rotation with the highest
+	// RotationNumber for the given configDigest, or nil if there is none.
+	LatestSharedSecretRotation(ctx context.Context, configDigest types.ConfigDigest) (*ocr3_1config.SharedSecretRotation, error)
+}
+
+// SharedSecretSchedule keeps track of the shared secret rotations adopted by
+// this oracle. Pacemaker and outcome generation use it to determine the leader
+// of an epoch, transmission uses it to determine the transmission order.
+//
+// All its functions are thread-safe.
+type SharedSecretSchedule struct {
+	mutex sync.Mutex
+
+	initialLeaderSelectionKey   [16]byte
+	initialTransmissionOrderKey [16]byte
+	adopted                     []adoptedSharedSecretRotation
+	epoch                       uint64
+	chLateAdoption              chan struct{}
+}
+
+type adoptedSharedSecretRotation struct {
+	certificate          CertifiedSharedSecretRotation
+	leaderSelectionKey   [16]byte
+	transmissionOrderKey [16]byte
+}
+
+func NewSharedSecretSchedule(config *ocr3_1config.SharedConfig) *SharedSecretSchedule {
+	return &SharedSecretSchedule{
+		sync.Mutex{},
+
+		config.LeaderSelectionKey(),
+		config.TransmissionOrderKey(),
+		nil,
+		0,
+		make(chan struct{}, 1),
+	}
+}
+
+// StartEpoch returns the leader selection key for epoch. It also records
+// epoch as the current epoch for the purposes of TransmissionOrderKey.
+func (s *SharedSecretSchedule) StartEpoch(epoch uint64) (leaderSelectionKey [16]byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.epoch < epoch {
+		s.epoch = epoch
+	}
+	if a := s.activeLocked(epoch); a != nil {
+		return a.leaderSelectionKey
+	}
+	return s.initialLeaderSelectionKey
+}
+
+// TransmissionOrderKey returns the transmission order key for reports
+// committed in epoch.
+func (s *SharedSecretSchedule) TransmissionOrderKey(epoch uint64) [16]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.activeLocked(epoch); a != nil {
+		return a.transmissionOrderKey
+	}
+	return s.initialTransmissionOrderKey
+}
+
+// lateAdoption receives a value whenever an adopted rotation becomes the
+// active rotation for the highest epoch passed to StartEpoch, i.e. when the
+// leader selection key of the current epoch has changed.
+func (s *SharedSecretSchedule) lateAdoption() <-chan struct{} {
+	return s.chLateAdoption
+}
+
+// LatestRotationNumber returns the RotationNumber of the latest adopted
+// rotation, or 0 if no rotation has been adopted.
+func (s *SharedSecretSchedule) LatestRotationNumber() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.adopted) == 0 {
+		return 0
+	}
+	return s.adopted[len(s.adopted)-1].certificate.SharedSecretRotation.RotationNumber
+}
+
+func (s *SharedSecretSchedule) latestActivationEpoch() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.adopted) == 0 {
+		return 0
+	}
+	return s.adopted[len(s.adopted)-1].certificate.SharedSecretRotation.ActivationEpoch
+}
+
+func (s *SharedSecretSchedule) currentEpoch() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.epoch
+}
+
+func (s *SharedSecretSchedule) hasAdopted(rotationNumber uint64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, a := range s.adopted {
+		if a.certificate.SharedSecretRotation.RotationNumber == rotationNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// certificates returns the certificates of all adopted rotations, ordered by
+// RotationNumber.
+func (s *SharedSecretSchedule) certificates() []CertifiedSharedSecretRotation {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	certificates := make([]CertifiedSharedSecretRotation, 0, len(s.adopted))
+	for _, a := range s.adopted {
+		certificates = append(certificates, a.certificate)
+	}
+	return certificates
+}
+
+func (s *SharedSecretSchedule) adopt(csr CertifiedSharedSecretRotation, sharedSecret *[config.SharedSecretSize]byte) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Rotations may be adopted out of order, e.g. when an oracle that missed
+	// a rotation receives its certificate after that of a later one. We keep
+	// adopted ordered by RotationNumber and require ActivationEpochs to be
+	// ordered the same way.
+	rotation := csr.SharedSecretRotation
+	i := 0
+	for i < len(s.adopted) && s.adopted[i].certificate.SharedSecretRotation.RotationNumber < rotation.RotationNumber {
+		i++
+	}
+	if i > 0 && rotation.ActivationEpoch < s.adopted[i-1].certificate.SharedSecretRotation.ActivationEpoch {
+		return false
+	}
+	if i < len(s.adopted) {
+		next := s.adopted[i].certificate.SharedSecretRotation
+		if next.RotationNumber == rotation.RotationNumber || next.ActivationEpoch < rotation.ActivationEpoch {
+			return false
+		}
+	}
+	s.adopted = slices.Insert(s.adopted, i, adoptedSharedSecretRotation{
+		csr,
+		rotation.LeaderSelectionKey(sharedSecret),
+		rotation.TransmissionOrderKey(sharedSecret),
+	})
+	if s.activeLocked(s.epoch) == &s.adopted[i] {
+		select {
+		case s.chLateAdoption <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+func (s *SharedSecretSchedule) activeLocked(epoch uint64) *adoptedSharedSecretRotation {
+	for i := len(s.adopted) - 1; i >= 0; i-- {
+		if s.adopted[i].certificate.SharedSecretRotation.ActivationEpoch <= epoch {
+			return &s.adopted[i]
+		}
+	}
+	return nil
+}
+
+// restore adopts the certified rotations that this oracle persisted before a
+// restart.
+func (s *SharedSecretSchedule) restore(
+	csrs []CertifiedSharedSecretRotation,
+	id commontypes.OracleID,
+	offchainKeyring types.OffchainKeyring,
+	logger loghelper.LoggerWithContext,
+) {
+	for _, csr := range csrs {
+		logger := logger.MakeChild(commontypes.LogFields{
+			"rotationNumber":  csr.SharedSecretRotation.RotationNumber,
+			"activationEpoch": csr.SharedSecretRotation.ActivationEpoch,
+		})
+		sharedSecret, err := csr.SharedSecretRotation.Decrypt(id, offchainKeyring)
+		if err != nil {
+			logger.Error("SharedSecretRotation: could not decrypt restored rotation, dropping it", commontypes.LogFields{
+				"error": err,
+			})
+			continue
+		}
+		if !s.adopt(csr, sharedSecret) {
+			logger.Warn("SharedSecretRotation: restored rotation is out of order, dropping it", nil)
+			continue
+		}
+		logger.Info("SharedSecretRotation: restored rotation", nil)
+	}
+}
+
+// RunSharedSecretRotation runs the protocol for rotating the shared secret
+// without changing the ConfigDigest:
+//
+//  1. Each oracle polls its SharedSecretRotationSource. If the source returns
+//     a new rotation that the oracle can decrypt, the oracle endorses it by
+//     broadcasting a signature over its digest. An oracle endorses at most one
+//     rotation per RotationNumber.
+//  2. Once an oracle that knows the rotation has collected endorsements by a
+//     byzantine quorum of oracles, it adopts the rotation and broadcasts a
+//     certificate.
+//  3. Oracles adopt any valid certificate they receive, even if their own
+//     source hasn't supplied the rotation (yet).
+//
+// Adopted rotations and the oracle's own endorsement are persisted and
+// restored on restart. The endorsement is persisted before it is broadcast.
+// The certificates of all adopted rotations are periodically re-broadcast, so
+// that oracles that were offline catch up, including on rotations that
+// precede the latest one they have adopted.
+// Until an oracle has adopted a rotation, it may select a different leader for
+// epochs at or after the rotation's ActivationEpoch than its peers. Oracles
+// therefore only endorse rotations that activate after their current epoch,
+// and an oracle that adopts a rotation late asks the pacemaker to move to a
+// new epoch, rather than switching leaders mid-epoch. Admins should still pick
+// an ActivationEpoch sufficiently far in the future.
+func RunSharedSecretRotation[RI any](
+	ctx context.Context,
+
+	chNetToSharedSecretRotation <-chan MessageToSharedSecretRotationWithSender[RI],
+	config ocr3_1config.SharedConfig,
+	database Database,
+	id commontypes.OracleID,
+	localConfig types.LocalConfig,
+	logger loghelper.LoggerWithContext,
+	netSender NetworkSender[RI],
+	offchainKeyring types.OffchainKeyring,
+	sharedSecretRotationSource SharedSecretRotationSource,
+	restoredEndorsement *SharedSecretRotationEndorsement,
+	sharedSecretSchedule *SharedSecretSchedule,
+) {
+	ssr := sharedSecretRotationState[RI]{
+		ctx,
+
+		chNetToSharedSecretRotation,
+		config,
+		database,
+		id,
+		localConfig,
+		logger.MakeUpdated(commontypes.LogFields{"proto": "sharedSecretRotation"}),
+		netSender,
+		offchainKeyring,
+		sharedSecretRotationSource,
+		sharedSecretSchedule,
+
+		nil,
+		nil,
+		make([]*MessageSharedSecretRotationEndorsement[RI], config.N()),
+		nil,
+		nil,
+	}
+	if restoredEndorsement != nil {
+		ssr.restoreEndorsement(*restoredEndorsement)
+	}
+	ssr.run()
+}
+
+type sharedSecretRotationState[RI any] struct {
+	ctx context.Context
+
+	chNetToSharedSecretRotation <-chan MessageToSharedSecretRotationWithSender[RI]
+	config                      ocr3_1config.SharedConfig
+	database                    Database
+	id                          commontypes.OracleID
+	localConfig                 types.LocalConfig
+	logger                      loghelper.LoggerWithContext
+	netSender                   NetworkSender[RI]
+	offchainKeyring             types.OffchainKeyring
+	sharedSecretRotationSource  SharedSecretRotationSource
+	sharedSecretSchedule        *SharedSecretSchedule
+
+	// candidate is the latest rotation supplied by our source and endorsed by us
+	candidate *ocr3_1config.SharedSecretRotation
+	// ownEndorsement is our endorsement of candidate
+	ownEndorsement *MessageSharedSecretRotationEndorsement[RI]
+	// endorsements[j] is the endorsement with the highest RotationNumber
+	// received from oracle j
+	endorsements []*MessageSharedSecretRotationEndorsement[RI]
+
+	tPoll   <-chan time.Time
+	tResend <-chan time.Time
+}
+
+func (ssr *sharedSecretRotationState[RI]) run() {
+	ssr.logger.Info("SharedSecretRotation: running", nil)
+
+	if ssr.sharedSecretRotationSource != nil {
+		ssr.tPoll = time.After(0)
+	}
+	ssr.tResend = time.After(ssr.config.GetDeltaResend())
+
+	chDone := ssr.ctx.Done()
+	for {
+		select {
+		case msg := <-ssr.chNetToSharedSecretRotation:
+			msg.msg.processSharedSecretRotation(ssr, msg.sender)
+		case <-ssr.tPoll:
+			ssr.eventTPollTimeout()
+		case <-ssr.tResend:
+			ssr.eventTResendTimeout()
+		case <-chDone:
+		}
+
+		// ensure prompt exit
+		select {
+		case <-chDone:
+			ssr.logger.Info("SharedSecretRotation: exiting", nil)
+			return
+		default:
+		}
+	}
+}
+
+func (ssr *sharedSecretRotationState[RI]) restoreEndorsement(endorsement SharedSecretRotationEndorsement) {
+	rotation := endorsement.SharedSecretRotation
+	ssr.candidate = &rotation
+	ssr.ownEndorsement = &MessageSharedSecretRotationEndorsement[RI]{
+		rotation.RotationNumber,
+		rotation.Digest(),
+		endorsement.Signature,
+	}
+	ssr.endorsements[ssr.id] = ssr.ownEndorsement
+	ssr.logger.Info("SharedSecretRotation: restored endorsement", commontypes.LogFields{
+		"rotationNumber":  rotation.RotationNumber,
+		"activationEpoch": rotation.ActivationEpoch,
+	})
+}
+
+func (ssr *sharedSecretRotationState[RI]) eventTPollTimeout() {
+	ssr.tPoll = time.After(ssr.localConfig.ContractConfigTrackerPollInterval)
+
+	rotation, err := func() (*ocr3_1config.SharedSecretRotation, error) {
+		ctx, cancel := context.WithTimeout(ssr.ctx, ssr.localConfig.ContractConfigLoadTimeout)
+		defer cancel()
+		return ssr.sharedSecretRotationSource.LatestSharedSecretRotation(ctx, ssr.config.ConfigDigest)
+	}()
+	if err != nil {
+		ssr.logger.Warn("SharedSecretRotation: error while polling SharedSecretRotationSource", commontypes.LogFields{
+			"error": err,
+		})
+		return
+	}
+	if rotation == nil || rotation.RotationNumber <= ssr.sharedSecretSchedule.LatestRotationNumber() {
+		return
+	}
+	if ssr.candidate != nil && rotation.RotationNumber <= ssr.candidate.RotationNumber {
+		if rotation.RotationNumber == ssr.candidate.RotationNumber && rotation.Digest() != ssr.candidate.Digest() {
+			ssr.logger.Warn("SharedSecretRotation: source returned a different rotation with the same RotationNumber as an already endorsed rotation, ignoring", commontypes.LogFields{
+				"rotationNumber": rotation.RotationNumber,
+			})
+		}
+		return
+	}
+
+	logger := ssr.logger.MakeChild(commontypes.LogFields{
+		"rotationNumber":  rotation.RotationNumber,
+		"activationEpoch": rotation.ActivationEpoch,
+	})
+
+	if rotation.ConfigDigest != ssr.config.ConfigDigest {
+		logger.Warn("SharedSecretRotation: rotation from source has wrong ConfigDigest, ignoring", commontypes.LogFields{
+			"rotationConfigDigest": rotation.ConfigDigest,
+		})
+		return
+	}
+	if len(rotation.SharedSecretEncryptions.Encryptions) != ssr.config.N() {
+		logger.Warn("SharedSecretRotation: rotation from source has wrong number of encryptions, ignoring", commontypes.LogFields{
+			"encryptions": len(rotation.SharedSecretEncryptions.Encryptions),
+			"n":           ssr.config.N(),
+		})
+		return
+	}
+	if rotation.ActivationEpoch < ssr.sharedSecretSchedule.latestActivationEpoch() {
+		logger.Warn("SharedSecretRotation: rotation from source activates before the latest adopted rotation, ignoring", nil)
+		return
+	}
+	if currentEpoch := ssr.sharedSecretSchedule.currentEpoch(); rotation.ActivationEpoch <= currentEpoch {
+		logger.Warn("SharedSecretRotation: rotation from source doesn't activate after the current epoch, not endorsing", commontypes.LogFields{
+			"currentEpoch": currentEpoch,
+		})
+		return
+	}
+	if _, err := rotation.Decrypt(ssr.id, ssr.offchainKeyring); err != nil {
+		logger.Error("SharedSecretRotation: could not decrypt rotation from source, not endorsing", commontypes.LogFields{
+			"error": err,
+		})
+		return
+	}
+
+	rotationDigest := rotation.Digest()
+	signature, err := MakeSharedSecretRotationEndorsementSignature(rotationDigest, ssr.offchainKeyring.OffchainSign)
+	if err != nil {
+		logger.Error("SharedSecretRotation: could not sign endorsement", commontypes.LogFields{
+			"error": err,
+		})
+		return
+	}
+
+	if err := func() error {
+		writeCtx, writeCancel := context.WithTimeout(ssr.ctx, ssr.localConfig.DatabaseTimeout)
+		defer writeCancel()
+		return ssr.database.WriteSharedSecretRotationEndorsement(writeCtx, ssr.config.ConfigDigest, SharedSecretRotationEndorsement{
+			*rotation,
+			signature,
+		})
+	}(); err != nil {
+		logger.Error("SharedSecretRotation: error while persisting endorsement, not endorsing", commontypes.LogFields{
+			"error": err,
+		})
+		return
+	}
+
+	ssr.candidate = rotation
+	ssr.ownEndorsement = &MessageSharedSecretRotationEndorsement[RI]{
+		rotation.RotationNumber,
+		rotationDigest,
+		signature,
+	}
+	ssr.endorsements[ssr.id] = ssr.ownEndorsement
+
+	logger.Info("SharedSecretRotation: endorsing rotation", nil)
+	ssr.netSender.Broadcast(*ssr.ownEndorsement)
+	ssr.tryCertify()
+}
+
+func (ssr *sharedSecretRotationState[RI]) eventTResendTimeout() {
+	ssr.tResend = time.After(ssr.config.GetDeltaResend())
+
+	for _, certificate := range ssr.sharedSecretSchedule.certificates() {
+		ssr.netSender.Broadcast(MessageSharedSecretRotationCertificate[RI]{certificate})
+	}
+	if ssr.ownEndorsement != nil && ssr.ownEndorsement.RotationNumber > ssr.sharedSecretSchedule.LatestRotationNumber() {
+		ssr.netSender.Broadcast(*ssr.ownEndorsement)
+	}
+}
+
+func (ssr *sharedSecretRotationState[RI]) messageSharedSecretRotationEndorsement(msg MessageSharedSecretRotationEndorsement[RI], sender commontypes.OracleID) {
+	if msg.RotationNumber <= ssr.sharedSecretSchedule.LatestRotationNumber() {
+		return
+	}
+	if prev := ssr.endorsements[sender]; prev != nil && msg.RotationNumber <= prev.RotationNumber {
+		return
+	}
+	if err := msg.Signature.Verify(msg.RotationDigest, ssr.config.OracleIdentities[sender].OffchainPublicKey); err != nil {
+		ssr.logger.Warn("SharedSecretRotation: dropping MessageSharedSecretRotationEndorsement with invalid signature", commontypes.LogFields{
+			"sender": sender,
+			"error":  err,
+		})
+		return
+	}
+	ssr.endorsements[sender] = &msg
+	ssr.tryCertify()
+}
+
+func (ssr *sharedSecretRotationState[RI]) tryCertify() {
+	if ssr.candidate == nil || ssr.candidate.RotationNumber <= ssr.sharedSecretSchedule.LatestRotationNumber() {
+		return
+	}
+
+	candidateDigest := ssr.candidate.Digest()
+	endorsements := []AttributedSharedSecretRotationEndorsementSignature{}
+	for i, endorsement := range ssr.endorsements {
+		if endorsement == nil || endorsement.RotationNumber != ssr.candidate.RotationNumber || endorsement.RotationDigest != candidateDigest {
+			continue
+		}
+		endorsements = append(endorsements, AttributedSharedSecretRotationEndorsementSignature{
+			endorsement.Signature,
+			commontypes.OracleID(i),
+		})
+		if len(endorsements) == ssr.config.ByzQuorumSize() {
+			ssr.adopt(CertifiedSharedSecretRotation{*ssr.candidate, endorsements})
+			return
+		}
+	}
+}
+
+func (ssr *sharedSecretRotationState[RI]) messageSharedSecretRotationCertificate(msg MessageSharedSecretRotationCertificate[RI], sender commontypes.OracleID) {
+	csr := msg.CertifiedSharedSecretRotation
+	if ssr.sharedSecretSchedule.hasAdopted(csr.SharedSecretRotation.RotationNumber) {
+		return
+	}
+	if err := csr.Verify(ssr.config.PublicConfig); err != nil {
+		ssr.logger.Warn("SharedSecretRotation: dropping invalid MessageSharedSecretRotationCertificate", commontypes.LogFields{
+			"sender": sender,
+			"error":  err,
+		})
+		return
+	}
+	ssr.adopt(csr)
+}
+
+func (ssr *sharedSecretRotationState[RI]) adopt(csr CertifiedSharedSecretRotation) {
+	rotation := csr.SharedSecretRotation
+	logger := ssr.logger.MakeChild(commontypes.LogFields{
+		"rotationNumber":  rotation.RotationNumber,
+		"activationEpoch": rotation.ActivationEpoch,
+	})
+
+	sharedSecret, err := rotation.Decrypt(ssr.id, ssr.offchainKeyring)
+	if err != nil {
+		// A byzantine quorum endorsed the rotation, so at least f+1 honest
+		// oracles could decrypt it. We can't, so the dealer must have made
+		// a mistake.
+		logger.Error("SharedSecretRotation: could not decrypt certified rotation, cannot adopt it", commontypes.LogFields{
+			"error": err,
+		})
+		return
+	}
+	if !ssr.sharedSecretSchedule.adopt(csr, sharedSecret) {
+		logger.Warn("SharedSecretRotation: certified rotation's ActivationEpoch is out of order with adopted rotations, cannot adopt it", nil)
+		return
+	}
+
+	logger.Info("SharedSecretRotation: adopted rotation", nil)
+	ssr.persist()
+	ssr.netSender.Broadcast(MessageSharedSecretRotationCertificate[RI]{csr})
+}
+
+func (ssr *sharedSecretRotationState[RI]) persist() {
+	writeCtx, writeCancel := context.WithTimeout(ssr.ctx, ssr.localConfig.DatabaseTimeout)
+	defer writeCancel()
+	if err := ssr.database.WriteSharedSecretRotations(writeCtx, ssr.config.ConfigDigest, ssr.sharedSecretSchedule.certificates()); err != nil {
+		ssr.logger.Error("SharedSecretRotation: error while persisting adopted rotations", commontypes.LogFields{
+			"error": err,
+		})
+	}
+}
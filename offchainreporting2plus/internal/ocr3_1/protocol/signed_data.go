@@ -909,6 +909,109 @@ func (gstb *GenesisStateTransitionBlock) stateRootDigest() StateRootDigest {
 	return gstb.StateRootDigest
 }
 
+const sharedSecretRotationEndorsementDomainSeparator = "ocr3.1/SharedSecretRotationEndorsement/"
+
+type SharedSecretRotationEndorsementSignature []byte
+
+func MakeSharedSecretRotationEndorsementSignature(
+	rotationDigest ocr3_1config.SharedSecretRotationDigest,
+	signer func(msg []byte) ([]byte, error),
+) (SharedSecretRotationEndorsementSignature, error) {
+	return signer(sharedSecretRotationEndorsementMsg(rotationDigest))
+}
+
+func (sig SharedSecretRotationEndorsementSignature) Verify(
+	rotationDigest ocr3_1config.SharedSecretRotationDigest,
+	publicKey types.OffchainPublicKey,
+) error {
+	pk := ed25519.PublicKey(publicKey[:])
+
+	if len(pk) != ed25519.PublicKeySize {
+		return fmt.Errorf("ed25519 public key size mismatch, expected %v but got %v", ed25519.PublicKeySize, len(pk))
+	}
+
+	ok := ed25519.Verify(pk, sharedSecretRotationEndorsementMsg(rotationDigest), sig)
+	if !ok {
+		return fmt.Errorf("SharedSecretRotationEndorsementSignature failed to verify")
+	}
+
+	return nil
+}
+
+func sharedSecretRotationEndorsementMsg(rotationDigest ocr3_1config.SharedSecretRotationDigest) []byte {
+	h := sha256.New()
+
+	_, _ = h.Write([]byte(sharedSecretRotationEndorsementDomainSeparator))
+
+	_, _ = h.Write(rotationDigest[:])
+
+	return ocr3_1DomainSeparatedSum(h)
+}
+
+type AttributedSharedSecretRotationEndorsementSignature struct {
+	Signature SharedSecretRotationEndorsementSignature
+	Signer    commontypes.OracleID
+}
+
+// CertifiedSharedSecretRotation proves that a byzantine quorum of oracles has
+// endorsed SharedSecretRotation. Since an honest oracle only endorses a
+// rotation that its operator has supplied and whose encryption it could
+// decrypt, a certified rotation is authorised by the oracle set of the config.
+//
+// Endorsements are signed with the oracles' offchain keys rather than their
+// onchain keys. An OnchainKeyring can only sign reports and its signatures
+// are only meaningful to the chain-specific verifier, whereas a rotation never
+// goes onchain and only needs to be verified by the oracles themselves, just
+// like the prepare and commit signatures that protect the protocol's state.
+type CertifiedSharedSecretRotation struct {
+	SharedSecretRotation ocr3_1config.SharedSecretRotation
+	Endorsements         []AttributedSharedSecretRotationEndorsementSignature
+}
+
+func (csr *CertifiedSharedSecretRotation) Verify(config ocr3_1config.PublicConfig) error {
+	if csr.SharedSecretRotation.ConfigDigest != config.ConfigDigest {
+		return fmt.Errorf("ConfigDigest mismatch, expected %v but got %v", config.ConfigDigest, csr.SharedSecretRotation.ConfigDigest)
+	}
+	if len(csr.SharedSecretRotation.SharedSecretEncryptions.Encryptions) != config.N() {
+		return fmt.Errorf("wrong number of shared secret encryptions, expected %d but got %d", config.N(), len(csr.SharedSecretRotation.SharedSecretEncryptions.Encryptions))
+	}
+	byzQuorumSize := config.ByzQuorumSize()
+	if byzQuorumSize != len(csr.Endorsements) {
+		return fmt.Errorf("wrong number of endorsements, expected %d for byz. quorum but got %d", byzQuorumSize, len(csr.Endorsements))
+	}
+
+	rotationDigest := csr.SharedSecretRotation.Digest()
+	seen := make(map[commontypes.OracleID]bool)
+	for i, aes := range csr.Endorsements {
+		if seen[aes.Signer] {
+			return fmt.Errorf("duplicate endorsement by %v", aes.Signer)
+		}
+		seen[aes.Signer] = true
+		if !(0 <= int(aes.Signer) && int(aes.Signer) < len(config.OracleIdentities)) {
+			return fmt.Errorf("signer out of bounds: %v", aes.Signer)
+		}
+		if err := aes.Signature.Verify(rotationDigest, config.OracleIdentities[aes.Signer].OffchainPublicKey); err != nil {
+			return fmt.Errorf("%v-th endorsement by %v-th oracle does not verify: %w", i, aes.Signer, err)
+		}
+	}
+	return nil
+}
+
+func (csr *CertifiedSharedSecretRotation) CheckSize(n int, f int) bool {
+	if len(csr.SharedSecretRotation.SharedSecretEncryptions.Encryptions) != n {
+		return false
+	}
+	if len(csr.Endorsements) != byzquorum.Size(n, f) {
+		return false
+	}
+	for _, aes := range csr.Endorsements {
+		if len(aes.Signature) != ed25519.SignatureSize {
+			return false
+		}
+	}
+	return true
+}
+
 type BlobDigest = blobtypes.BlobDigest
 type BlobChunkDigest = blobtypes.BlobChunkDigest
 type BlobChunkDigestsRoot = blobtypes.BlobChunkDigestsRoot
@@ -18,12 +18,14 @@ func RunStateSync[RI any](
 	ctx context.Context,
 
 	chNetToStateSync <-chan MessageToStateSyncWithSender[RI],
+	chObserverToStateSync <-chan MessageToStateSyncFromObserverWithSender[RI],
 	chOutcomeGenerationToStateSync <-chan EventToStateSync[RI],
 	chReportAttestationToStateSync <-chan EventToStateSync[RI],
 	config ocr3_1config.SharedConfig,
 	database Database,
 	id commontypes.OracleID,
 	kvDb KeyValueDatabase,
+	latencyHints OracleLatencyHints,
 	logger loghelper.LoggerWithContext,
 	netSender NetworkSender[RI],
 	reportingPlugin ocr3_1types.ReportingPlugin[RI],
@@ -49,11 +51,12 @@ func RunStateSync[RI any](
 
 	newStateSyncState(ctx,
 		chNetToStateSync,
+		chObserverToStateSync,
 		chNotificationToStateBlockReplay,
 		chNotificationToStateDestroyIfNeeded,
 		chOutcomeGenerationToStateSync,
 		chReportAttestationToStateSync,
-		config, database, id, kvDb, logger, netSender).run()
+		config, database, id, kvDb, latencyHints, logger, netSender).run()
 }
 
 type syncMode int
@@ -69,6 +72,7 @@ type stateSyncState[RI any] struct {
 	ctx context.Context
 
 	chNetToStateSync                     <-chan MessageToStateSyncWithSender[RI]
+	chObserverToStateSync                <-chan MessageToStateSyncFromObserverWithSender[RI]
 	chNotificationToStateBlockReplay     chan<- struct{}
 	chNotificationToStateDestroyIfNeeded chan<- struct{}
 	chOutcomeGenerationToStateSync       <-chan EventToStateSync[RI]
@@ -117,6 +121,8 @@ func (stasy *stateSyncState[RI]) run() {
 		select {
 		case msg := <-stasy.chNetToStateSync:
 			msg.msg.processStateSync(stasy, msg.sender)
+		case msg := <-stasy.chObserverToStateSync:
+			msg.msg.processStateSyncFromObserver(stasy, msg.observer)
 		case ev := <-stasy.chOutcomeGenerationToStateSync:
 			ev.processStateSync(stasy)
 		case ev := <-stasy.chReportAttestationToStateSync:
@@ -451,6 +457,7 @@ func (stasy *stateSyncState[RI]) mustTreeSyncToPrevInstance() bool {
 func newStateSyncState[RI any](
 	ctx context.Context,
 	chNetToStateSync <-chan MessageToStateSyncWithSender[RI],
+	chObserverToStateSync <-chan MessageToStateSyncFromObserverWithSender[RI],
 	chNotificationToStateBlockReplay chan<- struct{},
 	chNotificationToStateDestroyIfNeeded chan<- struct{},
 	chOutcomeGenerationToStateSync <-chan EventToStateSync[RI],
@@ -459,6 +466,7 @@ func newStateSyncState[RI any](
 	database Database,
 	id commontypes.OracleID,
 	kvDb KeyValueDatabase,
+	latencyHints OracleLatencyHints,
 	logger loghelper.LoggerWithContext,
 	netSender NetworkSender[RI],
 ) *stateSyncState[RI] {
@@ -477,6 +485,7 @@ func newStateSyncState[RI any](
 		ctx,
 
 		chNetToStateSync,
+		chObserverToStateSync,
 		chNotificationToStateBlockReplay,
 		chNotificationToStateDestroyIfNeeded,
 		chOutcomeGenerationToStateSync,
@@ -522,6 +531,7 @@ func newStateSyncState[RI any](
 		stasy.sendBlockSyncRequest,
 		stasy.getPendingBlocksToRequest,
 		stasy.getBlockSyncSeeders,
+		latencyFn(latencyHints),
 	)
 	stasy.treeSyncState.treeChunkRequesterGadget = requestergadget.NewRequesterGadget[treeSyncChunkRequestItem](
 		config.N(),
@@ -529,6 +539,7 @@ func newStateSyncState[RI any](
 		stasy.sendTreeSyncChunkRequest,
 		stasy.getPendingTreeSyncChunksToRequest,
 		stasy.getTreeSyncChunkSeeders,
+		latencyFn(latencyHints),
 	)
 	return stasy
 }
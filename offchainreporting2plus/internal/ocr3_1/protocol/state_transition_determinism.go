@@ -0,0 +1,532 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/smartcontractkit/libocr/internal/byzquorum"
+	"github.com/smartcontractkit/libocr/internal/jmt"
+	"github.com/smartcontractkit/libocr/internal/util"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/blobtypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// The determinism checker never writes to the database. It keeps the tree it
+// works on in memory, at versions far above any persisted version.
+const (
+	determinismCheckWorkingVersion    jmt.Version = math.MaxUint64 - 1
+	determinismCheckReExecutedVersion jmt.Version = math.MaxUint64
+)
+
+// CheckStateTransitionDeterminism re-executes plugin.StateTransition for the
+// attested blocks fromSeqNr through toSeqNr (both inclusive) and compares the
+// resulting write sets, state roots, and ReportsPlusPrecursors with the
+// attested ones. Each block is re-executed on top of the attested state of its
+// predecessor, so a single non-deterministic StateTransition does not cause
+// spurious divergences further on. Checking stops at the first divergence.
+// limits must be the ReportingPluginLimits the plugin was run with.
+func CheckStateTransitionDeterminism[RI any](
+	ctx context.Context,
+	kvDb KeyValueDatabaseReadTransactionFactory,
+	config ocr3_1config.PublicConfig,
+	plugin ocr3_1types.ReportingPlugin[RI],
+	limits ocr3_1types.ReportingPluginLimits,
+	inputsSource ocr3_1types.StateTransitionInputsSource,
+	fromSeqNr uint64,
+	toSeqNr uint64,
+) (ocr3_1types.StateTransitionDeterminismReport, error) {
+	var report ocr3_1types.StateTransitionDeterminismReport
+
+	if fromSeqNr > toSeqNr {
+		return report, fmt.Errorf("from seq nr %d is greater than to seq nr %d", fromSeqNr, toSeqNr)
+	}
+
+	tx, err := kvDb.NewReadTransactionUnchecked()
+	if err != nil {
+		return report, fmt.Errorf("failed to create read transaction: %w", err)
+	}
+	defer tx.Discard()
+
+	lowest, highest, err := retainedSeqNrs(tx, config)
+	if err != nil {
+		return report, err
+	}
+	// re-executing fromSeqNr requires the state as of fromSeqNr-1
+	if fromSeqNr <= lowest {
+		return report, fmt.Errorf("from seq nr %d must be above lowest retained seq nr %d: %w", fromSeqNr, lowest, ocr3_1types.ErrKeyValueStateSeqNrPruned)
+	}
+	if toSeqNr > highest {
+		return report, fmt.Errorf("to seq nr %d is above highest committed seq nr %d: %w", toSeqNr, highest, ocr3_1types.ErrKeyValueStateSeqNrNotCommitted)
+	}
+
+	snapshotSeqNr, ok := highestCompleteSnapshotSeqNrNotAbove(fromSeqNr-1, config)
+	if !ok {
+		snapshotSeqNr = genesisSeqNr(config)
+	}
+
+	tree := newDeterminismCheckTree(tx)
+	if _, err := jmt.BatchUpdate(tree, tree, tree, RootVersion(snapshotSeqNr, config), determinismCheckWorkingVersion, nil); err != nil {
+		return report, fmt.Errorf("failed to copy snapshot at seq nr %d: %w", snapshotSeqNr, err)
+	}
+	for seqNr := snapshotSeqNr + 1; seqNr < fromSeqNr; seqNr++ {
+		stb, err := readStateTransitionBlockForDeterminismCheck(tx, seqNr)
+		if err != nil {
+			return report, err
+		}
+		if err := tree.apply(determinismCheckWorkingVersion, stb.StateWriteSet.Entries); err != nil {
+			return report, fmt.Errorf("failed to apply write set of seq nr %d: %w", seqNr, err)
+		}
+	}
+
+	for seqNr := fromSeqNr; seqNr <= toSeqNr; seqNr++ {
+		stb, err := readStateTransitionBlockForDeterminismCheck(tx, seqNr)
+		if err != nil {
+			return report, err
+		}
+
+		candidates, err := inputsSource.StateTransitionInputs(seqNr)
+		if err != nil {
+			return report, fmt.Errorf("failed to get state transition inputs for seq nr %d: %w", seqNr, err)
+		}
+		inputsIndex := slices.IndexFunc(candidates, func(inputs ocr3_1types.StateTransitionInputs) bool {
+			return MakeStateTransitionInputsDigest(config.ConfigDigest, seqNr, inputs.AttributedQuery, inputs.AttributedObservations) == stb.StateTransitionInputsDigest
+		})
+
+		if inputsIndex < 0 {
+			report.MissingInputsSeqNrs = append(report.MissingInputsSeqNrs, seqNr)
+		} else {
+			divergence, err := reExecuteStateTransition(ctx, tx, tree, config, plugin, limits, stb, candidates[inputsIndex])
+			if err != nil {
+				return report, err
+			}
+			if divergence != nil {
+				report.Divergence = divergence
+				return report, nil
+			}
+			report.CheckedSeqNrs = append(report.CheckedSeqNrs, seqNr)
+		}
+
+		// continue from the attested state
+		if err := tree.apply(determinismCheckWorkingVersion, stb.StateWriteSet.Entries); err != nil {
+			return report, fmt.Errorf("failed to apply write set of seq nr %d: %w", seqNr, err)
+		}
+		stateRootDigest, err := jmt.ReadRootDigest(tree, tree, determinismCheckWorkingVersion)
+		if err != nil {
+			return report, fmt.Errorf("failed to read state root digest: %w", err)
+		}
+		if stateRootDigest != stb.StateRootDigest {
+			return report, fmt.Errorf("attested write set of seq nr %d does not yield attested state root, database might be corrupt", seqNr)
+		}
+	}
+	return report, nil
+}
+
+func readStateTransitionBlockForDeterminismCheck(tx KeyValueDatabaseReadTransaction, seqNr uint64) (StateTransitionBlock, error) {
+	astb, err := tx.ReadAttestedStateTransitionBlock(seqNr)
+	if err != nil {
+		return StateTransitionBlock{}, fmt.Errorf("failed to read attested state transition block %d: %w", seqNr, err)
+	}
+	if astb.StateTransitionBlock.SeqNr() != seqNr {
+		return StateTransitionBlock{}, fmt.Errorf("attested state transition block %d is missing", seqNr)
+	}
+	return astb.StateTransitionBlock, nil
+}
+
+func reExecuteStateTransition[RI any](
+	ctx context.Context,
+	tx KeyValueDatabaseReadTransaction,
+	tree *determinismCheckTree,
+	config ocr3_1config.PublicConfig,
+	plugin ocr3_1types.ReportingPlugin[RI],
+	limits ocr3_1types.ReportingPluginLimits,
+	stb StateTransitionBlock,
+	inputs ocr3_1types.StateTransitionInputs,
+) (*ocr3_1types.StateTransitionDivergence, error) {
+	seqNr := stb.SeqNr()
+	kvReadWriter := &determinismCheckKeyValueStateReadWriter{
+		sync.Mutex{},
+		tree,
+		map[string][]byte{},
+		0,
+		0,
+		limits,
+	}
+	blobReleases := newStateTransitionBlobReleases(seqNr, kvReadWriter)
+	if err := blobReleases.prune(); err != nil {
+		return nil, fmt.Errorf("failed to prune blob releases for seq nr %d: %w", seqNr, err)
+	}
+	blobFetcher := &determinismCheckBlobFetcher{
+		tx,
+		config,
+		seqNr,
+		blobReleases,
+	}
+
+	reportsPlusPrecursor, err := plugin.StateTransition(
+		ctx,
+		seqNr,
+		inputs.AttributedQuery,
+		inputs.AttributedObservations,
+		&stateTransitionKeyValueState{kvReadWriter, blobReleases},
+		blobFetcher,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-execute StateTransition for seq nr %d: %w", seqNr, err)
+	}
+
+	writeSet := kvReadWriter.writeSet()
+	stateRootDigest, err := tree.reExecutedStateRootDigest(writeSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute re-executed state root for seq nr %d: %w", seqNr, err)
+	}
+
+	key, attested, reExecuted, writeSetDiverges := firstDivergingKey(stb.StateWriteSet.Entries, writeSet)
+	divergence := ocr3_1types.StateTransitionDivergence{
+		seqNr,
+		writeSetDiverges,
+		key,
+		attested,
+		reExecuted,
+		stateRootDigest != stb.StateRootDigest,
+		MakeReportsPlusPrecursorDigest(config.ConfigDigest, seqNr, reportsPlusPrecursor) != stb.ReportsPlusPrecursorDigest,
+	}
+	if divergence.WriteSetDiverges || divergence.StateRootDiverges || divergence.ReportsPlusPrecursorDiverges {
+		return &divergence, nil
+	}
+	return nil, nil
+}
+
+// firstDivergingKey returns the lowest key on which the write sets a and b
+// disagree.
+func firstDivergingKey(a []KeyValuePairWithDeletions, b []KeyValuePairWithDeletions) ([]byte, ocr3_1types.KeyValueModification, ocr3_1types.KeyValueModification, bool) {
+	modifications := func(writeSet []KeyValuePairWithDeletions) map[string]ocr3_1types.KeyValueModification {
+		m := make(map[string]ocr3_1types.KeyValueModification, len(writeSet))
+		for _, kv := range writeSet {
+			var value []byte
+			if !kv.Deleted {
+				value = util.NilCoalesceSlice(kv.Value)
+			}
+			m[string(kv.Key)] = ocr3_1types.KeyValueModification{true, kv.Deleted, value}
+		}
+		return m
+	}
+	ma, mb := modifications(a), modifications(b)
+
+	keys := make([]string, 0, len(ma)+len(mb))
+	for key := range ma {
+		keys = append(keys, key)
+	}
+	for key := range mb {
+		if _, ok := ma[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		modA, modB := ma[key], mb[key]
+		if modA.Modified != modB.Modified || modA.Deleted != modB.Deleted || !bytes.Equal(modA.Value, modB.Value) {
+			return []byte(key), modA, modB, true
+		}
+	}
+	return nil, ocr3_1types.KeyValueModification{}, ocr3_1types.KeyValueModification{}, false
+}
+
+// determinismCheckKeyValueStateReadWriter reads from the working tree and
+// mirrors the write set semantics and limit checks of the
+// KeyValueStateReadWriter passed to StateTransition by the protocol.
+type determinismCheckKeyValueStateReadWriter struct {
+	mu                   sync.Mutex
+	tree                 *determinismCheckTree
+	modifications        map[string][]byte // nil value indicates deletion
+	keys                 int
+	keysPlusValuesLength int
+	limits               ocr3_1types.ReportingPluginLimits
+}
+
+var _ ocr3_1types.KeyValueStateReadWriter = &determinismCheckKeyValueStateReadWriter{}
+
+func (rw *determinismCheckKeyValueStateReadWriter) Read(key []byte) ([]byte, error) {
+	if !(len(key) <= ocr3_1types.MaxMaxKeyValueKeyBytes) {
+		return nil, fmt.Errorf("key length %d exceeds maximum %d", len(key), ocr3_1types.MaxMaxKeyValueKeyBytes)
+	}
+
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if value, ok := rw.modifications[string(key)]; ok {
+		return bytes.Clone(value), nil
+	}
+	return jmt.Read(rw.tree, rw.tree, determinismCheckWorkingVersion, key)
+}
+
+func (rw *determinismCheckKeyValueStateReadWriter) Write(key []byte, value []byte) error {
+	if !(len(key) <= ocr3_1types.MaxMaxKeyValueKeyBytes) {
+		return fmt.Errorf("key length %d exceeds maximum %d", len(key), ocr3_1types.MaxMaxKeyValueKeyBytes)
+	}
+	if !(len(value) <= ocr3_1types.MaxMaxKeyValueValueBytes) {
+		return fmt.Errorf("value length %d exceeds maximum %d", len(value), ocr3_1types.MaxMaxKeyValueValueBytes)
+	}
+	return rw.modify(key, util.NilCoalesceSlice(value))
+}
+
+func (rw *determinismCheckKeyValueStateReadWriter) Delete(key []byte) error {
+	if !(len(key) <= ocr3_1types.MaxMaxKeyValueKeyBytes) {
+		return fmt.Errorf("key length %d exceeds maximum %d", len(key), ocr3_1types.MaxMaxKeyValueKeyBytes)
+	}
+	return rw.modify(key, nil)
+}
+
+func (rw *determinismCheckKeyValueStateReadWriter) modify(key []byte, value []byte) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	keys, keysPlusValuesLength := rw.keys, rw.keysPlusValuesLength
+	if prevValue, ok := rw.modifications[string(key)]; ok {
+		keysPlusValuesLength += len(value) - len(prevValue)
+	} else {
+		keys++
+		keysPlusValuesLength += len(key) + len(value)
+	}
+	if keys > rw.limits.MaxKeyValueModifiedKeys {
+		return fmt.Errorf("keys %d exceed limit %d", keys, rw.limits.MaxKeyValueModifiedKeys)
+	}
+	if keysPlusValuesLength > rw.limits.MaxKeyValueModifiedKeysPlusValuesBytes {
+		return fmt.Errorf("keys + values length %d exceeds limit %d", keysPlusValuesLength, rw.limits.MaxKeyValueModifiedKeysPlusValuesBytes)
+	}
+
+	rw.modifications[string(key)] = bytes.Clone(value)
+	rw.keys, rw.keysPlusValuesLength = keys, keysPlusValuesLength
+	return nil
+}
+
+// writeSet returns the modifications sorted by key, like the write sets of
+// state transition blocks.
+func (rw *determinismCheckKeyValueStateReadWriter) writeSet() []KeyValuePairWithDeletions {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	writeSet := make([]KeyValuePairWithDeletions, 0, len(rw.modifications))
+	for key, value := range rw.modifications {
+		writeSet = append(writeSet, KeyValuePairWithDeletions{
+			[]byte(key),
+			value,
+			value == nil,
+		})
+	}
+	sort.Slice(writeSet, func(i, j int) bool {
+		return bytes.Compare(writeSet[i].Key, writeSet[j].Key) < 0
+	})
+	return writeSet
+}
+
+// determinismCheckBlobFetcher only serves blobs from local storage. Releases
+// and expiry keys are handled like in StateTransitionBlobFetcher.
+type determinismCheckBlobFetcher struct {
+	tx       KeyValueDatabaseReadTransaction
+	config   ocr3_1config.PublicConfig
+	seqNr    uint64
+	releases *stateTransitionBlobReleases
+}
+
+var _ ocr3_1types.BlobFetcher = &determinismCheckBlobFetcher{}
+var _ ocr3_1types.BlobStreamFetcher = &determinismCheckBlobFetcher{}
+var _ ocr3_1types.BlobReleaser = &determinismCheckBlobFetcher{}
+
+func (f *determinismCheckBlobFetcher) verifiedCertAndDigest(handle ocr3_1types.BlobHandle) (*LightCertifiedBlob, BlobDigest, error) {
+	cert, ok := blobtypes.ExtractBlobHandleSumType(handle).(*LightCertifiedBlob)
+	if !ok || cert == nil {
+		return nil, BlobDigest{}, fmt.Errorf("zero value blob handle provided")
+	}
+	if err := cert.Verify(f.config.ConfigDigest, f.config.OracleIdentities, byzquorum.Size(f.config.N(), f.config.F), f.config.N()); err != nil {
+		return nil, BlobDigest{}, fmt.Errorf("invalid blob handle: %w", err)
+	}
+	blobDigest := blobtypes.MakeBlobDigest(
+		f.config.ConfigDigest,
+		cert.ChunkDigestsRoot,
+		cert.PayloadLength,
+		cert.ExpirySeqNr,
+		cert.ExpiryKey,
+		cert.Submitter,
+		cert.ErasureCoded,
+	)
+	return cert, blobDigest, nil
+}
+
+func (f *determinismCheckBlobFetcher) FetchBlob(ctx context.Context, handle ocr3_1types.BlobHandle) ([]byte, error) {
+	cert, blobDigest, err := f.verifiedCertAndDigest(handle)
+	if err != nil {
+		return nil, err
+	}
+	if cert.ExpirySeqNr < f.seqNr {
+		return nil, fmt.Errorf("blob expired")
+	}
+	if err := f.releases.checkFetch(cert, blobDigest); err != nil {
+		return nil, err
+	}
+	if err := f.releases.link(cert, blobDigest); err != nil {
+		return nil, err
+	}
+
+	meta, err := f.tx.ReadBlobMeta(blobDigest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob meta: %w", err)
+	}
+	if meta == nil {
+		return nil, fmt.Errorf("blob is not available locally, it might have been reaped")
+	}
+	if meta.ErasureCoded {
+		return readErasureCodedBlobPayload(f.tx, blobDigest, makeBlobErasureCodingLayout(meta.PayloadLength, f.config))
+	}
+	payload, err := f.tx.ReadBlobPayload(blobDigest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob payload: %w", err)
+	}
+	if payload == nil {
+		return nil, fmt.Errorf("blob is not available locally, it might have been reaped")
+	}
+	return payload, nil
+}
+
+func (f *determinismCheckBlobFetcher) FetchBlobReader(ctx context.Context, handle ocr3_1types.BlobHandle) (io.ReadCloser, error) {
+	payload, err := f.FetchBlob(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(payload)), nil
+}
+
+func (f *determinismCheckBlobFetcher) ReleaseBlob(handle ocr3_1types.BlobHandle) error {
+	cert, blobDigest, err := f.verifiedCertAndDigest(handle)
+	if err != nil {
+		return err
+	}
+	return f.releases.release(ReleasedBlob{cert.ExpirySeqNr, blobDigest})
+}
+
+// determinismCheckTree overlays in-memory JMT nodes and roots on top of the
+// tree stored in the database. Stale nodes are not tracked, since the overlay
+// is thrown away after the check anyway.
+type determinismCheckTree struct {
+	tx    KeyValueDatabaseReadTransaction
+	roots map[jmt.Version]jmt.NodeKey
+	nodes map[determinismCheckNodeKey]jmt.Node // nil node indicates deletion
+}
+
+type determinismCheckNodeKey struct {
+	version    jmt.Version
+	numNibbles int
+	nibbles    string
+}
+
+func makeDeterminismCheckNodeKey(nodeKey jmt.NodeKey) determinismCheckNodeKey {
+	return determinismCheckNodeKey{
+		nodeKey.Version,
+		nodeKey.NibblePath.NumNibbles(),
+		string(nodeKey.NibblePath.Bytes()),
+	}
+}
+
+var _ jmt.RootReadWriter = &determinismCheckTree{}
+var _ jmt.NodeReadWriter = &determinismCheckTree{}
+var _ jmt.StaleNodeWriter = &determinismCheckTree{}
+
+func newDeterminismCheckTree(tx KeyValueDatabaseReadTransaction) *determinismCheckTree {
+	return &determinismCheckTree{
+		tx,
+		map[jmt.Version]jmt.NodeKey{},
+		map[determinismCheckNodeKey]jmt.Node{},
+	}
+}
+
+func (t *determinismCheckTree) ReadRoot(version jmt.Version) (jmt.NodeKey, error) {
+	if nodeKey, ok := t.roots[version]; ok {
+		return nodeKey, nil
+	}
+	return t.tx.ReadRoot(version)
+}
+
+func (t *determinismCheckTree) WriteRoot(version jmt.Version, nodeKey jmt.NodeKey) error {
+	t.roots[version] = nodeKey
+	return nil
+}
+
+func (t *determinismCheckTree) ReadNode(nodeKey jmt.NodeKey) (jmt.Node, error) {
+	if node, ok := t.nodes[makeDeterminismCheckNodeKey(nodeKey)]; ok {
+		return node, nil
+	}
+	node, err := t.tx.ReadNode(nodeKey)
+	if err != nil {
+		return nil, err
+	}
+	// Leaves only exist for present keys, but empty values are deserialized as
+	// nil, which Read would report as absent.
+	if leaf, ok := node.(*jmt.LeafNode); ok && leaf.Value == nil {
+		return &jmt.LeafNode{leaf.KeyDigest, leaf.Key, leaf.ValueDigest, []byte{}}, nil
+	}
+	return node, nil
+}
+
+func (t *determinismCheckTree) WriteNode(nodeKey jmt.NodeKey, nodeOrNil jmt.Node) error {
+	var node jmt.Node
+	switch n := nodeOrNil.(type) {
+	case *jmt.InternalNode:
+		internalNode := &jmt.InternalNode{}
+		for i, child := range n.Children {
+			if child != nil {
+				childCopy := *child
+				internalNode.Children[i] = &childCopy
+			}
+		}
+		node = internalNode
+	case *jmt.LeafNode:
+		node = &jmt.LeafNode{n.KeyDigest, bytes.Clone(n.Key), n.ValueDigest, bytes.Clone(n.Value)}
+	case nil:
+	}
+	t.nodes[makeDeterminismCheckNodeKey(nodeKey)] = node
+	return nil
+}
+
+func (t *determinismCheckTree) WriteStaleNode(jmt.StaleNode) error {
+	return nil
+}
+
+// apply applies writeSet in place at version.
+func (t *determinismCheckTree) apply(version jmt.Version, writeSet []KeyValuePairWithDeletions) error {
+	_, err := jmt.BatchUpdate(t, t, t, version, version, determinismCheckKeyValueUpdates(writeSet))
+	return err
+}
+
+// reExecutedStateRootDigest returns the state root digest resulting from
+// applying writeSet to the working tree, leaving the working tree unchanged.
+func (t *determinismCheckTree) reExecutedStateRootDigest(writeSet []KeyValuePairWithDeletions) (StateRootDigest, error) {
+	defer func() {
+		delete(t.roots, determinismCheckReExecutedVersion)
+		for nodeKey := range t.nodes {
+			if nodeKey.version == determinismCheckReExecutedVersion {
+				delete(t.nodes, nodeKey)
+			}
+		}
+	}()
+	if _, err := jmt.BatchUpdate(t, t, t, determinismCheckWorkingVersion, determinismCheckReExecutedVersion, determinismCheckKeyValueUpdates(writeSet)); err != nil {
+		return StateRootDigest{}, err
+	}
+	return jmt.ReadRootDigest(t, t, determinismCheckReExecutedVersion)
+}
+
+func determinismCheckKeyValueUpdates(writeSet []KeyValuePairWithDeletions) []jmt.KeyValue {
+	updates := make([]jmt.KeyValue, 0, len(writeSet))
+	for _, kv := range writeSet {
+		var value []byte
+		if !kv.Deleted {
+			value = util.NilCoalesceSlice(kv.Value)
+		}
+		updates = append(updates, jmt.KeyValue{kv.Key, value})
+	}
+	return updates
+}
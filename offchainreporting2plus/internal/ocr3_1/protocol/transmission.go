@@ -13,6 +13,7 @@ import (
 
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/common/pendingtransmissions"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/common/scheduler"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3types"
@@ -29,11 +30,16 @@ func RunTransmission[RI any](
 	chReportAttestationToTransmission <-chan EventToTransmission[RI],
 	config ocr3_1config.SharedConfig,
 	contractTransmitter ocr3types.ContractTransmitter[RI],
+	database Database,
 	id commontypes.OracleID,
 	localConfig types.LocalConfig,
 	logger loghelper.LoggerWithContext,
 	reportingPlugin ocr3_1types.ReportingPlugin[RI],
+	reportInfoCodec ocr3types.ReportInfoCodec[RI],
+	sharedSecretSchedule *SharedSecretSchedule,
 ) {
+	logger = logger.MakeUpdated(commontypes.LogFields{"proto": "transmission"})
+
 	sched := scheduler.NewScheduler[EventAttestedReport[RI]]()
 	defer sched.Close()
 
@@ -44,12 +50,21 @@ func RunTransmission[RI any](
 		chReportAttestationToTransmission,
 		config,
 		contractTransmitter,
+		database,
 		id,
 		localConfig,
-		logger.MakeUpdated(commontypes.LogFields{"proto": "transmission"}),
+		logger,
 		reportingPlugin,
+		reportInfoCodec,
+		sharedSecretSchedule,
 
 		sched,
+		pendingtransmissions.NewTracker[PendingTransmission](
+			ctx,
+			logger,
+			pendingTransmissionDatabase{database, config.ConfigDigest},
+			localConfig.DatabaseTimeout,
+		),
 	}
 	t.run()
 }
@@ -61,18 +76,25 @@ type transmissionState[RI any] struct {
 	chReportAttestationToTransmission <-chan EventToTransmission[RI]
 	config                            ocr3_1config.SharedConfig
 	contractTransmitter               ocr3types.ContractTransmitter[RI]
+	database                          Database
 	id                                commontypes.OracleID
 	localConfig                       types.LocalConfig
 	logger                            loghelper.LoggerWithContext
 	reportingPlugin                   ocr3_1types.ReportingPlugin[RI]
+	reportInfoCodec                   ocr3types.ReportInfoCodec[RI] // may be nil
+	sharedSecretSchedule              *SharedSecretSchedule
 
 	scheduler *scheduler.Scheduler[EventAttestedReport[RI]]
+
+	pendingTransmissions *pendingtransmissions.Tracker[PendingTransmission]
 }
 
 // run runs the event loop for the local transmission protocol
 func (t *transmissionState[RI]) run() {
 	t.logger.Info("Transmission: running", nil)
 
+	t.resumePendingTransmissions()
+
 	chDone := t.ctx.Done()
 	for {
 		select {
@@ -106,7 +128,7 @@ func (t *transmissionState[RI]) eventAttestedReport(ev EventAttestedReport[RI])
 func (t *transmissionState[RI]) backgroundEventAttestedReport(ctx context.Context, start time.Time, ev EventAttestedReport[RI]) {
 	var delay time.Duration
 	{
-		delayMaybe := t.transmitDelay(ev.SeqNr, ev.Index, ev.TransmissionScheduleOverride)
+		delayMaybe := t.transmitDelay(ev.SeqNr, ev.Epoch, ev.Index, ev.TransmissionScheduleOverride)
 		if delayMaybe == nil {
 			t.logger.Debug("dropping EventAttestedReport because we're not included in transmission schedule", commontypes.LogFields{
 				"seqNr":                        ev.SeqNr,
@@ -153,7 +175,16 @@ func (t *transmissionState[RI]) backgroundEventAttestedReport(ctx context.Contex
 		"delay":                        delay.String(),
 		"transmissionScheduleOverride": ev.TransmissionScheduleOverride != nil,
 	})
-	t.scheduler.ScheduleDeadline(ev, start.Add(delay))
+	scheduledAt := start.Add(delay)
+	expiresAt := start.Add(t.lastStageDelay(ev.TransmissionScheduleOverride) + t.config.DeltaStage)
+	if !t.addPendingTransmission(ev, scheduledAt, expiresAt) {
+		t.logger.Debug("AttestedReport is already scheduled for transmission", commontypes.LogFields{
+			"seqNr": ev.SeqNr,
+			"index": ev.Index,
+		})
+		return
+	}
+	t.scheduler.ScheduleDeadline(ev, scheduledAt)
 }
 
 func (t *transmissionState[RI]) scheduled(ev EventAttestedReport[RI]) {
@@ -163,6 +194,14 @@ func (t *transmissionState[RI]) scheduled(ev EventAttestedReport[RI]) {
 }
 
 func (t *transmissionState[RI]) backgroundScheduled(ctx context.Context, ev EventAttestedReport[RI]) {
+	defer func() {
+		// If we are shutting down, the transmission remains pending so that
+		// it is resumed after a restart
+		if ctx.Err() == nil {
+			t.removePendingTransmission(ev.SeqNr, ev.Index)
+		}
+	}()
+
 	shouldTransmit, ok := common.CallPlugin[bool](
 		ctx,
 		t.logger,
@@ -238,8 +277,8 @@ func (t *transmissionState[RI]) backgroundScheduled(ctx context.Context, ev Even
 	})
 }
 
-func (t *transmissionState[RI]) transmitPermutationKey(seqNr uint64, index int) [16]byte {
-	transmissionOrderKey := t.config.TransmissionOrderKey()
+func (t *transmissionState[RI]) transmitPermutationKey(seqNr uint64, epoch uint64, index int) [16]byte {
+	transmissionOrderKey := t.sharedSecretSchedule.TransmissionOrderKey(epoch)
 	mac := hmac.New(sha256.New, transmissionOrderKey[:])
 	_ = binary.Write(mac, binary.BigEndian, seqNr)
 	_ = binary.Write(mac, binary.BigEndian, uint64(index))
@@ -249,7 +288,7 @@ func (t *transmissionState[RI]) transmitPermutationKey(seqNr uint64, index int)
 	return key
 }
 
-func (t *transmissionState[RI]) transmitDelayFromOverride(seqNr uint64, index int, transmissionScheduleOverride ocr3types.TransmissionSchedule) *time.Duration {
+func (t *transmissionState[RI]) transmitDelayFromOverride(seqNr uint64, epoch uint64, index int, transmissionScheduleOverride ocr3types.TransmissionSchedule) *time.Duration {
 	if len(transmissionScheduleOverride.TransmissionDelays) != len(transmissionScheduleOverride.Transmitters) {
 		t.logger.Error("invalid TransmissionScheduleOverride, cannot compute delay", commontypes.LogFields{
 			"seqNr":                        seqNr,
@@ -263,13 +302,13 @@ func (t *transmissionState[RI]) transmitDelayFromOverride(seqNr uint64, index in
 	if oracleIndex < 0 {
 		return nil
 	}
-	pi := permutation.Permutation(len(transmissionScheduleOverride.TransmissionDelays), t.transmitPermutationKey(seqNr, index))
+	pi := permutation.Permutation(len(transmissionScheduleOverride.TransmissionDelays), t.transmitPermutationKey(seqNr, epoch, index))
 	delay := transmissionScheduleOverride.TransmissionDelays[pi[oracleIndex]]
 	return &delay
 }
 
-func (t *transmissionState[RI]) transmitDelayDefault(seqNr uint64, index int) *time.Duration {
-	pi := permutation.Permutation(t.config.N(), t.transmitPermutationKey(seqNr, index))
+func (t *transmissionState[RI]) transmitDelayDefault(seqNr uint64, epoch uint64, index int) *time.Duration {
+	pi := permutation.Permutation(t.config.N(), t.transmitPermutationKey(seqNr, epoch, index))
 	sum := 0
 	for i, s := range t.config.S {
 		sum += s
@@ -281,10 +320,10 @@ func (t *transmissionState[RI]) transmitDelayDefault(seqNr uint64, index int) *t
 	return nil
 }
 
-func (t *transmissionState[RI]) transmitDelay(seqNr uint64, index int, transmissionScheduleOverride *ocr3types.TransmissionSchedule) *time.Duration {
+func (t *transmissionState[RI]) transmitDelay(seqNr uint64, epoch uint64, index int, transmissionScheduleOverride *ocr3types.TransmissionSchedule) *time.Duration {
 	if transmissionScheduleOverride != nil {
-		return t.transmitDelayFromOverride(seqNr, index, *transmissionScheduleOverride)
+		return t.transmitDelayFromOverride(seqNr, epoch, index, *transmissionScheduleOverride)
 	} else {
-		return t.transmitDelayDefault(seqNr, index)
+		return t.transmitDelayDefault(seqNr, epoch, index)
 	}
 }
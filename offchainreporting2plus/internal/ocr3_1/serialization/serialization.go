@@ -20,6 +20,9 @@ func Serialize[RI any](m protocol.Message[RI]) ([]byte, *MessageWrapper, error)
 	if err != nil {
 		return nil, nil, err
 	}
+	// Tells the receiver that it may send us blob chunk requests at low
+	// priority
+	pb.AcceptsLowPriorityBlobChunks = true
 	b, err := proto.Marshal(pb)
 	if err != nil {
 		return nil, nil, err
@@ -80,6 +83,7 @@ func SerializeBlobMeta(m protocol.BlobMeta) ([]byte, error) {
 		tpm.chunkDigests(m.ChunkDigests),
 		m.ExpirySeqNr,
 		uint32(m.Submitter),
+		m.ErasureCoded,
 	}
 	return proto.Marshal(&pb)
 }
@@ -196,6 +200,7 @@ func DeserializeBlobMeta(b []byte) (protocol.BlobMeta, error) {
 		chunkDigests,
 		pb.ExpirySeqNr,
 		commontypes.OracleID(pb.Submitter),
+		pb.ErasureCoded,
 	}, nil
 }
 
@@ -470,6 +475,9 @@ func (tpm *toProtoMessage[RI]) messageWrapper(m protocol.Message[RI]) (*MessageW
 			v.ChunkDigestsRoot[:],
 			v.PayloadLength,
 			v.ExpirySeqNr,
+			v.ErasureCoded,
+			tpm.chunkDigests(v.ChunkDigests),
+			v.ExpiryKey,
 		}
 		msgWrapper.Msg = &MessageWrapper_MessageBlobOffer{pm}
 	case protocol.MessageBlobOfferResponse[RI]:
@@ -513,6 +521,28 @@ func (tpm *toProtoMessage[RI]) messageWrapper(m protocol.Message[RI]) (*MessageW
 			pbProof,
 		}
 		msgWrapper.Msg = &MessageWrapper_MessageBlobChunkResponse{pm}
+	case protocol.MessageSharedSecretRotationEndorsement[RI]:
+		pm := &MessageSharedSecretRotationEndorsement{
+			// zero-initialize protobuf built-ins
+			protoimpl.MessageState{},
+			0,
+			nil,
+			// fields
+			v.RotationNumber,
+			v.RotationDigest[:],
+			v.Signature,
+		}
+		msgWrapper.Msg = &MessageWrapper_MessageSharedSecretRotationEndorsement{pm}
+	case protocol.MessageSharedSecretRotationCertificate[RI]:
+		pm := &MessageSharedSecretRotationCertificate{
+			// zero-initialize protobuf built-ins
+			protoimpl.MessageState{},
+			0,
+			nil,
+			// fields
+			tpm.certifiedSharedSecretRotation(v.CertifiedSharedSecretRotation),
+		}
+		msgWrapper.Msg = &MessageWrapper_MessageSharedSecretRotationCertificate{pm}
 	default:
 		return nil, fmt.Errorf("unable to serialize message of type %T", m)
 
@@ -919,6 +949,10 @@ func (fpm *fromProtoMessage[RI]) messageWrapper(wrapper *MessageWrapper) (protoc
 		return fpm.messageBlobChunkRequest(wrapper.GetMessageBlobChunkRequest())
 	case *MessageWrapper_MessageBlobChunkResponse:
 		return fpm.messageBlobChunkResponse(wrapper.GetMessageBlobChunkResponse())
+	case *MessageWrapper_MessageSharedSecretRotationEndorsement:
+		return fpm.messageSharedSecretRotationEndorsement(wrapper.GetMessageSharedSecretRotationEndorsement())
+	case *MessageWrapper_MessageSharedSecretRotationCertificate:
+		return fpm.messageSharedSecretRotationCertificate(wrapper.GetMessageSharedSecretRotationCertificate())
 
 	default:
 		return nil, fmt.Errorf("unrecognized Msg type %T", msg)
@@ -1483,12 +1517,23 @@ func (fpm *fromProtoMessage[RI]) messageBlobOffer(m *MessageBlobOffer) (protocol
 	}
 	var chunkDigestsRoot mt.Digest
 	copy(chunkDigestsRoot[:], m.ChunkDigestsRoot)
+	var chunkDigests []protocol.BlobChunkDigest
+	if len(m.ChunkDigests) != 0 {
+		var err error
+		chunkDigests, err = fpm.chunkDigests(m.ChunkDigests)
+		if err != nil {
+			return protocol.MessageBlobOffer[RI]{}, err
+		}
+	}
 	return protocol.MessageBlobOffer[RI]{
 		fpm.requestHandle,
 		types.EmptyRequestInfoForInboundRequest,
 		chunkDigestsRoot,
 		m.PayloadLength,
 		m.ExpirySeqNr,
+		m.ExpiryKey,
+		m.ErasureCoded,
+		chunkDigests,
 	}, nil
 }
 
@@ -0,0 +1,112 @@
+package serialization
+
+import (
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"google.golang.org/protobuf/proto"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+)
+
+func SerializePendingTransmission(pt protocol.PendingTransmission) ([]byte, error) {
+	pbSignatures := make([]*PendingTransmissionAttributedSignature, 0, len(pt.AttributedSignatures))
+	for _, aos := range pt.AttributedSignatures {
+		pbSignatures = append(pbSignatures, &PendingTransmissionAttributedSignature{
+			// zero-initialize protobuf built-ins
+			protoimpl.MessageState{},
+			0,
+			nil,
+			// fields
+			aos.Signature,
+			uint32(aos.Signer),
+		})
+	}
+	pb := PendingTransmission{
+		// zero-initialize protobuf built-ins
+		protoimpl.MessageState{},
+		0,
+		nil,
+		// fields
+		pt.SeqNr,
+		uint64(pt.Index),
+		pt.ScheduledAt.UnixNano(),
+		pt.ExpiresAt.UnixNano(),
+		pt.Report,
+		pt.ReportInfo,
+		pbSignatures,
+		pt.Epoch,
+	}
+
+	return proto.Marshal(&pb)
+}
+
+// This oracle wrote the pending transmission, so it's fine to trust it.
+func DeserializeTrustedPendingTransmission(b []byte) (protocol.PendingTransmission, error) {
+	pb := PendingTransmission{}
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return protocol.PendingTransmission{}, err
+	}
+
+	signatures := make([]types.AttributedOnchainSignature, 0, len(pb.AttributedSignatures))
+	for _, pbaos := range pb.AttributedSignatures {
+		signatures = append(signatures, types.AttributedOnchainSignature{
+			pbaos.Signature,
+			commontypes.OracleID(pbaos.Signer),
+		})
+	}
+	return protocol.PendingTransmission{
+		pb.SeqNr,
+		pb.Epoch,
+		int(pb.Index),
+		time.Unix(0, pb.ScheduledAtUnixNano),
+		time.Unix(0, pb.ExpiresAtUnixNano),
+		pb.Report,
+		pb.ReportInfo,
+		signatures,
+	}, nil
+}
+
+func SerializePendingTransmissionKeys(keys []protocol.PendingTransmissionKey) ([]byte, error) {
+	pbKeys := make([]*PendingTransmissionKey, 0, len(keys))
+	for _, key := range keys {
+		pbKeys = append(pbKeys, &PendingTransmissionKey{
+			// zero-initialize protobuf built-ins
+			protoimpl.MessageState{},
+			0,
+			nil,
+			// fields
+			key.SeqNr,
+			uint64(key.Index),
+		})
+	}
+	pb := PendingTransmissionKeys{
+		// zero-initialize protobuf built-ins
+		protoimpl.MessageState{},
+		0,
+		nil,
+		// fields
+		pbKeys,
+	}
+
+	return proto.Marshal(&pb)
+}
+
+// This oracle wrote the keys, so it's fine to trust them.
+func DeserializeTrustedPendingTransmissionKeys(b []byte) ([]protocol.PendingTransmissionKey, error) {
+	pb := PendingTransmissionKeys{}
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return nil, err
+	}
+
+	keys := make([]protocol.PendingTransmissionKey, 0, len(pb.Keys))
+	for _, pbKey := range pb.Keys {
+		keys = append(keys, protocol.PendingTransmissionKey{
+			pbKey.SeqNr,
+			int(pbKey.Index),
+		})
+	}
+	return keys, nil
+}
@@ -0,0 +1,235 @@
+package serialization
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+
+	"google.golang.org/protobuf/proto"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+)
+
+func SerializeSharedSecretRotation(r ocr3_1config.SharedSecretRotation) ([]byte, error) {
+	tpm := toProtoMessage[struct{}]{}
+
+	return proto.Marshal(tpm.sharedSecretRotation(r))
+}
+
+func DeserializeSharedSecretRotation(b []byte) (ocr3_1config.SharedSecretRotation, error) {
+	pb := SharedSecretRotation{}
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return ocr3_1config.SharedSecretRotation{}, fmt.Errorf("could not unmarshal protobuf: %w", err)
+	}
+
+	fpm := fromProtoMessage[struct{}]{}
+	return fpm.sharedSecretRotation(&pb)
+}
+
+func SerializeCertifiedSharedSecretRotations(csrs []protocol.CertifiedSharedSecretRotation) ([]byte, error) {
+	tpm := toProtoMessage[struct{}]{}
+
+	pbCsrs := make([]*CertifiedSharedSecretRotation, 0, len(csrs))
+	for _, csr := range csrs {
+		pbCsrs = append(pbCsrs, tpm.certifiedSharedSecretRotation(csr))
+	}
+	pb := CertifiedSharedSecretRotations{
+		// zero-initialize protobuf built-ins
+		protoimpl.MessageState{},
+		0,
+		nil,
+		// fields
+		pbCsrs,
+	}
+
+	return proto.Marshal(&pb)
+}
+
+// This oracle wrote the certified rotations, so it's fine to trust them.
+func DeserializeTrustedCertifiedSharedSecretRotations(b []byte) ([]protocol.CertifiedSharedSecretRotation, error) {
+	pb := CertifiedSharedSecretRotations{}
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return nil, fmt.Errorf("could not unmarshal protobuf: %w", err)
+	}
+
+	// We trust the certified rotations we deserialize here, so we can simply
+	// use the maximum number of oracles for n.
+	fpm := fromProtoMessage[struct{}]{types.MaxOracles, nil}
+	csrs := make([]protocol.CertifiedSharedSecretRotation, 0, len(pb.CertifiedSharedSecretRotations))
+	for _, pbCsr := range pb.CertifiedSharedSecretRotations {
+		csr, err := fpm.certifiedSharedSecretRotation(pbCsr)
+		if err != nil {
+			return nil, err
+		}
+		csrs = append(csrs, csr)
+	}
+	return csrs, nil
+}
+
+func SerializeSharedSecretRotationEndorsement(e protocol.SharedSecretRotationEndorsement) ([]byte, error) {
+	tpm := toProtoMessage[struct{}]{}
+
+	pb := SharedSecretRotationEndorsement{
+		// zero-initialize protobuf built-ins
+		protoimpl.MessageState{},
+		0,
+		nil,
+		// fields
+		tpm.sharedSecretRotation(e.SharedSecretRotation),
+		e.Signature,
+	}
+
+	return proto.Marshal(&pb)
+}
+
+// This oracle wrote the endorsement, so it's fine to trust it.
+func DeserializeTrustedSharedSecretRotationEndorsement(b []byte) (protocol.SharedSecretRotationEndorsement, error) {
+	pb := SharedSecretRotationEndorsement{}
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return protocol.SharedSecretRotationEndorsement{}, fmt.Errorf("could not unmarshal protobuf: %w", err)
+	}
+
+	fpm := fromProtoMessage[struct{}]{}
+	rotation, err := fpm.sharedSecretRotation(pb.SharedSecretRotation)
+	if err != nil {
+		return protocol.SharedSecretRotationEndorsement{}, err
+	}
+	return protocol.SharedSecretRotationEndorsement{
+		rotation,
+		pb.Signature,
+	}, nil
+}
+
+func (tpm *toProtoMessage[RI]) sharedSecretRotation(r ocr3_1config.SharedSecretRotation) *SharedSecretRotation {
+	encryptions := make([][]byte, 0, len(r.SharedSecretEncryptions.Encryptions))
+	for _, encryption := range r.SharedSecretEncryptions.Encryptions {
+		encryptions = append(encryptions, encryption[:])
+	}
+	return &SharedSecretRotation{
+		// zero-initialize protobuf built-ins
+		protoimpl.MessageState{},
+		0,
+		nil,
+		// fields
+		r.ConfigDigest[:],
+		r.RotationNumber,
+		r.ActivationEpoch,
+		r.SharedSecretEncryptions.DiffieHellmanPoint[:],
+		r.SharedSecretEncryptions.SharedSecretHash[:],
+		encryptions,
+	}
+}
+
+func (tpm *toProtoMessage[RI]) certifiedSharedSecretRotation(csr protocol.CertifiedSharedSecretRotation) *CertifiedSharedSecretRotation {
+	endorsements := make([]*AttributedSharedSecretRotationEndorsement, 0, len(csr.Endorsements))
+	for _, aes := range csr.Endorsements {
+		endorsements = append(endorsements, &AttributedSharedSecretRotationEndorsement{
+			// zero-initialize protobuf built-ins
+			protoimpl.MessageState{},
+			0,
+			nil,
+			// fields
+			aes.Signature,
+			uint32(aes.Signer),
+		})
+	}
+	return &CertifiedSharedSecretRotation{
+		// zero-initialize protobuf built-ins
+		protoimpl.MessageState{},
+		0,
+		nil,
+		// fields
+		tpm.sharedSecretRotation(csr.SharedSecretRotation),
+		endorsements,
+	}
+}
+
+func (fpm *fromProtoMessage[RI]) messageSharedSecretRotationEndorsement(m *MessageSharedSecretRotationEndorsement) (protocol.MessageSharedSecretRotationEndorsement[RI], error) {
+	if m == nil {
+		return protocol.MessageSharedSecretRotationEndorsement[RI]{}, fmt.Errorf("unable to extract a MessageSharedSecretRotationEndorsement value")
+	}
+	var rotationDigest ocr3_1config.SharedSecretRotationDigest
+	if len(m.RotationDigest) != len(rotationDigest) {
+		return protocol.MessageSharedSecretRotationEndorsement[RI]{}, fmt.Errorf("RotationDigest has wrong length. Expected %v bytes, got %v bytes", len(rotationDigest), len(m.RotationDigest))
+	}
+	copy(rotationDigest[:], m.RotationDigest)
+	return protocol.MessageSharedSecretRotationEndorsement[RI]{
+		m.RotationNumber,
+		rotationDigest,
+		m.Signature,
+	}, nil
+}
+
+func (fpm *fromProtoMessage[RI]) messageSharedSecretRotationCertificate(m *MessageSharedSecretRotationCertificate) (protocol.MessageSharedSecretRotationCertificate[RI], error) {
+	if m == nil {
+		return protocol.MessageSharedSecretRotationCertificate[RI]{}, fmt.Errorf("unable to extract a MessageSharedSecretRotationCertificate value")
+	}
+	csr, err := fpm.certifiedSharedSecretRotation(m.CertifiedSharedSecretRotation)
+	if err != nil {
+		return protocol.MessageSharedSecretRotationCertificate[RI]{}, err
+	}
+	return protocol.MessageSharedSecretRotationCertificate[RI]{
+		csr,
+	}, nil
+}
+
+func (fpm *fromProtoMessage[RI]) certifiedSharedSecretRotation(m *CertifiedSharedSecretRotation) (protocol.CertifiedSharedSecretRotation, error) {
+	if m == nil {
+		return protocol.CertifiedSharedSecretRotation{}, fmt.Errorf("unable to extract a CertifiedSharedSecretRotation value")
+	}
+	rotation, err := fpm.sharedSecretRotation(m.SharedSecretRotation)
+	if err != nil {
+		return protocol.CertifiedSharedSecretRotation{}, err
+	}
+	endorsements := make([]protocol.AttributedSharedSecretRotationEndorsementSignature, 0, len(m.Endorsements))
+	for _, aes := range m.Endorsements {
+		if aes == nil {
+			return protocol.CertifiedSharedSecretRotation{}, fmt.Errorf("unable to extract an AttributedSharedSecretRotationEndorsement value")
+		}
+		signer, err := fpm.oracleID(aes.GetSigner())
+		if err != nil {
+			return protocol.CertifiedSharedSecretRotation{}, err
+		}
+		endorsements = append(endorsements, protocol.AttributedSharedSecretRotationEndorsementSignature{
+			aes.GetSignature(),
+			signer,
+		})
+	}
+	return protocol.CertifiedSharedSecretRotation{
+		rotation,
+		endorsements,
+	}, nil
+}
+
+func (fpm *fromProtoMessage[RI]) sharedSecretRotation(m *SharedSecretRotation) (ocr3_1config.SharedSecretRotation, error) {
+	if m == nil {
+		return ocr3_1config.SharedSecretRotation{}, fmt.Errorf("unable to extract a SharedSecretRotation value")
+	}
+	var r ocr3_1config.SharedSecretRotation
+	if len(m.ConfigDigest) != len(r.ConfigDigest) {
+		return ocr3_1config.SharedSecretRotation{}, fmt.Errorf("ConfigDigest has wrong length. Expected %v bytes, got %v bytes", len(r.ConfigDigest), len(m.ConfigDigest))
+	}
+	copy(r.ConfigDigest[:], m.ConfigDigest)
+	r.RotationNumber = m.RotationNumber
+	r.ActivationEpoch = m.ActivationEpoch
+	if len(m.DiffieHellmanPoint) != len(r.SharedSecretEncryptions.DiffieHellmanPoint) {
+		return ocr3_1config.SharedSecretRotation{}, fmt.Errorf("DiffieHellmanPoint has wrong length. Expected %v bytes, got %v bytes", len(r.SharedSecretEncryptions.DiffieHellmanPoint), len(m.DiffieHellmanPoint))
+	}
+	copy(r.SharedSecretEncryptions.DiffieHellmanPoint[:], m.DiffieHellmanPoint)
+	if len(m.SharedSecretHash) != len(r.SharedSecretEncryptions.SharedSecretHash) {
+		return ocr3_1config.SharedSecretRotation{}, fmt.Errorf("SharedSecretHash has wrong length. Expected %v bytes, got %v bytes", len(r.SharedSecretEncryptions.SharedSecretHash), len(m.SharedSecretHash))
+	}
+	copy(r.SharedSecretEncryptions.SharedSecretHash[:], m.SharedSecretHash)
+	r.SharedSecretEncryptions.Encryptions = make([]config.EncryptedSharedSecret, 0, len(m.Encryptions))
+	for i, encryptionRaw := range m.Encryptions {
+		var encryption config.EncryptedSharedSecret
+		if len(encryptionRaw) != len(encryption) {
+			return ocr3_1config.SharedSecretRotation{}, fmt.Errorf("Encryptions[%v] has wrong length. Expected %v bytes, got %v bytes", i, len(encryption), len(encryptionRaw))
+		}
+		copy(encryption[:], encryptionRaw)
+		r.SharedSecretEncryptions.Encryptions = append(r.SharedSecretEncryptions.Encryptions, encryption)
+	}
+	return r, nil
+}
@@ -2,6 +2,7 @@ package shim
 
 import (
 	"context"
+	"slices"
 
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
 
@@ -16,6 +17,11 @@ type SerializingOCR3_1Database struct {
 
 var _ protocol.Database = (*SerializingOCR3_1Database)(nil)
 
+const (
+	sharedSecretRotationsKey           = "sharedSecretRotations"
+	sharedSecretRotationEndorsementKey = "sharedSecretRotationEndorsement"
+)
+
 func (db *SerializingOCR3_1Database) ReadConfig(ctx context.Context) (*types.ContractConfig, error) {
 	return db.BinaryDb.ReadConfig(ctx)
 }
@@ -73,3 +79,154 @@ func (db *SerializingOCR3_1Database) WriteCert(ctx context.Context, configDigest
 
 	return db.BinaryDb.WriteProtocolState(ctx, configDigest, certKey, raw)
 }
+
+func (db *SerializingOCR3_1Database) ReadPendingTransmissions(ctx context.Context, configDigest types.ConfigDigest) ([]protocol.PendingTransmission, error) {
+	keys, err := db.readPendingTransmissionKeys(ctx, configDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	var pendingTransmissions []protocol.PendingTransmission
+	var presentKeys []protocol.PendingTransmissionKey
+	for _, key := range keys {
+		raw, err := db.BinaryDb.ReadProtocolState(ctx, configDigest, pendingTransmissionKey(key.SeqNr, key.Index))
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		pt, err := serialization.DeserializeTrustedPendingTransmission(raw)
+		if err != nil {
+			return nil, err
+		}
+		pendingTransmissions = append(pendingTransmissions, pt)
+		presentKeys = append(presentKeys, key)
+	}
+
+	// Drop keys whose write or delete was interrupted
+	if len(presentKeys) != len(keys) {
+		if err := db.writePendingTransmissionKeys(ctx, configDigest, presentKeys); err != nil {
+			return nil, err
+		}
+	}
+	return pendingTransmissions, nil
+}
+
+// The key is recorded before the entry is written, so that no entry is ever
+// left unreachable.
+func (db *SerializingOCR3_1Database) WritePendingTransmission(ctx context.Context, configDigest types.ConfigDigest, pendingTransmission protocol.PendingTransmission) error {
+	raw, err := serialization.SerializePendingTransmission(pendingTransmission)
+	if err != nil {
+		return err
+	}
+
+	keys, err := db.readPendingTransmissionKeys(ctx, configDigest)
+	if err != nil {
+		return err
+	}
+	key := protocol.PendingTransmissionKey{pendingTransmission.SeqNr, pendingTransmission.Index}
+	if !slices.Contains(keys, key) {
+		if err := db.writePendingTransmissionKeys(ctx, configDigest, append(keys, key)); err != nil {
+			return err
+		}
+	}
+
+	return db.BinaryDb.WriteProtocolState(ctx, configDigest, pendingTransmissionKey(key.SeqNr, key.Index), raw)
+}
+
+func (db *SerializingOCR3_1Database) DeletePendingTransmission(ctx context.Context, configDigest types.ConfigDigest, seqNr uint64, index int) error {
+	if err := db.BinaryDb.WriteProtocolState(ctx, configDigest, pendingTransmissionKey(seqNr, index), nil); err != nil {
+		return err
+	}
+
+	keys, err := db.readPendingTransmissionKeys(ctx, configDigest)
+	if err != nil {
+		return err
+	}
+	i := slices.Index(keys, protocol.PendingTransmissionKey{seqNr, index})
+	if i < 0 {
+		return nil
+	}
+	return db.writePendingTransmissionKeys(ctx, configDigest, slices.Delete(keys, i, i+1))
+}
+
+func (db *SerializingOCR3_1Database) readPendingTransmissionKeys(ctx context.Context, configDigest types.ConfigDigest) ([]protocol.PendingTransmissionKey, error) {
+	raw, err := db.BinaryDb.ReadProtocolState(ctx, configDigest, pendingTransmissionKeysKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	return serialization.DeserializeTrustedPendingTransmissionKeys(raw)
+}
+
+// Writing no keys is the same as deleting.
+func (db *SerializingOCR3_1Database) writePendingTransmissionKeys(ctx context.Context, configDigest types.ConfigDigest, keys []protocol.PendingTransmissionKey) error {
+	if len(keys) == 0 {
+		return db.BinaryDb.WriteProtocolState(ctx, configDigest, pendingTransmissionKeysKey, nil)
+	}
+
+	raw, err := serialization.SerializePendingTransmissionKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	return db.BinaryDb.WriteProtocolState(ctx, configDigest, pendingTransmissionKeysKey, raw)
+}
+
+func (db *SerializingOCR3_1Database) ReadSharedSecretRotations(ctx context.Context, configDigest types.ConfigDigest) ([]protocol.CertifiedSharedSecretRotation, error) {
+	raw, err := db.BinaryDb.ReadProtocolState(ctx, configDigest, sharedSecretRotationsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	return serialization.DeserializeTrustedCertifiedSharedSecretRotations(raw)
+}
+
+// Writing no rotations is the same as deleting.
+func (db *SerializingOCR3_1Database) WriteSharedSecretRotations(ctx context.Context, configDigest types.ConfigDigest, rotations []protocol.CertifiedSharedSecretRotation) error {
+	if len(rotations) == 0 {
+		return db.BinaryDb.WriteProtocolState(ctx, configDigest, sharedSecretRotationsKey, nil)
+	}
+
+	raw, err := serialization.SerializeCertifiedSharedSecretRotations(rotations)
+	if err != nil {
+		return err
+	}
+
+	return db.BinaryDb.WriteProtocolState(ctx, configDigest, sharedSecretRotationsKey, raw)
+}
+
+func (db *SerializingOCR3_1Database) ReadSharedSecretRotationEndorsement(ctx context.Context, configDigest types.ConfigDigest) (*protocol.SharedSecretRotationEndorsement, error) {
+	raw, err := db.BinaryDb.ReadProtocolState(ctx, configDigest, sharedSecretRotationEndorsementKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	endorsement, err := serialization.DeserializeTrustedSharedSecretRotationEndorsement(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &endorsement, nil
+}
+
+func (db *SerializingOCR3_1Database) WriteSharedSecretRotationEndorsement(ctx context.Context, configDigest types.ConfigDigest, endorsement protocol.SharedSecretRotationEndorsement) error {
+	raw, err := serialization.SerializeSharedSecretRotationEndorsement(endorsement)
+	if err != nil {
+		return err
+	}
+
+	return db.BinaryDb.WriteProtocolState(ctx, configDigest, sharedSecretRotationEndorsementKey, raw)
+}
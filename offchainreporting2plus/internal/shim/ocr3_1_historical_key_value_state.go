@@ -0,0 +1,57 @@
+package shim
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// readOnlyOCR3_1KeyValueDatabase only hands out read transactions. Unlike
+// NewSemanticOCR3_1KeyValueDatabase, constructing it never writes to the
+// underlying database, which makes it suitable for inspecting the database of
+// a (possibly running) protocol instance from external tooling.
+type readOnlyOCR3_1KeyValueDatabase struct {
+	keyValueDatabase ocr3_1types.KeyValueDatabase
+	config           ocr3_1config.PublicConfig
+}
+
+var _ protocol.KeyValueDatabaseReadTransactionFactory = readOnlyOCR3_1KeyValueDatabase{}
+
+func (r readOnlyOCR3_1KeyValueDatabase) NewReadTransactionUnchecked() (protocol.KeyValueDatabaseReadTransaction, error) {
+	tx, err := r.keyValueDatabase.NewReadTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create read transaction: %w", err)
+	}
+	return &SemanticOCR3_1KeyValueDatabaseReadTransaction{tx, r.config}, nil
+}
+
+func newReadOnlyOCR3_1KeyValueDatabase(keyValueDatabase ocr3_1types.KeyValueDatabase, config ocr3_1config.PublicConfig) (readOnlyOCR3_1KeyValueDatabase, error) {
+	rawTx, err := keyValueDatabase.NewReadTransaction()
+	if err != nil {
+		return readOnlyOCR3_1KeyValueDatabase{}, fmt.Errorf("failed to create read transaction: %w", err)
+	}
+	defer rawTx.Discard()
+
+	schemaVersion, err := readSchemaVersion(rawTx)
+	if err != nil {
+		return readOnlyOCR3_1KeyValueDatabase{}, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if schemaVersion == nil {
+		return readOnlyOCR3_1KeyValueDatabase{}, fmt.Errorf("database has not been initialized")
+	}
+	if *schemaVersion != supportedSchemaVersion {
+		return readOnlyOCR3_1KeyValueDatabase{}, fmt.Errorf("unsupported schema version: %q, we support: %q", *schemaVersion, supportedSchemaVersion)
+	}
+
+	return readOnlyOCR3_1KeyValueDatabase{keyValueDatabase, config}, nil
+}
+
+func NewOCR3_1HistoricalKeyValueState(keyValueDatabase ocr3_1types.KeyValueDatabase, config ocr3_1config.PublicConfig) (ocr3_1types.HistoricalKeyValueState, error) {
+	readOnlyKeyValueDatabase, err := newReadOnlyOCR3_1KeyValueDatabase(keyValueDatabase, config)
+	if err != nil {
+		return nil, err
+	}
+	return protocol.NewHistoricalKeyValueState(readOnlyKeyValueDatabase, config), nil
+}
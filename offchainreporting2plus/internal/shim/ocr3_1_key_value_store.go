@@ -1030,6 +1030,38 @@ func (s *SemanticOCR3_1KeyValueDatabaseReadWriteTransaction) DeleteStaleBlobInde
 	return s.rawTransaction.Delete(staleBlobIndexPrefixKey(staleBlob))
 }
 
+func (s *SemanticOCR3_1KeyValueDatabaseReadTransaction) ReadReleasedBlobs(minExpirySeqNr uint64, maxExpirySeqNrs int) ([]protocol.ReleasedBlob, bool, error) {
+	// Releases are recorded by the protocol in the plugin's key space, under
+	// keys that order by expiry seqNr.
+	it := s.rawTransaction.Range(
+		pluginPrefixedUnhashedKey(protocol.ReleasedBlobsKey(minExpirySeqNr)),
+		pluginPrefixedUnhashedKey(append(protocol.ReleasedBlobsKey(math.MaxUint64), 0)),
+	)
+	defer it.Close()
+
+	var releasedBlobs []protocol.ReleasedBlob
+
+	i := 0
+	for ; i < maxExpirySeqNrs && it.Next(); i++ {
+		key := it.Key()[len(pluginPrefix):]
+		value, err := it.Value()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read released blobs value: %w", err)
+		}
+		releasedBlobsWithExpirySeqNr, err := protocol.ParseReleasedBlobs(key, value)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse released blobs: %w", err)
+		}
+		releasedBlobs = append(releasedBlobs, releasedBlobsWithExpirySeqNr...)
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, false, fmt.Errorf("error iterating over released blobs: %w", err)
+	}
+
+	return releasedBlobs, i == maxExpirySeqNrs, nil
+}
+
 func (s *SemanticOCR3_1KeyValueDatabaseReadTransaction) ReadReportsPlusPrecursor(seqNr uint64, reportsPlusPrecursorDigest protocol.ReportsPlusPrecursorDigest) (*ocr3_1types.ReportsPlusPrecursor, error) {
 	reportsPlusPrecursor, err := s.rawTransaction.Read(reportsPlusPrecursorKey(seqNr, reportsPlusPrecursorDigest))
 	if err != nil {
@@ -0,0 +1,25 @@
+package shim
+
+import (
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// OCR3_1OracleLatencyHints looks up the latencies of the oracles of a config
+// by their PeerIDs.
+type OCR3_1OracleLatencyHints struct {
+	PeerLatencyHints ocr3_1types.PeerLatencyHints
+	PeerIDs          []string
+}
+
+var _ protocol.OracleLatencyHints = (*OCR3_1OracleLatencyHints)(nil)
+
+func (h *OCR3_1OracleLatencyHints) RoundTripLatency(oracleID commontypes.OracleID) (time.Duration, bool) {
+	if int(oracleID) < 0 || int(oracleID) >= len(h.PeerIDs) {
+		return 0, false
+	}
+	return h.PeerLatencyHints.RoundTripLatency(h.PeerIDs[oracleID])
+}
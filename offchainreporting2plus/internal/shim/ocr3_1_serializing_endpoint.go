@@ -4,6 +4,7 @@ package shim
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -25,10 +26,14 @@ type OCR3_1SerializingEndpoint[RI any] struct {
 	maxSigLen              int
 	logger                 commontypes.Logger
 	metrics                *serializingEndpointMetrics
+	numObservers           int
 	pluginLimits           ocr3_1types.ReportingPluginLimits
 	publicConfig           ocr3_1config.PublicConfig
 	serializedLengthLimits limits.OCR3_1SerializedLengthLimits
 
+	// Indexed by OracleID
+	peersAcceptingLowPriorityBlobChunks []atomic.Bool
+
 	mutex        sync.Mutex
 	subprocesses subprocesses.Subprocesses
 	started      bool
@@ -48,6 +53,7 @@ func NewOCR3_1SerializingEndpoint[RI any](
 	maxSigLen int,
 	logger commontypes.Logger,
 	metricsRegisterer prometheus.Registerer,
+	numObservers int,
 	pluginLimits ocr3_1types.ReportingPluginLimits,
 	publicConfig ocr3_1config.PublicConfig,
 	serializedLengthLimits limits.OCR3_1SerializedLengthLimits,
@@ -59,10 +65,13 @@ func NewOCR3_1SerializingEndpoint[RI any](
 		maxSigLen,
 		logger,
 		newSerializingEndpointMetrics(metricsRegisterer, logger),
+		numObservers,
 		pluginLimits,
 		publicConfig,
 		serializedLengthLimits,
 
+		make([]atomic.Bool, publicConfig.N()),
+
 		sync.Mutex{},
 		subprocesses.Subprocesses{},
 		false,
@@ -93,7 +102,23 @@ func (n *OCR3_1SerializingEndpoint[RI]) sendTelemetry(t *serialization.Telemetry
 	}
 }
 
-func (n *OCR3_1SerializingEndpoint[RI]) toOutboundBinaryMessage(msg protocol.Message[RI]) (outboundBinaryMessage types.OutboundBinaryMessage, pbMessageForTelemetry *serialization.MessageWrapper) {
+// Blob chunks are bulk data and shouldn't delay consensus messages, so we
+// request them at low priority from peers that have told us they accept this.
+// Older oracles only accept blob chunks at default priority and don't tell us
+// anything, so we keep requesting at default priority from them. Responses
+// are sent at the priority of the request.
+func (n *OCR3_1SerializingEndpoint[RI]) blobChunkRequestPriority(to commontypes.OracleID) types.BinaryMessageOutboundPriority {
+	if int(to) < len(n.peersAcceptingLowPriorityBlobChunks) && n.peersAcceptingLowPriorityBlobChunks[to].Load() {
+		return types.BinaryMessagePriorityLow
+	}
+	return types.BinaryMessagePriorityDefault
+}
+
+func isBlobChunkInboundPriority(priority types.BinaryMessageOutboundPriority) bool {
+	return priority == types.BinaryMessagePriorityDefault || priority == types.BinaryMessagePriorityLow
+}
+
+func (n *OCR3_1SerializingEndpoint[RI]) toOutboundBinaryMessage(msg protocol.Message[RI], blobChunkRequestPriority types.BinaryMessageOutboundPriority) (outboundBinaryMessage types.OutboundBinaryMessage, pbMessageForTelemetry *serialization.MessageWrapper) {
 	if !msg.CheckSize(n.publicConfig.N(), n.publicConfig.F, n.pluginLimits, n.maxSigLen, n.publicConfig) {
 		n.logger.Error("OCR3_1SerializingEndpoint: Dropping outgoing message because it fails size check", commontypes.LogFields{
 			"limits": n.pluginLimits,
@@ -188,12 +213,16 @@ func (n *OCR3_1SerializingEndpoint[RI]) toOutboundBinaryMessage(msg protocol.Mes
 				msg.RequestInfo.ExpiryTimestamp,
 			},
 			payload,
-			types.BinaryMessagePriorityDefault,
+			blobChunkRequestPriority,
 		}, pbMessageForTelemetry
 	case protocol.MessageBlobChunkResponse[RI]:
 		return msg.RequestHandle.MakeResponse(payload), pbMessageForTelemetry
 	case protocol.MessageBlobOfferResponse[RI]:
 		return msg.RequestHandle.MakeResponse(payload), pbMessageForTelemetry
+	case protocol.MessageSharedSecretRotationEndorsement[RI]:
+		return types.OutboundBinaryMessagePlain{payload, types.BinaryMessagePriorityDefault}, pbMessageForTelemetry
+	case protocol.MessageSharedSecretRotationCertificate[RI]:
+		return types.OutboundBinaryMessagePlain{payload, types.BinaryMessagePriorityDefault}, pbMessageForTelemetry
 	}
 
 	panic("unreachable")
@@ -294,13 +323,21 @@ func (n *OCR3_1SerializingEndpoint[RI]) fromInboundBinaryMessage(inboundBinaryMe
 			return protocol.MessageBlobOfferResponse[RI]{}, pbMessageForTelemetry, fmt.Errorf("wrong type or priority for MessageBlobOfferResponse")
 		}
 	case protocol.MessageBlobChunkRequest[RI]:
-		if ibm, ok := inboundBinaryMessage.(types.InboundBinaryMessageRequest); !ok || ibm.Priority != types.BinaryMessagePriorityDefault {
+		if ibm, ok := inboundBinaryMessage.(types.InboundBinaryMessageRequest); !ok || !isBlobChunkInboundPriority(ibm.Priority) {
 			return protocol.MessageBlobChunkRequest[RI]{}, pbMessageForTelemetry, fmt.Errorf("wrong type or priority for MessageBlobChunkRequest")
 		}
 	case protocol.MessageBlobChunkResponse[RI]:
-		if ibm, ok := inboundBinaryMessage.(types.InboundBinaryMessageResponse); !ok || ibm.Priority != types.BinaryMessagePriorityDefault {
+		if ibm, ok := inboundBinaryMessage.(types.InboundBinaryMessageResponse); !ok || !isBlobChunkInboundPriority(ibm.Priority) {
 			return protocol.MessageBlobChunkResponse[RI]{}, pbMessageForTelemetry, fmt.Errorf("wrong type or priority for MessageBlobChunkResponse")
 		}
+	case protocol.MessageSharedSecretRotationEndorsement[RI]:
+		if ibm, ok := inboundBinaryMessage.(types.InboundBinaryMessagePlain); !ok || ibm.Priority != types.BinaryMessagePriorityDefault {
+			return protocol.MessageSharedSecretRotationEndorsement[RI]{}, pbMessageForTelemetry, fmt.Errorf("wrong type or priority for MessageSharedSecretRotationEndorsement")
+		}
+	case protocol.MessageSharedSecretRotationCertificate[RI]:
+		if ibm, ok := inboundBinaryMessage.(types.InboundBinaryMessagePlain); !ok || ibm.Priority != types.BinaryMessagePriorityDefault {
+			return protocol.MessageSharedSecretRotationCertificate[RI]{}, pbMessageForTelemetry, fmt.Errorf("wrong type or priority for MessageSharedSecretRotationCertificate")
+		}
 	}
 
 	if !message.CheckSize(n.publicConfig.N(), n.publicConfig.F, n.pluginLimits, n.maxSigLen, n.publicConfig) {
@@ -362,6 +399,21 @@ func (n *OCR3_1SerializingEndpoint[RI]) Start() error {
 					break
 				}
 
+				// Observers are assigned OracleIDs >= N and may only request
+				// committed state and reports
+				if int(raw.Sender) >= n.publicConfig.N() && !protocol.IsObserverRequest(message) {
+					n.logger.Warn("OCR3_1SerializingEndpoint: Dropping message from observer that observers may not send", commontypes.LogFields{
+						"sender": raw.Sender,
+					})
+					break
+				}
+
+				// Follow the peer's latest message, so that we notice if it
+				// is downgraded to an older release
+				if int(raw.Sender) < len(n.peersAcceptingLowPriorityBlobChunks) {
+					n.peersAcceptingLowPriorityBlobChunks[raw.Sender].Store(pbMessageForTelemetry.GetAcceptsLowPriorityBlobChunks())
+				}
+
 				redactPbMessageForTelemetryToSaveBandwidth(pbMessageForTelemetry)
 				n.sendTelemetry(&serialization.TelemetryWrapper{
 					Wrapped: &serialization.TelemetryWrapper_MessageReceived{&serialization.TelemetryMessageReceived{
@@ -412,7 +464,7 @@ func (n *OCR3_1SerializingEndpoint[RI]) Close() error {
 }
 
 func (n *OCR3_1SerializingEndpoint[RI]) SendTo(msg protocol.Message[RI], to commontypes.OracleID) {
-	oMsg, pbMessageForTelemetry := n.toOutboundBinaryMessage(msg)
+	oMsg, pbMessageForTelemetry := n.toOutboundBinaryMessage(msg, n.blobChunkRequestPriority(to))
 	if oMsg != nil {
 		n.endpoint.SendTo(oMsg, to)
 		redactPbMessageForTelemetryToSaveBandwidth(pbMessageForTelemetry)
@@ -429,9 +481,17 @@ func (n *OCR3_1SerializingEndpoint[RI]) SendTo(msg protocol.Message[RI], to comm
 }
 
 func (n *OCR3_1SerializingEndpoint[RI]) Broadcast(msg protocol.Message[RI]) {
-	oMsg, pbMessageForTelemetry := n.toOutboundBinaryMessage(msg)
+	oMsg, pbMessageForTelemetry := n.toOutboundBinaryMessage(msg, types.BinaryMessagePriorityDefault)
 	if oMsg != nil {
-		n.endpoint.Broadcast(oMsg)
+		if n.numObservers == 0 || protocol.IsBroadcastToObservers(msg) {
+			n.endpoint.Broadcast(oMsg)
+		} else {
+			// The underlying endpoint also counts observers as peers, but most
+			// messages are only meant for oracles
+			for i := 0; i < n.publicConfig.N(); i++ {
+				n.endpoint.SendTo(oMsg, commontypes.OracleID(i))
+			}
+		}
 		redactPbMessageForTelemetryToSaveBandwidth(pbMessageForTelemetry)
 		n.sendTelemetry(&serialization.TelemetryWrapper{
 			Wrapped: &serialization.TelemetryWrapper_MessageBroadcast{&serialization.TelemetryMessageBroadcast{
@@ -0,0 +1,34 @@
+package shim
+
+import (
+	"context"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/serialization"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+type SerializingOCR3_1SharedSecretRotationSource struct {
+	BinarySource ocr3_1types.SharedSecretRotationSource
+}
+
+var _ protocol.SharedSecretRotationSource = (*SerializingOCR3_1SharedSecretRotationSource)(nil)
+
+func (s *SerializingOCR3_1SharedSecretRotationSource) LatestSharedSecretRotation(ctx context.Context, configDigest types.ConfigDigest) (*ocr3_1config.SharedSecretRotation, error) {
+	raw, err := s.BinarySource.LatestSharedSecretRotation(ctx, configDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	rotation, err := serialization.DeserializeSharedSecretRotation(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &rotation, nil
+}
@@ -0,0 +1,35 @@
+package shim
+
+import (
+	"context"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+func CheckOCR3_1StateTransitionDeterminism[RI any](
+	ctx context.Context,
+	keyValueDatabase ocr3_1types.KeyValueDatabase,
+	config ocr3_1config.PublicConfig,
+	reportingPlugin ocr3_1types.ReportingPlugin[RI],
+	reportingPluginLimits ocr3_1types.ReportingPluginLimits,
+	inputsSource ocr3_1types.StateTransitionInputsSource,
+	fromSeqNr uint64,
+	toSeqNr uint64,
+) (ocr3_1types.StateTransitionDeterminismReport, error) {
+	readOnlyKeyValueDatabase, err := newReadOnlyOCR3_1KeyValueDatabase(keyValueDatabase, config)
+	if err != nil {
+		return ocr3_1types.StateTransitionDeterminismReport{}, err
+	}
+	return protocol.CheckStateTransitionDeterminism(
+		ctx,
+		readOnlyKeyValueDatabase,
+		config,
+		reportingPlugin,
+		reportingPluginLimits,
+		inputsSource,
+		fromSeqNr,
+		toSeqNr,
+	)
+}
@@ -2,6 +2,8 @@ package shim
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3/protocol"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3/serialization"
@@ -19,6 +21,15 @@ const pacemakerKey = "pacemaker"
 
 const certKey = "cert"
 
+// Pending transmissions are stored under individual keys. Since
+// ProtocolStateDatabase can't enumerate keys, their keys are kept in a list
+// under pendingTransmissionKeysKey.
+const pendingTransmissionKeysKey = "pendingTransmissionKeys"
+
+func pendingTransmissionKey(seqNr uint64, index int) string {
+	return fmt.Sprintf("pendingTransmission|%d|%d", seqNr, index)
+}
+
 func (db *SerializingOCR3Database) ReadConfig(ctx context.Context) (*types.ContractConfig, error) {
 	return db.BinaryDb.ReadConfig(ctx)
 }
@@ -76,3 +87,101 @@ func (db *SerializingOCR3Database) WriteCert(ctx context.Context, configDigest t
 
 	return db.BinaryDb.WriteProtocolState(ctx, configDigest, certKey, raw)
 }
+
+func (db *SerializingOCR3Database) ReadPendingTransmissions(ctx context.Context, configDigest types.ConfigDigest) ([]protocol.PendingTransmission, error) {
+	keys, err := db.readPendingTransmissionKeys(ctx, configDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	var pendingTransmissions []protocol.PendingTransmission
+	var presentKeys []protocol.PendingTransmissionKey
+	for _, key := range keys {
+		raw, err := db.BinaryDb.ReadProtocolState(ctx, configDigest, pendingTransmissionKey(key.SeqNr, key.Index))
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		pt, err := serialization.DeserializeTrustedPendingTransmission(raw)
+		if err != nil {
+			return nil, err
+		}
+		pendingTransmissions = append(pendingTransmissions, pt)
+		presentKeys = append(presentKeys, key)
+	}
+
+	// Drop keys whose write or delete was interrupted
+	if len(presentKeys) != len(keys) {
+		if err := db.writePendingTransmissionKeys(ctx, configDigest, presentKeys); err != nil {
+			return nil, err
+		}
+	}
+	return pendingTransmissions, nil
+}
+
+// The key is recorded before the entry is written, so that no entry is ever
+// left unreachable.
+func (db *SerializingOCR3Database) WritePendingTransmission(ctx context.Context, configDigest types.ConfigDigest, pendingTransmission protocol.PendingTransmission) error {
+	raw, err := serialization.SerializePendingTransmission(pendingTransmission)
+	if err != nil {
+		return err
+	}
+
+	keys, err := db.readPendingTransmissionKeys(ctx, configDigest)
+	if err != nil {
+		return err
+	}
+	key := protocol.PendingTransmissionKey{pendingTransmission.SeqNr, pendingTransmission.Index}
+	if !slices.Contains(keys, key) {
+		if err := db.writePendingTransmissionKeys(ctx, configDigest, append(keys, key)); err != nil {
+			return err
+		}
+	}
+
+	return db.BinaryDb.WriteProtocolState(ctx, configDigest, pendingTransmissionKey(key.SeqNr, key.Index), raw)
+}
+
+func (db *SerializingOCR3Database) DeletePendingTransmission(ctx context.Context, configDigest types.ConfigDigest, seqNr uint64, index int) error {
+	if err := db.BinaryDb.WriteProtocolState(ctx, configDigest, pendingTransmissionKey(seqNr, index), nil); err != nil {
+		return err
+	}
+
+	keys, err := db.readPendingTransmissionKeys(ctx, configDigest)
+	if err != nil {
+		return err
+	}
+	i := slices.Index(keys, protocol.PendingTransmissionKey{seqNr, index})
+	if i < 0 {
+		return nil
+	}
+	return db.writePendingTransmissionKeys(ctx, configDigest, slices.Delete(keys, i, i+1))
+}
+
+func (db *SerializingOCR3Database) readPendingTransmissionKeys(ctx context.Context, configDigest types.ConfigDigest) ([]protocol.PendingTransmissionKey, error) {
+	raw, err := db.BinaryDb.ReadProtocolState(ctx, configDigest, pendingTransmissionKeysKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	return serialization.DeserializeTrustedPendingTransmissionKeys(raw)
+}
+
+// Writing no keys is the same as deleting.
+func (db *SerializingOCR3Database) writePendingTransmissionKeys(ctx context.Context, configDigest types.ConfigDigest, keys []protocol.PendingTransmissionKey) error {
+	if len(keys) == 0 {
+		return db.BinaryDb.WriteProtocolState(ctx, configDigest, pendingTransmissionKeysKey, nil)
+	}
+
+	raw, err := serialization.SerializePendingTransmissionKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	return db.BinaryDb.WriteProtocolState(ctx, configDigest, pendingTransmissionKeysKey, raw)
+}
@@ -0,0 +1,853 @@
+// Package kvdbtest checks implementations of [ocr3_1types.KeyValueDatabase]
+// and [ocr3_1types.KeyValueDatabaseFactory] against the parts of their
+// contract that are easy to get wrong: at most one open read-write
+// transaction, nil values on Write meaning empty slices, Range semantics with
+// nil bounds, snapshot isolation for read transactions, and Discard after
+// Commit being a no-op.
+//
+// Run the suites from a test in the package of the implementation:
+//
+//	func TestConformance(t *testing.T) {
+//		kvdbtest.RunFactoryConformanceTests(t, func(t *testing.T) ocr3_1types.KeyValueDatabaseFactory {
+//			return keyvaluedatabase.NewPebbleKeyValueDatabaseFactory(t.TempDir())
+//		})
+//	}
+package kvdbtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// NewKeyValueDatabaseFunc must return a fresh, empty database. The suite
+// closes it.
+type NewKeyValueDatabaseFunc func(t *testing.T) ocr3_1types.KeyValueDatabase
+
+// NewKeyValueDatabaseFactoryFunc must return a factory that hasn't created
+// any databases yet. Calling it twice within the same test must return
+// factories backed by different storage.
+type NewKeyValueDatabaseFactoryFunc func(t *testing.T) ocr3_1types.KeyValueDatabaseFactory
+
+// How long a second NewReadWriteTransaction call must stay blocked for us to
+// consider it blocked.
+const blockedGracePeriod = 100 * time.Millisecond
+
+// RunConformanceTests runs all KeyValueDatabase conformance tests as subtests
+// of t.
+func RunConformanceTests(t *testing.T, newDatabase NewKeyValueDatabaseFunc) {
+	tests := []struct {
+		name string
+		test func(*testing.T, ocr3_1types.KeyValueDatabase)
+	}{
+		{"ReadMissingKey", testReadMissingKey},
+		{"WriteNilValue", testWriteNilValue},
+		{"Delete", testDelete},
+		{"ReadYourWrites", testReadYourWrites},
+		{"RangeBounds", testRangeBounds},
+		{"Iterator", testIterator},
+		{"Discard", testDiscard},
+		{"DiscardAfterCommit", testDiscardAfterCommit},
+		{"SnapshotIsolation", testSnapshotIsolation},
+		{"SingleReadWriteTransaction", testSingleReadWriteTransaction},
+		{"RandomOperations", testRandomOperations},
+		{"ConcurrentReadersAndWriter", testConcurrentReadersAndWriter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDatabase(t)
+			t.Cleanup(func() {
+				if err := db.Close(); err != nil {
+					t.Errorf("Close: %v", err)
+				}
+			})
+			tt.test(t, db)
+		})
+	}
+}
+
+// RunFactoryConformanceTests runs the KeyValueDatabaseFactory conformance
+// tests and, on databases created by the factory, all tests of
+// RunConformanceTests.
+func RunFactoryConformanceTests(t *testing.T, newFactory NewKeyValueDatabaseFactoryFunc) {
+	t.Run("NewKeyValueDatabaseIfExists", func(t *testing.T) {
+		testNewKeyValueDatabaseIfExists(t, newFactory(t))
+	})
+	t.Run("Persistence", func(t *testing.T) {
+		testPersistence(t, newFactory(t))
+	})
+	t.Run("ConfigDigestIsolation", func(t *testing.T) {
+		testConfigDigestIsolation(t, newFactory(t))
+	})
+	t.Run("KeyValueDatabase", func(t *testing.T) {
+		RunConformanceTests(t, func(t *testing.T) ocr3_1types.KeyValueDatabase {
+			db, err := newFactory(t).NewKeyValueDatabase(types.ConfigDigest{0x42})
+			if err != nil {
+				t.Fatalf("NewKeyValueDatabase: %v", err)
+			}
+			return db
+		})
+	})
+}
+
+// ────────────────────────── helpers ───────────────────────────
+
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+func newReadWriteTransaction(t *testing.T, db ocr3_1types.KeyValueDatabase) ocr3_1types.KeyValueDatabaseReadWriteTransaction {
+	t.Helper()
+	tx, err := db.NewReadWriteTransaction()
+	if err != nil {
+		t.Fatalf("NewReadWriteTransaction: %v", err)
+	}
+	return tx
+}
+
+func newReadTransaction(t *testing.T, db ocr3_1types.KeyValueDatabase) ocr3_1types.KeyValueDatabaseReadTransaction {
+	t.Helper()
+	tx, err := db.NewReadTransaction()
+	if err != nil {
+		t.Fatalf("NewReadTransaction: %v", err)
+	}
+	return tx
+}
+
+func write(t *testing.T, tx ocr3_1types.KeyValueDatabaseReadWriteTransaction, key []byte, value []byte) {
+	t.Helper()
+	if err := tx.Write(key, value); err != nil {
+		t.Fatalf("Write(%x): %v", key, err)
+	}
+}
+
+func commit(t *testing.T, tx ocr3_1types.KeyValueDatabaseReadWriteTransaction) {
+	t.Helper()
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+func writeCommitted(t *testing.T, db ocr3_1types.KeyValueDatabase, kvs ...keyValue) {
+	t.Helper()
+	tx := newReadWriteTransaction(t, db)
+	for _, kv := range kvs {
+		write(t, tx, kv.key, kv.value)
+	}
+	commit(t, tx)
+}
+
+func assertRead(t *testing.T, tx ocr3_1types.KeyValueDatabaseReadTransaction, key []byte, expected []byte) {
+	t.Helper()
+	if err := checkRead(tx, key, expected); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkRead(tx ocr3_1types.KeyValueDatabaseReadTransaction, key []byte, expected []byte) error {
+	value, err := tx.Read(key)
+	if err != nil {
+		return fmt.Errorf("Read(%x): %w", key, err)
+	}
+	if expected == nil {
+		if value != nil {
+			return fmt.Errorf("Read(%x) = %x, expected key to be absent", key, value)
+		}
+		return nil
+	}
+	if value == nil {
+		return fmt.Errorf("Read(%x) = nil, expected %x", key, expected)
+	}
+	if !bytes.Equal(value, expected) {
+		return fmt.Errorf("Read(%x) = %x, expected %x", key, value, expected)
+	}
+	return nil
+}
+
+func collectRange(t *testing.T, tx ocr3_1types.KeyValueDatabaseReadTransaction, loKey []byte, hiKeyExcl []byte) []keyValue {
+	t.Helper()
+	kvs, err := readRange(tx, loKey, hiKeyExcl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kvs
+}
+
+func readRange(tx ocr3_1types.KeyValueDatabaseReadTransaction, loKey []byte, hiKeyExcl []byte) (kvs []keyValue, err error) {
+	it := tx.Range(loKey, hiKeyExcl)
+	defer func() {
+		if closeErr := it.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("Close iterator: %w", closeErr)
+		}
+	}()
+	for it.Next() {
+		value, err := it.Value()
+		if err != nil {
+			return nil, fmt.Errorf("Value: %w", err)
+		}
+		if value == nil {
+			return nil, fmt.Errorf("Value of key %x is nil, must be non-nil for existing keys", it.Key())
+		}
+		kvs = append(kvs, keyValue{it.Key(), value})
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("Err: %w", err)
+	}
+	return kvs, nil
+}
+
+func assertRange(t *testing.T, tx ocr3_1types.KeyValueDatabaseReadTransaction, loKey []byte, hiKeyExcl []byte, expected []keyValue) {
+	t.Helper()
+	if err := checkRange(tx, loKey, hiKeyExcl, expected); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkRange(tx ocr3_1types.KeyValueDatabaseReadTransaction, loKey []byte, hiKeyExcl []byte, expected []keyValue) error {
+	actual, err := readRange(tx, loKey, hiKeyExcl)
+	if err != nil {
+		return err
+	}
+	if err := equalKeyValues(actual, expected); err != nil {
+		return fmt.Errorf("Range(%x, %x): %w", loKey, hiKeyExcl, err)
+	}
+	return nil
+}
+
+func equalKeyValues(actual []keyValue, expected []keyValue) error {
+	if len(actual) != len(expected) {
+		return fmt.Errorf("got %d key-value pairs, expected %d", len(actual), len(expected))
+	}
+	for i := range actual {
+		if !bytes.Equal(actual[i].key, expected[i].key) {
+			return fmt.Errorf("key #%d is %x, expected %x", i, actual[i].key, expected[i].key)
+		}
+		if !bytes.Equal(actual[i].value, expected[i].value) {
+			return fmt.Errorf("value of key %x is %x, expected %x", actual[i].key, actual[i].value, expected[i].value)
+		}
+	}
+	return nil
+}
+
+// ────────────────────────── KeyValueDatabase ───────────────────────────
+
+func testReadMissingKey(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	tx := newReadTransaction(t, db)
+	defer tx.Discard()
+	assertRead(t, tx, []byte("missing"), nil)
+	assertRange(t, tx, nil, nil, nil)
+}
+
+func testWriteNilValue(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	writeCommitted(t, db, keyValue{[]byte("nil"), nil}, keyValue{[]byte("empty"), []byte{}})
+
+	tx := newReadTransaction(t, db)
+	defer tx.Discard()
+	assertRead(t, tx, []byte("nil"), []byte{})
+	assertRead(t, tx, []byte("empty"), []byte{})
+	assertRange(t, tx, nil, nil, []keyValue{{[]byte("empty"), []byte{}}, {[]byte("nil"), []byte{}}})
+}
+
+func testDelete(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	writeCommitted(t, db, keyValue{[]byte("a"), []byte("1")}, keyValue{[]byte("b"), []byte("2")})
+
+	rwTx := newReadWriteTransaction(t, db)
+	if err := rwTx.Delete([]byte("a")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := rwTx.Delete([]byte("never-written")); err != nil {
+		t.Fatalf("Delete of absent key must succeed: %v", err)
+	}
+	commit(t, rwTx)
+
+	tx := newReadTransaction(t, db)
+	defer tx.Discard()
+	assertRead(t, tx, []byte("a"), nil)
+	assertRange(t, tx, nil, nil, []keyValue{{[]byte("b"), []byte("2")}})
+}
+
+func testReadYourWrites(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	writeCommitted(t, db, keyValue{[]byte("a"), []byte("1")}, keyValue{[]byte("c"), []byte("3")})
+
+	rwTx := newReadWriteTransaction(t, db)
+	defer rwTx.Discard()
+	write(t, rwTx, []byte("b"), []byte("2"))
+	write(t, rwTx, []byte("c"), []byte("33"))
+	if err := rwTx.Delete([]byte("a")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	assertRead(t, rwTx, []byte("a"), nil)
+	assertRead(t, rwTx, []byte("b"), []byte("2"))
+	assertRead(t, rwTx, []byte("c"), []byte("33"))
+	assertRange(t, rwTx, nil, nil, []keyValue{{[]byte("b"), []byte("2")}, {[]byte("c"), []byte("33")}})
+}
+
+func testRangeBounds(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	all := []keyValue{
+		{[]byte{0x00}, []byte("0")},
+		{[]byte{0x00, 0x00}, []byte("00")},
+		{[]byte{0x01}, []byte("1")},
+		{[]byte{0x01, 0xff}, []byte("1ff")},
+		{[]byte{0x02}, []byte("2")},
+		{[]byte{0xff}, []byte("ff")},
+		{[]byte{0xff, 0xff}, []byte("ffff")},
+	}
+	// write in reverse so that insertion order doesn't accidentally match
+	rwTx := newReadWriteTransaction(t, db)
+	for i := len(all) - 1; i >= 0; i-- {
+		write(t, rwTx, all[i].key, all[i].value)
+	}
+	commit(t, rwTx)
+
+	tx := newReadTransaction(t, db)
+	defer tx.Discard()
+	assertRange(t, tx, nil, nil, all)
+	assertRange(t, tx, []byte{}, []byte{}, all)
+	assertRange(t, tx, []byte{0x01}, nil, all[2:])
+	assertRange(t, tx, []byte{0x01}, []byte{}, all[2:])
+	assertRange(t, tx, nil, []byte{0x01}, all[:2])
+	assertRange(t, tx, []byte{}, []byte{0x01}, all[:2])
+	assertRange(t, tx, []byte{0x01}, []byte{0x02}, all[2:4])
+	assertRange(t, tx, []byte{0x00, 0x01}, []byte{0x01, 0xff}, all[2:3])
+	assertRange(t, tx, []byte{0x01}, []byte{0x01}, nil)
+	assertRange(t, tx, []byte{0x03}, []byte{0xff}, nil)
+	assertRange(t, tx, []byte{0xff, 0xff, 0x00}, nil, nil)
+}
+
+func testIterator(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	writeCommitted(t, db, keyValue{[]byte("a"), []byte("1")}, keyValue{[]byte("b"), []byte("2")})
+
+	tx := newReadTransaction(t, db)
+	defer tx.Discard()
+
+	// closing without iterating must work
+	if err := tx.Range(nil, nil).Close(); err != nil {
+		t.Fatalf("Close of unused iterator: %v", err)
+	}
+
+	it := tx.Range(nil, nil)
+	if !it.Next() {
+		t.Fatalf("Next = false, expected true")
+	}
+	key := it.Key()
+	value, err := it.Value()
+	if err != nil {
+		t.Fatalf("Value: %v", err)
+	}
+	// keys and values must stay valid after advancing the iterator
+	if !it.Next() {
+		t.Fatalf("Next = false, expected true")
+	}
+	if !bytes.Equal(key, []byte("a")) || !bytes.Equal(value, []byte("1")) {
+		t.Fatalf("first key-value pair changed to %x: %x after Next", key, value)
+	}
+	if err := it.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if it.Next() {
+		t.Fatalf("Next after Close = true, expected false")
+	}
+}
+
+func testDiscard(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	writeCommitted(t, db, keyValue{[]byte("a"), []byte("1")})
+
+	rwTx := newReadWriteTransaction(t, db)
+	write(t, rwTx, []byte("a"), []byte("2"))
+	write(t, rwTx, []byte("b"), []byte("2"))
+	rwTx.Discard()
+	// Discard must be idempotent
+	rwTx.Discard()
+
+	tx := newReadTransaction(t, db)
+	defer tx.Discard()
+	assertRead(t, tx, []byte("a"), []byte("1"))
+	assertRead(t, tx, []byte("b"), nil)
+
+	readTx := newReadTransaction(t, db)
+	readTx.Discard()
+	readTx.Discard()
+}
+
+func testDiscardAfterCommit(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	rwTx := newReadWriteTransaction(t, db)
+	write(t, rwTx, []byte("a"), []byte("1"))
+	commit(t, rwTx)
+	rwTx.Discard()
+
+	if err := rwTx.Commit(); err == nil {
+		t.Fatalf("second Commit succeeded, expected an error")
+	}
+
+	tx := newReadTransaction(t, db)
+	defer tx.Discard()
+	assertRead(t, tx, []byte("a"), []byte("1"))
+
+	// Commit and Discard must release the read-write transaction slot
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rwTx, err := db.NewReadWriteTransaction()
+		if err != nil {
+			t.Errorf("NewReadWriteTransaction after Commit and Discard: %v", err)
+			return
+		}
+		rwTx.Discard()
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("NewReadWriteTransaction blocked after previous transaction was committed and discarded")
+	}
+}
+
+func testSnapshotIsolation(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	writeCommitted(t, db, keyValue{[]byte("a"), []byte("1")}, keyValue{[]byte("b"), []byte("1")})
+
+	before := newReadTransaction(t, db)
+	defer before.Discard()
+	beforeIt := before.Range(nil, nil)
+	defer beforeIt.Close()
+
+	rwTx := newReadWriteTransaction(t, db)
+	write(t, rwTx, []byte("a"), []byte("2"))
+	write(t, rwTx, []byte("c"), []byte("2"))
+	if err := rwTx.Delete([]byte("b")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	during := newReadTransaction(t, db)
+	defer during.Discard()
+	assertRead(t, during, []byte("a"), []byte("1"))
+	assertRead(t, during, []byte("c"), nil)
+
+	commit(t, rwTx)
+
+	expectedBefore := []keyValue{{[]byte("a"), []byte("1")}, {[]byte("b"), []byte("1")}}
+	for _, tx := range []ocr3_1types.KeyValueDatabaseReadTransaction{before, during} {
+		assertRead(t, tx, []byte("a"), []byte("1"))
+		assertRead(t, tx, []byte("b"), []byte("1"))
+		assertRead(t, tx, []byte("c"), nil)
+		assertRange(t, tx, nil, nil, expectedBefore)
+	}
+
+	// an iterator opened before the commit must not observe it either
+	var iterated []keyValue
+	for beforeIt.Next() {
+		value, err := beforeIt.Value()
+		if err != nil {
+			t.Fatalf("Value: %v", err)
+		}
+		iterated = append(iterated, keyValue{beforeIt.Key(), value})
+	}
+	if err := beforeIt.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if err := equalKeyValues(iterated, expectedBefore); err != nil {
+		t.Fatalf("iterator opened before commit: %v", err)
+	}
+
+	after := newReadTransaction(t, db)
+	defer after.Discard()
+	assertRange(t, after, nil, nil, []keyValue{{[]byte("a"), []byte("2")}, {[]byte("c"), []byte("2")}})
+}
+
+func testSingleReadWriteTransaction(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	first := newReadWriteTransaction(t, db)
+	write(t, first, []byte("a"), []byte("first"))
+
+	type result struct {
+		tx  ocr3_1types.KeyValueDatabaseReadWriteTransaction
+		err error
+	}
+	chResult := make(chan result, 1)
+	go func() {
+		tx, err := db.NewReadWriteTransaction()
+		chResult <- result{tx, err}
+	}()
+
+	// An implementation may either block or error while another read-write
+	// transaction is open, but it must not hand out a second one.
+	select {
+	case r := <-chResult:
+		if r.err == nil {
+			r.tx.Discard()
+			first.Discard()
+			t.Fatalf("NewReadWriteTransaction succeeded while another read-write transaction was open")
+		}
+		commit(t, first)
+		return
+	case <-time.After(blockedGracePeriod):
+	}
+
+	commit(t, first)
+
+	var second ocr3_1types.KeyValueDatabaseReadWriteTransaction
+	select {
+	case r := <-chResult:
+		if r.err != nil {
+			t.Fatalf("blocked NewReadWriteTransaction failed after the first transaction committed: %v", r.err)
+		}
+		second = r.tx
+	case <-time.After(10 * time.Second):
+		t.Fatalf("NewReadWriteTransaction still blocked after the first transaction committed")
+	}
+	defer second.Discard()
+	assertRead(t, second, []byte("a"), []byte("first"))
+}
+
+type randomOperation struct {
+	Kind     int // 0 and 1 write, 2 deletes, 3 reads
+	Key      []byte
+	Value    []byte
+	NilValue bool
+}
+
+type randomTransaction struct {
+	Operations    []randomOperation
+	RangeLoKey    []byte
+	RangeHiKey    []byte
+	Discard       bool
+	SnapshotLoKey []byte
+	SnapshotHiKey []byte
+}
+
+// genRandomBytes draws from a small alphabet, which makes overwrites,
+// deletions and prefix relations between keys likely.
+func genRandomBytes(maxLen int) gopter.Gen {
+	return gen.IntRange(0, maxLen).FlatMap(func(n interface{}) gopter.Gen {
+		return gen.SliceOfN(n.(int), gen.OneConstOf(byte(0x00), byte(0x55), byte(0xaa), byte(0xff)), reflect.TypeOf(byte(0)))
+	}, reflect.TypeOf([]byte{}))
+}
+
+func genRandomTransaction() gopter.Gen {
+	return gen.Struct(reflect.TypeOf(randomTransaction{}), map[string]gopter.Gen{
+		"Operations": gen.SliceOf(gen.Struct(reflect.TypeOf(randomOperation{}), map[string]gopter.Gen{
+			"Kind":     gen.IntRange(0, 3),
+			"Key":      genRandomBytes(3),
+			"Value":    genRandomBytes(4),
+			"NilValue": gen.Weighted([]gen.WeightedGen{{1, gen.Const(true)}, {7, gen.Const(false)}}),
+		})),
+		"RangeLoKey":    genRandomBytes(2),
+		"RangeHiKey":    genRandomBytes(2),
+		"Discard":       gen.Weighted([]gen.WeightedGen{{1, gen.Const(true)}, {2, gen.Const(false)}}),
+		"SnapshotLoKey": genRandomBytes(2),
+		"SnapshotHiKey": genRandomBytes(2),
+	})
+}
+
+// testRandomOperations checks the database against a simple in-memory model
+// over random sequences of transactions.
+func testRandomOperations(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 20
+	parameters.MaxSize = 20
+	properties := gopter.NewProperties(parameters)
+	properties.Property("database matches in-memory model", prop.ForAll(
+		func(transactions []randomTransaction) string {
+			if err := checkRandomTransactions(db, transactions); err != nil {
+				return err.Error()
+			}
+			return ""
+		},
+		gen.SliceOf(genRandomTransaction()),
+	))
+	properties.TestingRun(t)
+}
+
+func modelRange(model map[string][]byte, loKey []byte, hiKeyExcl []byte) []keyValue {
+	var kvs []keyValue
+	for k, v := range model {
+		key := []byte(k)
+		if bytes.Compare(key, loKey) >= 0 && (len(hiKeyExcl) == 0 || bytes.Compare(key, hiKeyExcl) < 0) {
+			kvs = append(kvs, keyValue{key, v})
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return bytes.Compare(kvs[i].key, kvs[j].key) < 0 })
+	return kvs
+}
+
+// checkRandomTransactions empties db, applies transactions to it and checks
+// the results against the model.
+func checkRandomTransactions(db ocr3_1types.KeyValueDatabase, transactions []randomTransaction) error {
+	if err := clearDatabase(db); err != nil {
+		return err
+	}
+
+	model := map[string][]byte{}
+	for _, randomTx := range transactions {
+		pending := make(map[string][]byte, len(model))
+		for k, v := range model {
+			pending[k] = v
+		}
+
+		rwTx, err := db.NewReadWriteTransaction()
+		if err != nil {
+			return fmt.Errorf("NewReadWriteTransaction: %w", err)
+		}
+		if err := applyRandomOperations(rwTx, randomTx, pending); err != nil {
+			rwTx.Discard()
+			return err
+		}
+		if randomTx.Discard {
+			rwTx.Discard()
+		} else {
+			if err := rwTx.Commit(); err != nil {
+				return fmt.Errorf("Commit: %w", err)
+			}
+			model = pending
+		}
+
+		tx, err := db.NewReadTransaction()
+		if err != nil {
+			return fmt.Errorf("NewReadTransaction: %w", err)
+		}
+		err = checkRange(tx, nil, nil, modelRange(model, nil, nil))
+		if err == nil {
+			err = checkRange(tx, randomTx.SnapshotLoKey, randomTx.SnapshotHiKey, modelRange(model, randomTx.SnapshotLoKey, randomTx.SnapshotHiKey))
+		}
+		tx.Discard()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyRandomOperations(rwTx ocr3_1types.KeyValueDatabaseReadWriteTransaction, randomTx randomTransaction, pending map[string][]byte) error {
+	for _, op := range randomTx.Operations {
+		switch op.Kind {
+		case 0, 1:
+			value := op.Value
+			if op.NilValue {
+				value = nil
+			}
+			if err := rwTx.Write(op.Key, value); err != nil {
+				return fmt.Errorf("Write(%x): %w", op.Key, err)
+			}
+			if value == nil {
+				value = []byte{}
+			}
+			pending[string(op.Key)] = value
+		case 2:
+			if err := rwTx.Delete(op.Key); err != nil {
+				return fmt.Errorf("Delete(%x): %w", op.Key, err)
+			}
+			delete(pending, string(op.Key))
+		case 3:
+			if err := checkRead(rwTx, op.Key, pending[string(op.Key)]); err != nil {
+				return err
+			}
+		}
+	}
+	return checkRange(rwTx, randomTx.RangeLoKey, randomTx.RangeHiKey, modelRange(pending, randomTx.RangeLoKey, randomTx.RangeHiKey))
+}
+
+func clearDatabase(db ocr3_1types.KeyValueDatabase) error {
+	rwTx, err := db.NewReadWriteTransaction()
+	if err != nil {
+		return fmt.Errorf("NewReadWriteTransaction: %w", err)
+	}
+	defer rwTx.Discard()
+	kvs, err := readRange(rwTx, nil, nil)
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		if err := rwTx.Delete(kv.key); err != nil {
+			return fmt.Errorf("Delete(%x): %w", kv.key, err)
+		}
+	}
+	if err := rwTx.Commit(); err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
+	return nil
+}
+
+// testConcurrentReadersAndWriter checks that commits are atomic from the
+// point of view of concurrent readers: every commit writes the same value to
+// all keys, so every snapshot must observe a single value.
+func testConcurrentReadersAndWriter(t *testing.T, db ocr3_1types.KeyValueDatabase) {
+	const (
+		numKeys    = 16
+		numCommits = 100
+		numReaders = 4
+	)
+	key := func(i int) []byte { return []byte(fmt.Sprintf("key-%02d", i)) }
+
+	var wg sync.WaitGroup
+	chDone := make(chan struct{})
+	errs := make(chan error, numReaders+1)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(chDone)
+		for c := 1; c <= numCommits; c++ {
+			rwTx, err := db.NewReadWriteTransaction()
+			if err != nil {
+				errs <- fmt.Errorf("NewReadWriteTransaction: %w", err)
+				return
+			}
+			for i := 0; i < numKeys; i++ {
+				if err := rwTx.Write(key(i), []byte(fmt.Sprint(c))); err != nil {
+					rwTx.Discard()
+					errs <- fmt.Errorf("Write: %w", err)
+					return
+				}
+			}
+			if err := rwTx.Commit(); err != nil {
+				errs <- fmt.Errorf("Commit: %w", err)
+				return
+			}
+		}
+	}()
+
+	for r := 0; r < numReaders; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-chDone:
+					return
+				default:
+				}
+				if err := checkConsistentSnapshot(db, numKeys, key); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func checkConsistentSnapshot(db ocr3_1types.KeyValueDatabase, numKeys int, key func(int) []byte) error {
+	tx, err := db.NewReadTransaction()
+	if err != nil {
+		return fmt.Errorf("NewReadTransaction: %w", err)
+	}
+	defer tx.Discard()
+
+	var first []byte
+	for i := 0; i < numKeys; i++ {
+		value, err := tx.Read(key(i))
+		if err != nil {
+			return fmt.Errorf("Read: %w", err)
+		}
+		if i == 0 {
+			first = value
+		} else if !bytes.Equal(value, first) {
+			return fmt.Errorf("inconsistent snapshot: %s = %q but %s = %q", key(0), first, key(i), value)
+		}
+	}
+
+	it := tx.Range(nil, nil)
+	defer it.Close()
+	n := 0
+	for it.Next() {
+		value, err := it.Value()
+		if err != nil {
+			return fmt.Errorf("Value: %w", err)
+		}
+		if !bytes.Equal(value, first) {
+			return fmt.Errorf("inconsistent snapshot: Read returned %q but Range returned %q for %s", first, value, it.Key())
+		}
+		n++
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("Err: %w", err)
+	}
+	if first != nil && n != numKeys {
+		return fmt.Errorf("Range returned %d keys, expected %d", n, numKeys)
+	}
+	return nil
+}
+
+// ────────────────────────── KeyValueDatabaseFactory ───────────────────────────
+
+func testNewKeyValueDatabaseIfExists(t *testing.T, factory ocr3_1types.KeyValueDatabaseFactory) {
+	configDigest := types.ConfigDigest{0x01}
+	_, err := factory.NewKeyValueDatabaseIfExists(configDigest)
+	if !errors.Is(err, ocr3_1types.ErrKeyValueDatabaseDoesNotExist) {
+		t.Fatalf("NewKeyValueDatabaseIfExists of unknown config digest: got error %v, expected %v", err, ocr3_1types.ErrKeyValueDatabaseDoesNotExist)
+	}
+
+	db, err := factory.NewKeyValueDatabase(configDigest)
+	if err != nil {
+		t.Fatalf("NewKeyValueDatabase: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	db, err = factory.NewKeyValueDatabaseIfExists(configDigest)
+	if err != nil {
+		t.Fatalf("NewKeyValueDatabaseIfExists of created database: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func testPersistence(t *testing.T, factory ocr3_1types.KeyValueDatabaseFactory) {
+	configDigest := types.ConfigDigest{0x02}
+	db, err := factory.NewKeyValueDatabase(configDigest)
+	if err != nil {
+		t.Fatalf("NewKeyValueDatabase: %v", err)
+	}
+	writeCommitted(t, db, keyValue{[]byte("a"), []byte("1")}, keyValue{[]byte("b"), nil})
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	db, err = factory.NewKeyValueDatabase(configDigest)
+	if err != nil {
+		t.Fatalf("NewKeyValueDatabase: %v", err)
+	}
+	defer db.Close()
+	tx := newReadTransaction(t, db)
+	defer tx.Discard()
+	assertRange(t, tx, nil, nil, []keyValue{{[]byte("a"), []byte("1")}, {[]byte("b"), []byte{}}})
+}
+
+func testConfigDigestIsolation(t *testing.T, factory ocr3_1types.KeyValueDatabaseFactory) {
+	db1, err := factory.NewKeyValueDatabase(types.ConfigDigest{0x03})
+	if err != nil {
+		t.Fatalf("NewKeyValueDatabase: %v", err)
+	}
+	defer db1.Close()
+	db2, err := factory.NewKeyValueDatabase(types.ConfigDigest{0x04})
+	if err != nil {
+		t.Fatalf("NewKeyValueDatabase: %v", err)
+	}
+	defer db2.Close()
+
+	writeCommitted(t, db1, keyValue{[]byte("a"), []byte("1")})
+	writeCommitted(t, db2, keyValue{[]byte("b"), []byte("2")})
+
+	tx1 := newReadTransaction(t, db1)
+	defer tx1.Discard()
+	assertRange(t, tx1, nil, nil, []keyValue{{[]byte("a"), []byte("1")}})
+	tx2 := newReadTransaction(t, db2)
+	defer tx2.Discard()
+	assertRange(t, tx2, nil, nil, []keyValue{{[]byte("b"), []byte("2")}})
+}
@@ -184,6 +184,11 @@ var _ ocr3_1types.KeyValueDatabaseIterator = &pebbleIterator{}
 func newPebbleIterator(reader pebble.Reader, loKey []byte, hiKeyExcl []byte) *pebbleIterator {
 	loKey = util.NilCoalesceSlice(bytes.Clone(loKey))
 	hiKeyExcl = bytes.Clone(hiKeyExcl)
+	if len(hiKeyExcl) == 0 {
+		// pebble would interpret a non-nil empty upper bound as excluding
+		// every key
+		hiKeyExcl = nil
+	}
 
 	opts := &pebble.IterOptions{
 		LowerBound: loKey,
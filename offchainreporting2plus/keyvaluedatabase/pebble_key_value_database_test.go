@@ -0,0 +1,15 @@
+package keyvaluedatabase_test
+
+import (
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/keyvaluedatabase"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/keyvaluedatabase/kvdbtest"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+func TestPebbleKeyValueDatabaseConformance(t *testing.T) {
+	kvdbtest.RunFactoryConformanceTests(t, func(t *testing.T) ocr3_1types.KeyValueDatabaseFactory {
+		return keyvaluedatabase.NewPebbleKeyValueDatabaseFactory(t.TempDir())
+	})
+}
@@ -0,0 +1,408 @@
+package keyvaluedatabase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/smartcontractkit/libocr/internal/util"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+const sqliteBusyTimeoutMilliseconds = 10_000
+
+// NewSQLiteKeyValueDatabaseFactory produces a
+// [ocr3_1types.KeyValueDatabaseFactory] that keeps the databases for all
+// config digests in the single SQLite file at path, which is created if it
+// doesn't exist. The file is opened lazily, so NewKeyValueDatabase may fail if
+// path is not writeable. The file is kept in WAL mode, so back it up with
+// SQLite tooling (e.g. VACUUM INTO or the sqlite3 .backup command) rather than
+// by copying it. The factory requires exclusive control of the file:
+// external changes are forbidden, and there must be at most one factory per
+// file.
+func NewSQLiteKeyValueDatabaseFactory(path string) ocr3_1types.KeyValueDatabaseFactory {
+	return &sqliteKeyValueDatabaseFactory{path: path}
+}
+
+type sqliteKeyValueDatabaseFactory struct {
+	path string
+
+	mu                   sync.Mutex
+	db                   *sql.DB
+	refCount             int
+	rwSerializationLocks map[types.ConfigDigest]*sqliteRWSerializationLock
+}
+
+// sqliteRWSerializationLock enforces that we can have at most one active
+// read-write transaction across all databases for a config digest. SQLite
+// allows a single writer per file, but we leave it to SQLite to serialize
+// writers for different config digests: it waits at most
+// sqliteBusyTimeoutMilliseconds, whereas a lock across all config digests
+// would let the instance for one config digest block the instance for
+// another indefinitely, e.g. during a config handover.
+type sqliteRWSerializationLock struct {
+	mu       sync.Mutex
+	refCount int
+}
+
+var _ ocr3_1types.KeyValueDatabaseFactory = &sqliteKeyValueDatabaseFactory{}
+
+func (s *sqliteKeyValueDatabaseFactory) NewKeyValueDatabase(configDigest types.ConfigDigest) (ocr3_1types.KeyValueDatabase, error) {
+	return s.newKeyValueDatabase(configDigest, true)
+}
+
+func (s *sqliteKeyValueDatabaseFactory) NewKeyValueDatabaseIfExists(configDigest types.ConfigDigest) (ocr3_1types.KeyValueDatabase, error) {
+	return s.newKeyValueDatabase(configDigest, false)
+}
+
+func (s *sqliteKeyValueDatabaseFactory) newKeyValueDatabase(configDigest types.ConfigDigest, createIfNotExists bool) (ocr3_1types.KeyValueDatabase, error) {
+	db, rwSerializationLock, err := s.acquire(configDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if createIfNotExists {
+		_, err = db.Exec(`INSERT OR IGNORE INTO databases (config_digest) VALUES (?)`, configDigest[:])
+		exists = true
+	} else {
+		err = db.QueryRow(`SELECT EXISTS (SELECT 1 FROM databases WHERE config_digest = ?)`, configDigest[:]).Scan(&exists)
+	}
+	if err != nil {
+		s.release(configDigest)
+		return nil, fmt.Errorf("failed to look up database for config digest %s: %w", configDigest, err)
+	}
+	if !exists {
+		s.release(configDigest)
+		return nil, ocr3_1types.ErrKeyValueDatabaseDoesNotExist
+	}
+
+	return &sqliteKeyValueDatabase{
+		s,
+		db,
+		configDigest,
+		&rwSerializationLock.mu,
+		sync.Once{},
+	}, nil
+}
+
+// acquire opens the file on first use. Every successful call must be paired
+// with a call to release for the same configDigest.
+func (s *sqliteKeyValueDatabaseFactory) acquire(configDigest types.ConfigDigest) (*sql.DB, *sqliteRWSerializationLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		db, err := openSQLiteFile(s.path)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.db = db
+	}
+	s.refCount++
+
+	if s.rwSerializationLocks == nil {
+		s.rwSerializationLocks = make(map[types.ConfigDigest]*sqliteRWSerializationLock)
+	}
+	rwSerializationLock, ok := s.rwSerializationLocks[configDigest]
+	if !ok {
+		rwSerializationLock = &sqliteRWSerializationLock{}
+		s.rwSerializationLocks[configDigest] = rwSerializationLock
+	}
+	rwSerializationLock.refCount++
+	return s.db, rwSerializationLock, nil
+}
+
+func (s *sqliteKeyValueDatabaseFactory) release(configDigest types.ConfigDigest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rwSerializationLock := s.rwSerializationLocks[configDigest]; rwSerializationLock != nil {
+		rwSerializationLock.refCount--
+		if rwSerializationLock.refCount == 0 {
+			delete(s.rwSerializationLocks, configDigest)
+		}
+	}
+
+	s.refCount--
+	if s.refCount > 0 {
+		return nil
+	}
+	db := s.db
+	s.db = nil
+	return db.Close()
+}
+
+func openSQLiteFile(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeoutMilliseconds))
+	query.Add("_pragma", "journal_mode(WAL)")
+	// pebble syncs on every commit, so do we
+	query.Add("_pragma", "synchronous(FULL)")
+	dsn := (&url.URL{Scheme: "file", Opaque: path, RawQuery: query.Encode()}).String()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite file %q: %w", path, err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS databases (
+			config_digest BLOB NOT NULL PRIMARY KEY
+		) WITHOUT ROWID;
+		CREATE TABLE IF NOT EXISTS key_values (
+			config_digest BLOB NOT NULL,
+			key BLOB NOT NULL,
+			value BLOB NOT NULL,
+			PRIMARY KEY (config_digest, key)
+		) WITHOUT ROWID;
+	`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize schema of sqlite file %q: %w", path, err)
+	}
+	return db, nil
+}
+
+type sqliteKeyValueDatabase struct {
+	factory             *sqliteKeyValueDatabaseFactory
+	db                  *sql.DB
+	configDigest        types.ConfigDigest
+	rwSerializationLock *sync.Mutex
+	closeOnce           sync.Once
+}
+
+var _ ocr3_1types.KeyValueDatabase = &sqliteKeyValueDatabase{}
+
+func (s *sqliteKeyValueDatabase) Close() error {
+	err := fmt.Errorf("database already closed")
+	s.closeOnce.Do(func() {
+		err = s.factory.release(s.configDigest)
+	})
+	return err
+}
+
+// The resulting transaction is NOT thread-safe.
+
+func (s *sqliteKeyValueDatabase) NewReadTransaction() (ocr3_1types.KeyValueDatabaseReadTransaction, error) {
+	conn, err := s.db.Conn(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	if _, err := conn.ExecContext(context.Background(), `BEGIN DEFERRED`); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to begin read transaction: %w", err)
+	}
+	// A deferred transaction only takes its snapshot on the first read, so
+	// read right away to pin the snapshot to the time of creation.
+	var ignored int
+	if err := conn.QueryRowContext(context.Background(), `SELECT COUNT(*) FROM databases`).Scan(&ignored); err != nil {
+		rollbackAndClose(conn)
+		return nil, fmt.Errorf("failed to take snapshot: %w", err)
+	}
+	return &sqliteReadTransaction{
+		conn,
+		s.configDigest,
+		false,
+		nil,
+	}, nil
+}
+
+// The resulting transaction is NOT thread-safe.
+
+func (s *sqliteKeyValueDatabase) NewReadWriteTransaction() (ocr3_1types.KeyValueDatabaseReadWriteTransaction, error) {
+	s.rwSerializationLock.Lock()
+	conn, err := s.db.Conn(context.Background())
+	if err != nil {
+		s.rwSerializationLock.Unlock()
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	if _, err := conn.ExecContext(context.Background(), `BEGIN IMMEDIATE`); err != nil {
+		conn.Close()
+		s.rwSerializationLock.Unlock()
+		return nil, fmt.Errorf("failed to begin read-write transaction: %w", err)
+	}
+	return &sqliteReadWriteTransaction{
+		sqliteReadTransaction{
+			conn,
+			s.configDigest,
+			false,
+			func() {
+				s.rwSerializationLock.Unlock()
+			},
+		},
+	}, nil
+}
+
+func rollbackAndClose(conn *sql.Conn) {
+	_, _ = conn.ExecContext(context.Background(), `ROLLBACK`)
+	_ = conn.Close()
+}
+
+type sqliteReadTransaction struct {
+	conn         *sql.Conn
+	configDigest types.ConfigDigest
+
+	committedOrDiscarded     bool
+	afterCommitOrDiscardFunc func()
+}
+
+var _ ocr3_1types.KeyValueDatabaseReadTransaction = &sqliteReadTransaction{}
+
+var errSQLiteTransactionDone = errors.New("transaction has been committed or discarded")
+
+func (s *sqliteReadTransaction) Discard() {
+	if s.committedOrDiscarded {
+		return
+	}
+	s.committedOrDiscarded = true
+	rollbackAndClose(s.conn)
+	if s.afterCommitOrDiscardFunc != nil {
+		s.afterCommitOrDiscardFunc()
+	}
+}
+
+func (s *sqliteReadTransaction) Read(key []byte) ([]byte, error) {
+	if s.committedOrDiscarded {
+		return nil, errSQLiteTransactionDone
+	}
+	var value []byte
+	err := s.conn.QueryRowContext(
+		context.Background(),
+		`SELECT value FROM key_values WHERE config_digest = ? AND key = ?`,
+		s.configDigest[:],
+		util.NilCoalesceSlice(key),
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return util.NilCoalesceSlice(value), nil
+}
+
+func (s *sqliteReadTransaction) Range(loKey []byte, hiKeyExcl []byte) ocr3_1types.KeyValueDatabaseIterator {
+	if s.committedOrDiscarded {
+		return &sqliteIterator{nil, nil, nil, errSQLiteTransactionDone}
+	}
+	var rows *sql.Rows
+	var err error
+	if len(hiKeyExcl) == 0 {
+		rows, err = s.conn.QueryContext(
+			context.Background(),
+			`SELECT key, value FROM key_values WHERE config_digest = ? AND key >= ? ORDER BY key`,
+			s.configDigest[:],
+			util.NilCoalesceSlice(loKey),
+		)
+	} else {
+		rows, err = s.conn.QueryContext(
+			context.Background(),
+			`SELECT key, value FROM key_values WHERE config_digest = ? AND key >= ? AND key < ? ORDER BY key`,
+			s.configDigest[:],
+			util.NilCoalesceSlice(loKey),
+			hiKeyExcl,
+		)
+	}
+	if err != nil {
+		return &sqliteIterator{nil, nil, nil, err}
+	}
+	return &sqliteIterator{rows, nil, nil, nil}
+}
+
+type sqliteReadWriteTransaction struct {
+	sqliteReadTransaction
+}
+
+var _ ocr3_1types.KeyValueDatabaseReadWriteTransaction = &sqliteReadWriteTransaction{}
+
+func (s *sqliteReadWriteTransaction) Write(key, value []byte) error {
+	if s.committedOrDiscarded {
+		return errSQLiteTransactionDone
+	}
+	_, err := s.conn.ExecContext(
+		context.Background(),
+		`INSERT INTO key_values (config_digest, key, value) VALUES (?, ?, ?) ON CONFLICT (config_digest, key) DO UPDATE SET value = excluded.value`,
+		s.configDigest[:],
+		util.NilCoalesceSlice(key),
+		util.NilCoalesceSlice(value),
+	)
+	return err
+}
+
+func (s *sqliteReadWriteTransaction) Delete(key []byte) error {
+	if s.committedOrDiscarded {
+		return errSQLiteTransactionDone
+	}
+	_, err := s.conn.ExecContext(
+		context.Background(),
+		`DELETE FROM key_values WHERE config_digest = ? AND key = ?`,
+		s.configDigest[:],
+		util.NilCoalesceSlice(key),
+	)
+	return err
+}
+
+func (s *sqliteReadWriteTransaction) Commit() error {
+	if s.committedOrDiscarded {
+		return errSQLiteTransactionDone
+	}
+	s.committedOrDiscarded = true
+	defer s.afterCommitOrDiscardFunc()
+	if _, err := s.conn.ExecContext(context.Background(), `COMMIT`); err != nil {
+		rollbackAndClose(s.conn)
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return s.conn.Close()
+}
+
+type sqliteIterator struct {
+	rows  *sql.Rows
+	key   []byte
+	value []byte
+	err   error
+}
+
+var _ ocr3_1types.KeyValueDatabaseIterator = &sqliteIterator{}
+
+func (s *sqliteIterator) Next() bool {
+	if s.rows == nil || s.err != nil {
+		return false
+	}
+	if !s.rows.Next() {
+		s.err = s.rows.Err()
+		return false
+	}
+	var key, value []byte
+	if err := s.rows.Scan(&key, &value); err != nil {
+		s.err = err
+		return false
+	}
+	s.key = util.NilCoalesceSlice(key)
+	s.value = util.NilCoalesceSlice(value)
+	return true
+}
+
+func (s *sqliteIterator) Key() []byte {
+	return s.key
+}
+
+func (s *sqliteIterator) Value() ([]byte, error) {
+	return s.value, nil
+}
+
+func (s *sqliteIterator) Err() error {
+	return s.err
+}
+
+func (s *sqliteIterator) Close() error {
+	if s.rows == nil {
+		return s.err
+	}
+	return s.rows.Close()
+}
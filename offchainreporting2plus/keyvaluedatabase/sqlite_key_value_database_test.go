@@ -0,0 +1,16 @@
+package keyvaluedatabase_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/keyvaluedatabase"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/keyvaluedatabase/kvdbtest"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+func TestSQLiteKeyValueDatabaseConformance(t *testing.T) {
+	kvdbtest.RunFactoryConformanceTests(t, func(t *testing.T) ocr3_1types.KeyValueDatabaseFactory {
+		return keyvaluedatabase.NewSQLiteKeyValueDatabaseFactory(filepath.Join(t.TempDir(), "kv.sqlite"))
+	})
+}
@@ -0,0 +1,28 @@
+package offchainreporting2plus
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/shim"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// NewOCR3_1HistoricalKeyValueState provides read-only access to the
+// KeyValueState of an OCR3.1 protocol instance as of any retained sequence
+// number, e.g. for answering "what was the value of key k when the reports for
+// seqNr n were produced". keyValueDatabase must be the database returned by
+// KeyValueDatabaseFactory.NewKeyValueDatabase for contractConfig.ConfigDigest.
+// keyValueDatabase is never written to and is not closed by the returned
+// HistoricalKeyValueState.
+func NewOCR3_1HistoricalKeyValueState(
+	keyValueDatabase ocr3_1types.KeyValueDatabase,
+	contractConfig types.ContractConfig,
+) (ocr3_1types.HistoricalKeyValueState, error) {
+	publicConfig, err := ocr3_1config.PublicConfigFromContractConfig(true, contractConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error while decoding ContractConfig: %w", err)
+	}
+	return shim.NewOCR3_1HistoricalKeyValueState(keyValueDatabase, publicConfig)
+}
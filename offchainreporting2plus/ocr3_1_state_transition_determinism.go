@@ -0,0 +1,67 @@
+package offchainreporting2plus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/shim"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// CheckOCR3_1StateTransitionDeterminism re-executes
+// reportingPlugin.StateTransition for the attested blocks fromSeqNr through
+// toSeqNr (both inclusive) stored in keyValueDatabase, and compares the
+// resulting write sets, state roots and ReportsPlusPrecursors with the attested
+// ones. It stops at the first divergence, which the returned report pinpoints
+// down to the first diverging key. fromSeqNr-1 must be retained, see
+// HistoricalKeyValueState.RetainedSeqNrs.
+//
+// keyValueDatabase must be the database returned by
+// KeyValueDatabaseFactory.NewKeyValueDatabase for contractConfig.ConfigDigest.
+// It is never written to and may belong to a running oracle, but blobs fetched
+// by StateTransition are only served from it, so blobs that have been reaped
+// in the meantime make the check fail. reportingPluginLimits must be the
+// Limits of the ReportingPluginInfo1 the plugin was run with.
+//
+// Attested blocks only commit to a digest of the query and observations, so
+// these must be supplied by inputsSource, e.g. one obtained from
+// trafficrecording.NewOCR3_1StateTransitionInputsSource. Blocks for which
+// inputsSource has no matching inputs are skipped.
+//
+// For example, to check a plugin against a recording of one of its oracles:
+//
+//	records, err := trafficrecording.ReadRecording(recordingDir)
+//	...
+//	inputsSource := trafficrecording.NewOCR3_1StateTransitionInputsSource(records, contractConfig.ConfigDigest, len(contractConfig.Signers))
+//	plugin, info, err := myPluginFactory.NewReportingPlugin(ctx, reportingPluginConfig, nil)
+//	...
+//	report, err := offchainreporting2plus.CheckOCR3_1StateTransitionDeterminism(
+//		ctx, keyValueDatabase, contractConfig, plugin, info.(ocr3_1types.ReportingPluginInfo1).Limits, inputsSource, fromSeqNr, toSeqNr,
+//	)
+func CheckOCR3_1StateTransitionDeterminism[RI any](
+	ctx context.Context,
+	keyValueDatabase ocr3_1types.KeyValueDatabase,
+	contractConfig types.ContractConfig,
+	reportingPlugin ocr3_1types.ReportingPlugin[RI],
+	reportingPluginLimits ocr3_1types.ReportingPluginLimits,
+	inputsSource ocr3_1types.StateTransitionInputsSource,
+	fromSeqNr uint64,
+	toSeqNr uint64,
+) (ocr3_1types.StateTransitionDeterminismReport, error) {
+	publicConfig, err := ocr3_1config.PublicConfigFromContractConfig(true, contractConfig)
+	if err != nil {
+		return ocr3_1types.StateTransitionDeterminismReport{}, fmt.Errorf("error while decoding ContractConfig: %w", err)
+	}
+	return shim.CheckOCR3_1StateTransitionDeterminism(
+		ctx,
+		keyValueDatabase,
+		publicConfig,
+		reportingPlugin,
+		reportingPluginLimits,
+		inputsSource,
+		fromSeqNr,
+		toSeqNr,
+	)
+}
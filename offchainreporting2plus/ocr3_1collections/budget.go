@@ -0,0 +1,115 @@
+package ocr3_1collections
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+var ErrBudgetExceeded = errors.New("key value modification budget exceeded")
+
+// Cost measures modifications in the units of
+// ReportingPluginLimits.MaxKeyValueModifiedKeys and
+// ReportingPluginLimits.MaxKeyValueModifiedKeysPlusValuesBytes.
+type Cost struct {
+	ModifiedKeys                int
+	ModifiedKeysPlusValuesBytes int
+}
+
+// Budget tracks how much of the ReportingPluginLimits modification limits a
+// single StateTransition has used, with the same accounting the protocol
+// applies: a key counts once no matter how often it is modified, and only
+// its last value counts towards the bytes. Create a fresh Budget for every
+// StateTransition and route all writes through ReadWriter, otherwise the
+// Budget underestimates what has been used.
+type Budget struct {
+	limits ocr3_1types.ReportingPluginLimits
+	// length of the last value written for each modified key
+	modified map[string]int
+	used     Cost
+}
+
+func NewBudget(limits ocr3_1types.ReportingPluginLimits) *Budget {
+	return &Budget{limits, map[string]int{}, Cost{}}
+}
+
+func (b *Budget) Used() Cost {
+	return b.used
+}
+
+func (b *Budget) Remaining() Cost {
+	return Cost{
+		b.limits.MaxKeyValueModifiedKeys - b.used.ModifiedKeys,
+		b.limits.MaxKeyValueModifiedKeysPlusValuesBytes - b.used.ModifiedKeysPlusValuesBytes,
+	}
+}
+
+func (b *Budget) usedAfter(used Cost, key string, valueLen int) Cost {
+	if prevValueLen, ok := b.modified[key]; ok {
+		used.ModifiedKeysPlusValuesBytes += valueLen - prevValueLen
+	} else {
+		used.ModifiedKeys++
+		used.ModifiedKeysPlusValuesBytes += len(key) + valueLen
+	}
+	return used
+}
+
+func (b *Budget) within(used Cost) bool {
+	return used.ModifiedKeys <= b.limits.MaxKeyValueModifiedKeys &&
+		used.ModifiedKeysPlusValuesBytes <= b.limits.MaxKeyValueModifiedKeysPlusValuesBytes
+}
+
+// Fits reports whether applying the plan through ReadWriter would stay within
+// the limits, taking into account keys that have already been modified.
+func (b *Budget) Fits(p *Plan) bool {
+	used := b.used
+	for key, value := range p.modifications {
+		used = b.usedAfter(used, key, len(value))
+	}
+	return b.within(used)
+}
+
+func (b *Budget) charge(key []byte, value []byte) error {
+	used := b.usedAfter(b.used, string(key), len(value))
+	if !b.within(used) {
+		return fmt.Errorf("modifying key %x would use %+v of limits %d keys and %d bytes: %w",
+			key, used, b.limits.MaxKeyValueModifiedKeys, b.limits.MaxKeyValueModifiedKeysPlusValuesBytes, ErrBudgetExceeded)
+	}
+	b.modified[string(key)] = len(value)
+	b.used = used
+	return nil
+}
+
+// ReadWriter returns a KeyValueStateReadWriter that charges every
+// modification to the Budget before passing it on to rw. Modifications that
+// would exceed the limits are rejected with an error wrapping
+// ErrBudgetExceeded and not passed on.
+func (b *Budget) ReadWriter(rw ocr3_1types.KeyValueStateReadWriter) ocr3_1types.KeyValueStateReadWriter {
+	return &budgetedReadWriter{b, rw}
+}
+
+type budgetedReadWriter struct {
+	budget *Budget
+	rw     ocr3_1types.KeyValueStateReadWriter
+}
+
+var _ ocr3_1types.KeyValueStateReadWriter = &budgetedReadWriter{}
+
+func (brw *budgetedReadWriter) Read(key []byte) ([]byte, error) {
+	return brw.rw.Read(key)
+}
+
+func (brw *budgetedReadWriter) Write(key []byte, value []byte) error {
+	if err := brw.budget.charge(key, value); err != nil {
+		return err
+	}
+	return brw.rw.Write(key, value)
+}
+
+func (brw *budgetedReadWriter) Delete(key []byte) error {
+	if err := brw.budget.charge(key, nil); err != nil {
+		return err
+	}
+	return brw.rw.Delete(key)
+}
@@ -0,0 +1,65 @@
+package ocr3_1collections
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec translates between values and their byte representation in the
+// KeyValueState. Encode must be deterministic: equal values must always encode
+// to equal bytes, on every oracle. When used for keys, Encode must also be
+// injective.
+type Codec[T any] interface {
+	Encode(T) ([]byte, error)
+	Decode([]byte) (T, error)
+}
+
+type BytesCodec struct{}
+
+var _ Codec[[]byte] = BytesCodec{}
+
+func (BytesCodec) Encode(b []byte) ([]byte, error) { return b, nil }
+
+func (BytesCodec) Decode(b []byte) ([]byte, error) { return b, nil }
+
+type StringCodec struct{}
+
+var _ Codec[string] = StringCodec{}
+
+func (StringCodec) Encode(s string) ([]byte, error) { return []byte(s), nil }
+
+func (StringCodec) Decode(b []byte) (string, error) { return string(b), nil }
+
+// Uint64Codec encodes big endian, so the encoding preserves order.
+type Uint64Codec struct{}
+
+var _ Codec[uint64] = Uint64Codec{}
+
+func (Uint64Codec) Encode(n uint64) ([]byte, error) {
+	return binary.BigEndian.AppendUint64(nil, n), nil
+}
+
+func (Uint64Codec) Decode(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("expected 8 bytes for uint64, got %d", len(b))
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// JSONCodec encodes values with encoding/json. Note that the encoding is only
+// deterministic for types whose JSON encoding is deterministic, which excludes
+// e.g. types with custom MarshalJSON methods that iterate over maps.
+type JSONCodec[T any] struct{}
+
+var _ Codec[struct{}] = JSONCodec[struct{}]{}
+
+func (JSONCodec[T]) Encode(t T) ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func (JSONCodec[T]) Decode(b []byte) (T, error) {
+	var t T
+	err := json.Unmarshal(b, &t)
+	return t, err
+}
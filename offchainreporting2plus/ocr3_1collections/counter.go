@@ -0,0 +1,68 @@
+package ocr3_1collections
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// Counter is a uint64 stored in the KeyValueState. A zero counter occupies no
+// key.
+type Counter struct {
+	ns namespace
+}
+
+func NewCounter(name string) Counter {
+	return Counter{newNamespace(name)}
+}
+
+func (c Counter) key() []byte {
+	return c.ns.key(tagCounter, nil)
+}
+
+func (c Counter) Get(r ocr3_1types.KeyValueStateReader) (uint64, error) {
+	n, err := readUint64OrZero(r, c.key())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read counter: %w", err)
+	}
+	return n, nil
+}
+
+func (c Counter) Set(rw ocr3_1types.KeyValueStateReadWriter, n uint64) error {
+	if err := writeUint64OrDelete(rw, c.key(), n); err != nil {
+		return fmt.Errorf("failed to write counter: %w", err)
+	}
+	return nil
+}
+
+// Add adds delta to the counter and returns the new value. It errors instead
+// of overflowing.
+func (c Counter) Add(rw ocr3_1types.KeyValueStateReadWriter, delta uint64) (uint64, error) {
+	n, err := c.Get(rw)
+	if err != nil {
+		return 0, err
+	}
+	if delta == 0 {
+		return n, nil
+	}
+	if n+delta < n {
+		return 0, fmt.Errorf("counter overflow: %d + %d", n, delta)
+	}
+	return n + delta, c.Set(rw, n+delta)
+}
+
+// Sub subtracts delta from the counter and returns the new value. It errors
+// instead of underflowing.
+func (c Counter) Sub(rw ocr3_1types.KeyValueStateReadWriter, delta uint64) (uint64, error) {
+	n, err := c.Get(rw)
+	if err != nil {
+		return 0, err
+	}
+	if delta == 0 {
+		return n, nil
+	}
+	if delta > n {
+		return 0, fmt.Errorf("counter underflow: %d - %d", n, delta)
+	}
+	return n - delta, c.Set(rw, n-delta)
+}
@@ -0,0 +1,20 @@
+// Package ocr3_1collections provides deterministic typed collections (Map,
+// Set, Queue, Counter) on top of the replicated KeyValueState of OCR3.1
+// plugins.
+//
+// Collections are stateless handles: all state lives in the KeyValueState, so
+// the same handle can be used from StateTransition (with a
+// KeyValueStateReadWriter) and from read-only methods such as Observation or
+// Committed (with a KeyValueStateReader). Each collection owns the keys under
+// its name; names must be unique within a plugin and stable across plugin
+// versions. Keys written by a collection are prefixed with the length of its
+// name, so collection keys never collide with each other. They may collide
+// with keys a plugin writes directly, so don't mix the two without care.
+//
+// Every modification counts towards
+// ReportingPluginLimits.MaxKeyValueModifiedKeys and
+// ReportingPluginLimits.MaxKeyValueModifiedKeysPlusValuesBytes. Use a Plan to
+// find out what a sequence of operations would modify without writing
+// anything, and a Budget to check that it fits into what is left of the
+// limits for the current StateTransition.
+package ocr3_1collections
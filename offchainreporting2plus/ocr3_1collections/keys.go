@@ -0,0 +1,55 @@
+package ocr3_1collections
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+const (
+	tagMapEntry    byte = 'e'
+	tagMapLen      byte = 'n'
+	tagQueueBounds byte = 'b'
+	tagQueueItem   byte = 'i'
+	tagCounter     byte = 'c'
+)
+
+// namespace is the key prefix owned by a single collection. The name is
+// length-prefixed so that no namespace is a prefix of another.
+type namespace []byte
+
+func newNamespace(name string) namespace {
+	ns := binary.AppendUvarint(nil, uint64(len(name)))
+	return append(ns, name...)
+}
+
+func (ns namespace) key(tag byte, suffix []byte) []byte {
+	key := make([]byte, 0, len(ns)+1+len(suffix))
+	key = append(key, ns...)
+	key = append(key, tag)
+	return append(key, suffix...)
+}
+
+func readUint64OrZero(r ocr3_1types.KeyValueStateReader, key []byte) (uint64, error) {
+	raw, err := r.Read(key)
+	if err != nil {
+		return 0, err
+	}
+	if raw == nil {
+		return 0, nil
+	}
+	if len(raw) != 8 {
+		return 0, fmt.Errorf("expected 8 bytes, got %d", len(raw))
+	}
+	return binary.BigEndian.Uint64(raw), nil
+}
+
+// writeUint64OrDelete deletes the key instead of writing zero, so that empty
+// collections leave no trace in the KeyValueState.
+func writeUint64OrDelete(rw ocr3_1types.KeyValueStateReadWriter, key []byte, n uint64) error {
+	if n == 0 {
+		return rw.Delete(key)
+	}
+	return rw.Write(key, binary.BigEndian.AppendUint64(nil, n))
+}
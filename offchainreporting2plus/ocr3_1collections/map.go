@@ -0,0 +1,134 @@
+package ocr3_1collections
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// Map is a typed map stored in the KeyValueState. It keeps track of its
+// length, so Put of a new key and Delete of an existing key modify two keys:
+// the entry and the length.
+type Map[K any, V any] struct {
+	ns         namespace
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+}
+
+func NewMap[K any, V any](name string, keyCodec Codec[K], valueCodec Codec[V]) Map[K, V] {
+	return Map[K, V]{newNamespace(name), keyCodec, valueCodec}
+}
+
+func (m Map[K, V]) entryKey(k K) ([]byte, error) {
+	encK, err := m.keyCodec.Encode(k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+	return m.ns.key(tagMapEntry, encK), nil
+}
+
+func (m Map[K, V]) lenKey() []byte {
+	return m.ns.key(tagMapLen, nil)
+}
+
+// Get returns the value stored for k. ok is false if there is none.
+func (m Map[K, V]) Get(r ocr3_1types.KeyValueStateReader, k K) (v V, ok bool, err error) {
+	key, err := m.entryKey(k)
+	if err != nil {
+		return v, false, err
+	}
+	raw, err := r.Read(key)
+	if err != nil {
+		return v, false, fmt.Errorf("failed to read entry: %w", err)
+	}
+	if raw == nil {
+		return v, false, nil
+	}
+	v, err = m.valueCodec.Decode(raw)
+	if err != nil {
+		return v, false, fmt.Errorf("failed to decode value: %w", err)
+	}
+	return v, true, nil
+}
+
+func (m Map[K, V]) Has(r ocr3_1types.KeyValueStateReader, k K) (bool, error) {
+	key, err := m.entryKey(k)
+	if err != nil {
+		return false, err
+	}
+	raw, err := r.Read(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read entry: %w", err)
+	}
+	return raw != nil, nil
+}
+
+func (m Map[K, V]) Len(r ocr3_1types.KeyValueStateReader) (uint64, error) {
+	n, err := readUint64OrZero(r, m.lenKey())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read length: %w", err)
+	}
+	return n, nil
+}
+
+func (m Map[K, V]) Put(rw ocr3_1types.KeyValueStateReadWriter, k K, v V) error {
+	key, err := m.entryKey(k)
+	if err != nil {
+		return err
+	}
+	encV, err := m.valueCodec.Encode(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+	if encV == nil {
+		// nil values are indistinguishable from absent keys
+		encV = []byte{}
+	}
+	prev, err := rw.Read(key)
+	if err != nil {
+		return fmt.Errorf("failed to read entry: %w", err)
+	}
+	if prev == nil {
+		n, err := m.Len(rw)
+		if err != nil {
+			return err
+		}
+		if err := writeUint64OrDelete(rw, m.lenKey(), n+1); err != nil {
+			return fmt.Errorf("failed to write length: %w", err)
+		}
+	}
+	if err := rw.Write(key, encV); err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes k and reports whether it was present. Deleting an absent key
+// modifies nothing.
+func (m Map[K, V]) Delete(rw ocr3_1types.KeyValueStateReadWriter, k K) (deleted bool, err error) {
+	key, err := m.entryKey(k)
+	if err != nil {
+		return false, err
+	}
+	prev, err := rw.Read(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read entry: %w", err)
+	}
+	if prev == nil {
+		return false, nil
+	}
+	n, err := m.Len(rw)
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, fmt.Errorf("length is zero but entry exists")
+	}
+	if err := writeUint64OrDelete(rw, m.lenKey(), n-1); err != nil {
+		return false, fmt.Errorf("failed to write length: %w", err)
+	}
+	if err := rw.Delete(key); err != nil {
+		return false, fmt.Errorf("failed to delete entry: %w", err)
+	}
+	return true, nil
+}
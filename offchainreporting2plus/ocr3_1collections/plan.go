@@ -0,0 +1,74 @@
+package ocr3_1collections
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// Plan is a KeyValueStateReadWriter that records modifications on top of a
+// KeyValueStateReader instead of performing them. Reads observe the recorded
+// modifications. Run collection operations against a Plan to learn their Cost
+// or to check them against a Budget, then Apply the plan or drop it.
+type Plan struct {
+	r ocr3_1types.KeyValueStateReader
+	// nil values record deletions
+	modifications map[string][]byte
+}
+
+var _ ocr3_1types.KeyValueStateReadWriter = &Plan{}
+
+func NewPlan(r ocr3_1types.KeyValueStateReader) *Plan {
+	return &Plan{r, map[string][]byte{}}
+}
+
+func (p *Plan) Read(key []byte) ([]byte, error) {
+	if value, ok := p.modifications[string(key)]; ok {
+		return bytes.Clone(value), nil
+	}
+	return p.r.Read(key)
+}
+
+func (p *Plan) Write(key []byte, value []byte) error {
+	p.modifications[string(key)] = bytes.Clone(value)
+	return nil
+}
+
+func (p *Plan) Delete(key []byte) error {
+	p.modifications[string(key)] = nil
+	return nil
+}
+
+// Cost returns the cost of the recorded modifications, assuming that none of
+// the modified keys has been modified before in the current StateTransition.
+func (p *Plan) Cost() Cost {
+	cost := Cost{}
+	for key, value := range p.modifications {
+		cost.ModifiedKeys++
+		cost.ModifiedKeysPlusValuesBytes += len(key) + len(value)
+	}
+	return cost
+}
+
+// Apply performs the recorded modifications on rw in a deterministic order.
+func (p *Plan) Apply(rw ocr3_1types.KeyValueStateReadWriter) error {
+	keys := make([]string, 0, len(p.modifications))
+	for key := range p.modifications {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var err error
+		if value := p.modifications[key]; value == nil {
+			err = rw.Delete([]byte(key))
+		} else {
+			err = rw.Write([]byte(key), value)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply modification of key %x: %w", key, err)
+		}
+	}
+	return nil
+}
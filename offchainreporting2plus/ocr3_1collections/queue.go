@@ -0,0 +1,149 @@
+package ocr3_1collections
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// Queue is a typed FIFO queue stored in the KeyValueState. Push and Pop each
+// modify two keys: the item and the queue bounds.
+type Queue[T any] struct {
+	ns    namespace
+	codec Codec[T]
+}
+
+func NewQueue[T any](name string, codec Codec[T]) Queue[T] {
+	return Queue[T]{newNamespace(name), codec}
+}
+
+// queueBounds identifies the items of the queue as the indices in [head,
+// tail).
+type queueBounds struct {
+	head uint64
+	tail uint64
+}
+
+func (q Queue[T]) boundsKey() []byte {
+	return q.ns.key(tagQueueBounds, nil)
+}
+
+func (q Queue[T]) itemKey(index uint64) []byte {
+	return q.ns.key(tagQueueItem, binary.BigEndian.AppendUint64(nil, index))
+}
+
+func (q Queue[T]) readBounds(r ocr3_1types.KeyValueStateReader) (queueBounds, error) {
+	raw, err := r.Read(q.boundsKey())
+	if err != nil {
+		return queueBounds{}, fmt.Errorf("failed to read queue bounds: %w", err)
+	}
+	if raw == nil {
+		return queueBounds{}, nil
+	}
+	if len(raw) != 16 {
+		return queueBounds{}, fmt.Errorf("expected 16 bytes for queue bounds, got %d", len(raw))
+	}
+	bounds := queueBounds{binary.BigEndian.Uint64(raw[:8]), binary.BigEndian.Uint64(raw[8:])}
+	if bounds.head > bounds.tail {
+		return queueBounds{}, fmt.Errorf("corrupt queue bounds: head %d > tail %d", bounds.head, bounds.tail)
+	}
+	return bounds, nil
+}
+
+func (q Queue[T]) writeBounds(rw ocr3_1types.KeyValueStateReadWriter, bounds queueBounds) error {
+	var err error
+	if bounds.head == bounds.tail {
+		// reset empty queues so that indices don't grow forever
+		err = rw.Delete(q.boundsKey())
+	} else {
+		raw := binary.BigEndian.AppendUint64(nil, bounds.head)
+		raw = binary.BigEndian.AppendUint64(raw, bounds.tail)
+		err = rw.Write(q.boundsKey(), raw)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write queue bounds: %w", err)
+	}
+	return nil
+}
+
+func (q Queue[T]) Len(r ocr3_1types.KeyValueStateReader) (uint64, error) {
+	bounds, err := q.readBounds(r)
+	if err != nil {
+		return 0, err
+	}
+	return bounds.tail - bounds.head, nil
+}
+
+// Get returns the i-th item from the front of the queue. ok is false if the
+// queue has no more than i items.
+func (q Queue[T]) Get(r ocr3_1types.KeyValueStateReader, i uint64) (t T, ok bool, err error) {
+	bounds, err := q.readBounds(r)
+	if err != nil {
+		return t, false, err
+	}
+	if !(i < bounds.tail-bounds.head) {
+		return t, false, nil
+	}
+	raw, err := r.Read(q.itemKey(bounds.head + i))
+	if err != nil {
+		return t, false, fmt.Errorf("failed to read item: %w", err)
+	}
+	if raw == nil {
+		return t, false, fmt.Errorf("item %d is missing", bounds.head+i)
+	}
+	t, err = q.codec.Decode(raw)
+	if err != nil {
+		return t, false, fmt.Errorf("failed to decode item: %w", err)
+	}
+	return t, true, nil
+}
+
+// Peek returns the item at the front of the queue without removing it.
+func (q Queue[T]) Peek(r ocr3_1types.KeyValueStateReader) (t T, ok bool, err error) {
+	return q.Get(r, 0)
+}
+
+// Push appends t to the back of the queue.
+func (q Queue[T]) Push(rw ocr3_1types.KeyValueStateReadWriter, t T) error {
+	bounds, err := q.readBounds(rw)
+	if err != nil {
+		return err
+	}
+	if bounds.tail+1 < bounds.tail {
+		return fmt.Errorf("queue index overflow")
+	}
+	raw, err := q.codec.Encode(t)
+	if err != nil {
+		return fmt.Errorf("failed to encode item: %w", err)
+	}
+	if raw == nil {
+		raw = []byte{}
+	}
+	if err := rw.Write(q.itemKey(bounds.tail), raw); err != nil {
+		return fmt.Errorf("failed to write item: %w", err)
+	}
+	bounds.tail++
+	return q.writeBounds(rw, bounds)
+}
+
+// Pop removes and returns the item at the front of the queue. ok is false if
+// the queue is empty, in which case nothing is modified.
+func (q Queue[T]) Pop(rw ocr3_1types.KeyValueStateReadWriter) (t T, ok bool, err error) {
+	t, ok, err = q.Peek(rw)
+	if err != nil || !ok {
+		return t, ok, err
+	}
+	bounds, err := q.readBounds(rw)
+	if err != nil {
+		return t, false, err
+	}
+	if err := rw.Delete(q.itemKey(bounds.head)); err != nil {
+		return t, false, fmt.Errorf("failed to delete item: %w", err)
+	}
+	bounds.head++
+	if err := q.writeBounds(rw, bounds); err != nil {
+		return t, false, err
+	}
+	return t, true, nil
+}
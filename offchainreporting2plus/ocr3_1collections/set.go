@@ -0,0 +1,49 @@
+package ocr3_1collections
+
+import (
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+)
+
+// Set is a typed set stored in the KeyValueState. Like Map, it keeps track of
+// its length.
+type Set[T any] struct {
+	m Map[T, struct{}]
+}
+
+func NewSet[T any](name string, codec Codec[T]) Set[T] {
+	return Set[T]{NewMap[T, struct{}](name, codec, emptyCodec{})}
+}
+
+func (s Set[T]) Contains(r ocr3_1types.KeyValueStateReader, t T) (bool, error) {
+	return s.m.Has(r, t)
+}
+
+func (s Set[T]) Len(r ocr3_1types.KeyValueStateReader) (uint64, error) {
+	return s.m.Len(r)
+}
+
+// Add inserts t and reports whether it was newly added. Adding a present
+// element modifies nothing.
+func (s Set[T]) Add(rw ocr3_1types.KeyValueStateReadWriter, t T) (added bool, err error) {
+	ok, err := s.m.Has(rw, t)
+	if err != nil || ok {
+		return false, err
+	}
+	if err := s.m.Put(rw, t, struct{}{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Remove deletes t and reports whether it was present.
+func (s Set[T]) Remove(rw ocr3_1types.KeyValueStateReadWriter, t T) (removed bool, err error) {
+	return s.m.Delete(rw, t)
+}
+
+type emptyCodec struct{}
+
+var _ Codec[struct{}] = emptyCodec{}
+
+func (emptyCodec) Encode(struct{}) ([]byte, error) { return []byte{}, nil }
+
+func (emptyCodec) Decode([]byte) (struct{}, error) { return struct{}{}, nil }
@@ -8,6 +8,7 @@ import (
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/confighelper"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/config/ocr3_1config"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/serialization"
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
 	"golang.org/x/crypto/curve25519"
 )
@@ -450,3 +451,27 @@ func ContractSetConfigArgsDeterministic(
 		&ephemeralSk,
 	)
 }
+
+// MakeSharedSecretRotation generates a fresh shared secret for the config
+// identified by configDigest and encrypts it for the given oracles, which must
+// be identical to (and in the same order as) the oracles of that config. The
+// result is meant to be supplied to the oracles through
+// ocr3_1types.SharedSecretRotationSource. Once adopted, the new shared secret
+// is used from activationEpoch onwards. rotationNumber must be greater than
+// that of any previous rotation for configDigest.
+func MakeSharedSecretRotation(
+	configDigest types.ConfigDigest,
+	rotationNumber uint64,
+	activationEpoch uint64,
+	oracles []confighelper.OracleIdentityExtra,
+) ([]byte, error) {
+	configEncryptionPublicKeys := make([]types.ConfigEncryptionPublicKey, 0, len(oracles))
+	for _, oracle := range oracles {
+		configEncryptionPublicKeys = append(configEncryptionPublicKeys, oracle.ConfigEncryptionPublicKey)
+	}
+	rotation, err := ocr3_1config.MakeSharedSecretRotation(configDigest, rotationNumber, activationEpoch, configEncryptionPublicKeys, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return serialization.SerializeSharedSecretRotation(rotation)
+}
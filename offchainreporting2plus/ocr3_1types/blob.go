@@ -2,6 +2,8 @@ package ocr3_1types
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/blobtypes"
 )
@@ -20,6 +22,41 @@ type BlobExpirationHintSequenceNumber struct{ SeqNr uint64 }
 
 func (BlobExpirationHintSequenceNumber) isBlobExpirationHint() {}
 
+var _ BlobExpirationHint = BlobExpirationHintDuration{}
+
+// BlobExpirationHintDuration asks for a blob to be retained for roughly
+// Duration after it was broadcast. At broadcast time, the duration is
+// converted into an expiry sequence number relative to the highest committed
+// sequence number, assuming that rounds are at least MinRoundInterval apart.
+// The blob therefore expires no earlier than Duration from broadcast, unless
+// rounds progress faster than MinRoundInterval. Duration must be positive.
+type BlobExpirationHintDuration struct{ Duration time.Duration }
+
+func (BlobExpirationHintDuration) isBlobExpirationHint() {}
+
+var _ BlobExpirationHint = BlobExpirationHintKeyDeleted{}
+
+// MaxBlobExpiryKeyBytes upper bounds the length of
+// BlobExpirationHintKeyDeleted.Key.
+const MaxBlobExpiryKeyBytes = 1024
+
+// BlobExpirationHintKeyDeleted asks for a blob to be retained while Key is
+// present in the KeyValueState, and no longer than Bound, which must be a
+// BlobExpirationHintSequenceNumber or BlobExpirationHintDuration. Key must be
+// non-empty and at most MaxBlobExpiryKeyBytes long.
+//
+// StateTransition can only fetch the blob while Key is present, so the plugin
+// should write Key before it first fetches the blob. Each such fetch links the
+// blob to Key. Once StateTransition deletes Key, all blobs linked to it are
+// released, see BlobReleaser. Like release records, links live under
+// ReservedKeyPrefix and count towards the limits on modified keys.
+type BlobExpirationHintKeyDeleted struct {
+	Key   []byte
+	Bound BlobExpirationHint
+}
+
+func (BlobExpirationHintKeyDeleted) isBlobExpirationHint() {}
+
 type BlobBroadcaster interface {
 	BroadcastBlob(ctx context.Context, payload []byte, expirationHint BlobExpirationHint) (BlobHandle, error)
 }
@@ -32,3 +69,60 @@ type BlobBroadcastFetcher interface {
 	BlobBroadcaster
 	BlobFetcher
 }
+
+// BlobStreamBroadcaster is implemented by the BlobBroadcastFetcher passed to
+// the ReportingPlugin. Plugins can type-assert to it in order to broadcast
+// large blobs without holding their payload in memory.
+type BlobStreamBroadcaster interface {
+	// BroadcastBlobReader is like BroadcastBlob, but reads the payload of
+	// exactly payloadLength bytes from payload. The payload is written to
+	// local storage chunk by chunk as it is read. It is an error for payload
+	// to yield fewer or more than payloadLength bytes.
+	BroadcastBlobReader(ctx context.Context, payloadLength uint64, payload io.Reader, expirationHint BlobExpirationHint) (BlobHandle, error)
+}
+
+// BlobErasureCodedBroadcaster is implemented by the BlobBroadcastFetcher passed
+// to the ReportingPlugin. Plugins can type-assert to it in order to broadcast
+// large blobs with less upstream bandwidth.
+type BlobErasureCodedBroadcaster interface {
+	// BroadcastBlobErasureCoded is like BroadcastBlob, but erasure codes the
+	// payload such that each oracle only receives a fragment of roughly
+	// 1/(F+1) of its length from the submitter. Fetching an erasure coded
+	// blob is transparent to the plugin, but requires reconstructing the
+	// payload from the fragments of other oracles. If a faulty submitter
+	// erasure coded the payload inconsistently, fetching the blob fails on
+	// all correct oracles alike.
+	BroadcastBlobErasureCoded(ctx context.Context, payload []byte, expirationHint BlobExpirationHint) (BlobHandle, error)
+}
+
+// BlobStreamFetcher is implemented by the BlobFetchers passed to the
+// ReportingPlugin. Plugins can type-assert to it in order to fetch large blobs
+// without holding their payload in memory.
+type BlobStreamFetcher interface {
+	// FetchBlobReader is like FetchBlob, but returns a reader for the payload
+	// once it is available in local storage. The reader reads the payload
+	// chunk by chunk and verifies each chunk against the chunk digests root
+	// certified in the handle. Reads error if the blob is reaped in the
+	// meantime. The reader must be closed after use.
+	FetchBlobReader(ctx context.Context, handle BlobHandle) (io.ReadCloser, error)
+}
+
+// BlobReleaser is implemented by the BlobFetcher passed to
+// ReportingPlugin.StateTransition. Plugins that no longer need a blob, e.g.
+// because it has been consumed in StateTransition, can type-assert the
+// blobFetcher to BlobReleaser and release the blob before its expiry sequence
+// number is reached.
+type BlobReleaser interface {
+	// ReleaseBlob releases the blob with the given handle as of the seqNr of
+	// the ongoing StateTransition. The release is recorded in the
+	// KeyValueState, so it only takes effect if that seqNr is committed, and
+	// it takes effect on all oracles alike: later StateTransitions fail to
+	// fetch the blob, and oracles reap their copies, which then stop counting
+	// towards the submitter's quota. Releasing a blob that expires at or
+	// before seqNr is a no-op. Releasing too many blobs that expire at the
+	// same seqNr fails.
+	//
+	// Release records live under ReservedKeyPrefix and count towards the
+	// limits on modified keys of the StateTransition.
+	ReleaseBlob(handle BlobHandle) error
+}
@@ -0,0 +1,76 @@
+package ocr3_1types
+
+import (
+	"context"
+	"errors"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3types"
+)
+
+// ErrKeyValueStateSeqNrPruned is returned (wrapped) when historical
+// KeyValueState is requested for a sequence number that is no longer retained.
+// How far back state is retained is determined by the SnapshotInterval and
+// MaxHistoricalSnapshotsRetained config parameters.
+var ErrKeyValueStateSeqNrPruned = errors.New("KeyValueState for seqNr has been pruned")
+
+// ErrKeyValueStateSeqNrNotCommitted is returned (wrapped) when historical
+// KeyValueState is requested for a sequence number that has not been committed
+// yet.
+var ErrKeyValueStateSeqNrNotCommitted = errors.New("KeyValueState for seqNr has not been committed")
+
+type KeyValuePair struct {
+	Key   []byte
+	Value []byte
+}
+
+// HistoricalKeyValueState provides read-only access to the KeyValueState as
+// of any retained sequence number.
+type HistoricalKeyValueState interface {
+	// RetainedSeqNrs returns the range of sequence numbers (both inclusive)
+	// for which NewHistoricalKeyValueStateReader currently succeeds. The
+	// range moves forward as the protocol progresses.
+	RetainedSeqNrs() (lowest uint64, highest uint64, err error)
+
+	// NewHistoricalKeyValueStateReader returns a reader for the KeyValueState
+	// as it was after the StateTransition for seqNr was committed, i.e. the
+	// state passed to Committed and the state from which the reports of seqNr
+	// were produced. Returns an error wrapping ErrKeyValueStateSeqNrPruned or
+	// ErrKeyValueStateSeqNrNotCommitted if seqNr is out of the retained range.
+	// The returned reader must be discarded after use.
+	NewHistoricalKeyValueStateReader(seqNr uint64) (HistoricalKeyValueStateReader, error)
+}
+
+type HistoricalKeyValueStateReader interface {
+	// Read reads a key as of SeqNr(). Reads are cheapest for the highest
+	// committed seqNr and for seqNrs at snapshot boundaries. Otherwise their
+	// cost grows with the distance to the preceding snapshot.
+	KeyValueStateReader
+
+	// ReadRange returns all key-value pairs with loKey <= key < hiKeyExcl as of
+	// SeqNr(), sorted by key. A nil hiKeyExcl means there is no upper bound.
+	// ReadRange scans the entire state and is intended for tooling rather
+	// than hot paths.
+	ReadRange(loKey []byte, hiKeyExcl []byte) ([]KeyValuePair, error)
+
+	SeqNr() uint64
+
+	Discard()
+}
+
+// ReportingPluginFactoryWithHistoricalKeyValueState may optionally be
+// implemented by a ReportingPluginFactory whose plugins need to read the
+// KeyValueState as of past sequence numbers, e.g. from Committed or Reports.
+// If implemented, NewReportingPluginWithHistoricalKeyValueState is called
+// instead of NewReportingPlugin. The HistoricalKeyValueState errors until the
+// protocol instance's KeyValueDatabase has been opened, which happens after
+// the plugin has been created.
+type ReportingPluginFactoryWithHistoricalKeyValueState[RI any] interface {
+	ReportingPluginFactory[RI]
+
+	NewReportingPluginWithHistoricalKeyValueState(
+		context.Context,
+		ocr3types.ReportingPluginConfig,
+		BlobBroadcastFetcher,
+		HistoricalKeyValueState,
+	) (ReportingPlugin[RI], ReportingPluginInfo, error)
+}
@@ -0,0 +1,31 @@
+package ocr3_1types
+
+import (
+	"context"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// ObserverConsumer receives the committed reports of the OCR3.1 protocol
+// instances followed by an observer.
+type ObserverConsumer interface {
+	// ObservedReportsPlusPrecursor is called with the ReportsPlusPrecursor
+	// committed for seqNr in the protocol instance with configDigest, after
+	// it has been checked against the attested block for seqNr. Calls are
+	// made in increasing seqNr order.
+	//
+	// Delivery is best effort: oracles only retain ReportsPlusPrecursors for a
+	// few rounds, so sequence numbers the observer fails to fetch in time are
+	// skipped, and sequence numbers may be delivered again after a restart.
+	//
+	// keyValueState provides read-only access to the replicated KeyValueState,
+	// e.g. as of seqNr. It must not be used after ctx is done. Errors are
+	// logged, there are no retries.
+	ObservedReportsPlusPrecursor(
+		ctx context.Context,
+		configDigest types.ConfigDigest,
+		seqNr uint64,
+		reportsPlusPrecursor ReportsPlusPrecursor,
+		keyValueState HistoricalKeyValueState,
+	) error
+}
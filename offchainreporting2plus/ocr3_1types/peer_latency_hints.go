@@ -0,0 +1,15 @@
+package ocr3_1types
+
+import "time"
+
+// PeerLatencyHints lets an oracle send state sync and blob requests to the
+// closest responsive oracles first. The networking stack measures latencies
+// with its ping protocol; see networking's OCR3_1PeerLatencyHints.
+//
+// All functions must be thread-safe and return quickly.
+type PeerLatencyHints interface {
+	// RoundTripLatency returns the current estimate of the round-trip latency
+	// to the oracle with the given PeerID. ok is false if there is no estimate,
+	// or if the oracle doesn't appear to be responsive.
+	RoundTripLatency(peerID string) (latency time.Duration, ok bool)
+}
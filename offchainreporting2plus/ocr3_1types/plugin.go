@@ -33,6 +33,11 @@ type KeyValueStateReader interface {
 // Deprecated: Use KeyValueStateReadWriter instead.
 type KeyValueReadWriter = KeyValueStateReadWriter
 
+// ReservedKeyPrefix is the prefix of the keys in the KeyValueState that the
+// protocol uses for its own records, e.g. for released blobs. Plugins can read
+// these keys, but writing or deleting them fails.
+const ReservedKeyPrefix = "\xffocr3_1|"
+
 // Provides read and write access to the replicated KeyValueState.
 type KeyValueStateReadWriter interface {
 	KeyValueStateReader
@@ -266,7 +271,7 @@ type ReportingPlugin[RI any] interface {
 	// The blobFetcher enables fetching blobs. It must not be used outside the execution
 	// of this function. (It's okay to use it anywhere in the call tree rooted at this function
 	// and to pass it to separate goroutines, but they must stop using it before this
-	// function returns.)
+	// function returns.) The blobFetcher also implements BlobReleaser.
 	StateTransition(
 		ctx context.Context,
 		seqNr uint64,
@@ -362,7 +367,7 @@ const (
 	MaxMaxKeyValueModifiedKeys                = 10_000
 	MaxMaxKeyValueModifiedKeysPlusValuesBytes = 10 * mib
 
-	MaxMaxBlobPayloadBytes = 5 * mib
+	MaxMaxBlobPayloadBytes = 100 * mib
 )
 
 // Limits for data returned by the ReportingPlugin.
@@ -398,7 +403,9 @@ type ReportingPluginLimits struct {
 	MaxKeyValueModifiedKeysPlusValuesBytes int
 
 	// MaxBlobPayloadBytes upper bounds the payload bytes for a single blob. A
-	// broadcast with a larger payload will be rejected.
+	// broadcast with a larger payload will be rejected. Blobs larger than a few
+	// MiB are best broadcast and fetched through BlobStreamBroadcaster and
+	// BlobStreamFetcher.
 	MaxBlobPayloadBytes int
 	// MaxPerOracleUnexpiredBlobCumulativePayloadBytes upper bounds the
 	// cumulative payload length for all unreaped blobs from a single oracle.
@@ -0,0 +1,20 @@
+package ocr3_1types
+
+import (
+	"context"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// SharedSecretRotationSource supplies an oracle with shared secret rotations
+// approved by its operator. Rotations are created with
+// ocr3_1confighelper.MakeSharedSecretRotation and replace the shared secret
+// (used for leader selection and transmission order) without a
+// reconfiguration. A rotation is only adopted once a byzantine quorum of the
+// oracles in the config has received it from their sources.
+type SharedSecretRotationSource interface {
+	// LatestSharedSecretRotation returns the serialized rotation with the
+	// highest rotation number for the given configDigest, or nil if there is
+	// none.
+	LatestSharedSecretRotation(ctx context.Context, configDigest types.ConfigDigest) ([]byte, error)
+}
@@ -0,0 +1,62 @@
+package ocr3_1types
+
+import (
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// StateTransitionInputs are the inputs of ReportingPlugin.StateTransition
+// that are agreed upon by the protocol.
+type StateTransitionInputs struct {
+	AttributedQuery        types.AttributedQuery
+	AttributedObservations []types.AttributedObservation
+}
+
+// StateTransitionInputsSource provides the inputs for re-executing
+// StateTransition. Attested blocks only commit to a digest of their inputs, so
+// the inputs themselves have to be obtained elsewhere, e.g. from a traffic
+// recording.
+type StateTransitionInputsSource interface {
+	// StateTransitionInputs returns candidate inputs for seqNr. Candidates that
+	// don't match the digest committed to by the attested block are ignored,
+	// so it is fine to return the inputs of several (e.g. aborted) rounds for
+	// the same seqNr. Returns no candidates if the inputs are unknown.
+	StateTransitionInputs(seqNr uint64) ([]StateTransitionInputs, error)
+}
+
+// KeyValueModification describes what a StateTransition did to a single key.
+type KeyValueModification struct {
+	// Modified is false if the key is absent from the write set.
+	Modified bool
+	Deleted  bool
+	Value    []byte
+}
+
+// StateTransitionDivergence describes how re-executing StateTransition for
+// SeqNr diverged from the attested block.
+type StateTransitionDivergence struct {
+	SeqNr uint64
+
+	// If WriteSetDiverges, FirstDivergingKey is the lowest key on which the
+	// attested and re-executed write sets disagree.
+	WriteSetDiverges       bool
+	FirstDivergingKey      []byte
+	AttestedModification   KeyValueModification
+	ReExecutedModification KeyValueModification
+
+	StateRootDiverges            bool
+	ReportsPlusPrecursorDiverges bool
+}
+
+// StateTransitionDeterminismReport is the result of re-executing
+// StateTransition over a range of attested blocks.
+type StateTransitionDeterminismReport struct {
+	// CheckedSeqNrs lists the sequence numbers that were re-executed without
+	// divergence.
+	CheckedSeqNrs []uint64
+	// MissingInputsSeqNrs lists the sequence numbers that were skipped
+	// because the StateTransitionInputsSource had no matching inputs.
+	MissingInputsSeqNrs []uint64
+	// Divergence is nil if no divergence was found. Otherwise, it describes
+	// the first diverging sequence number, after which checking stops.
+	Divergence *StateTransitionDivergence
+}
@@ -282,6 +282,21 @@ type ReportingPlugin[RI any] interface {
 	Close() error
 }
 
+// ReportInfoCodec may additionally be implemented by a ReportingPlugin (or an
+// ocr3_1types.ReportingPlugin). Reports accepted by ShouldAcceptAttestedReport
+// are then persisted, so that their transmission resumes if the oracle
+// restarts before their scheduled time. A persisted report is deleted once
+// a report with the same index and a higher seqNr is accepted, so only the
+// latest report per index is resumed. Reports of plugins that don't implement
+// ReportInfoCodec are only kept in memory.
+//
+// DecodeReportInfo must return an info equal to the one passed to
+// EncodeReportInfo, also across versions of the plugin.
+type ReportInfoCodec[RI any] interface {
+	EncodeReportInfo(info RI) ([]byte, error)
+	DecodeReportInfo(encoded []byte) (RI, error)
+}
+
 // It's much easier to increase these than to decrease them, so we start with
 // conservative values. Talk to the maintainers if you need higher limits for
 // your plugin.
@@ -290,9 +290,26 @@ type OCR3_1OracleArgs[RI any] struct {
 	// offchain and by the target contract.
 	OnchainKeyring ocr3types.OnchainKeyring[RI]
 
+	// ObserverPeerIDs lists the peer IDs of observers (see OCR3_1ObserverArgs)
+	// that may follow this protocol instance. Observers can request committed
+	// state and reports, but never take part in the protocol otherwise. This
+	// may be nil.
+	ObserverPeerIDs []string
+
+	// PeerLatencyHints lets the oracle send state sync and blob requests to the
+	// closest responsive oracles first. This may be nil. Networking peers
+	// provide latency hints through OCR3_1PeerLatencyHints().
+	PeerLatencyHints ocr3_1types.PeerLatencyHints
+
 	// PluginFactory creates Plugins that determine the "application logic" used
 	// in a protocol instance.
 	ReportingPluginFactory ocr3_1types.ReportingPluginFactory[RI]
+
+	// SharedSecretRotationSource supplies shared secret rotations that allow
+	// replacing the shared secret without a reconfiguration. This may be nil,
+	// in which case the oracle doesn't endorse rotations, but still adopts
+	// rotations endorsed by a quorum of other oracles.
+	SharedSecretRotationSource ocr3_1types.SharedSecretRotationSource
 }
 
 func (OCR3_1OracleArgs[RI]) oracleArgsMarker() {}
@@ -315,10 +332,101 @@ func (args OCR3_1OracleArgs[RI]) runManaged(ctx context.Context) {
 		args.MetricsRegisterer,
 		args.MonitoringEndpoint,
 		args.BinaryNetworkEndpointFactory,
+		args.ObserverPeerIDs,
 		args.OffchainConfigDigester,
 		args.OffchainKeyring,
 		args.OnchainKeyring,
+		args.PeerLatencyHints,
 		args.ReportingPluginFactory,
+		args.SharedSecretRotationSource,
+	)
+}
+
+// OCR3_1ObserverArgs contains the configuration and services a caller must
+// provide in order to follow an OCR3.1 protocol instance as an observer. An
+// observer is not part of the oracle set. It replicates the committed
+// KeyValueState and fetches committed reports from the oracles, checking
+// everything against the attestations of the on-chain signer set. It holds no
+// keys, never signs anything, and never counts towards any quorum.
+//
+// Every oracle must list the observer's peer ID in
+// OCR3_1OracleArgs.ObserverPeerIDs, otherwise it won't talk to the observer.
+// Pass OCR3_1ObserverArgs to NewOracle to run the observer.
+type OCR3_1ObserverArgs struct {
+	// A factory for producing network endpoints. The observer's peer ID must
+	// not be the peer ID of any oracle.
+	BinaryNetworkEndpointFactory types.BinaryNetworkEndpoint2Factory
+
+	// V2Bootstrappers is the list of bootstrap node addresses and IDs for the v2 stack.
+	V2Bootstrappers []commontypes.BootstrapperLocator
+
+	// Tracks configuration changes.
+	ContractConfigTracker types.ContractConfigTracker
+
+	// Database provides persistent storage for the latest contract config.
+	Database types.ConfigDatabase
+
+	// KeyValueDatabaseFactory produces KeyValueDatabase for keeping the
+	// replicated KeyValueState. Use NewOCR3_1HistoricalKeyValueState to read
+	// it from outside the observer.
+	KeyValueDatabaseFactory ocr3_1types.KeyValueDatabaseFactory
+
+	// LocalConfig contains observer-specific configuration details.
+	LocalConfig types.LocalConfig
+
+	// Logger logs stuff.
+	Logger commontypes.Logger
+
+	// MaxSignatureLength must be the OnchainKeyring.MaxSignatureLength() of
+	// the oracles. It is needed to size check attested blocks.
+	MaxSignatureLength int
+
+	// Enables adding metrics to track. This may be nil.
+	MetricsRegisterer prometheus.Registerer
+
+	// Used to send logs to a monitor.
+	MonitoringEndpoint commontypes.MonitoringEndpoint
+
+	// ObserverConsumer receives the committed reports. This may be nil, in
+	// which case the observer only replicates the KeyValueState.
+	ObserverConsumer ocr3_1types.ObserverConsumer
+
+	// Computes a config digest using purely offchain logic.
+	OffchainConfigDigester types.OffchainConfigDigester
+
+	// PeerLatencyHints lets the observer send requests to the closest
+	// responsive oracles first. This may be nil.
+	PeerLatencyHints ocr3_1types.PeerLatencyHints
+
+	// ReportingPluginLimits must be the limits in the ReportingPluginInfo1
+	// returned by the oracles' ReportingPluginFactory.
+	ReportingPluginLimits ocr3_1types.ReportingPluginLimits
+}
+
+func (OCR3_1ObserverArgs) oracleArgsMarker() {}
+
+func (args OCR3_1ObserverArgs) localConfig() types.LocalConfig { return args.LocalConfig }
+
+func (args OCR3_1ObserverArgs) runManaged(ctx context.Context) {
+	logger := loghelper.MakeRootLoggerWithContext(args.Logger)
+
+	managed.RunManagedOCR3_1Observer(
+		ctx,
+
+		args.V2Bootstrappers,
+		args.ContractConfigTracker,
+		args.Database,
+		args.KeyValueDatabaseFactory,
+		args.LocalConfig,
+		logger,
+		args.MaxSignatureLength,
+		args.MetricsRegisterer,
+		args.MonitoringEndpoint,
+		args.BinaryNetworkEndpointFactory,
+		args.ObserverConsumer,
+		args.OffchainConfigDigester,
+		args.PeerLatencyHints,
+		args.ReportingPluginLimits,
 	)
 }
 
@@ -0,0 +1,66 @@
+package trafficrecording
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr2/serialization"
+	ocr3serialization "github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3/serialization"
+	ocr3_1serialization "github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/serialization"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Protocol determines how the payloads of a recording are decoded.
+type Protocol string
+
+const (
+	ProtocolOCR2   Protocol = "ocr2"
+	ProtocolOCR3   Protocol = "ocr3"
+	ProtocolOCR3_1 Protocol = "ocr3_1"
+)
+
+// decode decodes payload with the serialization package of protocol. It
+// returns the name of the protocol message type and the protobuf wrapper of
+// the message in its JSON representation.
+func decode(protocol Protocol, n int, payload []byte) (string, json.RawMessage, error) {
+	var (
+		msg     any
+		wrapper proto.Message
+		err     error
+	)
+	switch protocol {
+	case ProtocolOCR2:
+		msg, wrapper, err = serialization.Deserialize(payload)
+	case ProtocolOCR3:
+		// The report info type parameter doesn't affect deserialization
+		msg, wrapper, err = ocr3serialization.Deserialize[struct{}](n, payload)
+	case ProtocolOCR3_1:
+		msg, wrapper, err = ocr3_1serialization.Deserialize[struct{}](n, payload, nil)
+	default:
+		return "", nil, fmt.Errorf("unknown protocol %q", protocol)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	encoded, err := protojson.Marshal(wrapper)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode message as JSON: %w", err)
+	}
+	return messageTypeName(msg), encoded, nil
+}
+
+// messageTypeName strips the package and type parameters from the type name of
+// msg, e.g. "MessageNewEpochWish".
+func messageTypeName(msg any) string {
+	name := fmt.Sprintf("%T", msg)
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
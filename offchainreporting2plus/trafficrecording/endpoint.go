@@ -0,0 +1,269 @@
+package trafficrecording
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"github.com/smartcontractkit/libocr/subprocesses"
+)
+
+// WrapBinaryNetworkEndpointFactory returns a factory whose endpoints record
+// their traffic with recorder. protocol must be ProtocolOCR2 or ProtocolOCR3,
+// matching the oracle that factory is passed to.
+func WrapBinaryNetworkEndpointFactory(factory types.BinaryNetworkEndpointFactory, protocol Protocol, recorder *Recorder) types.BinaryNetworkEndpointFactory {
+	return &recordingBinaryNetworkEndpointFactory{factory, protocol, recorder}
+}
+
+// WrapBinaryNetworkEndpoint2Factory returns a factory whose endpoints record
+// their traffic with recorder. It is intended for OCR3.1 oracles.
+func WrapBinaryNetworkEndpoint2Factory(factory types.BinaryNetworkEndpoint2Factory, recorder *Recorder) types.BinaryNetworkEndpoint2Factory {
+	return &recordingBinaryNetworkEndpoint2Factory{factory, recorder}
+}
+
+// endpointRecorder creates the records of a single endpoint.
+type endpointRecorder struct {
+	recorder     *Recorder
+	protocol     Protocol
+	configDigest types.ConfigDigest
+	self         commontypes.OracleID
+	n            int
+}
+
+func newEndpointRecorder(recorder *Recorder, protocol Protocol, configDigest types.ConfigDigest, peerIDs []string, selfPeerID string) (endpointRecorder, error) {
+	self := slices.Index(peerIDs, selfPeerID)
+	if self < 0 {
+		return endpointRecorder{}, fmt.Errorf("own peer ID %s is not present in given peerIDs", selfPeerID)
+	}
+	return endpointRecorder{recorder, protocol, configDigest, commontypes.OracleID(self), len(peerIDs)}, nil
+}
+
+func (er endpointRecorder) record(direction Direction, peer *commontypes.OracleID, kind Kind, priority types.BinaryMessageOutboundPriority, payload []byte) {
+	rec := Record{
+		time.Now(),
+		er.configDigest,
+		er.protocol,
+		er.self,
+		direction,
+		peer,
+		kind,
+		priority,
+		payload,
+		"",
+		nil,
+		"",
+	}
+	er.recorder.record(pendingRecord{rec, er.n})
+}
+
+type recordingBinaryNetworkEndpointFactory struct {
+	factory  types.BinaryNetworkEndpointFactory
+	protocol Protocol
+	recorder *Recorder
+}
+
+var _ types.BinaryNetworkEndpointFactory = &recordingBinaryNetworkEndpointFactory{}
+
+func (f *recordingBinaryNetworkEndpointFactory) NewEndpoint(
+	cd types.ConfigDigest,
+	peerIDs []string,
+	v2bootstrappers []commontypes.BootstrapperLocator,
+	failureThreshold int,
+	limits types.BinaryNetworkEndpointLimits,
+) (commontypes.BinaryNetworkEndpoint, error) {
+	er, err := newEndpointRecorder(f.recorder, f.protocol, cd, peerIDs, f.factory.PeerID())
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := f.factory.NewEndpoint(cd, peerIDs, v2bootstrappers, failureThreshold, limits)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingBinaryNetworkEndpoint{
+		endpoint,
+		er,
+		make(chan commontypes.BinaryMessageWithSender),
+		make(chan struct{}),
+		sync.Once{},
+		subprocesses.Subprocesses{},
+	}, nil
+}
+
+func (f *recordingBinaryNetworkEndpointFactory) PeerID() string {
+	return f.factory.PeerID()
+}
+
+type recordingBinaryNetworkEndpoint struct {
+	endpoint  commontypes.BinaryNetworkEndpoint
+	recorder  endpointRecorder
+	chReceive chan commontypes.BinaryMessageWithSender
+	chClose   chan struct{}
+	closeOnce sync.Once
+	subs      subprocesses.Subprocesses
+}
+
+var _ commontypes.BinaryNetworkEndpoint = &recordingBinaryNetworkEndpoint{}
+
+func (e *recordingBinaryNetworkEndpoint) SendTo(payload []byte, to commontypes.OracleID) {
+	e.recorder.record(DirectionSent, &to, KindPlain, 0, payload)
+	e.endpoint.SendTo(payload, to)
+}
+
+func (e *recordingBinaryNetworkEndpoint) Broadcast(payload []byte) {
+	e.recorder.record(DirectionBroadcast, nil, KindPlain, 0, payload)
+	e.endpoint.Broadcast(payload)
+}
+
+func (e *recordingBinaryNetworkEndpoint) Receive() <-chan commontypes.BinaryMessageWithSender {
+	return e.chReceive
+}
+
+func (e *recordingBinaryNetworkEndpoint) Start() error {
+	if err := e.endpoint.Start(); err != nil {
+		return err
+	}
+	e.subs.Go(func() {
+		chReceive := e.endpoint.Receive()
+		for {
+			select {
+			case msg := <-chReceive:
+				e.recorder.record(DirectionReceived, &msg.Sender, KindPlain, 0, msg.Msg)
+				select {
+				case e.chReceive <- msg:
+				case <-e.chClose:
+					return
+				}
+			case <-e.chClose:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (e *recordingBinaryNetworkEndpoint) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.chClose)
+	})
+	e.subs.Wait()
+	return e.endpoint.Close()
+}
+
+type recordingBinaryNetworkEndpoint2Factory struct {
+	factory  types.BinaryNetworkEndpoint2Factory
+	recorder *Recorder
+}
+
+var _ types.BinaryNetworkEndpoint2Factory = &recordingBinaryNetworkEndpoint2Factory{}
+
+func (f *recordingBinaryNetworkEndpoint2Factory) NewEndpoint(
+	cd types.ConfigDigest,
+	peerIDs []string,
+	v2bootstrappers []commontypes.BootstrapperLocator,
+	defaultPriorityConfig types.BinaryNetworkEndpoint2Config,
+	lowPriorityConfig types.BinaryNetworkEndpoint2Config,
+) (types.BinaryNetworkEndpoint2, error) {
+	er, err := newEndpointRecorder(f.recorder, ProtocolOCR3_1, cd, peerIDs, f.factory.PeerID())
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := f.factory.NewEndpoint(cd, peerIDs, v2bootstrappers, defaultPriorityConfig, lowPriorityConfig)
+	if err != nil {
+		return nil, err
+	}
+	e := &recordingBinaryNetworkEndpoint2{
+		endpoint,
+		er,
+		make(chan types.InboundBinaryMessageWithSender),
+		make(chan struct{}),
+		sync.Once{},
+		subprocesses.Subprocesses{},
+	}
+	// BinaryNetworkEndpoint2 has no Start, it delivers messages right away
+	e.subs.Go(e.receiveLoop)
+	return e, nil
+}
+
+func (f *recordingBinaryNetworkEndpoint2Factory) PeerID() string {
+	return f.factory.PeerID()
+}
+
+type recordingBinaryNetworkEndpoint2 struct {
+	endpoint  types.BinaryNetworkEndpoint2
+	recorder  endpointRecorder
+	chReceive chan types.InboundBinaryMessageWithSender
+	chClose   chan struct{}
+	closeOnce sync.Once
+	subs      subprocesses.Subprocesses
+}
+
+var _ types.BinaryNetworkEndpoint2 = &recordingBinaryNetworkEndpoint2{}
+
+func (e *recordingBinaryNetworkEndpoint2) SendTo(msg types.OutboundBinaryMessage, to commontypes.OracleID) {
+	kind, priority := outboundKindAndPriority(msg)
+	e.recorder.record(DirectionSent, &to, kind, priority, msg.GetPayload())
+	e.endpoint.SendTo(msg, to)
+}
+
+func (e *recordingBinaryNetworkEndpoint2) Broadcast(msg types.OutboundBinaryMessage) {
+	kind, priority := outboundKindAndPriority(msg)
+	e.recorder.record(DirectionBroadcast, nil, kind, priority, msg.GetPayload())
+	e.endpoint.Broadcast(msg)
+}
+
+func (e *recordingBinaryNetworkEndpoint2) Receive() <-chan types.InboundBinaryMessageWithSender {
+	return e.chReceive
+}
+
+func (e *recordingBinaryNetworkEndpoint2) receiveLoop() {
+	chReceive := e.endpoint.Receive()
+	for {
+		select {
+		case msg := <-chReceive:
+			kind, priority := inboundKindAndPriority(msg.InboundBinaryMessage)
+			e.recorder.record(DirectionReceived, &msg.Sender, kind, priority, msg.GetPayload())
+			select {
+			case e.chReceive <- msg:
+			case <-e.chClose:
+				return
+			}
+		case <-e.chClose:
+			return
+		}
+	}
+}
+
+func (e *recordingBinaryNetworkEndpoint2) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.chClose)
+	})
+	e.subs.Wait()
+	return e.endpoint.Close()
+}
+
+func outboundKindAndPriority(msg types.OutboundBinaryMessage) (Kind, types.BinaryMessageOutboundPriority) {
+	switch msg := msg.(type) {
+	case types.OutboundBinaryMessagePlain:
+		return KindPlain, msg.Priority
+	case types.OutboundBinaryMessageRequest:
+		return KindRequest, msg.Priority
+	case types.OutboundBinaryMessageResponse:
+		return KindResponse, msg.Priority
+	}
+	panic("outboundKindAndPriority: unreachable")
+}
+
+func inboundKindAndPriority(msg types.InboundBinaryMessage) (Kind, types.BinaryMessageOutboundPriority) {
+	switch msg := msg.(type) {
+	case types.InboundBinaryMessagePlain:
+		return KindPlain, msg.Priority
+	case types.InboundBinaryMessageRequest:
+		return KindRequest, msg.Priority
+	case types.InboundBinaryMessageResponse:
+		return KindResponse, msg.Priority
+	}
+	panic("inboundKindAndPriority: unreachable")
+}
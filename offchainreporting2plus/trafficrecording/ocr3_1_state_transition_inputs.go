@@ -0,0 +1,106 @@
+package trafficrecording
+
+import (
+	"slices"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/protocol"
+	ocr3_1serialization "github.com/smartcontractkit/libocr/offchainreporting2plus/internal/ocr3_1/serialization"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/ocr3_1types"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+)
+
+// NewOCR3_1StateTransitionInputsSource returns a StateTransitionInputsSource
+// that reconstructs the inputs of StateTransition from the MessageRoundStart
+// and MessageProposal messages in the recording, for the OCR3.1 protocol
+// instance with configDigest and n oracles. Records from several oracles may
+// be mixed. The inputs of a round are only available if both its
+// MessageRoundStart and its MessageProposal were recorded.
+func NewOCR3_1StateTransitionInputsSource(records []Record, configDigest types.ConfigDigest, n int) ocr3_1types.StateTransitionInputsSource {
+	type roundKey struct {
+		epoch    uint64
+		seqNr    uint64
+		proposer commontypes.OracleID
+	}
+	type round struct {
+		queries   []types.Query
+		proposals [][]types.AttributedObservation
+	}
+	rounds := map[roundKey]*round{}
+	var roundKeys []roundKey
+	getRound := func(key roundKey) *round {
+		if r, ok := rounds[key]; ok {
+			return r
+		}
+		r := &round{}
+		rounds[key] = r
+		roundKeys = append(roundKeys, key)
+		return r
+	}
+
+	for _, rec := range records {
+		if rec.Protocol != ProtocolOCR3_1 || rec.ConfigDigest != configDigest {
+			continue
+		}
+		// both messages are sent by the leader of the round
+		proposer := rec.Self
+		if rec.Direction == DirectionReceived {
+			if rec.Peer == nil {
+				continue
+			}
+			proposer = *rec.Peer
+		}
+
+		msg, _, err := ocr3_1serialization.Deserialize[struct{}](n, rec.Payload, nil)
+		if err != nil {
+			continue
+		}
+		switch msg := msg.(type) {
+		case protocol.MessageRoundStart[struct{}]:
+			r := getRound(roundKey{msg.Epoch, msg.SeqNr, proposer})
+			if !slices.ContainsFunc(r.queries, func(query types.Query) bool { return slices.Equal(query, msg.Query) }) {
+				r.queries = append(r.queries, msg.Query)
+			}
+		case protocol.MessageProposal[struct{}]:
+			aos := make([]types.AttributedObservation, 0, len(msg.AttributedSignedObservations))
+			for _, aso := range msg.AttributedSignedObservations {
+				aos = append(aos, types.AttributedObservation{
+					aso.SignedObservation.Observation,
+					aso.Observer,
+				})
+			}
+			r := getRound(roundKey{msg.Epoch, msg.SeqNr, proposer})
+			if !slices.ContainsFunc(r.proposals, func(proposal []types.AttributedObservation) bool {
+				return slices.EqualFunc(proposal, aos, func(a, b types.AttributedObservation) bool {
+					return a.Observer == b.Observer && slices.Equal(a.Observation, b.Observation)
+				})
+			}) {
+				r.proposals = append(r.proposals, aos)
+			}
+		}
+	}
+
+	inputs := map[uint64][]ocr3_1types.StateTransitionInputs{}
+	for _, key := range roundKeys {
+		r := rounds[key]
+		for _, query := range r.queries {
+			for _, aos := range r.proposals {
+				inputs[key.seqNr] = append(inputs[key.seqNr], ocr3_1types.StateTransitionInputs{
+					types.AttributedQuery{query, key.proposer},
+					aos,
+				})
+			}
+		}
+	}
+	return ocr3_1StateTransitionInputsSource{inputs}
+}
+
+type ocr3_1StateTransitionInputsSource struct {
+	inputs map[uint64][]ocr3_1types.StateTransitionInputs
+}
+
+var _ ocr3_1types.StateTransitionInputsSource = ocr3_1StateTransitionInputsSource{}
+
+func (s ocr3_1StateTransitionInputsSource) StateTransitionInputs(seqNr uint64) ([]ocr3_1types.StateTransitionInputs, error) {
+	return s.inputs[seqNr], nil
+}
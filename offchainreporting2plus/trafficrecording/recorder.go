@@ -0,0 +1,361 @@
+// Package trafficrecording records the OCR messages that an oracle sends and
+// receives through its BinaryNetworkEndpoint, and replays such recordings into
+// a single oracle instance for offline debugging.
+//
+// Recording is opt-in: wrap the endpoint factory passed to an oracle with
+// WrapBinaryNetworkEndpointFactory or WrapBinaryNetworkEndpoint2Factory. To
+// replay, pass a factory created by NewReplayBinaryNetworkEndpointFactory or
+// NewReplayBinaryNetworkEndpoint2Factory to an oracle instead.
+//
+// Only messages received by the replayed oracle are fed back to it; its own
+// outgoing messages are dropped. Replays are therefore only meaningful if the
+// oracle under test runs with the same plugin, contract configuration and
+// database state as the recorded oracle.
+package trafficrecording
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"github.com/smartcontractkit/libocr/subprocesses"
+)
+
+type Direction string
+
+const (
+	DirectionSent      Direction = "sent"
+	DirectionBroadcast Direction = "broadcast"
+	DirectionReceived  Direction = "received"
+)
+
+// Kind distinguishes the message types of BinaryNetworkEndpoint2. Messages of
+// BinaryNetworkEndpoint are always KindPlain.
+type Kind string
+
+const (
+	KindPlain    Kind = "plain"
+	KindRequest  Kind = "request"
+	KindResponse Kind = "response"
+)
+
+// Record is a single message that passed through a recorded endpoint. Message
+// holds the decoded protocol message, or is absent if DecodeError is set.
+type Record struct {
+	Time         time.Time                           `json:"time"`
+	ConfigDigest types.ConfigDigest                  `json:"configDigest"`
+	Protocol     Protocol                            `json:"protocol"`
+	Self         commontypes.OracleID                `json:"self"`
+	Direction    Direction                           `json:"direction"`
+	Peer         *commontypes.OracleID               `json:"peer,omitempty"` // recipient or sender, nil for broadcasts
+	Kind         Kind                                `json:"kind"`
+	Priority     types.BinaryMessageOutboundPriority `json:"priority,omitempty"`
+	Payload      []byte                              `json:"payload"`
+	MessageType  string                              `json:"messageType,omitempty"`
+	Message      json.RawMessage                     `json:"message,omitempty"`
+	DecodeError  string                              `json:"decodeError,omitempty"`
+}
+
+// pendingRecord is a Record whose message hasn't been decoded yet. Decoding
+// is left to the writer, so that it doesn't slow down the endpoint.
+type pendingRecord struct {
+	Record
+	n int // number of oracles, needed for decoding
+}
+
+type RecorderConfig struct {
+	// Directory that the recording files are written to. It is created if it
+	// doesn't exist.
+	Directory string
+	// A new file is started once the current file exceeds MaxFileSize bytes.
+	MaxFileSize int64
+	// The oldest files are deleted once there are more than MaxFiles. Zero
+	// means that no files are deleted.
+	MaxFiles int
+	// Number of records that are buffered for writing. Records are dropped
+	// while the buffer is full, so that recording never blocks an endpoint.
+	BufferSize int
+}
+
+const (
+	fileNamePrefix = "traffic-"
+	fileNameSuffix = ".jsonl"
+	// Lexicographic order of file names matches chronological order
+	fileNameTimeFormat = "20060102T150405.000000000Z"
+)
+
+// Recorder writes records as JSON lines to a rotating set of files. All its
+// functions are thread-safe.
+type Recorder struct {
+	config RecorderConfig
+	logger commontypes.Logger
+
+	chRecords chan pendingRecord
+	subs      subprocesses.Subprocesses
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	closeOnce sync.Once
+	closeErr  error
+
+	dropped atomic.Uint64
+
+	// only accessed by writeLoop, and by Close after writeLoop has exited
+	file     *os.File
+	writer   *bufio.Writer
+	fileSize int64
+}
+
+func NewRecorder(config RecorderConfig, logger commontypes.Logger) (*Recorder, error) {
+	if config.Directory == "" {
+		return nil, fmt.Errorf("Directory must be set")
+	}
+	if config.MaxFileSize <= 0 {
+		return nil, fmt.Errorf("MaxFileSize must be positive, but is %v", config.MaxFileSize)
+	}
+	if config.MaxFiles < 0 {
+		return nil, fmt.Errorf("MaxFiles must not be negative, but is %v", config.MaxFiles)
+	}
+	if config.BufferSize <= 0 {
+		return nil, fmt.Errorf("BufferSize must be positive, but is %v", config.BufferSize)
+	}
+	if err := os.MkdirAll(config.Directory, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	r := &Recorder{
+		config,
+		logger,
+		make(chan pendingRecord, config.BufferSize),
+		subprocesses.Subprocesses{},
+		ctx,
+		ctxCancel,
+		sync.Once{},
+		nil,
+		atomic.Uint64{},
+		nil,
+		nil,
+		0,
+	}
+	r.subs.Go(r.writeLoop)
+	return r, nil
+}
+
+// record never blocks. It drops the record if the buffer is full or the
+// recorder is closed.
+func (r *Recorder) record(rec pendingRecord) {
+	select {
+	case <-r.ctx.Done():
+		return
+	default:
+	}
+	select {
+	case r.chRecords <- rec:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Close flushes all buffered records and closes the current file.
+func (r *Recorder) Close() error {
+	r.closeOnce.Do(func() {
+		r.ctxCancel()
+		r.subs.Wait()
+		r.closeErr = r.closeFile()
+	})
+	return r.closeErr
+}
+
+func (r *Recorder) writeLoop() {
+	for {
+		select {
+		case rec := <-r.chRecords:
+			r.write(rec)
+		case <-r.ctx.Done():
+			// drain what was buffered before Close
+			for {
+				select {
+				case rec := <-r.chRecords:
+					r.write(rec)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Recorder) write(pending pendingRecord) {
+	if dropped := r.dropped.Swap(0); dropped != 0 {
+		r.logger.Warn("trafficrecording: Dropped records because the buffer was full", commontypes.LogFields{
+			"dropped": dropped,
+		})
+	}
+
+	rec := pending.Record
+	messageType, message, err := decode(rec.Protocol, pending.n, rec.Payload)
+	if err != nil {
+		rec.DecodeError = err.Error()
+	} else {
+		rec.MessageType = messageType
+		rec.Message = message
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		r.logger.Error("trafficrecording: Failed to marshal record", commontypes.LogFields{"error": err})
+		return
+	}
+	line = append(line, '\n')
+
+	if r.file != nil && r.fileSize+int64(len(line)) > r.config.MaxFileSize && r.fileSize > 0 {
+		if err := r.closeFile(); err != nil {
+			r.logger.Error("trafficrecording: Failed to close recording file", commontypes.LogFields{"error": err})
+		}
+	}
+	if r.file == nil {
+		if err := r.openFile(rec.Time); err != nil {
+			r.logger.Error("trafficrecording: Failed to open recording file", commontypes.LogFields{"error": err})
+			return
+		}
+	}
+
+	n, err := r.writer.Write(line)
+	r.fileSize += int64(n)
+	if err != nil {
+		r.logger.Error("trafficrecording: Failed to write record", commontypes.LogFields{"error": err})
+		return
+	}
+	// Flush when there is nothing else to write, so that recordings are
+	// complete when an incident is investigated on a running node.
+	if len(r.chRecords) == 0 {
+		if err := r.writer.Flush(); err != nil {
+			r.logger.Error("trafficrecording: Failed to flush recording file", commontypes.LogFields{"error": err})
+		}
+	}
+}
+
+func (r *Recorder) openFile(t time.Time) error {
+	name := filepath.Join(r.config.Directory, fileNamePrefix+t.UTC().Format(fileNameTimeFormat)+fileNameSuffix)
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.writer = bufio.NewWriter(f)
+	r.fileSize = info.Size()
+
+	if r.config.MaxFiles > 0 {
+		files, err := recordingFiles(r.config.Directory)
+		if err != nil {
+			r.logger.Warn("trafficrecording: Failed to list recording files", commontypes.LogFields{"error": err})
+			return nil
+		}
+		for len(files) > r.config.MaxFiles {
+			if err := os.Remove(files[0]); err != nil {
+				r.logger.Warn("trafficrecording: Failed to delete old recording file", commontypes.LogFields{
+					"file":  files[0],
+					"error": err,
+				})
+			}
+			files = files[1:]
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	flushErr := r.writer.Flush()
+	closeErr := r.file.Close()
+	r.file = nil
+	r.writer = nil
+	r.fileSize = 0
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+// recordingFiles returns the recording files in directory, oldest first.
+func recordingFiles(directory string) ([]string, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), fileNamePrefix) || !strings.HasSuffix(e.Name(), fileNameSuffix) {
+			continue
+		}
+		files = append(files, filepath.Join(directory, e.Name()))
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// ReadRecording reads all records from the recording files in directory, in
+// the order in which they were written.
+func ReadRecording(directory string) ([]Record, error) {
+	files, err := recordingFiles(directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recording files: %w", err)
+	}
+	var records []Record
+	for _, name := range files {
+		fileRecords, err := readRecordingFile(name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, nil
+}
+
+func readRecordingFile(name string) ([]Record, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording file: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	reader := bufio.NewReader(f)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read recording file %s: %w", name, err)
+		}
+		if line = bytes.TrimSpace(line); len(line) != 0 {
+			var rec Record
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return nil, fmt.Errorf("failed to parse line %v of recording file %s: %w", lineNumber, name, err)
+			}
+			records = append(records, rec)
+		}
+		if err != nil { // io.EOF
+			return records, nil
+		}
+	}
+}
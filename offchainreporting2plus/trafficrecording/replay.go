@@ -0,0 +1,241 @@
+package trafficrecording
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/offchainreporting2plus/types"
+	"github.com/smartcontractkit/libocr/subprocesses"
+)
+
+type ReplayConfig struct {
+	// Messages are delivered at the pace at which they were recorded,
+	// multiplied by Speed. Zero delivers them as fast as the oracle consumes
+	// them.
+	Speed float64
+}
+
+// NewReplayBinaryNetworkEndpointFactory returns a factory for an OCR2 or OCR3
+// oracle with the given peerID. Its endpoints deliver the messages that the
+// oracle with peerID received in the recording, for the config digest the
+// endpoint is created for. Messages sent by the oracle are dropped; wrap the
+// factory with WrapBinaryNetworkEndpointFactory to record them.
+func NewReplayBinaryNetworkEndpointFactory(records []Record, peerID string, config ReplayConfig, logger commontypes.Logger) types.BinaryNetworkEndpointFactory {
+	return &replayBinaryNetworkEndpointFactory{records, peerID, config, logger}
+}
+
+// NewReplayBinaryNetworkEndpoint2Factory is like
+// NewReplayBinaryNetworkEndpointFactory, but for OCR3.1 oracles. Requests in
+// the recording are delivered with a request handle whose responses are
+// dropped.
+func NewReplayBinaryNetworkEndpoint2Factory(records []Record, peerID string, config ReplayConfig, logger commontypes.Logger) types.BinaryNetworkEndpoint2Factory {
+	return &replayBinaryNetworkEndpoint2Factory{records, peerID, config, logger}
+}
+
+// receivedRecords returns the records of messages that self received for
+// configDigest.
+func receivedRecords(records []Record, configDigest types.ConfigDigest, peerIDs []string, peerID string) []Record {
+	self := slices.Index(peerIDs, peerID)
+	var received []Record
+	for _, rec := range records {
+		if rec.ConfigDigest == configDigest && rec.Direction == DirectionReceived && rec.Peer != nil && int(rec.Self) == self {
+			received = append(received, rec)
+		}
+	}
+	return received
+}
+
+// replayer delivers records to an endpoint's Receive channel.
+type replayer[M any] struct {
+	records   []Record
+	config    ReplayConfig
+	toMessage func(Record) M
+	logger    commontypes.Logger
+
+	chReceive chan M
+	chClose   chan struct{}
+	closeOnce sync.Once
+	subs      subprocesses.Subprocesses
+}
+
+func newReplayer[M any](records []Record, config ReplayConfig, toMessage func(Record) M, logger commontypes.Logger) *replayer[M] {
+	return &replayer[M]{
+		records,
+		config,
+		toMessage,
+		logger,
+		make(chan M),
+		make(chan struct{}),
+		sync.Once{},
+		subprocesses.Subprocesses{},
+	}
+}
+
+func (r *replayer[M]) start() {
+	r.subs.Go(r.run)
+}
+
+func (r *replayer[M]) run() {
+	r.logger.Info("trafficrecording: Starting replay", commontypes.LogFields{"messages": len(r.records)})
+	start := time.Now()
+	for i, rec := range r.records {
+		if r.config.Speed > 0 {
+			offset := rec.Time.Sub(r.records[0].Time)
+			wait := time.Until(start.Add(time.Duration(float64(offset) / r.config.Speed)))
+			select {
+			case <-time.After(wait):
+			case <-r.chClose:
+				return
+			}
+		}
+		select {
+		case r.chReceive <- r.toMessage(rec):
+		case <-r.chClose:
+			r.logger.Info("trafficrecording: Replay aborted", commontypes.LogFields{"delivered": i})
+			return
+		}
+	}
+	r.logger.Info("trafficrecording: Replay finished", commontypes.LogFields{"messages": len(r.records)})
+}
+
+func (r *replayer[M]) close() {
+	r.closeOnce.Do(func() {
+		close(r.chClose)
+	})
+	r.subs.Wait()
+}
+
+type replayBinaryNetworkEndpointFactory struct {
+	records []Record
+	peerID  string
+	config  ReplayConfig
+	logger  commontypes.Logger
+}
+
+var _ types.BinaryNetworkEndpointFactory = &replayBinaryNetworkEndpointFactory{}
+
+func (f *replayBinaryNetworkEndpointFactory) NewEndpoint(
+	cd types.ConfigDigest,
+	peerIDs []string,
+	v2bootstrappers []commontypes.BootstrapperLocator,
+	failureThreshold int,
+	limits types.BinaryNetworkEndpointLimits,
+) (commontypes.BinaryNetworkEndpoint, error) {
+	return &replayBinaryNetworkEndpoint{newReplayer(
+		receivedRecords(f.records, cd, peerIDs, f.peerID),
+		f.config,
+		func(rec Record) commontypes.BinaryMessageWithSender {
+			return commontypes.BinaryMessageWithSender{rec.Payload, *rec.Peer}
+		},
+		f.logger,
+	)}, nil
+}
+
+func (f *replayBinaryNetworkEndpointFactory) PeerID() string {
+	return f.peerID
+}
+
+type replayBinaryNetworkEndpoint struct {
+	replayer *replayer[commontypes.BinaryMessageWithSender]
+}
+
+var _ commontypes.BinaryNetworkEndpoint = &replayBinaryNetworkEndpoint{}
+
+// Messages sent by the replayed oracle are dropped
+
+func (e *replayBinaryNetworkEndpoint) SendTo(payload []byte, to commontypes.OracleID) {
+}
+
+func (e *replayBinaryNetworkEndpoint) Broadcast(payload []byte) {
+}
+
+func (e *replayBinaryNetworkEndpoint) Receive() <-chan commontypes.BinaryMessageWithSender {
+	return e.replayer.chReceive
+}
+
+func (e *replayBinaryNetworkEndpoint) Start() error {
+	e.replayer.start()
+	return nil
+}
+
+func (e *replayBinaryNetworkEndpoint) Close() error {
+	e.replayer.close()
+	return nil
+}
+
+type replayBinaryNetworkEndpoint2Factory struct {
+	records []Record
+	peerID  string
+	config  ReplayConfig
+	logger  commontypes.Logger
+}
+
+var _ types.BinaryNetworkEndpoint2Factory = &replayBinaryNetworkEndpoint2Factory{}
+
+func (f *replayBinaryNetworkEndpoint2Factory) NewEndpoint(
+	cd types.ConfigDigest,
+	peerIDs []string,
+	v2bootstrappers []commontypes.BootstrapperLocator,
+	defaultPriorityConfig types.BinaryNetworkEndpoint2Config,
+	lowPriorityConfig types.BinaryNetworkEndpoint2Config,
+) (types.BinaryNetworkEndpoint2, error) {
+	e := &replayBinaryNetworkEndpoint2{newReplayer(
+		receivedRecords(f.records, cd, peerIDs, f.peerID),
+		f.config,
+		func(rec Record) types.InboundBinaryMessageWithSender {
+			var msg types.InboundBinaryMessage
+			switch rec.Kind {
+			case KindRequest:
+				msg = types.InboundBinaryMessageRequest{replayRequestHandle{rec.Priority}, rec.Payload, rec.Priority}
+			case KindResponse:
+				msg = types.InboundBinaryMessageResponse{rec.Payload, rec.Priority}
+			default:
+				msg = types.InboundBinaryMessagePlain{rec.Payload, rec.Priority}
+			}
+			return types.InboundBinaryMessageWithSender{msg, *rec.Peer}
+		},
+		f.logger,
+	)}
+	// BinaryNetworkEndpoint2 has no Start, it delivers messages right away
+	e.replayer.start()
+	return e, nil
+}
+
+func (f *replayBinaryNetworkEndpoint2Factory) PeerID() string {
+	return f.peerID
+}
+
+type replayBinaryNetworkEndpoint2 struct {
+	replayer *replayer[types.InboundBinaryMessageWithSender]
+}
+
+var _ types.BinaryNetworkEndpoint2 = &replayBinaryNetworkEndpoint2{}
+
+// Messages sent by the replayed oracle are dropped
+
+func (e *replayBinaryNetworkEndpoint2) SendTo(msg types.OutboundBinaryMessage, to commontypes.OracleID) {
+}
+
+func (e *replayBinaryNetworkEndpoint2) Broadcast(msg types.OutboundBinaryMessage) {
+}
+
+func (e *replayBinaryNetworkEndpoint2) Receive() <-chan types.InboundBinaryMessageWithSender {
+	return e.replayer.chReceive
+}
+
+func (e *replayBinaryNetworkEndpoint2) Close() error {
+	e.replayer.close()
+	return nil
+}
+
+// replayRequestHandle is the request handle of replayed requests. Responses
+// must use the priority of their request, just like with ragep2p.
+type replayRequestHandle struct {
+	priority types.BinaryMessageOutboundPriority
+}
+
+func (h replayRequestHandle) MakeResponse(payload []byte) types.OutboundBinaryMessageResponse {
+	return types.MustMakeOutboundBinaryMessageResponse(h, payload, h.priority)
+}
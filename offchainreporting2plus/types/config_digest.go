@@ -41,6 +41,8 @@ const (
 
 	_ ConfigDigestPrefix = 0x0013 // reserved
 
+	ConfigDigestPrefixSignedConfig ConfigDigestPrefix = 0x0014 // Configs distributed off-chain, signed by a quorum of admin keys
+
 	ConfigDigestPrefixOCR1 ConfigDigestPrefix = 0xEEEE // we translate ocr1 config digest to ocr2 config digests in the networking layer
 	_                      ConfigDigestPrefix = 0xFFFF // reserved for future use
 
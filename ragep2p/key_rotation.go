@@ -0,0 +1,137 @@
+package ragep2p
+
+import (
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"github.com/smartcontractkit/libocr/ragep2p/internal/knock"
+	"github.com/smartcontractkit/libocr/ragep2p/types"
+)
+
+// previousIdentityValid returns whether we may still use our previous
+// identity.
+func (ho *Host) previousIdentityValid(now time.Time) bool {
+	return ho.config.PreviousKeyring != nil && now.Before(ho.config.PreviousKeyringValidUntil)
+}
+
+func (ho *Host) isOwnID(id types.PeerID) bool {
+	return id == ho.id || (ho.config.PreviousKeyring != nil && id == ho.previousID)
+}
+
+// dialIdentity returns the identity we authenticate as on outgoing
+// connections. While our key rotation is valid, we use our previous identity
+// since peers that don't know about the rotation only accept that one.
+func (ho *Host) dialIdentity() (types.PeerID, types.PeerKeyring, tls.Certificate) {
+	if ho.previousIdentityValid(time.Now()) {
+		return ho.previousID, ho.config.PreviousKeyring, ho.previousTLSCert
+	}
+	return ho.id, ho.keyring, ho.tlsCert
+}
+
+// verifyKnock verifies a knock addressed to our PeerID or, while our key
+// rotation is valid, to our previous PeerID. It returns the sender of the
+// knock and the certificate for the identity that was knocked on.
+func (ho *Host) verifyKnock(knck []byte) (*types.PeerID, tls.Certificate, error) {
+	other, err := knock.VerifyKnock(ho.id, knck)
+	if errors.Is(err, knock.ErrInvalidSignature) && ho.previousIdentityValid(time.Now()) {
+		other, err = knock.VerifyKnock(ho.previousID, knck)
+		if err != nil {
+			return nil, tls.Certificate{}, err
+		}
+		if ho.isOwnID(*other) {
+			return nil, tls.Certificate{}, knock.ErrFromSelfDial
+		}
+		return other, ho.previousTLSCert, nil
+	}
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+	if ho.isOwnID(*other) {
+		return nil, tls.Certificate{}, knock.ErrFromSelfDial
+	}
+	return other, ho.tlsCert, nil
+}
+
+func (ho *Host) findKeyRotation(id types.PeerID) (types.KeyRotation, bool) {
+	krd, ok := ho.discoverer.(KeyRotationDiscoverer)
+	if !ok {
+		return types.KeyRotation{}, false
+	}
+	return krd.FindKeyRotation(id)
+}
+
+// lockedCanonicalPeerID returns the PeerID under which we keep track of other.
+// This is the old PeerID if other is part of a valid key rotation, or if we
+// are still connected to other under its old PeerID. Caller should hold
+// peersMu.
+func (ho *Host) lockedCanonicalPeerID(other types.PeerID) types.PeerID {
+	if rotation, ok := ho.findKeyRotation(other); ok {
+		if p, ok := ho.peers[rotation.OldPeerID]; ok {
+			// Make sure that p keeps accepting the new PeerID after the
+			// rotation expires, even if we are connected right now and don't
+			// dial.
+			ho.updatePeerRotation(p)
+		}
+		return rotation.OldPeerID
+	}
+	if _, ok := ho.peers[other]; ok {
+		return other
+	}
+	if p, ok := ho.findPeerByIdentity(other); ok {
+		return p.other
+	}
+	return other
+}
+
+// findPeerByIdentity finds the peer that may authenticate as id. Caller should
+// hold peersMu.
+func (ho *Host) findPeerByIdentity(id types.PeerID) (*peer, bool) {
+	if p, ok := ho.peers[id]; ok {
+		return p, true
+	}
+	if rotation, ok := ho.findKeyRotation(id); ok && rotation.NewPeerID == id {
+		if p, ok := ho.peers[rotation.OldPeerID]; ok {
+			return p, true
+		}
+	}
+	for _, p := range ho.peers {
+		p.rotationMu.Lock()
+		rotated := p.rotation != nil && p.rotation.NewPeerID == id
+		p.rotationMu.Unlock()
+		if rotated {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// updatePeerRotation records the key rotation of p if the discoverer knows
+// about one, and returns the recorded rotation, if any.
+func (ho *Host) updatePeerRotation(p *peer) *types.KeyRotation {
+	rotation, ok := ho.findKeyRotation(p.other)
+	p.rotationMu.Lock()
+	defer p.rotationMu.Unlock()
+	if ok && rotation.OldPeerID == p.other {
+		p.rotation = &rotation
+	}
+	return p.rotation
+}
+
+// acceptsIdentity returns whether the peer may authenticate as id, given its
+// recorded key rotation.
+func (p *peer) acceptsIdentity(rotation *types.KeyRotation, id types.PeerID, now time.Time) bool {
+	if rotation == nil {
+		return id == p.other
+	}
+	return id == rotation.NewPeerID || (id == p.other && now.Before(rotation.ValidUntil))
+}
+
+// dialTarget returns the PeerID we expect the peer to authenticate as when we
+// dial it, given its recorded key rotation.
+func (p *peer) dialTarget(rotation *types.KeyRotation) types.PeerID {
+	if rotation == nil {
+		return p.other
+	}
+	return rotation.NewPeerID
+}
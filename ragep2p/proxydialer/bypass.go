@@ -0,0 +1,95 @@
+package proxydialer
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// BypassRules decide which destinations are dialed directly rather than
+// through the proxy.
+type BypassRules struct {
+	all     bool
+	nets    []*net.IPNet
+	domains []string // without leading "."
+	hosts   []string
+}
+
+// ParseBypassRules parses bypass rules. Each rule is one of
+//   - "*", matching every destination,
+//   - an IP address such as "10.1.2.3" or "::1",
+//   - a CIDR range such as "10.0.0.0/8" or "fd00::/8",
+//   - a domain prefixed with "*." or ".", such as "*.internal.example.com",
+//     matching all its subdomains but not the domain itself, or
+//   - a hostname such as "localhost", matching only itself.
+//
+// Hostnames and domains are matched case-insensitively against the host part
+// of the address being dialed. Hostnames are not resolved, so an IP rule does
+// not match a hostname that resolves to the IP.
+func ParseBypassRules(rules []string) (BypassRules, error) {
+	var result BypassRules
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		switch {
+		case rule == "":
+			return BypassRules{}, fmt.Errorf("empty bypass rule")
+		case rule == "*":
+			result.all = true
+		case strings.Contains(rule, "/"):
+			_, ipNet, err := net.ParseCIDR(rule)
+			if err != nil {
+				return BypassRules{}, fmt.Errorf("invalid bypass rule %q: %w", rule, err)
+			}
+			result.nets = append(result.nets, ipNet)
+		case net.ParseIP(rule) != nil:
+			ip := net.ParseIP(rule)
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			result.nets = append(result.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		case strings.HasPrefix(rule, "*."):
+			result.domains = append(result.domains, normalizeHost(strings.TrimPrefix(rule, "*.")))
+		case strings.HasPrefix(rule, "."):
+			result.domains = append(result.domains, normalizeHost(strings.TrimPrefix(rule, ".")))
+		case strings.Contains(rule, "*"):
+			return BypassRules{}, fmt.Errorf("invalid bypass rule %q: wildcards are only allowed as \"*\" or as a \"*.\" prefix", rule)
+		default:
+			result.hosts = append(result.hosts, normalizeHost(rule))
+		}
+	}
+	return result, nil
+}
+
+// Match returns true if host, the host part of an address to be dialed, should
+// bypass the proxy.
+func (r BypassRules) Match(host string) bool {
+	if r.all {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, ipNet := range r.nets {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	host = normalizeHost(host)
+	for _, h := range r.hosts {
+		if host == h {
+			return true
+		}
+	}
+	for _, domain := range r.domains {
+		if strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
@@ -0,0 +1,100 @@
+package proxydialer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/smartcontractkit/libocr/ragep2p/types"
+)
+
+type httpConnectDialer struct {
+	proxyAddress string
+	auth         *Auth
+	direct       net.Dialer
+}
+
+var _ types.Dialer = &httpConnectDialer{}
+
+func (d *httpConnectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("HTTP CONNECT proxies only support TCP, got network %q", network)
+	}
+
+	conn, err := d.direct.DialContext(ctx, "tcp", d.proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial HTTP proxy: %w", err)
+	}
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			_ = conn.Close()
+		}
+	}()
+
+	// Bound the CONNECT exchange by ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Host: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if d.auth != nil {
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.auth.Username + ":" + d.auth.Password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		stop()
+		return nil, fmt.Errorf("failed to write CONNECT request: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONNECT response: %w", err)
+	}
+	// Any 2xx status means the tunnel has been established. Responses to
+	// successful CONNECT requests have no body.
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("HTTP proxy refused CONNECT to %v: %v", address, resp.Status)
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	succeeded = true
+	if br.Buffered() > 0 {
+		// The proxy already sent data from the destination
+		return &bufferedConn{conn, br}, nil
+	}
+	return conn, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/smartcontractkit/libocr/ragep2p/internal/mtls"
 	"github.com/smartcontractkit/libocr/ragep2p/internal/ratelimit"
 	"github.com/smartcontractkit/libocr/ragep2p/internal/ratelimitedconn"
+	"github.com/smartcontractkit/libocr/ragep2p/relay"
 	"github.com/smartcontractkit/libocr/ragep2p/types"
 	"github.com/smartcontractkit/libocr/subprocesses"
 )
@@ -119,6 +120,11 @@ type peer struct {
 
 	connRateLimiter *connRateLimiter
 
+	// Once the other peer's key rotation is known, the peer remains reachable
+	// under its new PeerID, even after the rotation has expired.
+	rotationMu sync.Mutex
+	rotation   *types.KeyRotation
+
 	connLifeCycleMu sync.Mutex
 	connLifeCycle   peerConnLifeCycle
 
@@ -141,6 +147,23 @@ type HostConfig struct {
 	// DurationBetweenDials is the minimum duration between two dials. It is
 	// not the exact duration because of jitter.
 	DurationBetweenDials time.Duration
+	// Dialer is used to establish outgoing TCP connections, e.g. through a
+	// proxy. The knock and TLS handshake are performed end to end over the
+	// returned connection. If nil, addresses are dialed directly.
+	Dialer types.Dialer
+	// RelayListener, if not nil, accepts incoming connections established
+	// through relays, see package ragep2p/relay. The host closes it when
+	// closing. Relay addresses of other peers are always dialed through the
+	// respective relay, regardless of RelayListener.
+	RelayListener net.Listener
+	// PreviousKeyring, if not nil, is the keyring of the key that the host is
+	// rotating away from. Until PreviousKeyringValidUntil, the host accepts
+	// connections under both its previous and its current PeerID and
+	// authenticates as its previous PeerID when dialing, so that peers which
+	// don't know about the rotation can still reach it. The Discoverer is
+	// responsible for announcing the rotation.
+	PreviousKeyring           types.PeerKeyring
+	PreviousKeyringValidUntil time.Time
 }
 
 // A Host allows users to establish Streams with other peers identified by their
@@ -162,6 +185,10 @@ type Host struct {
 	id      types.PeerID
 	tlsCert tls.Certificate
 
+	// Derived from config.PreviousKeyring, zero if it is nil
+	previousID      types.PeerID
+	previousTLSCert tls.Certificate
+
 	// Host state
 	stateMu sync.Mutex
 	state   hostState
@@ -198,6 +225,19 @@ func NewHost(
 		return nil, fmt.Errorf("failed to create certificate from keyring for host: %w", err)
 	}
 
+	var previousID types.PeerID
+	var previousTLSCert tls.Certificate
+	if config.PreviousKeyring != nil {
+		previousID = types.PeerIDFromKeyring(config.PreviousKeyring)
+		if previousID == id {
+			return nil, fmt.Errorf("previous keyring must differ from keyring")
+		}
+		previousTLSCert, err = mtls.NewMinimalX509CertFromKeyring(config.PreviousKeyring)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create certificate from previous keyring for host: %w", err)
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Host{
 		config,
@@ -213,6 +253,9 @@ func NewHost(
 		id,
 		tlsCert,
 
+		previousID,
+		previousTLSCert,
+
 		sync.Mutex{},
 		hostStatePending,
 
@@ -255,6 +298,12 @@ func (ho *Host) Start() error {
 		})
 	}
 
+	if ho.config.RelayListener != nil {
+		ho.subprocesses.Go(func() {
+			ho.listenLoop(ho.config.RelayListener)
+		})
+	}
+
 	err := ho.discoverer.Start(Wrapped(ho), ho.keyring, ho.logger)
 	if err != nil {
 		return fmt.Errorf("failed to start discoverer: %w", err)
@@ -315,6 +364,9 @@ func (ho *Host) findOrCreatePeer(other types.PeerID) *peer {
 
 			connRateLimiter,
 
+			sync.Mutex{},
+			nil,
+
 			sync.Mutex{},
 			peerConnLifeCycle{
 				func() {},
@@ -612,7 +664,9 @@ func (ho *Host) dialLoop() {
 					return
 				}
 
-				addresses, err := ho.discoverer.FindPeer(p.other)
+				target := p.dialTarget(ho.updatePeerRotation(p))
+
+				addresses, err := ho.discoverer.FindPeer(target)
 				if err != nil {
 					p.logger.Warn("Discoverer error", commontypes.LogFields{"error": err})
 					return
@@ -632,10 +686,7 @@ func (ho *Host) dialLoop() {
 
 				logger := p.logger.MakeChild(commontypes.LogFields{"direction": "out", "remoteAddr": address})
 
-				dialer := net.Dialer{
-					Timeout: ho.config.DurationBetweenDials,
-				}
-				conn, err := dialer.DialContext(ho.ctx, "tcp", address)
+				conn, err := ho.dial(address, target)
 				if err != nil {
 					logger.Warn("Dial error", commontypes.LogFields{"error": err})
 					return
@@ -643,7 +694,7 @@ func (ho *Host) dialLoop() {
 
 				logger.Trace("Dial succeeded", nil)
 				ho.subprocesses.Go(func() {
-					ho.handleOutgoingConnection(conn, p.other, logger)
+					ho.handleOutgoingConnection(conn, p.other, target, logger)
 				})
 			})
 
@@ -660,6 +711,23 @@ func (ho *Host) dialLoop() {
 	}
 }
 
+func (ho *Host) dial(address string, other types.PeerID) (net.Conn, error) {
+	if relay.IsAddress(types.Address(address)) {
+		ctx, cancel := context.WithTimeout(ho.ctx, ho.config.DurationBetweenDials)
+		defer cancel()
+		return relay.Dial(ctx, ho.config.Dialer, types.Address(address), other)
+	}
+	if ho.config.Dialer == nil {
+		dialer := net.Dialer{
+			Timeout: ho.config.DurationBetweenDials,
+		}
+		return dialer.DialContext(ho.ctx, "tcp", address)
+	}
+	ctx, cancel := context.WithTimeout(ho.ctx, ho.config.DurationBetweenDials)
+	defer cancel()
+	return ho.config.Dialer.DialContext(ctx, "tcp", address)
+}
+
 func (ho *Host) listenLoop(ln net.Listener) {
 	ho.subprocesses.Go(func() {
 		<-ho.ctx.Done()
@@ -681,7 +749,9 @@ func (ho *Host) listenLoop(ln net.Listener) {
 	}
 }
 
-func (ho *Host) handleOutgoingConnection(conn net.Conn, other types.PeerID, logger loghelper.LoggerWithContext) {
+// handleOutgoingConnection handles a connection to the peer identified by
+// other, which we expect to authenticate as target.
+func (ho *Host) handleOutgoingConnection(conn net.Conn, other types.PeerID, target types.PeerID, logger loghelper.LoggerWithContext) {
 	shouldClose := true
 	defer func() {
 		if shouldClose {
@@ -691,7 +761,8 @@ func (ho *Host) handleOutgoingConnection(conn net.Conn, other types.PeerID, logg
 		}
 	}()
 
-	knck, err := knock.BuildKnock(other, ho.id, ho.keyring)
+	selfID, selfKeyring, selfTLSCert := ho.dialIdentity()
+	knck, err := knock.BuildKnock(target, selfID, selfKeyring)
 	if err != nil {
 		logger.Warn("Error while building knock", commontypes.LogFields{"error": err})
 		return
@@ -719,11 +790,11 @@ func (ho *Host) handleOutgoingConnection(conn net.Conn, other types.PeerID, logg
 	rlConn := ratelimitedconn.NewRateLimitedConn(conn, peer.connRateLimiter, logger, peer.metrics.rawconnReadBytesTotal, peer.metrics.rawconnWrittenBytesTotal)
 
 	tlsConfig := newTLSConfig(
-		ho.tlsCert,
-		mtls.VerifyCertMatchesPubKey(other),
+		selfTLSCert,
+		mtls.VerifyCertMatchesPubKey(target),
 	)
 	tlsConn := tls.Client(rlConn, tlsConfig)
-	ho.handleConnection(false, rlConn, tlsConn, peer, logger)
+	ho.handleConnection(false, rlConn, tlsConn, peer, target, logger)
 }
 
 func (ho *Host) handleIncomingConnection(conn net.Conn) {
@@ -753,7 +824,7 @@ func (ho *Host) handleIncomingConnection(conn net.Conn) {
 		return
 	}
 
-	other, err := knock.VerifyKnock(ho.id, knck)
+	other, selfTLSCert, err := ho.verifyKnock(knck)
 	if err != nil {
 		if errors.Is(err, knock.ErrFromSelfDial) {
 			logger.Info("Self-dial knock, dropping connection. Someone has likely misconfigured their announce addresses.", nil)
@@ -764,27 +835,35 @@ func (ho *Host) handleIncomingConnection(conn net.Conn) {
 	}
 
 	ho.peersMu.Lock()
-	peer, ok := ho.peers[*other]
+	peer, ok := ho.findPeerByIdentity(*other)
 	ho.peersMu.Unlock()
 	if !ok {
 		logger.Warn("Received incoming connection from an unknown peer, closing", remotePeerIDField(*other))
 		return
 	}
 	logger = peer.logger.MakeChild(remoteAddrLogFields) // introduce remotePeerID in our logs since we now know it
+	if !peer.acceptsIdentity(ho.updatePeerRotation(peer), *other, time.Now()) {
+		logger.Warn("Received incoming connection from a peer that may no longer use its key, closing", commontypes.LogFields{
+			"authenticatedPeerID": *other,
+		})
+		return
+	}
 	rl := peer.connRateLimiter
 	rlConn := ratelimitedconn.NewRateLimitedConn(conn, rl, logger, peer.metrics.rawconnReadBytesTotal, peer.metrics.rawconnWrittenBytesTotal)
 
 	shouldClose = false
 
 	tlsConfig := newTLSConfig(
-		ho.tlsCert,
+		selfTLSCert,
 		mtls.VerifyCertMatchesPubKey(*other),
 	)
 	tlsConn := tls.Server(rlConn, tlsConfig)
-	ho.handleConnection(true, rlConn, tlsConn, peer, logger)
+	ho.handleConnection(true, rlConn, tlsConn, peer, *other, logger)
 }
 
-func (ho *Host) handleConnection(incoming bool, rlConn *ratelimitedconn.RateLimitedConn, tlsConn *tls.Conn, peer *peer, logger loghelper.LoggerWithContext) {
+// handleConnection takes over a connection with peer, which must authenticate
+// as identity. identity differs from peer.other during key rotations.
+func (ho *Host) handleConnection(incoming bool, rlConn *ratelimitedconn.RateLimitedConn, tlsConn *tls.Conn, peer *peer, identity types.PeerID, logger loghelper.LoggerWithContext) {
 	shouldClose := true
 	defer func() {
 		if shouldClose {
@@ -816,9 +895,9 @@ func (ho *Host) handleConnection(incoming bool, rlConn *ratelimitedconn.RateLimi
 		logger.Warn("Closing connection, error getting public key", commontypes.LogFields{"error": err})
 		return
 	}
-	if peer.other != types.PeerIDFromPeerPublicKey(pubKey) {
+	if identity != types.PeerIDFromPeerPublicKey(pubKey) {
 		logger.Warn("TLS handshake PeerID mismatch", commontypes.LogFields{
-			"expected": peer.other,
+			"expected": identity,
 			"actual":   types.PeerIDFromPeerPublicKey(pubKey),
 		})
 		return
@@ -890,7 +969,23 @@ func (ho *Host) NewStream(
 	messagesLimit TokenBucketParams, // rate limit for incoming messages
 	bytesLimit TokenBucketParams, // rate limit for incoming messages
 ) (*Stream, error) {
-	if other == ho.id {
+	return ho.newStream(ho.id, other, streamName, outgoingBufferSize, incomingBufferSize, maxMessageLength, messagesLimit, bytesLimit)
+}
+
+// newStream creates a stream in which we identify ourselves as self. self is
+// our previous PeerID for streams created through the host returned by
+// PreviousIdentity.
+func (ho *Host) newStream(
+	self types.PeerID,
+	other types.PeerID,
+	streamName string,
+	outgoingBufferSize int,
+	incomingBufferSize int,
+	maxMessageLength int,
+	messagesLimit TokenBucketParams,
+	bytesLimit TokenBucketParams,
+) (*Stream, error) {
+	if ho.isOwnID(other) {
 		return nil, fmt.Errorf("stream with self is forbidden")
 	}
 
@@ -907,10 +1002,13 @@ func (ho *Host) NewStream(
 
 	ho.peersMu.Lock()
 	defer ho.peersMu.Unlock()
+	// The stream ID is derived from the PeerIDs as given, but during key
+	// rotations the stream shares its peer (and connection) with the streams
+	// created for other's previous or next PeerID.
+	sid := getStreamID(self, other, streamName)
+	other = ho.lockedCanonicalPeerID(other)
 	p := ho.findOrCreatePeer(other)
 
-	sid := getStreamID(ho.id, other, streamName)
-
 	var response peerStreamOpenResponse
 	select {
 	// it's important that we hold peersMu here. otherwise the peer could have
@@ -932,9 +1030,8 @@ func (ho *Host) NewStream(
 	}
 
 	ctx, cancel := context.WithCancel(ho.ctx)
-	streamID := getStreamID(ho.id, other, streamName)
 	streamLogger := loghelper.MakeRootLoggerWithContext(p.logger).MakeChild(commontypes.LogFields{
-		"streamID":   streamID,
+		"streamID":   sid,
 		"streamName": streamName,
 	})
 	s := Stream{
@@ -943,7 +1040,7 @@ func (ho *Host) NewStream(
 
 		streamName,
 		other,
-		streamID,
+		sid,
 
 		outgoingBufferSize,
 		maxMessageLength,
@@ -1532,3 +1629,13 @@ type Discoverer interface {
 	Close() error
 	FindPeer(peer types.PeerID) ([]types.Address, error)
 }
+
+// KeyRotationDiscoverer is optionally implemented by a Discoverer that learns
+// about key rotations of peers. While a peer's key rotation is valid, the host
+// accepts the peer under its old and new PeerID and identifies it by its old
+// PeerID.
+type KeyRotationDiscoverer interface {
+	// FindKeyRotation returns the valid key rotation that peer is the old or
+	// new PeerID of, if any. Must not block.
+	FindKeyRotation(peer types.PeerID) (types.KeyRotation, bool)
+}
@@ -0,0 +1,110 @@
+package ragep2pnew
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/compression"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/internaltypes"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/stream2types"
+)
+
+type Compression = stream2types.Compression
+
+const (
+	CompressionNone   = stream2types.CompressionNone
+	CompressionSnappy = stream2types.CompressionSnappy
+	CompressionZstd   = stream2types.CompressionZstd
+)
+
+// streamCompressions keeps track of the compression used for messages sent on
+// the streams with a peer. Local settings are maintained by peerLoop. The remote
+// peer's settings are taken from the OpenStream frames it sends on the current
+// connection. Incoming messages don't depend on this state, their frame headers
+// say whether they carry a compression prefix.
+type streamCompressions struct {
+	metrics *peerMetrics
+
+	mutex  sync.Mutex
+	local  map[internaltypes.StreamID]Compression
+	remote map[internaltypes.StreamID]compression.Mask
+}
+
+func newStreamCompressions(metrics *peerMetrics) *streamCompressions {
+	return &streamCompressions{
+		metrics,
+
+		sync.Mutex{},
+		map[internaltypes.StreamID]Compression{},
+		map[internaltypes.StreamID]compression.Mask{},
+	}
+}
+
+func (sc *streamCompressions) setLocal(sid internaltypes.StreamID, c Compression) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	if c == CompressionNone {
+		delete(sc.local, sid)
+	} else {
+		sc.local[sid] = c
+	}
+}
+
+// Must be called whenever a new connection is established, before frames are
+// read from or written to it.
+func (sc *streamCompressions) resetRemote() {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	clear(sc.remote)
+}
+
+func (sc *streamCompressions) setRemote(sid internaltypes.StreamID, accepted compression.Mask) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	if accepted == 0 {
+		delete(sc.remote, sid)
+	} else {
+		sc.remote[sid] = accepted
+	}
+}
+
+// framing returns whether outgoing message payloads on the stream carry a
+// compression prefix and which algorithm to compress them with.
+func (sc *streamCompressions) framing(sid internaltypes.StreamID) (bool, Compression) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	local, localOk := sc.local[sid]
+	accepted, remoteOk := sc.remote[sid]
+	if !localOk || !remoteOk {
+		return false, CompressionNone
+	}
+	if !accepted.Has(local) {
+		return true, CompressionNone
+	}
+	return true, local
+}
+
+// encodePayload prepares the payload of an outgoing message for the wire and
+// returns whether it carries a compression prefix.
+func (sc *streamCompressions) encodePayload(sid internaltypes.StreamID, payload []byte) ([]byte, bool) {
+	framed, c := sc.framing(sid)
+	if !framed {
+		return payload, false
+	}
+	encoded := compression.Compress(c, payload)
+	if c := Compression(encoded[0]); c != CompressionNone {
+		sc.metrics.ObserveCompressionRatio("sent", c, len(payload), len(encoded)-compression.MaxPrefixSize)
+	}
+	return encoded, true
+}
+
+// decompress decompresses the payload of an incoming message and records its
+// compression ratio.
+func (sc *streamCompressions) decompress(c Compression, compressed []byte, size int) ([]byte, error) {
+	payload, err := compression.Decompress(c, compressed, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	sc.metrics.ObserveCompressionRatio("received", c, size, len(compressed))
+	return payload, nil
+}
@@ -34,6 +34,21 @@
 // sequentially dialing all of them until a connection is successfully
 // established.
 //
+// # Transports
+//
+// By default, connections use TLS over TCP and all Streams with a peer are
+// multiplexed over a single TCP connection. If HostConfig.EnableQUIC is set,
+// the Host additionally listens for QUIC connections on UDP, using the same
+// addresses and ports as for TCP. Peers announce whether they accept QUIC
+// through discovery, and the Host tries QUIC first when dialing peers that do
+// (see QUICDiscoverer). Over QUIC, each Stream is carried by its own QUIC
+// stream, so that a lost packet only delays the Stream it belongs to. If a
+// peer can't be reached via QUIC, e.g. because a firewall blocks UDP, the Host
+// falls back to TCP for that peer's address and only retries QUIC after a
+// while. The knock is sent as the TLS ALPN protocol of the QUIC handshake. The
+// certificate is only presented after the knock has been verified, and
+// connections with an invalid knock are dropped without any response.
+//
 // # Thread Safety
 //
 // All public functions on Host and Stream are thread-safe.
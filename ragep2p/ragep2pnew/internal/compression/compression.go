@@ -0,0 +1,169 @@
+package compression
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/stream2types"
+	"github.com/smartcontractkit/libocr/ragep2p/types"
+)
+
+// Compression is negotiated per stream and connection. A peer that enables
+// compression for a stream appends a NUL byte and the Mask of algorithms it
+// accepts to the stream name in its OpenStream frame. Peers that don't support
+// compression treat this as a stream name mismatch, which is only logged.
+//
+// Once a peer has received the other peer's Mask for a stream on a connection
+// and has compression enabled itself, it prefixes the payloads of the messages
+// it sends on the stream with the algorithm byte and marks their frame headers
+// accordingly. Receivers rely only on the frame header, so a message is decoded
+// correctly no matter in which order it arrives relative to OpenStream frames.
+// Unless the algorithm is CompressionNone, the algorithm byte is followed by
+// the decompressed size as a big-endian uint32 and the compressed data. The
+// decompressed size lets the receiver enforce its limits before reading or
+// decompressing anything.
+
+// MaxPrefixSize is the maximum number of bytes that compression adds to a
+// message payload.
+const MaxPrefixSize = 1 + 4
+
+// OpenStreamPayloadOverhead is the number of bytes that compression adds to
+// the payload of OpenStream frames.
+const OpenStreamPayloadOverhead = 2
+
+// Don't bother compressing payloads smaller than this
+const minCompressSize = 256
+
+// Mask is a set of compression algorithms.
+type Mask byte
+
+// SupportedMask contains all algorithms that we can decompress.
+const SupportedMask = Mask(1<<stream2types.CompressionNone | 1<<stream2types.CompressionSnappy | 1<<stream2types.CompressionZstd)
+
+func (m Mask) Has(c stream2types.Compression) bool {
+	return c < 8 && m&(1<<c) != 0
+}
+
+// ValidateStreamName checks that streamName can be used for a stream with
+// compression enabled.
+func ValidateStreamName(streamName string) error {
+	if types.MaxStreamNameLength < len(streamName)+OpenStreamPayloadOverhead {
+		return fmt.Errorf("streamName '%v' is longer than maximum length %v for streams with compression", streamName, types.MaxStreamNameLength-OpenStreamPayloadOverhead)
+	}
+	if strings.ContainsRune(streamName, 0) {
+		return fmt.Errorf("streamName '%v' of stream with compression must not contain NUL bytes", streamName)
+	}
+	return nil
+}
+
+// EncodeOpenStreamPayload returns the payload of the OpenStream frame for a
+// stream with the given compression.
+func EncodeOpenStreamPayload(streamName string, c stream2types.Compression) []byte {
+	if c == stream2types.CompressionNone {
+		return []byte(streamName)
+	}
+	payload := make([]byte, 0, len(streamName)+OpenStreamPayloadOverhead)
+	payload = append(payload, streamName...)
+	return append(payload, 0, byte(SupportedMask))
+}
+
+// DecodeOpenStreamPayload extracts the stream name and the accepted
+// algorithms from the payload of an OpenStream frame. The returned Mask is 0 if
+// the other peer hasn't enabled compression for the stream.
+func DecodeOpenStreamPayload(payload []byte) (string, Mask) {
+	if len(payload) >= OpenStreamPayloadOverhead && payload[len(payload)-OpenStreamPayloadOverhead] == 0 {
+		return string(payload[:len(payload)-OpenStreamPayloadOverhead]), Mask(payload[len(payload)-1])
+	}
+	return string(payload), 0
+}
+
+var zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	if err != nil {
+		panic(fmt.Sprintf("failed to create zstd encoder: %v", err))
+	}
+	return encoder
+})
+
+var zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+	decoder, err := zstd.NewReader(nil,
+		zstd.WithDecoderConcurrency(0),
+		// Never decode more than the announced decompressed size
+		zstd.WithDecodeAllCapLimit(true),
+		zstd.WithDecoderMaxMemory(types.MaxMessageLength),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create zstd decoder: %v", err))
+	}
+	return decoder
+})
+
+// Compress returns payload prefixed as described above. payload is compressed
+// with c unless it is too small or doesn't get smaller.
+func Compress(c stream2types.Compression, payload []byte) []byte {
+	if c != stream2types.CompressionNone && len(payload) >= minCompressSize {
+		prefix := make([]byte, MaxPrefixSize, MaxPrefixSize+len(payload))
+		prefix[0] = byte(c)
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(payload)))
+
+		var compressed []byte
+		switch c {
+		case stream2types.CompressionSnappy:
+			compressed = append(prefix, snappy.Encode(nil, payload)...)
+		case stream2types.CompressionZstd:
+			compressed = zstdEncoder().EncodeAll(payload, prefix)
+		}
+		if compressed != nil && len(compressed) < 1+len(payload) {
+			return compressed
+		}
+	}
+
+	uncompressed := make([]byte, 0, 1+len(payload))
+	uncompressed = append(uncompressed, byte(stream2types.CompressionNone))
+	return append(uncompressed, payload...)
+}
+
+// DecodeSizePrefix decodes the decompressed size that follows the algorithm
+// byte for algorithms other than CompressionNone.
+func DecodeSizePrefix(encoded []byte) (int, error) {
+	size := binary.BigEndian.Uint32(encoded)
+	if size > types.MaxMessageLength {
+		return 0, fmt.Errorf("decompressed size %v exceeds maximum message length %v", size, types.MaxMessageLength)
+	}
+	return int(size), nil
+}
+
+// Decompress decompresses data compressed with c, returning an error unless
+// it decompresses to exactly size bytes.
+func Decompress(c stream2types.Compression, compressed []byte, size int) ([]byte, error) {
+	switch c {
+	case stream2types.CompressionSnappy:
+		decodedLen, err := snappy.DecodedLen(compressed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snappy data: %w", err)
+		}
+		if decodedLen != size {
+			return nil, fmt.Errorf("snappy data decompresses to %v bytes, expected %v", decodedLen, size)
+		}
+		decompressed, err := snappy.Decode(make([]byte, size), compressed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snappy data: %w", err)
+		}
+		return decompressed, nil
+	case stream2types.CompressionZstd:
+		decompressed, err := zstdDecoder().DecodeAll(compressed, make([]byte, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd data: %w", err)
+		}
+		if len(decompressed) != size {
+			return nil, fmt.Errorf("zstd data decompresses to %v bytes, expected %v", len(decompressed), size)
+		}
+		return decompressed, nil
+	default:
+		return nil, fmt.Errorf("unknown compression %v", c)
+	}
+}
@@ -33,6 +33,33 @@ const (
 	FrameTypeMessageResponse
 )
 
+// frameTypeFlagCompressionPrefix is set in the type byte of message frames
+// whose payload starts with a compression prefix. Peers only set it after the
+// other peer has announced compression support for the stream, so peers
+// without compression support never see it.
+const frameTypeFlagCompressionPrefix = 0x80
+
+func isMessageFrameType(t FrameType) bool {
+	return t == FrameTypeMessagePlain || t == FrameTypeMessageRequest || t == FrameTypeMessageResponse
+}
+
+// splitFrameType separates the compression prefix flag from the type byte of
+// message frames. The flag is not valid for other frame types.
+func splitFrameType(b byte) (FrameType, bool) {
+	t := FrameType(b &^ frameTypeFlagCompressionPrefix)
+	if b&frameTypeFlagCompressionPrefix != 0 && isMessageFrameType(t) {
+		return t, true
+	}
+	return FrameType(b), false
+}
+
+func messageFrameType(t FrameType, compressionPrefix bool) FrameType {
+	if compressionPrefix {
+		return t | frameTypeFlagCompressionPrefix
+	}
+	return t
+}
+
 //go-sumtype:decl FrameHeader
 
 type FrameHeader interface {
@@ -72,6 +99,9 @@ var frameHeaderSizes = map[FrameType]int{
 //     StreamID      streamID
 //     PayloadSize   uint32
 // }
+//
+// Message frames may additionally carry frameTypeFlagCompressionPrefix in the
+// type byte.
 
 type OpenStreamFrameHeader struct {
 	StreamID    internaltypes.StreamID
@@ -83,20 +113,23 @@ type CloseStreamFrameHeader struct {
 }
 
 type MessagePlainFrameHeader struct {
-	StreamID    internaltypes.StreamID
-	PayloadSize int
+	StreamID          internaltypes.StreamID
+	PayloadSize       int
+	CompressionPrefix bool
 }
 
 type MessageRequestFrameHeader struct {
-	StreamID    internaltypes.StreamID
-	PayloadSize int
-	RequestID   internaltypes.RequestID
+	StreamID          internaltypes.StreamID
+	PayloadSize       int
+	RequestID         internaltypes.RequestID
+	CompressionPrefix bool
 }
 
 type MessageResponseFrameHeader struct {
-	StreamID    internaltypes.StreamID
-	PayloadSize int
-	RequestID   internaltypes.RequestID
+	StreamID          internaltypes.StreamID
+	PayloadSize       int
+	RequestID         internaltypes.RequestID
+	CompressionPrefix bool
 }
 
 func (OpenStreamFrameHeader) isFrameHeader()      {}
@@ -167,7 +200,7 @@ func decodeBaseFrameHeader(encoded []byte, expectedType FrameType, expectedSize
 	if len(encoded) != expectedSize {
 		return internaltypes.StreamID{}, 0, errFrameHeaderSizeInvalid
 	}
-	if FrameType(encoded[0]) != expectedType {
+	if frameType, _ := splitFrameType(encoded[0]); frameType != expectedType {
 		return internaltypes.StreamID{}, 0, errInvalidFrameType
 	}
 
@@ -190,17 +223,17 @@ func (h CloseStreamFrameHeader) Encode() []byte {
 }
 
 func (h MessagePlainFrameHeader) Encode() []byte {
-	return encodeBaseFrameHeader(FrameTypeMessagePlain, h.StreamID, h.PayloadSize, 0)
+	return encodeBaseFrameHeader(messageFrameType(FrameTypeMessagePlain, h.CompressionPrefix), h.StreamID, h.PayloadSize, 0)
 }
 
 func (h MessageRequestFrameHeader) Encode() []byte {
-	buffer := encodeBaseFrameHeader(FrameTypeMessageRequest, h.StreamID, h.PayloadSize, requestIDSize)
+	buffer := encodeBaseFrameHeader(messageFrameType(FrameTypeMessageRequest, h.CompressionPrefix), h.StreamID, h.PayloadSize, requestIDSize)
 	buffer = append(buffer, h.RequestID[:]...)
 	return buffer
 }
 
 func (h MessageResponseFrameHeader) Encode() []byte {
-	buffer := encodeBaseFrameHeader(FrameTypeMessageResponse, h.StreamID, h.PayloadSize, requestIDSize)
+	buffer := encodeBaseFrameHeader(messageFrameType(FrameTypeMessageResponse, h.CompressionPrefix), h.StreamID, h.PayloadSize, requestIDSize)
 	buffer = append(buffer, h.RequestID[:]...)
 	return buffer
 }
@@ -210,7 +243,8 @@ func decodeFrameHeader(encoded []byte) (FrameHeader, error) {
 		return nil, errFrameHeaderSizeInvalid
 	}
 
-	switch FrameType(encoded[0]) {
+	frameType, _ := splitFrameType(encoded[0])
+	switch frameType {
 	case FrameTypeOpenStream:
 		return decodeOpenStreamFrameHeader(encoded)
 	case FrameTypeCloseStream:
@@ -255,7 +289,8 @@ func decodeMessagePlainFrameHeader(encoded []byte) (MessagePlainFrameHeader, err
 	if err != nil {
 		return MessagePlainFrameHeader{}, err
 	}
-	return MessagePlainFrameHeader{streamID, payloadSize}, err
+	_, compressionPrefix := splitFrameType(encoded[0])
+	return MessagePlainFrameHeader{streamID, payloadSize, compressionPrefix}, err
 }
 
 func decodeMessageRequestFrameHeader(encoded []byte) (MessageRequestFrameHeader, error) {
@@ -266,7 +301,8 @@ func decodeMessageRequestFrameHeader(encoded []byte) (MessageRequestFrameHeader,
 	}
 
 	copy(requestID[:], encoded[baseFrameHeaderSize:])
-	return MessageRequestFrameHeader{streamID, payloadSize, requestID}, nil
+	_, compressionPrefix := splitFrameType(encoded[0])
+	return MessageRequestFrameHeader{streamID, payloadSize, requestID, compressionPrefix}, nil
 }
 
 func decodeMessageResponseFrameHeader(encoded []byte) (MessageResponseFrameHeader, error) {
@@ -277,7 +313,8 @@ func decodeMessageResponseFrameHeader(encoded []byte) (MessageResponseFrameHeade
 	}
 
 	copy(requestID[:], encoded[baseFrameHeaderSize:])
-	return MessageResponseFrameHeader{streamID, payloadSize, requestID}, nil
+	_, compressionPrefix := splitFrameType(encoded[0])
+	return MessageResponseFrameHeader{streamID, payloadSize, requestID, compressionPrefix}, nil
 }
 
 var ErrReadFrameHeaderReadFailed = fmt.Errorf("failed to read frame header")
@@ -300,7 +337,7 @@ func (r *FrameHeaderReader) ReadFrameHeader() (header FrameHeader, err error) {
 	}
 
 	// Get the length of the frame header for the given type. Abort if the type is invalid.
-	frameType := FrameType(r.buf[0])
+	frameType, _ := splitFrameType(r.buf[0])
 	headerSize, ok := frameHeaderSizes[frameType]
 	if !ok {
 		return nil, fmt.Errorf("invalid frame type: %d", frameType)
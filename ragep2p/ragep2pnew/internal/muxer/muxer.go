@@ -3,58 +3,105 @@ package muxer
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/internal/loghelper"
-	"github.com/smartcontractkit/libocr/internal/randmap"
 	"github.com/smartcontractkit/libocr/internal/ringbuffer"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/frame"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/internaltypes"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/stream2types"
 )
 
-const invertPrioritiesEvery = 8
+// Messages are scheduled using start-time fair queueing on two levels: the
+// muxer first picks a priority class, sharing bandwidth between classes with
+// pending messages in proportion to their weights, and then picks a stream of
+// that class, sharing the class's bandwidth between its streams in proportion
+// to their bandwidth shares. Bandwidth is measured in bytes, so a stream
+// sending large messages can't crowd out a stream sending small ones by
+// sending fewer messages.
+//
+// Each class and stream carries a virtual time tag. We always pick the class
+// (resp. stream) with the smallest tag and advance its tag by the size of the
+// popped message divided by its weight (resp. share). When a class (resp.
+// stream) becomes pending, its tag is raised to the current virtual time so
+// that it can't accumulate credit while idle.
+
+type queuedMessage struct {
+	message  stream2types.OutboundBinaryMessage
+	enqueued time.Time
+}
 
 type streamRecord struct {
 	streamName    string
 	priority      stream2types.StreamPriority
+	share         uint32
 	enabled       bool
-	messageBuffer *ringbuffer.RingBuffer[stream2types.OutboundBinaryMessage]
+	busy          bool
+	pending       bool
+	tag           float64
+	messageBuffer *ringbuffer.RingBuffer[queuedMessage]
+}
+
+type priorityClass struct {
+	weight      uint32
+	tag         float64
+	virtualTime float64
+	pending     map[internaltypes.StreamID]*streamRecord
 }
 
 type Muxer struct {
-	logger loghelper.LoggerWithContext
+	logger               loghelper.LoggerWithContext
+	observeQueueDuration func(stream2types.StreamPriority, time.Duration)
 
 	chSignal chan struct{}
 
-	mutex                                    sync.Mutex
-	streamRecords                            map[internaltypes.StreamID]*streamRecord
-	defaultPriorityStreamsWithPendingMessage *randmap.Map[internaltypes.StreamID, *ringbuffer.RingBuffer[stream2types.OutboundBinaryMessage]]
-	lowPriorityStreamsWithPendingMessage     *randmap.Map[internaltypes.StreamID, *ringbuffer.RingBuffer[stream2types.OutboundBinaryMessage]]
-	popCount                                 uint
+	mutex         sync.Mutex
+	streamRecords map[internaltypes.StreamID]*streamRecord
+	classes       map[stream2types.StreamPriority]*priorityClass
+	virtualTime   float64
 }
 
-func NewMuxer(logger loghelper.LoggerWithContext) *Muxer {
+// priorityWeights must contain a positive weight for every priority that
+// streams are added with. observeQueueDuration is called for every popped
+// message with the time the message spent in the muxer.
+func NewMuxer(
+	logger loghelper.LoggerWithContext,
+	priorityWeights stream2types.PriorityWeights,
+	observeQueueDuration func(stream2types.StreamPriority, time.Duration),
+) *Muxer {
+	classes := make(map[stream2types.StreamPriority]*priorityClass, len(priorityWeights))
+	for priority, weight := range priorityWeights {
+		classes[priority] = &priorityClass{
+			weight,
+			0,
+			0,
+			map[internaltypes.StreamID]*streamRecord{},
+		}
+	}
 	return &Muxer{
 		logger,
+		observeQueueDuration,
 
 		make(chan struct{}, 1),
 		sync.Mutex{},
 		map[internaltypes.StreamID]*streamRecord{},
-		randmap.NewMap[internaltypes.StreamID, *ringbuffer.RingBuffer[stream2types.OutboundBinaryMessage]](),
-		randmap.NewMap[internaltypes.StreamID, *ringbuffer.RingBuffer[stream2types.OutboundBinaryMessage]](),
-
+		classes,
 		0,
 	}
 }
 
-// Adds a stream to the Muxer. The stream is initially disabled.
+// Adds a stream to the Muxer. The stream is initially disabled. A bandwidth
+// share of 0 is treated as 1.
 //
-// panics if maxOutgoingBufferedMessages is 0 or less
+// panics if maxOutgoingBufferedMessages is 0 or less or if priority has no
+// weight
 func (mux *Muxer) AddStream(
 	sid internaltypes.StreamID,
 	streamName string,
 	priority stream2types.StreamPriority,
 	maxOutgoingBufferedMessages int,
+	bandwidthShare uint32,
 ) bool {
 	mux.mutex.Lock()
 	defer mux.mutex.Unlock()
@@ -63,11 +110,19 @@ func (mux *Muxer) AddStream(
 		return false
 	}
 
+	if _, ok := mux.classes[priority]; !ok {
+		panic(fmt.Sprintf("unexpected stream priority: %#v", priority))
+	}
+
 	mux.streamRecords[sid] = &streamRecord{
 		streamName,
 		priority,
+		normalizeShare(bandwidthShare),
 		false,
-		ringbuffer.NewRingBuffer[stream2types.OutboundBinaryMessage](maxOutgoingBufferedMessages),
+		false,
+		false,
+		0,
+		ringbuffer.NewRingBuffer[queuedMessage](maxOutgoingBufferedMessages),
 	}
 
 	mux.logger.Debug("Muxer: stream added", commontypes.LogFields{
@@ -75,12 +130,13 @@ func (mux *Muxer) AddStream(
 		"streamName":                  streamName,
 		"priority":                    priority,
 		"maxOutgoingBufferedMessages": maxOutgoingBufferedMessages,
+		"bandwidthShare":              bandwidthShare,
 	})
 	return true
 }
 
 // panics if maxOutgoingBufferedMessages is 0 or less
-func (mux *Muxer) UpdateStream(sid internaltypes.StreamID, maxOutgoingBufferedMessages int) bool {
+func (mux *Muxer) UpdateStream(sid internaltypes.StreamID, maxOutgoingBufferedMessages int, bandwidthShare uint32) bool {
 	mux.mutex.Lock()
 	defer mux.mutex.Unlock()
 
@@ -90,6 +146,10 @@ func (mux *Muxer) UpdateStream(sid internaltypes.StreamID, maxOutgoingBufferedMe
 	}
 
 	streamRecord.messageBuffer.SetCap(maxOutgoingBufferedMessages)
+	streamRecord.share = normalizeShare(bandwidthShare)
+	if streamRecord.messageBuffer.IsEmpty() {
+		mux.unsetPending(sid, streamRecord)
+	}
 	return true
 }
 
@@ -102,8 +162,7 @@ func (mux *Muxer) RemoveStream(sid internaltypes.StreamID) bool {
 		return false
 	}
 
-	streamsWithPendingMessage := mux.streamsWithPendingMessageForPriority(streamRecord.priority)
-	streamsWithPendingMessage.Delete(sid)
+	mux.unsetPending(sid, streamRecord)
 
 	delete(mux.streamRecords, sid)
 
@@ -125,12 +184,11 @@ func (mux *Muxer) EnableStream(sid internaltypes.StreamID) bool {
 
 	streamRecord.enabled = true
 
-	if streamRecord.messageBuffer.IsEmpty() {
+	if streamRecord.busy || streamRecord.messageBuffer.IsEmpty() {
 		return true
 	}
 
-	streamsWithPendingMessage := mux.streamsWithPendingMessageForPriority(streamRecord.priority)
-	streamsWithPendingMessage.Set(sid, streamRecord.messageBuffer)
+	mux.setPending(sid, streamRecord)
 
 	select {
 	case mux.chSignal <- struct{}{}:
@@ -157,8 +215,7 @@ func (mux *Muxer) DisableStream(sid internaltypes.StreamID) bool {
 
 	streamRecord.enabled = false
 
-	streamsWithPendingMessage := mux.streamsWithPendingMessageForPriority(streamRecord.priority)
-	streamsWithPendingMessage.Delete(sid)
+	mux.unsetPending(sid, streamRecord)
 
 	mux.logger.Debug("Muxer: stream disabled", commontypes.LogFields{
 		"sid": sid,
@@ -166,6 +223,50 @@ func (mux *Muxer) DisableStream(sid internaltypes.StreamID) bool {
 	return true
 }
 
+// Marks a stream as busy. A busy stream doesn't emit messages via Pop() until
+// it is marked idle again. This allows consumers that write each stream
+// independently to keep at most one message per stream in flight while
+// leaving the remaining messages in the stream's ring buffer.
+func (mux *Muxer) MarkStreamBusy(sid internaltypes.StreamID) bool {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+
+	streamRecord, ok := mux.streamRecords[sid]
+	if !ok {
+		return false
+	}
+
+	streamRecord.busy = true
+
+	mux.unsetPending(sid, streamRecord)
+	return true
+}
+
+// Reverses MarkStreamBusy().
+func (mux *Muxer) MarkStreamIdle(sid internaltypes.StreamID) bool {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+
+	streamRecord, ok := mux.streamRecords[sid]
+	if !ok {
+		return false
+	}
+
+	streamRecord.busy = false
+
+	if !streamRecord.enabled || streamRecord.messageBuffer.IsEmpty() {
+		return true
+	}
+
+	mux.setPending(sid, streamRecord)
+
+	select {
+	case mux.chSignal <- struct{}{}:
+	default:
+	}
+	return true
+}
+
 // Pushes a message to the stream's ring buffer of messages and evicts the oldest message if the buffer is full.
 func (mux *Muxer) PushEvict(sid internaltypes.StreamID, m stream2types.OutboundBinaryMessage) bool {
 	mux.mutex.Lock()
@@ -176,11 +277,10 @@ func (mux *Muxer) PushEvict(sid internaltypes.StreamID, m stream2types.OutboundB
 		return false
 	}
 
-	streamRecord.messageBuffer.PushEvict(m)
+	streamRecord.messageBuffer.PushEvict(queuedMessage{m, time.Now()})
 
-	if streamRecord.enabled {
-		streamsWithPendingMessage := mux.streamsWithPendingMessageForPriority(streamRecord.priority)
-		streamsWithPendingMessage.Set(sid, streamRecord.messageBuffer)
+	if streamRecord.enabled && !streamRecord.busy {
+		mux.setPending(sid, streamRecord)
 	}
 
 	select {
@@ -198,75 +298,97 @@ func (mux *Muxer) SignalMaybePending() <-chan struct{} {
 	return mux.chSignal
 }
 
-func (mux *Muxer) streamsWithPendingMessageForPriority(priority stream2types.StreamPriority) *randmap.Map[internaltypes.StreamID, *ringbuffer.RingBuffer[stream2types.OutboundBinaryMessage]] {
-	switch priority {
-	case stream2types.StreamPriorityDefault:
-		return mux.defaultPriorityStreamsWithPendingMessage
-	case stream2types.StreamPriorityLow:
-		return mux.lowPriorityStreamsWithPendingMessage
+// Caller should hold mutex.
+func (mux *Muxer) setPending(sid internaltypes.StreamID, streamRecord *streamRecord) {
+	if streamRecord.pending {
+		return
+	}
+	class := mux.classes[streamRecord.priority]
+	if len(class.pending) == 0 {
+		class.tag = max(class.tag, mux.virtualTime)
 	}
-	panic(fmt.Sprintf("unexpected stream priority: %#v", priority))
+	streamRecord.tag = max(streamRecord.tag, class.virtualTime)
+	streamRecord.pending = true
+	class.pending[sid] = streamRecord
 }
 
-func (mux *Muxer) popForPriority(priority stream2types.StreamPriority) (stream2types.OutboundBinaryMessage, internaltypes.StreamID) {
-	streamsWithPendingMessage := mux.streamsWithPendingMessageForPriority(priority)
+// Caller should hold mutex.
+func (mux *Muxer) unsetPending(sid internaltypes.StreamID, streamRecord *streamRecord) {
+	if !streamRecord.pending {
+		return
+	}
+	delete(mux.classes[streamRecord.priority].pending, sid)
+	streamRecord.pending = false
+}
 
-	if streamsWithPendingMessage.Size() == 0 {
+// If any stream is enabled, not busy, and has messages buffered, one of these will be returned.
+// The policy for choosing which message to return is an implementation detail and may change.
+// For now, we implement weighted fair queueing across priority classes, and across the streams
+// of a priority class according to their bandwidth shares. Ties are broken arbitrarily.
+func (mux *Muxer) Pop() (stream2types.OutboundBinaryMessage, internaltypes.StreamID) {
+	mux.mutex.Lock()
+	defer mux.mutex.Unlock()
+
+	var class *priorityClass
+	for _, c := range mux.classes {
+		if len(c.pending) != 0 && (class == nil || c.tag < class.tag) {
+			class = c
+		}
+	}
+	if class == nil {
+		mux.logger.Info("Muxer: nothing to pop", commontypes.LogFields{})
 		return nil, internaltypes.StreamID{}
 	}
 
-	entry, ok := streamsWithPendingMessage.GetRandom()
-	if !ok {
-		return nil, internaltypes.StreamID{}
+	var sid internaltypes.StreamID
+	var streamRecord *streamRecord
+	for s, sr := range class.pending {
+		if streamRecord == nil || sr.tag < streamRecord.tag {
+			sid, streamRecord = s, sr
+		}
 	}
-	sid, messageBuffer := entry.Key, entry.Value
 
-	msg, ok := messageBuffer.Pop()
+	qm, ok := streamRecord.messageBuffer.Pop()
 	if !ok {
 		mux.logger.Error("Muxer: message buffer is unexpectedly empty, this points to a bug", commontypes.LogFields{
 			"sid": sid,
 		})
+		mux.unsetPending(sid, streamRecord)
 		return nil, internaltypes.StreamID{}
 	}
 
-	if messageBuffer.IsEmpty() {
-		streamsWithPendingMessage.Delete(sid)
-	}
+	cost := float64(messageCost(qm.message))
+	mux.virtualTime = class.tag
+	class.tag += cost / float64(class.weight)
+	class.virtualTime = streamRecord.tag
+	streamRecord.tag += cost / float64(streamRecord.share)
 
-	return msg, sid
-}
-
-// If any stream is enabled and has messages buffered, one of these will be returned.
-// The policy for choosing which message to return is an implementation detail and may change.
-// For now, we implement a simple policy that prefers DefaultPriority messages most
-// of the time, and LowPriority messages once in a while. If there are multiple enabled streams
-// of equal priority with buffered messages, we choose one uniformly at random.
-func (mux *Muxer) Pop() (stream2types.OutboundBinaryMessage, internaltypes.StreamID) {
-	mux.mutex.Lock()
-	defer mux.mutex.Unlock()
+	if streamRecord.messageBuffer.IsEmpty() {
+		mux.unsetPending(sid, streamRecord)
+	}
 
-	// Overflow is harmless
-	mux.popCount++
+	mux.observeQueueDuration(streamRecord.priority, time.Since(qm.enqueued))
 
-	var highPriority, lowPriority stream2types.StreamPriority
-	if mux.popCount%invertPrioritiesEvery == 0 {
-		highPriority = stream2types.StreamPriorityLow
-		lowPriority = stream2types.StreamPriorityDefault
-	} else {
-		highPriority = stream2types.StreamPriorityDefault
-		lowPriority = stream2types.StreamPriorityLow
-	}
+	return qm.message, sid
+}
 
-	msg, sid := mux.popForPriority(highPriority)
-	if msg != nil {
-		return msg, sid
+func normalizeShare(share uint32) uint32 {
+	if share == 0 {
+		return 1
 	}
+	return share
+}
 
-	msg, sid = mux.popForPriority(lowPriority)
-	if msg != nil {
-		return msg, sid
+// messageCost approximates the number of bytes m takes up on the wire.
+func messageCost(m stream2types.OutboundBinaryMessage) int {
+	var payloadLen int
+	switch m := m.(type) {
+	case stream2types.OutboundBinaryMessagePlain:
+		payloadLen = len(m.Payload)
+	case stream2types.OutboundBinaryMessageRequest:
+		payloadLen = len(m.Payload)
+	case stream2types.OutboundBinaryMessageResponse:
+		payloadLen = len(m.Payload)
 	}
-
-	mux.logger.Info("Muxer: nothing to pop", commontypes.LogFields{})
-	return nil, internaltypes.StreamID{}
+	return frame.MaxFrameHeaderSize + payloadLen
 }
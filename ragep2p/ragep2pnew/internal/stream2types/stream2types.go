@@ -8,6 +8,8 @@ import (
 	"github.com/smartcontractkit/libocr/ragep2p/types"
 )
 
+// StreamPriority identifies the priority class of a stream. Any non-zero value
+// can be used as long as it has a weight in the host's PriorityWeights.
 type StreamPriority byte
 
 const (
@@ -16,12 +18,72 @@ const (
 	StreamPriorityDefault
 )
 
+// PriorityWeights maps each priority class to its weight. When several classes
+// have messages waiting to be sent to a peer, each class gets a share of the
+// connection's bandwidth proportional to its weight.
+type PriorityWeights map[StreamPriority]uint32
+
+// DefaultPriorityWeights returns the weights used if none are configured. They
+// give StreamPriorityLow an eighth of the bandwidth under contention.
+func DefaultPriorityWeights() PriorityWeights {
+	return PriorityWeights{
+		StreamPriorityLow:     1,
+		StreamPriorityDefault: 7,
+	}
+}
+
+func (weights PriorityWeights) Validate() error {
+	if len(weights) == 0 {
+		return fmt.Errorf("no priority weights provided")
+	}
+	for priority, weight := range weights {
+		if priority == 0 {
+			return fmt.Errorf("priority 0 is reserved")
+		}
+		if weight == 0 {
+			return fmt.Errorf("weight of priority %v is not positive", priority)
+		}
+	}
+	return nil
+}
+
+// Compression selects the algorithm used to compress a stream's outgoing
+// messages. Compression is only used if both peers enable it for the stream,
+// so streams with compression enabled remain compatible with peers that
+// don't support it.
+type Compression byte
+
+const (
+	CompressionNone Compression = iota
+	CompressionSnappy
+	CompressionZstd
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionSnappy:
+		return "snappy"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("Compression(%d)", byte(c))
+	}
+}
+
 type Stream2Limits struct {
 	MaxOutgoingBufferedMessages int // number of messages that fit in the outgoing buffer
 	MaxIncomingBufferedMessages int // number of messages that fit in the incoming buffer
 	MaxMessageLength            int
 	MessagesLimit               types.TokenBucketParams // rate limit for (the number of) incoming messages
 	BytesLimit                  types.TokenBucketParams // rate limit for (the accumulated size in bytes of) incoming messages
+	// Share of the outgoing bandwidth of the stream's priority class relative
+	// to the class's other streams. 0 is treated as 1.
+	BandwidthShare uint32
+	// Compression of outgoing messages. Limits on incoming messages always
+	// apply to their decompressed size. Cannot be changed by UpdateLimits.
+	Compression Compression
 }
 
 func (limits Stream2Limits) Validate() (ValidatedStream2Limits, error) {
@@ -48,6 +110,9 @@ func (limits Stream2Limits) Validate() (ValidatedStream2Limits, error) {
 	if !(0 <= limits.BytesLimit.Capacity) { //nolint:staticcheck
 		return ValidatedStream2Limits{}, fmt.Errorf("bytesLimit.Capacity %v is not non-negative", limits.BytesLimit.Capacity)
 	}
+	if !(limits.Compression <= CompressionZstd) {
+		return ValidatedStream2Limits{}, fmt.Errorf("compression %v is unknown", limits.Compression)
+	}
 	return ValidatedStream2Limits{limits, struct{}{}}, nil
 }
 
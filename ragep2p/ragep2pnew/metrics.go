@@ -1,10 +1,14 @@
 package ragep2pnew
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/internal/metricshelper"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/ratelimitaggregator"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/stream2types"
 	"github.com/smartcontractkit/libocr/ragep2p/types"
 )
 
@@ -38,6 +42,7 @@ type peerMetrics struct {
 	registerer                  prometheus.Registerer
 	connEstablishedTotal        prometheus.Counter
 	connEstablishedInboundTotal prometheus.Counter
+	connEstablishedQUICTotal    prometheus.Counter
 	connReadProcessedBytesTotal prometheus.Counter
 	connReadSkippedBytesTotal   prometheus.Counter
 	connWrittenBytesTotal       prometheus.Counter
@@ -50,6 +55,8 @@ type peerMetrics struct {
 	bytesRateLimitRate          prometheus.Gauge
 	bytesRateLimitCapacity      prometheus.Gauge
 	messageBytes                prometheus.Histogram
+	muxerQueueDuration          *prometheus.HistogramVec
+	compressionRatio            *prometheus.HistogramVec
 }
 
 func newPeerMetrics(registerer prometheus.Registerer, logger commontypes.Logger, self types.PeerID, other types.PeerID) *peerMetrics {
@@ -71,6 +78,14 @@ func newPeerMetrics(registerer prometheus.Registerer, logger commontypes.Logger,
 
 	metricshelper.RegisterOrLogError(logger, registerer, connEstablishedInboundTotal, "ragep2p_peer_conn_established_inbound_total")
 
+	connEstablishedQUICTotal := prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "ragep2p_peer_conn_established_quic_total",
+		Help:        "The number of secure connections established with the remote peer that use QUIC rather than TCP. At most one connection can be active at any time.",
+		ConstLabels: labels,
+	})
+
+	metricshelper.RegisterOrLogError(logger, registerer, connEstablishedQUICTotal, "ragep2p_peer_conn_established_quic_total")
+
 	connReadProcessedBytesTotal := prometheus.NewCounter(prometheus.CounterOpts{
 		Name:        "ragep2p_peer_conn_read_processed_bytes_total",
 		Help:        "The number of bytes read on secure connections with the remote peer for processing",
@@ -99,7 +114,7 @@ func newPeerMetrics(registerer prometheus.Registerer, logger commontypes.Logger,
 		Name: "ragep2p_peer_rawconn_read_bytes_total",
 		Help: "The number of raw bytes read on raw (post-knock, tcp) connections with the remote " +
 			"peer. Knocks are ~100 bytes and thus have negligible impact. This metric is useful for " +
-			"tracking overall bandwidth usage.",
+			"tracking overall bandwidth usage. QUIC connections are not included.",
 		ConstLabels: labels,
 	})
 
@@ -109,7 +124,7 @@ func newPeerMetrics(registerer prometheus.Registerer, logger commontypes.Logger,
 		Name: "ragep2p_peer_rawconn_written_bytes_total",
 		Help: "The number of raw bytes written on raw (post-knock, tcp) connections with the remote " +
 			"peer. Knocks are ~100 bytes and thus have negligible impact. This metric is useful for " +
-			"tracking overall bandwidth usage.",
+			"tracking overall bandwidth usage. QUIC connections are not included.",
 		ConstLabels: labels,
 	})
 
@@ -172,10 +187,29 @@ func newPeerMetrics(registerer prometheus.Registerer, logger commontypes.Logger,
 
 	metricshelper.RegisterOrLogError(logger, registerer, messageBytes, "ragep2p_experimental_peer_message_bytes")
 
+	muxerQueueDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "ragep2p_experimental_peer_muxer_queue_duration_seconds",
+		Help:        "The time messages to the remote peer spend queued in the muxer before being sent, by stream priority",
+		ConstLabels: labels,
+		Buckets:     []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"priority"})
+
+	metricshelper.RegisterOrLogError(logger, registerer, muxerQueueDuration, "ragep2p_experimental_peer_muxer_queue_duration_seconds")
+
+	compressionRatio := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "ragep2p_experimental_peer_message_compression_ratio",
+		Help:        "The ratio of decompressed to compressed size of compressed messages exchanged with the remote peer, by direction (sent/received) and algorithm",
+		ConstLabels: labels,
+		Buckets:     []float64{1, 1.25, 1.5, 2, 3, 4, 6, 8, 16, 32, 64},
+	}, []string{"direction", "algorithm"})
+
+	metricshelper.RegisterOrLogError(logger, registerer, compressionRatio, "ragep2p_experimental_peer_message_compression_ratio")
+
 	return &peerMetrics{
 		registerer,
 		connEstablishedTotal,
 		connEstablishedInboundTotal,
+		connEstablishedQUICTotal,
 		connReadProcessedBytesTotal,
 		connReadSkippedBytesTotal,
 		connWrittenBytesTotal,
@@ -188,12 +222,15 @@ func newPeerMetrics(registerer prometheus.Registerer, logger commontypes.Logger,
 		bytesRateLimitRate,
 		bytesRateLimitCapacity,
 		messageBytes,
+		muxerQueueDuration,
+		compressionRatio,
 	}
 }
 
 func (m *peerMetrics) Close() {
 	m.registerer.Unregister(m.connEstablishedTotal)
 	m.registerer.Unregister(m.connEstablishedInboundTotal)
+	m.registerer.Unregister(m.connEstablishedQUICTotal)
 	m.registerer.Unregister(m.connReadProcessedBytesTotal)
 	m.registerer.Unregister(m.connReadSkippedBytesTotal)
 	m.registerer.Unregister(m.connWrittenBytesTotal)
@@ -206,6 +243,8 @@ func (m *peerMetrics) Close() {
 	m.registerer.Unregister(m.bytesRateLimitRate)
 	m.registerer.Unregister(m.bytesRateLimitCapacity)
 	m.registerer.Unregister(m.messageBytes)
+	m.registerer.Unregister(m.muxerQueueDuration)
+	m.registerer.Unregister(m.compressionRatio)
 }
 
 func (m *peerMetrics) SetRateLimits(messagesTokenBucketAggregate ratelimitaggregator.TokenBucketAggregate, bytesTokenBucketAggregate ratelimitaggregator.TokenBucketAggregate) {
@@ -214,3 +253,14 @@ func (m *peerMetrics) SetRateLimits(messagesTokenBucketAggregate ratelimitaggreg
 	m.bytesRateLimitRate.Set(bytesTokenBucketAggregate.Rate)
 	m.bytesRateLimitCapacity.Set(bytesTokenBucketAggregate.Capacity)
 }
+
+func (m *peerMetrics) ObserveMuxerQueueDuration(priority stream2types.StreamPriority, duration time.Duration) {
+	m.muxerQueueDuration.WithLabelValues(strconv.Itoa(int(priority))).Observe(duration.Seconds())
+}
+
+func (m *peerMetrics) ObserveCompressionRatio(direction string, c stream2types.Compression, decompressedSize int, compressedSize int) {
+	if compressedSize <= 0 {
+		return
+	}
+	m.compressionRatio.WithLabelValues(direction, c.String()).Observe(float64(decompressedSize) / float64(compressedSize))
+}
@@ -0,0 +1,960 @@
+package ragep2pnew
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/ragep2p/internal/knock"
+	"github.com/smartcontractkit/libocr/ragep2p/internal/mtls"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/compression"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/demuxer"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/frame"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/internaltypes"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/muxer"
+	"github.com/smartcontractkit/libocr/ragep2p/types"
+	"github.com/smartcontractkit/libocr/subprocesses"
+)
+
+// QUIC connections carry the same frames as TCP connections, but spread them
+// over multiple unidirectional QUIC streams so that packet loss affecting one
+// ragep2p stream doesn't stall the others. Each peer opens
+//   - one control stream carrying only OpenStream and CloseStream frames, and
+//   - one data stream per ragep2p stream carrying only message frames of that
+//     ragep2p stream. Data streams are opened lazily and closed when the
+//     ragep2p stream is closed.
+//
+// QUIC doesn't order the control stream against data streams. The reader of a
+// data stream waits until the OpenStream frame of its ragep2p stream has been
+// received on the control stream, while QUIC flow control holds back further
+// data. If the OpenStream frame doesn't arrive in time, the data stream is
+// rejected and the sender drops the message.
+//
+// The knock is sent as the only ALPN protocol in the TLS ClientHello. The
+// listener only presents its certificate if the knock is valid. Otherwise, it
+// sends nothing at all on the connection, just like it closes TCP connections
+// with an invalid knock without responding.
+//
+// Peers announce whether they accept QUIC connections through discovery, see
+// QUICDiscoverer. We only dial peers via QUIC that do.
+
+type connTransport string
+
+const (
+	transportTCP  connTransport = "tcp"
+	transportQUIC connTransport = "quic"
+)
+
+// If a QUIC dial to an address fails, we dial that address via TCP for this
+// long before trying QUIC again.
+const quicRetryInterval = 10 * time.Minute
+
+const quicKeepAlivePeriod = 10 * time.Second
+
+// One control stream plus one data stream per ragep2p stream, with some slack
+// for data streams that are being closed.
+const quicMaxIncomingUniStreams = 2*MaxStreamsPerPeer + 1
+
+// Error code with which we reject data streams whose ragep2p stream the other
+// peer hasn't opened in time.
+const quicStreamErrorCodeUnopened quic.StreamErrorCode = 1
+
+// quicTransport is a QUIC transport listening on one of our listen addresses.
+type quicTransport struct {
+	transport *quic.Transport
+	ip        net.IP // unspecified if listening on all interfaces
+}
+
+func newQUICConfig() *quic.Config {
+	return &quic.Config{
+		HandshakeIdleTimeout: netTimeout,
+		KeepAlivePeriod:      quicKeepAlivePeriod,
+		// We only use unidirectional streams
+		MaxIncomingStreams:    -1,
+		MaxIncomingUniStreams: quicMaxIncomingUniStreams,
+	}
+}
+
+func (ho *Host) listenQUIC(tcpAddr *net.TCPAddr) error {
+	// Use the port that was actually bound for TCP in case the listen address
+	// specified port 0.
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: tcpAddr.IP, Port: tcpAddr.Port, Zone: tcpAddr.Zone})
+	if err != nil {
+		return err
+	}
+	packetConn := newQUICPacketConn(udpConn)
+	tr := &quic.Transport{
+		Conn: packetConn,
+		ConnContext: func(ctx context.Context, _ *quic.ClientInfo) (context.Context, error) {
+			return context.WithValue(ctx, quicIncomingConnKey{}, &quicIncomingConn{packetConn, sync.Mutex{}, nil}), nil
+		},
+	}
+	config := newQUICConfig()
+	config.Tracer = quicIncomingConnTracer
+	ln, err := tr.Listen(&tls.Config{
+		MinVersion: tls.VersionTLS13,
+		MaxVersion: tls.VersionTLS13,
+
+		GetConfigForClient: ho.quicConfigForClient,
+	}, config)
+	if err != nil {
+		_ = tr.Close()
+		_ = udpConn.Close()
+		return err
+	}
+	ho.quicTransports = append(ho.quicTransports, quicTransport{tr, tcpAddr.IP})
+	ho.subprocesses.Go(func() {
+		ho.quicListenLoop(udpConn, tr, ln)
+	})
+	return nil
+}
+
+func (ho *Host) quicListenLoop(udpConn *net.UDPConn, tr *quic.Transport, ln *quic.Listener) {
+	ho.subprocesses.Go(func() {
+		<-ho.ctx.Done()
+		if err := ln.Close(); err != nil {
+			ho.logger.Warn("Failed to close QUIC listener", commontypes.LogFields{"error": err})
+		}
+		if err := tr.Close(); err != nil {
+			ho.logger.Warn("Failed to close QUIC transport", commontypes.LogFields{"error": err})
+		}
+		if err := udpConn.Close(); err != nil {
+			ho.logger.Warn("Failed to close UDP socket", commontypes.LogFields{"error": err})
+		}
+	})
+
+	for {
+		conn, err := ln.Accept(ho.ctx)
+		ho.hostMetrics.inboundDialsTotal.Inc()
+		if err != nil {
+			ho.logger.Info("Exiting Host.quicListenLoop due to error while Accepting", commontypes.LogFields{"error": err})
+			return
+		}
+		ho.subprocesses.Go(func() {
+			ho.handleIncomingQUICConnection(conn)
+		})
+	}
+}
+
+// quicConfigForClient verifies the knock of an incoming QUIC connection before
+// the TLS handshake proceeds. Returning an error aborts the handshake without
+// revealing our certificate. The resulting TLS alert is dropped, like all other
+// packets of the connection.
+func (ho *Host) quicConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	logFields := commontypes.LogFields{"direction": "in", "transport": transportQUIC}
+	if hello.Conn != nil {
+		logFields["remoteAddr"] = hello.Conn.RemoteAddr()
+	}
+	logger := ho.logger.MakeChild(logFields)
+
+	reject := func(err error) (*tls.Config, error) {
+		if ic, ok := hello.Context().Value(quicIncomingConnKey{}).(*quicIncomingConn); ok {
+			ic.drop()
+		}
+		return nil, err
+	}
+
+	if len(hello.SupportedProtos) != 1 || len(hello.SupportedProtos[0]) != knock.KnockSize {
+		logger.Warn("Missing knock", nil)
+		return reject(fmt.Errorf("missing knock"))
+	}
+
+	other, err := knock.VerifyKnock(ho.id, []byte(hello.SupportedProtos[0]))
+	if err != nil {
+		if errors.Is(err, knock.ErrFromSelfDial) {
+			logger.Info("Self-dial knock, dropping connection. Someone has likely misconfigured their announce addresses.", nil)
+		} else {
+			logger.Warn("Invalid knock", commontypes.LogFields{"error": err})
+		}
+		return reject(err)
+	}
+
+	ho.peersMu.Lock()
+	_, ok := ho.peers[*other]
+	ho.peersMu.Unlock()
+	if !ok {
+		logger.Warn("Received incoming connection from an unknown peer, closing", remotePeerIDField(*other))
+		return reject(fmt.Errorf("unknown peer"))
+	}
+
+	tlsConfig := newTLSConfig(
+		ho.tlsCert,
+		mtls.VerifyCertMatchesPubKey(*other),
+	)
+	tlsConfig.NextProtos = hello.SupportedProtos
+	return tlsConfig, nil
+}
+
+// quicTransportFor returns a transport that can reach addr. Transports
+// listening on all interfaces can reach both IPv4 and IPv6 addresses.
+func (ho *Host) quicTransportFor(addr *net.UDPAddr) (*quic.Transport, error) {
+	isIPv4 := addr.IP.To4() != nil
+	for _, t := range ho.quicTransports {
+		if t.ip.IsUnspecified() || (t.ip.To4() != nil) == isIPv4 {
+			return t.transport, nil
+		}
+	}
+	return nil, fmt.Errorf("no QUIC transport for the address family of %s", addr)
+}
+
+// dialQUIC dials address and performs the QUIC handshake.
+func (ho *Host) dialQUIC(other types.PeerID, address string) (*quic.Conn, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := ho.quicTransportFor(udpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	knck, err := knock.BuildKnock(other, ho.id, ho.keyring)
+	if err != nil {
+		return nil, fmt.Errorf("error while building knock: %w", err)
+	}
+
+	tlsConfig := newTLSConfig(
+		ho.tlsCert,
+		mtls.VerifyCertMatchesPubKey(other),
+	)
+	tlsConfig.NextProtos = []string{string(knck)}
+
+	timeout := netTimeout
+	if ho.config.DurationBetweenDials < timeout {
+		timeout = ho.config.DurationBetweenDials
+	}
+	ctx, cancel := context.WithTimeout(ho.ctx, timeout)
+	defer cancel()
+
+	// Dialing from the listening socket lets the other peer's replies reach us
+	// through NATs that have learned our announce address.
+	return tr.Dial(ctx, udpAddr, tlsConfig, newQUICConfig())
+}
+
+// quicPacketConn wraps the UDP socket of a QUIC transport. It drops the packets
+// of incoming connections whose knock was invalid, so that they time out on
+// the dialer's side without learning anything about us.
+type quicPacketConn struct {
+	*net.UDPConn
+
+	mu sync.Mutex
+	// destination connection IDs of packets we drop, until the given time
+	droppedConnIDs map[string]time.Time
+}
+
+func newQUICPacketConn(udpConn *net.UDPConn) *quicPacketConn {
+	return &quicPacketConn{udpConn, sync.Mutex{}, map[string]time.Time{}}
+}
+
+// dropConnID drops packets to connID for as long as the dialer keeps trying to
+// complete the handshake.
+func (c *quicPacketConn) dropConnID(connID []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for id, until := range c.droppedConnIDs {
+		if now.After(until) {
+			delete(c.droppedConnIDs, id)
+		}
+	}
+	c.droppedConnIDs[string(connID)] = now.Add(netTimeout)
+}
+
+// shouldDrop checks the destination connection ID of the first packet in b.
+// Connections are only rejected during the handshake, so all their packets
+// have long headers.
+func (c *quicPacketConn) shouldDrop(b []byte) bool {
+	if len(b) < 6 || b[0]&0x80 == 0 {
+		return false
+	}
+	connIDLen := int(b[5])
+	if len(b) < 6+connIDLen {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until, ok := c.droppedConnIDs[string(b[6:6+connIDLen])]
+	return ok && time.Now().Before(until)
+}
+
+func (c *quicPacketConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	if c.shouldDrop(b) {
+		return len(b), len(oob), nil
+	}
+	return c.UDPConn.WriteMsgUDP(b, oob, addr)
+}
+
+func (c *quicPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if c.shouldDrop(b) {
+		return len(b), nil
+	}
+	return c.UDPConn.WriteTo(b, addr)
+}
+
+var _ quic.OOBCapablePacketConn = &quicPacketConn{}
+
+type quicIncomingConnKey struct{}
+
+// quicIncomingConn is attached to the context of incoming QUIC connections. It
+// learns the connection ID the dialer has chosen for itself, which is the
+// destination connection ID of the packets we send on the connection.
+type quicIncomingConn struct {
+	packetConn *quicPacketConn
+
+	mu           sync.Mutex
+	remoteConnID []byte
+}
+
+func (ic *quicIncomingConn) drop() {
+	ic.mu.Lock()
+	remoteConnID := ic.remoteConnID
+	ic.mu.Unlock()
+	if remoteConnID != nil {
+		ic.packetConn.dropConnID(remoteConnID)
+	}
+}
+
+// quicIncomingConnTracer records the connection ID of the dialer in the
+// quicIncomingConn of an incoming connection. quic-go reports it before the
+// TLS handshake starts.
+func quicIncomingConnTracer(ctx context.Context, _ logging.Perspective, _ quic.ConnectionID) *logging.ConnectionTracer {
+	ic, ok := ctx.Value(quicIncomingConnKey{}).(*quicIncomingConn)
+	if !ok {
+		return nil
+	}
+	return &logging.ConnectionTracer{
+		StartedConnection: func(_, _ net.Addr, srcConnID, _ logging.ConnectionID) {
+			ic.mu.Lock()
+			defer ic.mu.Unlock()
+			ic.remoteConnID = srcConnID.Bytes()
+		},
+	}
+}
+
+func (ho *Host) handleOutgoingQUICConnection(conn *quic.Conn, other types.PeerID, logger loghelper.LoggerWithContext) {
+	logger = logger.MakeChild(commontypes.LogFields{"transport": transportQUIC})
+
+	ho.peersMu.Lock()
+	peer, ok := ho.peers[other]
+	ho.peersMu.Unlock()
+	if !ok {
+		// peer must have been deleted in the time between the dial being
+		// started and now
+		closeQUICConn(conn, logger)
+		return
+	}
+
+	ho.handleQUICConnection(false, conn, peer, logger)
+}
+
+func (ho *Host) handleIncomingQUICConnection(conn *quic.Conn) {
+	remoteAddrLogFields := commontypes.LogFields{"direction": "in", "transport": transportQUIC, "remoteAddr": conn.RemoteAddr()}
+	logger := ho.logger.MakeChild(remoteAddrLogFields)
+
+	pubKey, err := quicPeerPublicKey(conn)
+	if err != nil {
+		logger.Warn("Closing connection, error getting public key", commontypes.LogFields{"error": err})
+		closeQUICConn(conn, logger)
+		return
+	}
+	other := types.PeerIDFromPeerPublicKey(pubKey)
+
+	ho.peersMu.Lock()
+	peer, ok := ho.peers[other]
+	ho.peersMu.Unlock()
+	if !ok {
+		logger.Warn("Received incoming connection from an unknown peer, closing", remotePeerIDField(other))
+		closeQUICConn(conn, logger)
+		return
+	}
+	logger = peer.logger.MakeChild(remoteAddrLogFields) // introduce remotePeerID in our logs since we now know it
+
+	ho.handleQUICConnection(true, conn, peer, logger)
+}
+
+func (ho *Host) handleQUICConnection(
+	incoming bool,
+	conn *quic.Conn,
+	peer *peer,
+	logger loghelper.LoggerWithContext,
+) {
+	// The handshake has already been completed, but we double-check the
+	// public key just like for TCP connections.
+	pubKey, err := quicPeerPublicKey(conn)
+	if err != nil {
+		logger.Warn("Closing connection, error getting public key", commontypes.LogFields{"error": err})
+		closeQUICConn(conn, logger)
+		return
+	}
+	if peer.other != types.PeerIDFromPeerPublicKey(pubKey) {
+		logger.Warn("TLS handshake PeerID mismatch", commontypes.LogFields{
+			"expected": peer.other,
+			"actual":   types.PeerIDFromPeerPublicKey(pubKey),
+		})
+		closeQUICConn(conn, logger)
+		return
+	}
+
+	if !ho.adoptAuthenticatedConnection(incoming, transportQUIC, peer, logger, func(connCtx context.Context, chConnTerminated chan<- struct{}) {
+		authenticatedQUICConnectionLoop(
+			connCtx,
+			conn,
+			peer.chOtherStreamStateNotification,
+			peer.chSelfStreamStateNotification,
+			peer.mux,
+			peer.demux,
+			peer.compressions,
+			chConnTerminated,
+			logger,
+			peer.metrics,
+		)
+	}) {
+		closeQUICConn(conn, logger)
+	}
+}
+
+func quicPeerPublicKey(conn *quic.Conn) (types.PeerPublicKey, error) {
+	peerCertificates := conn.ConnectionState().TLS.PeerCertificates
+	if len(peerCertificates) == 0 {
+		return types.PeerPublicKey{}, fmt.Errorf("no peer certificate")
+	}
+	return mtls.PubKeyFromCert(peerCertificates[0])
+}
+
+func closeQUICConn(conn *quic.Conn, logger loghelper.LoggerWithContext) {
+	if err := conn.CloseWithError(0, ""); err != nil {
+		logger.Warn("Failed to close connection", commontypes.LogFields{"error": err})
+	}
+}
+
+/////////////////////////////////////////////
+// authenticated QUIC connection handling
+//////////////////////////////////////////////
+
+func authenticatedQUICConnectionLoop(
+	ctx context.Context,
+	conn *quic.Conn,
+	chOtherStreamStateNotification chan<- streamStateNotification,
+	chSelfStreamStateNotification <-chan streamStateNotification,
+	mux *muxer.Muxer,
+	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
+	chConnTerminated chan<- struct{},
+	logger loghelper.LoggerWithContext,
+	metrics *peerMetrics,
+) {
+	defer func() {
+		close(chConnTerminated)
+		logger.Info("authenticatedQUICConnectionLoop: exited", nil)
+	}()
+
+	compressions.resetRemote()
+
+	var subs subprocesses.Subprocesses
+	defer subs.Wait()
+
+	defer closeQUICConn(conn, logger)
+
+	childCtx, childCancel := context.WithCancel(ctx)
+	defer childCancel()
+
+	chReadTerminated := make(chan struct{})
+	subs.Go(func() {
+		authenticatedQUICConnectionReadLoop(
+			childCtx,
+			conn,
+			chOtherStreamStateNotification,
+			demux,
+			compressions,
+			chReadTerminated,
+			logger,
+			metrics,
+		)
+	})
+
+	chWriteTerminated := make(chan struct{})
+	subs.Go(func() {
+		authenticatedQUICConnectionWriteLoop(
+			childCtx,
+			conn,
+			chSelfStreamStateNotification,
+			mux,
+			demux,
+			compressions,
+			chWriteTerminated,
+			logger,
+			metrics,
+		)
+	})
+
+	select {
+	case <-ctx.Done():
+	case <-conn.Context().Done():
+	case <-chReadTerminated:
+	case <-chWriteTerminated:
+	}
+
+	logger.Info("authenticatedQUICConnectionLoop: winding down", nil)
+}
+
+func authenticatedQUICConnectionReadLoop(
+	ctx context.Context,
+	conn *quic.Conn,
+	chOtherStreamStateNotification chan<- streamStateNotification,
+	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
+	chReadTerminated chan<- struct{},
+	logger loghelper.LoggerWithContext,
+	metrics *peerMetrics,
+) {
+	var terminateOnce sync.Once
+	terminate := func() {
+		terminateOnce.Do(func() { close(chReadTerminated) })
+	}
+	defer terminate()
+
+	var subs subprocesses.Subprocesses
+	defer subs.Wait()
+
+	var controlStreamSeen atomic.Bool
+	remoteStreams := newQUICRemoteStreams()
+
+	// Stream state notifications from the control stream are forwarded, and
+	// only then marked in remoteStreams, so that messages are never pushed
+	// before the notification that the other peer opened their stream.
+	chControlStreamStateNotification := make(chan streamStateNotification)
+	subs.Go(func() {
+		for {
+			select {
+			case notification := <-chControlStreamStateNotification:
+				select {
+				case chOtherStreamStateNotification <- notification:
+				case <-ctx.Done():
+					return
+				}
+				remoteStreams.set(notification.streamID, notification.open)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	for {
+		stream, err := conn.AcceptUniStream(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("Error accepting QUIC stream", commontypes.LogFields{"error": err})
+			}
+			return
+		}
+		subs.Go(func() {
+			if !quicStreamReadLoop(ctx, stream, &controlStreamSeen, remoteStreams, chControlStreamStateNotification, demux, compressions, logger, metrics) {
+				terminate()
+			}
+		})
+	}
+}
+
+// quicRemoteStreams tracks which ragep2p streams the other peer has opened
+// according to its control stream.
+type quicRemoteStreams struct {
+	mu        sync.Mutex
+	open      map[internaltypes.StreamID]struct{}
+	chChanged chan struct{} // closed and replaced whenever open changes
+}
+
+func newQUICRemoteStreams() *quicRemoteStreams {
+	return &quicRemoteStreams{
+		sync.Mutex{},
+		map[internaltypes.StreamID]struct{}{},
+		make(chan struct{}),
+	}
+}
+
+func (s *quicRemoteStreams) set(sid internaltypes.StreamID, open bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if open {
+		s.open[sid] = struct{}{}
+	} else {
+		delete(s.open, sid)
+	}
+	close(s.chChanged)
+	s.chChanged = make(chan struct{})
+}
+
+// waitOpen returns true once sid is open. Returns false if that doesn't happen
+// within timeout or ctx is done.
+func (s *quicRemoteStreams) waitOpen(ctx context.Context, sid internaltypes.StreamID, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		_, open := s.open[sid]
+		chChanged := s.chChanged
+		s.mu.Unlock()
+		if open {
+			return true
+		}
+		select {
+		case <-chChanged:
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// quicStreamReadLoop reads frames from a QUIC stream opened by the other peer.
+// The first frame determines whether the stream is the control stream or a
+// data stream. Returns false if the connection should be torn down.
+func quicStreamReadLoop(
+	ctx context.Context,
+	stream *quic.ReceiveStream,
+	controlStreamSeen *atomic.Bool,
+	remoteStreams *quicRemoteStreams,
+	chOtherStreamStateNotification chan<- streamStateNotification,
+	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
+	logger loghelper.LoggerWithContext,
+	metrics *peerMetrics,
+) bool {
+	reachedEOF := false
+
+	readInternal := func(buf []byte) bool {
+		_, err := io.ReadFull(stream, buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				reachedEOF = true
+			} else {
+				logger.Warn("Error reading from QUIC stream", commontypes.LogFields{"error": err})
+			}
+			return false
+		}
+		metrics.connReadProcessedBytesTotal.Add(float64(len(buf)))
+		return true
+	}
+
+	skipInternal := func(n int) bool {
+		r, err := io.Copy(io.Discard, io.LimitReader(stream, int64(n)))
+		if err != nil || r != int64(n) {
+			logger.Warn("Error reading from QUIC stream", commontypes.LogFields{"error": err})
+			return false
+		}
+		metrics.connReadSkippedBytesTotal.Add(float64(n))
+		return true
+	}
+
+	isControlStream := false
+	isDataStream := false
+	dataStreamOpened := false
+	rejected := false
+	var dataStreamID internaltypes.StreamID
+	checkFrameHeaderInternal := func(header frame.FrameHeader) error {
+		isControlFrame := header.GetType() == frame.FrameTypeOpenStream || header.GetType() == frame.FrameTypeCloseStream
+		switch {
+		case isControlStream:
+			if !isControlFrame {
+				return fmt.Errorf("message frame on control stream")
+			}
+		case isDataStream:
+			if isControlFrame {
+				return fmt.Errorf("control frame on data stream")
+			}
+			if header.GetStreamID() != dataStreamID {
+				return fmt.Errorf("data stream for %v carries frame for %v", dataStreamID, header.GetStreamID())
+			}
+		case isControlFrame:
+			if !controlStreamSeen.CompareAndSwap(false, true) {
+				return fmt.Errorf("more than one control stream")
+			}
+			isControlStream = true
+		default:
+			isDataStream = true
+			dataStreamID = header.GetStreamID()
+		}
+		return nil
+	}
+
+	checkFrameHeader := func(header frame.FrameHeader) bool {
+		if err := checkFrameHeaderInternal(header); err != nil {
+			logger.Warn("authenticatedConnectionReadLoop: unexpected frame header, closing connection", commontypes.LogFields{
+				"error": err,
+			})
+			return false
+		}
+		// Only the first frame needs to wait, later frames may well arrive
+		// after the other peer has closed the stream again.
+		if isDataStream && !dataStreamOpened {
+			if !remoteStreams.waitOpen(ctx, dataStreamID, netTimeout) {
+				if ctx.Err() != nil {
+					return false
+				}
+				logger.Warn("Other peer didn't open stream in time, rejecting QUIC data stream", commontypes.LogFields{
+					"streamID": dataStreamID,
+				})
+				stream.CancelRead(quicStreamErrorCodeUnopened)
+				rejected = true
+				return false
+			}
+			dataStreamOpened = true
+		}
+		return true
+	}
+
+	readFrames(ctx, readInternal, skipInternal, checkFrameHeader, chOtherStreamStateNotification, demux, compressions, logger)
+
+	// The other peer closes data streams when the corresponding ragep2p stream
+	// is closed. The control stream must remain open.
+	return (reachedEOF || rejected) && !isControlStream
+}
+
+func authenticatedQUICConnectionWriteLoop(
+	ctx context.Context,
+	conn *quic.Conn,
+	chSelfStreamStateNotification <-chan streamStateNotification,
+	mux *muxer.Muxer,
+	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
+	chWriteTerminated chan<- struct{},
+	logger loghelper.LoggerWithContext,
+	metrics *peerMetrics,
+) {
+	var terminateOnce sync.Once
+	terminate := func() {
+		terminateOnce.Do(func() { close(chWriteTerminated) })
+	}
+	defer terminate()
+
+	writersCtx, writersCancel := context.WithCancel(ctx)
+	var writersSubs subprocesses.Subprocesses
+	writers := map[internaltypes.StreamID]*quicStreamWriter{}
+	defer func() {
+		writersCancel()
+		writersSubs.Wait()
+		// Messages that are in flight when the connection terminates are lost,
+		// just like on TCP connections.
+		for sid := range writers {
+			mux.MarkStreamIdle(sid)
+		}
+	}()
+
+	var controlStream *quic.SendStream
+	writeControlFrameInternal := func(buf []byte) bool {
+		if controlStream == nil {
+			var err error
+			controlStream, err = openQUICSendStream(ctx, conn)
+			if err != nil {
+				logger.Warn("Error opening QUIC control stream", commontypes.LogFields{"error": err})
+				return false
+			}
+		}
+		if err := writeQUICSendStream(controlStream, buf); err != nil {
+			logger.Warn("Error writing to QUIC control stream", commontypes.LogFields{"error": err})
+			return false
+		}
+		metrics.connWrittenBytesTotal.Add(float64(len(buf)))
+		return true
+	}
+
+	handleStreamStateNotificationsInternal := func(notification streamStateNotification) bool {
+		if notification.open {
+			payload := compression.EncodeOpenStreamPayload(notification.streamName, notification.compression)
+			return writeControlFrameInternal(append(
+				frame.OpenStreamFrameHeader{notification.streamID, len(payload)}.Encode(),
+				payload...,
+			))
+		}
+		if !writeControlFrameInternal(frame.CloseStreamFrameHeader{notification.streamID}.Encode()) {
+			return false
+		}
+		if writer, ok := writers[notification.streamID]; ok {
+			writer.closeStream()
+		}
+		return true
+	}
+
+	sendInternal := func(sid internaltypes.StreamID, msg OutboundBinaryMessage) bool {
+		// Keep further messages of this stream in the muxer until the writer is
+		// done with this one, so that the muxer can still evict them.
+		if !mux.MarkStreamBusy(sid) {
+			// stream has been removed in the meantime
+			return true
+		}
+		writer, ok := writers[sid]
+		if !ok {
+			writer = newQUICStreamWriter()
+			writers[sid] = writer
+			writersSubs.Go(func() {
+				writer.run(writersCtx, conn, sid, mux, demux, compressions, terminate, logger, metrics)
+			})
+		}
+		select {
+		case writer.chMessage <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case notification := <-chSelfStreamStateNotification:
+			if !handleStreamStateNotificationsInternal(notification) {
+				return
+			}
+			continue
+
+		case <-mux.SignalMaybePending():
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case notification := <-chSelfStreamStateNotification:
+				if !handleStreamStateNotificationsInternal(notification) {
+					return
+				}
+
+			default:
+			}
+
+			msg, sid := mux.Pop()
+			if msg == nil {
+				break
+			}
+			if !sendInternal(sid, msg) {
+				return
+			}
+		}
+	}
+}
+
+// quicStreamWriter writes the messages of a single ragep2p stream to its own
+// QUIC stream.
+type quicStreamWriter struct {
+	chMessage chan OutboundBinaryMessage
+	chClose   chan struct{}
+}
+
+func newQUICStreamWriter() *quicStreamWriter {
+	return &quicStreamWriter{
+		make(chan OutboundBinaryMessage, 1),
+		make(chan struct{}, 1),
+	}
+}
+
+// closeStream asks the writer to close its current QUIC stream. The next
+// message will be written to a new QUIC stream.
+func (w *quicStreamWriter) closeStream() {
+	select {
+	case w.chClose <- struct{}{}:
+	default:
+	}
+}
+
+func (w *quicStreamWriter) run(
+	ctx context.Context,
+	conn *quic.Conn,
+	sid internaltypes.StreamID,
+	mux *muxer.Muxer,
+	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
+	terminate func(),
+	logger loghelper.LoggerWithContext,
+	metrics *peerMetrics,
+) {
+	var stream *quic.SendStream
+
+	sendInternal := func(msg OutboundBinaryMessage) bool {
+		header, payload, err := encodeMessage(sid, msg, demux, compressions)
+		if err != nil {
+			logger.Error("Error while sending request (failed to generate random request id)", commontypes.LogFields{
+				"error": err,
+			})
+			return false
+		}
+
+		if stream == nil {
+			stream, err = openQUICSendStream(ctx, conn)
+			if err != nil {
+				logger.Warn("Error opening QUIC stream", commontypes.LogFields{"error": err, "streamID": sid})
+				return false
+			}
+		}
+
+		err = writeQUICSendStream(stream, header)
+		if err == nil {
+			err = writeQUICSendStream(stream, payload)
+		}
+		var streamErr *quic.StreamError
+		if errors.As(err, &streamErr) && streamErr.Remote && streamErr.ErrorCode == quicStreamErrorCodeUnopened {
+			logger.Warn("Other peer rejected QUIC stream, dropping message", commontypes.LogFields{"streamID": sid})
+			// the next message goes to a new QUIC stream
+			stream = nil
+			return true
+		}
+		if err != nil {
+			logger.Warn("Error writing to QUIC stream", commontypes.LogFields{"error": err, "streamID": sid})
+			return false
+		}
+		metrics.connWrittenBytesTotal.Add(float64(len(header) + len(payload)))
+		metrics.messageBytes.Observe(float64(len(payload)))
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-w.chClose:
+			if stream != nil {
+				if err := stream.Close(); err != nil {
+					logger.Warn("Failed to close QUIC stream", commontypes.LogFields{"error": err, "streamID": sid})
+				}
+				stream = nil
+			}
+
+		case msg := <-w.chMessage:
+			if !sendInternal(msg) {
+				// shut everything down
+				terminate()
+				return
+			}
+			mux.MarkStreamIdle(sid)
+		}
+	}
+}
+
+func openQUICSendStream(ctx context.Context, conn *quic.Conn) (*quic.SendStream, error) {
+	ctx, cancel := context.WithTimeout(ctx, netTimeout)
+	defer cancel()
+	return conn.OpenUniStreamSync(ctx)
+}
+
+func writeQUICSendStream(stream *quic.SendStream, buf []byte) error {
+	if err := stream.SetWriteDeadline(time.Now().Add(netTimeout)); err != nil {
+		return fmt.Errorf("error during SetWriteDeadline: %w", err)
+	}
+	_, err := stream.Write(buf)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"maps"
 	"math/rand"
 	"net"
 	"sync"
@@ -18,6 +19,7 @@ import (
 	"github.com/smartcontractkit/libocr/networking/ragep2pwrapper"
 	"github.com/smartcontractkit/libocr/ragep2p/internal/knock"
 	"github.com/smartcontractkit/libocr/ragep2p/internal/mtls"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/compression"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/demuxer"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/frame"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/muxer"
@@ -26,6 +28,7 @@ import (
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/ratelimitaggregator"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/stream2types"
 
+	"github.com/smartcontractkit/libocr/ragep2p/relay"
 	"github.com/smartcontractkit/libocr/ragep2p/types"
 	"github.com/smartcontractkit/libocr/subprocesses"
 
@@ -91,9 +94,10 @@ type newConnNotification struct {
 }
 
 type streamStateNotification struct {
-	streamID   internaltypes.StreamID
-	streamName string // Used for sanity check, populated only on stream open and empty on stream close
-	open       bool
+	streamID    internaltypes.StreamID
+	streamName  string // Used for sanity check, populated only on stream open and empty on stream close
+	open        bool
+	compression Compression // Announced in our OpenStream frame, unused for the other side's notifications
 }
 
 type peerConnLifeCycle struct {
@@ -118,8 +122,9 @@ type peer struct {
 	connLifeCycleMu sync.Mutex
 	connLifeCycle   peerConnLifeCycle
 
-	mux   *muxer.Muxer
-	demux *demuxer.Demuxer
+	mux          *muxer.Muxer
+	demux        *demuxer.Demuxer
+	compressions *streamCompressions
 
 	chNewConnNotification chan<- newConnNotification
 
@@ -140,6 +145,27 @@ type HostConfig struct {
 	// DurationBetweenDials is the minimum duration between two dials. It is
 	// not the exact duration because of jitter.
 	DurationBetweenDials time.Duration
+	// EnableQUIC makes the host additionally listen for QUIC connections on
+	// UDP, using the same addresses and ports as for TCP, and prefer QUIC when
+	// dialing other peers that accept QUIC according to the Discoverer, see
+	// QUICDiscoverer. Dials fall back to TCP if a peer can't be reached via
+	// QUIC, so hosts with and without QUIC can be mixed freely.
+	EnableQUIC bool
+	// Dialer is used to establish outgoing TCP connections, e.g. through a
+	// proxy. The knock and TLS handshake are performed end to end over the
+	// returned connection. If nil, addresses are dialed directly. QUIC dials
+	// are only attempted if Dialer is nil.
+	Dialer types.Dialer
+	// RelayListener, if not nil, accepts incoming connections established
+	// through relays, see package ragep2p/relay. The host closes it when
+	// closing. Relay addresses of other peers are always dialed through the
+	// respective relay, regardless of RelayListener.
+	RelayListener net.Listener
+	// PriorityWeights determines how the bandwidth to each peer is shared
+	// between priority classes when several of them have messages waiting to
+	// be sent. Streams can only be created with priorities listed here. If nil,
+	// DefaultPriorityWeights() is used.
+	PriorityWeights PriorityWeights
 }
 
 // A Host allows users to establish Streams with other peers identified by their
@@ -161,6 +187,9 @@ type Host struct {
 	id      types.PeerID
 	tlsCert tls.Certificate
 
+	// Derived from config
+	priorityWeights PriorityWeights
+
 	// Host state
 	stateMu sync.Mutex
 	state   hostState
@@ -173,6 +202,9 @@ type Host struct {
 	// Peers
 	peersMu sync.Mutex
 	peers   map[types.PeerID]*peer
+
+	// Populated by Start() if config.EnableQUIC is set, one per listen address
+	quicTransports []quicTransport
 }
 
 // NewHost creates a new Host with the provided config, Ed25519 secret key,
@@ -197,6 +229,14 @@ func NewHost(
 		return nil, fmt.Errorf("failed to create certificate from keyring for host: %w", err)
 	}
 
+	priorityWeights := DefaultPriorityWeights()
+	if config.PriorityWeights != nil {
+		if err := config.PriorityWeights.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid priority weights: %w", err)
+		}
+		priorityWeights = maps.Clone(config.PriorityWeights)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Host{
 		config,
@@ -212,6 +252,8 @@ func NewHost(
 		id,
 		tlsCert,
 
+		priorityWeights,
+
 		sync.Mutex{},
 		hostStatePending,
 
@@ -221,6 +263,8 @@ func NewHost(
 
 		sync.Mutex{},
 		map[types.PeerID]*peer{},
+
+		nil,
 	}, nil
 }
 
@@ -241,9 +285,6 @@ func (ho *Host) Start() error {
 	}
 	ho.state = hostStateOpen
 
-	ho.subprocesses.Go(func() {
-		ho.dialLoop()
-	})
 	for _, addr := range ho.listenAddresses {
 		ln, err := net.Listen("tcp", addr)
 		if err != nil {
@@ -252,7 +293,22 @@ func (ho *Host) Start() error {
 		ho.subprocesses.Go(func() {
 			ho.listenLoop(ln)
 		})
+		if ho.config.EnableQUIC {
+			if err := ho.listenQUIC(ln.Addr().(*net.TCPAddr)); err != nil {
+				return fmt.Errorf("failed to listen for QUIC connections on %q: %w", addr, err)
+			}
+		}
+	}
+	if ho.config.RelayListener != nil {
+		ho.subprocesses.Go(func() {
+			ho.listenLoop(ho.config.RelayListener)
+		})
 	}
+	// The dial loop uses quicTransports, so we start it only once they have
+	// been set up.
+	ho.subprocesses.Go(func() {
+		ho.dialLoop()
+	})
 
 	err := ho.discoverer.Start(Wrapped(ho), ho.keyring, ho.logger)
 	if err != nil {
@@ -286,8 +342,9 @@ func (ho *Host) findOrCreatePeer(other types.PeerID) (*peer, error) {
 		// close so that we re-dial and establish a connection
 		close(chConnTerminated)
 
-		mux := muxer.NewMuxer(logger)
+		mux := muxer.NewMuxer(logger, ho.priorityWeights, metrics.ObserveMuxerQueueDuration)
 		demux := demuxer.NewDemuxer()
+		compressions := newStreamCompressions(metrics)
 
 		chNewConnNotification := make(chan newConnNotification)
 
@@ -330,6 +387,7 @@ func (ho *Host) findOrCreatePeer(other types.PeerID) (*peer, error) {
 
 			mux,
 			demux,
+			compressions,
 
 			chNewConnNotification,
 
@@ -357,6 +415,7 @@ func (ho *Host) findOrCreatePeer(other types.PeerID) (*peer, error) {
 				chSelfStreamStateNotification,
 				mux,
 				demux,
+				compressions,
 				chStreamOpenRequest,
 				chStreamOpenResponse,
 				chStreamUpdateLimitsRequest,
@@ -380,6 +439,7 @@ func peerLoop(
 	chSelfStreamStateNotification chan<- streamStateNotification,
 	mux *muxer.Muxer,
 	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
 	chStreamOpenRequest <-chan peerStreamOpenRequest,
 	chStreamOpenResponse chan<- peerStreamOpenResponse,
 	chStreamUpdateLimitsRequest <-chan peerStreamUpdateLimitsRequest,
@@ -398,6 +458,7 @@ func peerLoop(
 		name                      string
 		priority                  stream2types.StreamPriority
 		messagesLimit, bytesLimit types.TokenBucketParams
+		compression               Compression
 	}
 	streams := map[internaltypes.StreamID]stream{}
 	otherStreams := map[internaltypes.StreamID]struct{}{}
@@ -417,6 +478,7 @@ func peerLoop(
 				streamID,
 				streams[streamID].name,
 				state,
+				streams[streamID].compression,
 			}
 			break
 		}
@@ -516,13 +578,13 @@ func peerLoop(
 					fmt.Errorf("too many streams, expected at most %d", MaxStreamsPerPeer),
 				}
 			} else {
-				if !mux.AddStream(req.streamID, req.streamName, req.streamPriority, req.limits.MaxOutgoingBufferedMessages) {
+				if !mux.AddStream(req.streamID, req.streamName, req.streamPriority, req.limits.MaxOutgoingBufferedMessages, req.limits.BandwidthShare) {
 					logger.Error("Assumption violation. Failed to add already existing stream to muxer. This stream may not work correctly.", commontypes.LogFields{
 						"streamOpenRequest": req,
 					})
 					// let's try to fix the problem by removing and adding the stream again
 					_ = mux.RemoveStream(req.streamID)
-					_ = mux.AddStream(req.streamID, req.streamName, req.streamPriority, req.limits.MaxOutgoingBufferedMessages)
+					_ = mux.AddStream(req.streamID, req.streamName, req.streamPriority, req.limits.MaxOutgoingBufferedMessages, req.limits.BandwidthShare)
 				}
 
 				if !demux.AddStream(req.streamID, req.limits.MaxIncomingBufferedMessages, req.limits.MaxMessageLength, req.limits.MessagesLimit, req.limits.BytesLimit) {
@@ -537,11 +599,14 @@ func peerLoop(
 				rateLimitAggregator.AddStream(req.limits.MessagesLimit, req.limits.BytesLimit)
 				metrics.SetRateLimits(rateLimitAggregator.Aggregates())
 
+				compressions.setLocal(req.streamID, req.limits.Compression)
+
 				streams[req.streamID] = stream{
 					req.streamName,
 					req.streamPriority,
 					req.limits.MessagesLimit,
 					req.limits.BytesLimit,
+					req.limits.Compression,
 				}
 				if chConnTerminated != nil {
 					pendingSelfStreamStateNotifications[req.streamID] = true
@@ -552,22 +617,27 @@ func peerLoop(
 			}
 
 		case req := <-chStreamUpdateLimitsRequest:
-			if oldS, ok := streams[req.streamID]; ok {
+			if oldS, ok := streams[req.streamID]; ok && oldS.compression != req.limits.Compression {
+				chStreamUpdateLimitsResponse <- peerStreamUpdateLimitsResponse{
+					fmt.Errorf("compression cannot be changed from %v to %v", oldS.compression, req.limits.Compression),
+				}
+			} else if ok {
 				s := stream{
 					oldS.name,
 					oldS.priority,
 					req.limits.MessagesLimit,
 					req.limits.BytesLimit,
+					oldS.compression,
 				}
 				streams[req.streamID] = s
 
-				if !mux.UpdateStream(req.streamID, req.limits.MaxOutgoingBufferedMessages) {
+				if !mux.UpdateStream(req.streamID, req.limits.MaxOutgoingBufferedMessages, req.limits.BandwidthShare) {
 					logger.Error("Assumption violation. Failed to update stream on muxer. This stream may not work correctly.", commontypes.LogFields{
 						"streamUpdateLimitsRequest": req,
 					})
 					// let's try to fix the problem by removing and adding the stream again
 					_ = mux.RemoveStream(req.streamID)
-					_ = mux.AddStream(req.streamID, s.name, s.priority, req.limits.MaxOutgoingBufferedMessages)
+					_ = mux.AddStream(req.streamID, s.name, s.priority, req.limits.MaxOutgoingBufferedMessages, req.limits.BandwidthShare)
 				}
 
 				if !demux.UpdateStream(
@@ -605,6 +675,7 @@ func peerLoop(
 				}
 
 				demux.RemoveStream(req.streamID)
+				compressions.setLocal(req.streamID, CompressionNone)
 
 				rateLimitAggregator.RemoveStream(s.messagesLimit, s.bytesLimit)
 				metrics.SetRateLimits(rateLimitAggregator.Aggregates())
@@ -661,9 +732,22 @@ func (ho *Host) ID() types.PeerID {
 	return ho.id
 }
 
+// AcceptsQUIC reports whether the host accepts QUIC connections, i.e. whether
+// HostConfig.EnableQUIC is set. Discoverers announce this to other peers.
+func (ho *Host) AcceptsQUIC() bool {
+	return ho.config.EnableQUIC
+}
+
+func (ho *Host) peerAcceptsQUIC(other types.PeerID) bool {
+	qd, ok := ho.discoverer.(QUICDiscoverer)
+	return ok && qd.AcceptsQUIC(other)
+}
+
 func (ho *Host) dialLoop() {
 	type dialState struct {
 		next uint
+		// QUIC dials to these addresses are skipped until the given time
+		quicUnavailableUntil map[string]time.Time
 	}
 	dialStates := make(map[types.PeerID]*dialState)
 	for {
@@ -673,7 +757,7 @@ func (ho *Host) dialLoop() {
 		for pid, p := range ho.peers {
 			peers = append(peers, p)
 			if dialStates[pid] == nil {
-				dialStates[pid] = &dialState{0}
+				dialStates[pid] = &dialState{0, map[string]time.Time{}}
 			}
 		}
 		// Some peers may have been discarded, garbage collect dial states
@@ -717,10 +801,24 @@ func (ho *Host) dialLoop() {
 
 				logger := p.logger.MakeChild(commontypes.LogFields{"direction": "out", "remoteAddr": address})
 
-				dialer := net.Dialer{
-					Timeout: ho.config.DurationBetweenDials,
+				if len(ho.quicTransports) != 0 && ho.config.Dialer == nil && !relay.IsAddress(types.Address(address)) && ho.peerAcceptsQUIC(p.other) && time.Now().After(ds.quicUnavailableUntil[address]) {
+					quicConn, err := ho.dialQUIC(p.other, address)
+					if err == nil {
+						delete(ds.quicUnavailableUntil, address)
+						logger.Trace("QUIC dial succeeded", nil)
+						ho.subprocesses.Go(func() {
+							ho.handleOutgoingQUICConnection(quicConn, p.other, logger)
+						})
+						return
+					}
+					logger.Debug("QUIC dial error, falling back to TCP", commontypes.LogFields{
+						"error":       err,
+						"retryQUICIn": quicRetryInterval,
+					})
+					ds.quicUnavailableUntil[address] = time.Now().Add(quicRetryInterval)
 				}
-				conn, err := dialer.DialContext(ho.ctx, "tcp", address)
+
+				conn, err := ho.dial(address, p.other)
 				if err != nil {
 					logger.Warn("Dial error", commontypes.LogFields{"error": err})
 					return
@@ -745,6 +843,23 @@ func (ho *Host) dialLoop() {
 	}
 }
 
+func (ho *Host) dial(address string, other types.PeerID) (net.Conn, error) {
+	if relay.IsAddress(types.Address(address)) {
+		ctx, cancel := context.WithTimeout(ho.ctx, ho.config.DurationBetweenDials)
+		defer cancel()
+		return relay.Dial(ctx, ho.config.Dialer, types.Address(address), other)
+	}
+	if ho.config.Dialer == nil {
+		dialer := net.Dialer{
+			Timeout: ho.config.DurationBetweenDials,
+		}
+		return dialer.DialContext(ho.ctx, "tcp", address)
+	}
+	ctx, cancel := context.WithTimeout(ho.ctx, ho.config.DurationBetweenDials)
+	defer cancel()
+	return ho.config.Dialer.DialContext(ctx, "tcp", address)
+}
+
 func (ho *Host) listenLoop(ln net.Listener) {
 	ho.subprocesses.Go(func() {
 		<-ho.ctx.Done()
@@ -922,23 +1037,55 @@ func (ho *Host) handleConnection(
 		return
 	}
 
+	overheadAwareConn.SetupComplete()
+
+	shouldClose = !ho.adoptAuthenticatedConnection(incoming, transportTCP, peer, logger, func(connCtx context.Context, chConnTerminated chan<- struct{}) {
+		authenticatedConnectionLoop(
+			connCtx,
+			overheadAwareConn,
+			tlsConn,
+			peer.chOtherStreamStateNotification,
+			peer.chSelfStreamStateNotification,
+			peer.mux,
+			peer.demux,
+			peer.compressions,
+			chConnTerminated,
+			logger,
+			peer.metrics,
+		)
+	})
+}
+
+// adoptAuthenticatedConnection makes an authenticated connection with peer the
+// peer's active connection, replacing any previous one. connLoop is run until
+// the connection terminates and must close chConnTerminated when it exits.
+// Returns false if the connection was not adopted, in which case the caller is
+// responsible for closing it.
+func (ho *Host) adoptAuthenticatedConnection(
+	incoming bool,
+	transport connTransport,
+	peer *peer,
+	logger loghelper.LoggerWithContext,
+	connLoop func(connCtx context.Context, chConnTerminated chan<- struct{}),
+) bool {
 	if incoming {
 		peer.incomingConnsLimiterMu.Lock()
 		allowed := peer.incomingConnsLimiter.RemoveTokens(1)
 		peer.incomingConnsLimiterMu.Unlock()
 		if !allowed {
 			logger.Warn("Incoming connection rate limited", nil)
-			return
+			return false
 		}
 	}
 
-	overheadAwareConn.SetupComplete()
-
-	logger.Info("Connection established", nil)
+	logger.Info("Connection established", commontypes.LogFields{"transport": transport})
 	peer.metrics.connEstablishedTotal.Inc()
 	if incoming {
 		peer.metrics.connEstablishedInboundTotal.Inc()
 	}
+	if transport == transportQUIC {
+		peer.metrics.connEstablishedQUICTotal.Inc()
+	}
 
 	// the lock here ensures there is at most one active connection at any time.
 	// it also prevents races on connLifeCycle.connSubs.
@@ -951,28 +1098,18 @@ func (ho *Host) handleConnection(
 	peer.connLifeCycle.chConnTerminated = chConnTerminated
 	peer.connLifeCycle.connSubs.Go(func() {
 		defer connCancel()
-		authenticatedConnectionLoop(
-			connCtx,
-			overheadAwareConn,
-			tlsConn,
-			peer.chOtherStreamStateNotification,
-			peer.chSelfStreamStateNotification,
-			peer.mux,
-			peer.demux,
-			chConnTerminated,
-			logger,
-			peer.metrics,
-		)
+		connLoop(connCtx, chConnTerminated)
 	})
 	peer.connLifeCycleMu.Unlock()
 
 	select {
 	case peer.chNewConnNotification <- newConnNotification{chConnTerminated}:
 		// keep the connection
-		shouldClose = false
+		return true
 	case <-peer.chDone:
 	case <-ho.ctx.Done():
 	}
+	return false
 }
 
 // NewStream creates a new bidirectional stream with peer other for streamName.
@@ -999,6 +1136,8 @@ func (ho *Host) NewStream(
 			maxMessageLength,
 			messagesLimit,
 			bytesLimit,
+			1,
+			CompressionNone,
 		},
 	)
 	if err != nil {
@@ -1029,6 +1168,16 @@ func (ho *Host) NewStream2(
 		return nil, fmt.Errorf("streamName '%v' is longer than maximum length %v", streamName, types.MaxStreamNameLength)
 	}
 
+	if limits.Compression != CompressionNone {
+		if err := compression.ValidateStreamName(streamName); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := ho.priorityWeights[priority]; !ok {
+		return nil, fmt.Errorf("priority %v has no weight in the host's PriorityWeights", priority)
+	}
+
 	validatedLimits, err := limits.Validate()
 	if err != nil {
 		return nil, err
@@ -1077,6 +1226,7 @@ func (ho *Host) NewStream2(
 
 		limits.MaxOutgoingBufferedMessages,
 		limits.MaxMessageLength,
+		limits.Compression,
 		ho,
 
 		subprocesses.Subprocesses{},
@@ -1105,6 +1255,9 @@ func (ho *Host) NewStream2(
 		"maxMessageLength":            limits.MaxMessageLength,
 		"messagesLimit":               limits.MessagesLimit,
 		"bytesLimit":                  limits.BytesLimit,
+		"priority":                    priority,
+		"bandwidthShare":              limits.BandwidthShare,
+		"compression":                 limits.Compression,
 	})
 
 	return &s, nil
@@ -1122,6 +1275,7 @@ func authenticatedConnectionLoop(
 	chSelfStreamStateNotification <-chan streamStateNotification,
 	mux *muxer.Muxer,
 	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
 	chConnTerminated chan<- struct{},
 	logger loghelper.LoggerWithContext,
 	metrics *peerMetrics,
@@ -1131,6 +1285,8 @@ func authenticatedConnectionLoop(
 		logger.Info("authenticatedConnectionLoop: exited", nil)
 	}()
 
+	compressions.resetRemote()
+
 	var subs subprocesses.Subprocesses
 	defer subs.Wait()
 
@@ -1151,6 +1307,7 @@ func authenticatedConnectionLoop(
 			conn,
 			chOtherStreamStateNotification,
 			demux,
+			compressions,
 			chReadTerminated,
 			logger,
 			metrics,
@@ -1165,6 +1322,7 @@ func authenticatedConnectionLoop(
 			chSelfStreamStateNotification,
 			mux,
 			demux, // added for request/response tracking
+			compressions,
 			chWriteTerminated,
 			logger,
 			metrics,
@@ -1186,6 +1344,7 @@ func authenticatedConnectionReadLoop(
 	conn net.Conn,
 	chOtherStreamStateNotification chan<- streamStateNotification,
 	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
 	chReadTerminated chan<- struct{},
 	logger loghelper.LoggerWithContext,
 	metrics *peerMetrics,
@@ -1226,6 +1385,24 @@ func authenticatedConnectionReadLoop(
 		return true
 	}
 
+	readFrames(ctx, readInternal, skipInternal, nil, chOtherStreamStateNotification, demux, compressions, logger)
+}
+
+// readFrames reads frames using readInternal and skipInternal and dispatches
+// them to the demuxer or chOtherStreamStateNotification. It returns once
+// reading fails, a frame is invalid, or ctx is done. If checkFrameHeader is not
+// nil, every frame header is passed to it before the frame is processed and
+// reading stops if it returns false. checkFrameHeader logs the reason itself.
+func readFrames(
+	ctx context.Context,
+	readInternal func(buf []byte) bool,
+	skipInternal func(n int) bool,
+	checkFrameHeader func(header frame.FrameHeader) bool,
+	chOtherStreamStateNotification chan<- streamStateNotification,
+	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
+	logger loghelper.LoggerWithContext,
+) {
 	// We taper some logs to prevent an adversary from spamming our logs
 	limitsExceededTaper := loghelper.LogarithmicTaper{}
 	// Note that we never reset this taper. There shouldn't be many messages
@@ -1319,6 +1496,77 @@ func authenticatedConnectionReadLoop(
 		return true
 	}
 
+	// readMessagePayloadInternal reads the payload of a message frame and
+	// decompresses it if necessary. Whether the payload starts with a
+	// compression prefix is taken from the frame header, not from the
+	// OpenStream frames received so far, since QUIC doesn't order the control
+	// stream against data streams. Limits are checked by shouldPush against
+	// the decompressed size before the payload is read. Returns a nil payload if
+	// the message has been dropped, and false if the connection should be
+	// closed.
+	readMessagePayloadInternal := func(header frame.FrameHeader, compressionPrefix bool, shouldPush func(payloadSize int) demuxer.ShouldPushResult) ([]byte, bool) {
+		remaining := header.GetPayloadSize()
+
+		c := CompressionNone
+		payloadSize := remaining
+		if compressionPrefix {
+			if remaining < 1 {
+				logWithHeaderInternal(header).Warn("authenticatedConnectionReadLoop: message lacks compression prefix, closing connection", nil)
+				return nil, false
+			}
+			var algorithm [1]byte
+			if !readInternal(algorithm[:]) {
+				return nil, false
+			}
+			remaining--
+			c = Compression(algorithm[0])
+			payloadSize = remaining
+			if c != CompressionNone {
+				if !compression.SupportedMask.Has(c) || remaining < compression.MaxPrefixSize-1 {
+					logWithHeaderInternal(header).Warn("authenticatedConnectionReadLoop: message has invalid compression prefix, closing connection", commontypes.LogFields{
+						"compression": c,
+					})
+					return nil, false
+				}
+				var size [compression.MaxPrefixSize - 1]byte
+				if !readInternal(size[:]) {
+					return nil, false
+				}
+				remaining -= len(size)
+				var err error
+				payloadSize, err = compression.DecodeSizePrefix(size[:])
+				if err != nil {
+					logWithHeaderInternal(header).Warn("authenticatedConnectionReadLoop: message has invalid compression prefix, closing connection", commontypes.LogFields{
+						"error": err,
+					})
+					return nil, false
+				}
+			}
+		}
+
+		demuxShouldPushResult := shouldPush(payloadSize)
+		if demuxShouldPushResult != demuxer.ShouldPushResultYes {
+			logNegativeDemuxResultInternal(header, demuxShouldPushResult)
+			return nil, skipInternal(remaining)
+		}
+
+		payload := make([]byte, remaining)
+		if !readInternal(payload) {
+			return nil, false
+		}
+		if c == CompressionNone {
+			return payload, true
+		}
+		payload, err := compressions.decompress(c, payload, payloadSize)
+		if err != nil {
+			logWithHeaderInternal(header).Warn("authenticatedConnectionReadLoop: invalid compressed message, closing connection", commontypes.LogFields{
+				"error": err,
+			})
+			return nil, false
+		}
+		return payload, true
+	}
+
 	frameHeaderReader := frame.MakeFrameHeaderReader(readInternal)
 
 	for {
@@ -1334,18 +1582,24 @@ func authenticatedConnectionReadLoop(
 			return
 		}
 
+		if checkFrameHeader != nil && !checkFrameHeader(header) {
+			return
+		}
+
 		switch header := header.(type) {
 		case frame.OpenStreamFrameHeader:
 			openCloseFramesReceived++
 
-			streamName := make([]byte, header.PayloadSize)
-			if !readInternal(streamName) {
+			payload := make([]byte, header.PayloadSize)
+			if !readInternal(payload) {
 				return
 			}
-			remoteStreamNameByID[header.StreamID] = string(streamName)
+			streamName, acceptedCompressions := compression.DecodeOpenStreamPayload(payload)
+			remoteStreamNameByID[header.StreamID] = streamName
+			compressions.setRemote(header.StreamID, acceptedCompressions)
 
 			select {
-			case chOtherStreamStateNotification <- streamStateNotification{header.StreamID, string(streamName), true}:
+			case chOtherStreamStateNotification <- streamStateNotification{header.StreamID, streamName, true, CompressionNone}:
 			case <-ctx.Done():
 				return
 			}
@@ -1357,8 +1611,9 @@ func authenticatedConnectionReadLoop(
 			openCloseFramesReceived++
 
 			delete(remoteStreamNameByID, header.StreamID)
+			compressions.setRemote(header.StreamID, 0)
 			select {
-			case chOtherStreamStateNotification <- streamStateNotification{header.StreamID, "", false}:
+			case chOtherStreamStateNotification <- streamStateNotification{header.StreamID, "", false, CompressionNone}:
 			case <-ctx.Done():
 				return
 			}
@@ -1367,60 +1622,45 @@ func authenticatedConnectionReadLoop(
 				return
 			}
 		case frame.MessagePlainFrameHeader:
-			demuxShouldPushResult := demux.ShouldPush(header.StreamID, header.PayloadSize)
-			switch demuxShouldPushResult {
-			case demuxer.ShouldPushResultMessagesLimitExceeded, demuxer.ShouldPushResultBytesLimitExceeded, demuxer.ShouldPushResultUnknownStream, demuxer.ShouldPushResultResponseRejected, demuxer.ShouldPushResultMessageTooBig:
-				logNegativeDemuxResultInternal(header, demuxShouldPushResult)
-				if !skipInternal(header.PayloadSize) {
-					return
-				}
-			case demuxer.ShouldPushResultYes:
-				payload := make([]byte, header.PayloadSize)
-				if !readInternal(payload) {
-					return
-				}
+			payload, ok := readMessagePayloadInternal(header, header.CompressionPrefix, func(payloadSize int) demuxer.ShouldPushResult {
+				return demux.ShouldPush(header.StreamID, payloadSize)
+			})
+			if !ok {
+				return
+			}
+			if payload != nil {
 				demuxPushInternal(
 					header,
 					InboundBinaryMessagePlain{payload},
 				)
 			}
 		case frame.MessageRequestFrameHeader:
-			demuxShouldPushResult := demux.ShouldPush(header.StreamID, header.PayloadSize)
-			switch demuxShouldPushResult {
-			case demuxer.ShouldPushResultMessagesLimitExceeded, demuxer.ShouldPushResultBytesLimitExceeded, demuxer.ShouldPushResultUnknownStream, demuxer.ShouldPushResultResponseRejected, demuxer.ShouldPushResultMessageTooBig:
-				logNegativeDemuxResultInternal(header, demuxShouldPushResult)
-				if !skipInternal(header.PayloadSize) {
-					return
-				}
-			case demuxer.ShouldPushResultYes:
-				payload := make([]byte, header.PayloadSize)
-				if !readInternal(payload) {
-					return
-				}
+			payload, ok := readMessagePayloadInternal(header, header.CompressionPrefix, func(payloadSize int) demuxer.ShouldPushResult {
+				return demux.ShouldPush(header.StreamID, payloadSize)
+			})
+			if !ok {
+				return
+			}
+			if payload != nil {
 				demuxPushInternal(
 					header,
 					InboundBinaryMessageRequest{RequestHandle(header.RequestID), payload},
 				)
 			}
 		case frame.MessageResponseFrameHeader:
-			demuxShouldPushResult := demux.ShouldPushResponse(header.StreamID, header.RequestID, header.PayloadSize)
-			switch demuxShouldPushResult {
-			case demuxer.ShouldPushResultMessagesLimitExceeded, demuxer.ShouldPushResultBytesLimitExceeded, demuxer.ShouldPushResultUnknownStream, demuxer.ShouldPushResultResponseRejected, demuxer.ShouldPushResultMessageTooBig:
-				logNegativeDemuxResultInternal(header, demuxShouldPushResult)
-				if !skipInternal(header.PayloadSize) {
-					return
-				}
-			case demuxer.ShouldPushResultYes:
+			payload, ok := readMessagePayloadInternal(header, header.CompressionPrefix, func(payloadSize int) demuxer.ShouldPushResult {
+				return demux.ShouldPushResponse(header.StreamID, header.RequestID, payloadSize)
+			})
+			if !ok {
+				return
+			}
+			if payload != nil {
 				limitsExceededTaper.Reset(func(oldCount uint64) {
 					logWithHeaderInternal(header).Info("authenticatedConnectionReadLoop: limits are no longer being exceeded", commontypes.LogFields{
 						"droppedCount": oldCount,
 					})
 				})
 
-				payload := make([]byte, header.PayloadSize)
-				if !readInternal(payload) {
-					return
-				}
 				demuxPushInternal(
 					header,
 					InboundBinaryMessageResponse{payload},
@@ -1438,6 +1678,7 @@ func authenticatedConnectionWriteLoop(
 	chSelfStreamStateNotification <-chan streamStateNotification,
 	mux *muxer.Muxer,
 	demux *demuxer.Demuxer,
+	compressions *streamCompressions,
 	chWriteTerminated chan<- struct{},
 	logger loghelper.LoggerWithContext,
 	metrics *peerMetrics,
@@ -1479,34 +1720,12 @@ func authenticatedConnectionWriteLoop(
 			return false
 		}
 
-		// The header's value is set based on the type of message in the switch statement below.
-		var header []byte
-		var payload []byte
-
-		switch m := message.(type) {
-		case OutboundBinaryMessageRequest:
-			requestID, err := internaltypes.MakeRandomRequestID()
-			if err != nil {
-				logger.Error("Error while sending request (failed to generate random request id)", commontypes.LogFields{
-					"error": err,
-				})
-				return false
-			}
-			demux.SetPolicy(streamID, requestID, m.ResponsePolicy)
-			header = frame.MessageRequestFrameHeader{streamID, len(m.Payload), requestID}.Encode()
-			payload = m.Payload
-
-		case OutboundBinaryMessageResponse:
-			header = frame.MessageResponseFrameHeader{
-				streamID,
-				len(m.Payload),
-				stream2types.RequestIDOfOutboundBinaryMessageResponse(m),
-			}.Encode()
-			payload = m.Payload
-
-		case OutboundBinaryMessagePlain:
-			header = frame.MessagePlainFrameHeader{streamID, len(m.Payload)}.Encode()
-			payload = m.Payload
+		header, payload, err := encodeMessage(streamID, message, demux, compressions)
+		if err != nil {
+			logger.Error("Error while sending request (failed to generate random request id)", commontypes.LogFields{
+				"error": err,
+			})
+			return false
 		}
 
 		if !writeTwoInternal(
@@ -1525,10 +1744,10 @@ func authenticatedConnectionWriteLoop(
 			return false
 		}
 		if notification.open {
-			streamName := []byte(notification.streamName)
+			payload := compression.EncodeOpenStreamPayload(notification.streamName, notification.compression)
 			if !writeTwoInternal(
-				frame.OpenStreamFrameHeader{notification.streamID, len(streamName)}.Encode(),
-				streamName,
+				frame.OpenStreamFrameHeader{notification.streamID, len(payload)}.Encode(),
+				payload,
 			) {
 				return false
 			}
@@ -1578,6 +1797,37 @@ func authenticatedConnectionWriteLoop(
 	}
 }
 
+// encodeMessage returns the frame header and payload for sending message on
+// streamID, compressing the payload if compression is in use on the stream.
+// For requests, it registers the response policy with demux.
+func encodeMessage(streamID internaltypes.StreamID, message OutboundBinaryMessage, demux *demuxer.Demuxer, compressions *streamCompressions) (header []byte, payload []byte, err error) {
+	var compressionPrefix bool
+	switch m := message.(type) {
+	case OutboundBinaryMessageRequest:
+		requestID, err := internaltypes.MakeRandomRequestID()
+		if err != nil {
+			return nil, nil, err
+		}
+		demux.SetPolicy(streamID, requestID, m.ResponsePolicy)
+		payload, compressionPrefix = compressions.encodePayload(streamID, m.Payload)
+		header = frame.MessageRequestFrameHeader{streamID, len(payload), requestID, compressionPrefix}.Encode()
+
+	case OutboundBinaryMessageResponse:
+		payload, compressionPrefix = compressions.encodePayload(streamID, m.Payload)
+		header = frame.MessageResponseFrameHeader{
+			streamID,
+			len(payload),
+			stream2types.RequestIDOfOutboundBinaryMessageResponse(m),
+			compressionPrefix,
+		}.Encode()
+
+	case OutboundBinaryMessagePlain:
+		payload, compressionPrefix = compressions.encodePayload(streamID, m.Payload)
+		header = frame.MessagePlainFrameHeader{streamID, len(payload), compressionPrefix}.Encode()
+	}
+	return header, payload, nil
+}
+
 // gotta be careful about closing tls connections to make sure we don't get
 // tarpitted
 func safeClose(conn net.Conn) error {
@@ -1610,3 +1860,12 @@ type Discoverer interface {
 	// The order of the returned addresses matters because the addresses are tried in order.
 	FindPeer(peer types.PeerID) ([]types.Address, error)
 }
+
+// QUICDiscoverer is optionally implemented by a Discoverer that learns whether
+// peers accept QUIC connections. The host only dials peers via QUIC if its
+// Discoverer reports that they do.
+type QUICDiscoverer interface {
+	// AcceptsQUIC reports whether peer has announced that it accepts QUIC
+	// connections.
+	AcceptsQUIC(peer types.PeerID) bool
+}
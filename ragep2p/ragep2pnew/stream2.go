@@ -7,6 +7,7 @@ import (
 
 	"github.com/smartcontractkit/libocr/commontypes"
 	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/compression"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/demuxer"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/internaltypes"
 	"github.com/smartcontractkit/libocr/ragep2p/ragep2pnew/internal/muxer"
@@ -23,6 +24,12 @@ const (
 	StreamPriorityDefault = stream2types.StreamPriorityDefault
 )
 
+type PriorityWeights = stream2types.PriorityWeights
+
+func DefaultPriorityWeights() PriorityWeights {
+	return stream2types.DefaultPriorityWeights()
+}
+
 type Stream2 interface {
 	Other() types.PeerID
 	Name() string
@@ -48,6 +55,7 @@ type stream2 struct {
 
 	maxOutgoingBufferedMessages int
 	maxMessageLength            int
+	compression                 Compression
 
 	host *Host
 
@@ -79,22 +87,28 @@ func (st *stream2) Name() string {
 
 // Best effort sending of messages. May fail without returning an error.
 func (st *stream2) Send(msg OutboundBinaryMessage) {
+	// Compression adds a prefix to the payload, which must still fit into a frame
+	globalMaxMessageLength := types.MaxMessageLength
+	if st.compression != CompressionNone {
+		globalMaxMessageLength -= compression.MaxPrefixSize
+	}
+
 	var (
 		ok            bool
 		payloadLength int
 	)
 	switch msg := msg.(type) {
 	case OutboundBinaryMessagePlain:
-		ok = len(msg.Payload) <= st.maxMessageLength
+		ok = len(msg.Payload) <= min(st.maxMessageLength, globalMaxMessageLength)
 		payloadLength = len(msg.Payload)
 	case OutboundBinaryMessageRequest:
-		ok = len(msg.Payload) <= st.maxMessageLength
+		ok = len(msg.Payload) <= min(st.maxMessageLength, globalMaxMessageLength)
 		payloadLength = len(msg.Payload)
 	case OutboundBinaryMessageResponse:
 		// Response size is limited by the policy of the corresponding request
 		// and may exceed the stream's default max message length.
 		// Responses must never exceed the global ragep2p max message length.
-		ok = len(msg.Payload) <= types.MaxMessageLength
+		ok = len(msg.Payload) <= globalMaxMessageLength
 		payloadLength = len(msg.Payload)
 	default:
 		panic(fmt.Sprintf("unknown OutboundBinaryMessage type: %T", msg))
@@ -104,7 +118,7 @@ func (st *stream2) Send(msg OutboundBinaryMessage) {
 		st.logger.Warn("dropping outbound message that is too large", commontypes.LogFields{
 			"messagePayloadLength":    payloadLength,
 			"streamMaxMessageLength":  st.maxMessageLength,
-			"ragep2pMaxMessageLength": types.MaxMessageLength,
+			"ragep2pMaxMessageLength": globalMaxMessageLength,
 		})
 		return
 	}
@@ -0,0 +1,60 @@
+package relay
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/smartcontractkit/libocr/ragep2p/types"
+)
+
+const addressPrefix = "relay:"
+
+// NewAddress returns the address under which a peer holding a reservation at
+// the relay with peer ID relayID, listening at relayAddress (host:port), can
+// be reached. Its form is "relay:<relayID>@<relayAddress>".
+func NewAddress(relayID types.PeerID, relayAddress string) types.Address {
+	return types.Address(addressPrefix + relayID.String() + "@" + relayAddress)
+}
+
+// IsAddress returns true if a is a relay address. It doesn't check that a is
+// well-formed, use ParseAddress for that.
+func IsAddress(a types.Address) bool {
+	return strings.HasPrefix(string(a), addressPrefix)
+}
+
+// ParseAddress splits a relay address into the relay's peer ID and network
+// address.
+func ParseAddress(a types.Address) (relayID types.PeerID, relayAddress string, err error) {
+	s, ok := strings.CutPrefix(string(a), addressPrefix)
+	if !ok {
+		return types.PeerID{}, "", fmt.Errorf("address %q is not a relay address", a)
+	}
+	idStr, relayAddress, ok := strings.Cut(s, "@")
+	if !ok {
+		return types.PeerID{}, "", fmt.Errorf("relay address %q is missing '@'", a)
+	}
+	if err := relayID.UnmarshalText([]byte(idStr)); err != nil {
+		return types.PeerID{}, "", fmt.Errorf("relay address %q contains invalid peer ID: %w", a, err)
+	}
+	if _, _, err := net.SplitHostPort(relayAddress); err != nil {
+		return types.PeerID{}, "", fmt.Errorf("relay address %q contains invalid network address: %w", a, err)
+	}
+	return relayID, relayAddress, nil
+}
+
+// Addr is the net.Addr of connections established through a relay.
+type Addr struct {
+	Relay   types.PeerID
+	Address string
+}
+
+var _ net.Addr = Addr{}
+
+func (a Addr) Network() string {
+	return "relay"
+}
+
+func (a Addr) String() string {
+	return string(NewAddress(a.Relay, a.Address))
+}
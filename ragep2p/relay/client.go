@@ -0,0 +1,276 @@
+package relay
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/ragep2p/internal/mtls"
+	"github.com/smartcontractkit/libocr/ragep2p/types"
+	"github.com/smartcontractkit/libocr/subprocesses"
+)
+
+// Minimum time between attempts to (re-)establish a reservation with a relay
+const reservationRetryInterval = 5 * time.Second
+
+// Listener holds reservations at relays and accepts connections that other
+// peers establish through them. It is meant for peers that can't accept
+// inbound connections, e.g. because they are behind a NAT or firewall.
+type Listener struct {
+	relays     []types.PeerInfo
+	tlsCert    tls.Certificate
+	dialer     types.Dialer
+	logger     loghelper.LoggerWithContext
+	chAccepted chan net.Conn
+
+	subprocesses subprocesses.Subprocesses
+	ctx          context.Context
+	cancel       context.CancelFunc
+}
+
+var _ net.Listener = &Listener{}
+
+// Listen starts holding reservations at relays, authenticating with the key in
+// keyring. Connections to relays are established using dialer, or directly if
+// dialer is nil. The peer should announce the addresses returned by
+// Addresses so that other peers can connect to it.
+func Listen(relays []types.PeerInfo, keyring types.PeerKeyring, dialer types.Dialer, logger commontypes.Logger) (*Listener, error) {
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no relays provided")
+	}
+	for _, r := range relays {
+		if len(r.Addrs) == 0 {
+			return nil, fmt.Errorf("relay %v has no addresses", r.ID)
+		}
+		for _, a := range r.Addrs {
+			if _, _, err := net.SplitHostPort(string(a)); err != nil {
+				return nil, fmt.Errorf("relay %v has invalid address %q: %w", r.ID, a, err)
+			}
+		}
+	}
+
+	tlsCert, err := mtls.NewMinimalX509CertFromKeyring(keyring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate from keyring for relay listener: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Listener{
+		relays,
+		tlsCert,
+		dialer,
+		loghelper.MakeRootLoggerWithContext(logger).MakeChild(commontypes.LogFields{
+			"id":     "RelayListener",
+			"peerID": types.PeerIDFromKeyring(keyring),
+		}),
+		make(chan net.Conn),
+
+		subprocesses.Subprocesses{},
+		ctx,
+		cancel,
+	}
+	for _, r := range relays {
+		l.subprocesses.Go(func() {
+			l.reserveLoop(r)
+		})
+	}
+	return l, nil
+}
+
+// Addresses returns the relay addresses under which the peer can be reached.
+func (l *Listener) Addresses() []types.Address {
+	var addrs []types.Address
+	for _, r := range l.relays {
+		for _, a := range r.Addrs {
+			addrs = append(addrs, NewAddress(r.ID, string(a)))
+		}
+	}
+	return addrs
+}
+
+// Accept waits for the next connection established through a relay. The
+// connection's RemoteAddr is an Addr.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.chAccepted:
+		return conn, nil
+	case <-l.ctx.Done():
+		return nil, net.ErrClosed
+	}
+}
+
+// Close gives up all reservations. Connections that have already been
+// accepted are unaffected. Close may be called multiple times.
+func (l *Listener) Close() error {
+	l.cancel()
+	l.subprocesses.Wait()
+	return nil
+}
+
+// Addr returns the Addr of the first relay.
+func (l *Listener) Addr() net.Addr {
+	return Addr{l.relays[0].ID, string(l.relays[0].Addrs[0])}
+}
+
+func (l *Listener) reserveLoop(relay types.PeerInfo) {
+	for next := 0; ; next++ {
+		address := string(relay.Addrs[next%len(relay.Addrs)])
+		logger := l.logger.MakeChild(commontypes.LogFields{"relayPeerID": relay.ID, "relayAddr": address})
+
+		err := l.reserve(relay.ID, address, logger)
+		if l.ctx.Err() != nil {
+			return
+		}
+		logger.Warn("Reservation failed, retrying", commontypes.LogFields{"error": err})
+
+		jitter := time.Duration(rand.Float32() * float32(reservationRetryInterval))
+		select {
+		case <-time.After(reservationRetryInterval + jitter):
+		case <-l.ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *Listener) reserve(relayID types.PeerID, address string, logger loghelper.LoggerWithContext) error {
+	dialCtx, cancelDial := context.WithTimeout(l.ctx, netTimeout)
+	conn, err := dialContext(dialCtx, l.dialer, address)
+	cancelDial()
+	if err != nil {
+		return fmt.Errorf("failed to dial relay: %w", err)
+	}
+	stop := context.AfterFunc(l.ctx, func() {
+		_ = conn.Close()
+	})
+	defer func() {
+		stop()
+		_ = conn.Close()
+	}()
+
+	if err := writeRequest(conn, requestReserve, nil); err != nil {
+		return fmt.Errorf("failed to write reserve request: %w", err)
+	}
+	tlsConn := tls.Client(conn, newTLSConfig(l.tlsCert, mtls.VerifyCertMatchesPubKey(relayID)))
+	handshakeCtx, cancelHandshake := context.WithTimeout(l.ctx, netTimeout)
+	err = tlsConn.HandshakeContext(handshakeCtx)
+	cancelHandshake()
+	if err != nil {
+		return fmt.Errorf("TLS handshake with relay failed: %w", err)
+	}
+
+	logger.Info("Reservation established", nil)
+
+	for {
+		if err := tlsConn.SetReadDeadline(time.Now().Add(reservationTimeout)); err != nil {
+			return err
+		}
+		var notification [1]byte
+		if _, err := io.ReadFull(tlsConn, notification[:]); err != nil {
+			return fmt.Errorf("failed to read notification: %w", err)
+		}
+		switch notification[0] {
+		case notificationPing:
+		case notificationCircuit:
+			var id circuitID
+			if _, err := io.ReadFull(tlsConn, id[:]); err != nil {
+				return fmt.Errorf("failed to read circuit ID: %w", err)
+			}
+			l.subprocesses.Go(func() {
+				l.accept(relayID, address, id, logger)
+			})
+		default:
+			return fmt.Errorf("unknown notification type %d", notification[0])
+		}
+	}
+}
+
+func (l *Listener) accept(relayID types.PeerID, address string, id circuitID, logger loghelper.LoggerWithContext) {
+	dialCtx, cancelDial := context.WithTimeout(l.ctx, netTimeout)
+	conn, err := dialContext(dialCtx, l.dialer, address)
+	cancelDial()
+	if err != nil {
+		logger.Warn("Failed to dial relay to accept circuit", commontypes.LogFields{"error": err})
+		return
+	}
+	shouldClose := true
+	defer func() {
+		if shouldClose {
+			_ = conn.Close()
+		}
+	}()
+
+	if err := writeRequest(conn, requestAccept, id[:]); err != nil {
+		logger.Warn("Failed to write accept request", commontypes.LogFields{"error": err})
+		return
+	}
+	if err := readStatus(conn, time.Now().Add(netTimeout)); err != nil {
+		logger.Warn("Relay did not accept circuit", commontypes.LogFields{"error": err})
+		return
+	}
+
+	select {
+	case l.chAccepted <- &relayedConn{conn, Addr{relayID, address}}:
+		shouldClose = false
+	case <-l.ctx.Done():
+	}
+}
+
+// Dial connects to the peer target through the relay at address, a relay
+// address as returned by NewAddress. The connection to the relay is
+// established using dialer, or directly if dialer is nil. The returned
+// connection is a plain byte stream to target: the caller must authenticate
+// target and encrypt traffic end to end.
+func Dial(ctx context.Context, dialer types.Dialer, address types.Address, target types.PeerID) (net.Conn, error) {
+	relayID, relayAddress, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialContext(ctx, dialer, relayAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial relay: %w", err)
+	}
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			_ = conn.Close()
+		}
+	}()
+
+	if err := writeRequest(conn, requestConnect, target[:]); err != nil {
+		return nil, fmt.Errorf("failed to write connect request: %w", err)
+	}
+
+	deadline := time.Now().Add(circuitAcceptTimeout + netTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Unix(1, 0))
+	})
+	err = readStatus(conn, deadline)
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	succeeded = true
+	return &relayedConn{conn, Addr{relayID, relayAddress}}, nil
+}
+
+type relayedConn struct {
+	net.Conn
+	remoteAddr Addr
+}
+
+func (c *relayedConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
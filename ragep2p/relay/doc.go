@@ -0,0 +1,15 @@
+// Package relay lets ragep2p peers that can't accept inbound connections,
+// e.g. because they are behind a strict NAT or firewall, be reached through a
+// relay such as a bootstrapper.
+//
+// A peer without inbound reachability holds a reservation at one or more
+// relays using a Listener and announces the corresponding relay addresses
+// (see NewAddress) via peer discovery. Other peers Dial such an address to
+// obtain a circuit: the relay splices their connection with one opened by the
+// target. The two peers then perform ragep2p's usual knock and mutually
+// authenticated TLS handshake end to end over the circuit, so the relay never
+// sees plaintext and can't impersonate either peer.
+//
+// Relay addresses are only understood by peers running a version of ragedisco
+// that supports them; older peers reject announcements containing them.
+package relay
@@ -0,0 +1,169 @@
+package relay
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/libocr/ragep2p/types"
+)
+
+// Every connection to a relay starts with protocolMagic followed by a one byte
+// request type:
+//
+//   - requestReserve: the client runs a TLS handshake as TLS client, with the
+//     relay authenticating the client by its peer key. Afterwards, the relay
+//     sends notifications over the TLS connection: notificationPing to keep the
+//     reservation alive, and notificationCircuit followed by a circuit ID when
+//     another peer wants to connect to the client.
+//   - requestConnect, followed by the target's public key: the relay notifies
+//     the target, which accepts the circuit on a new connection. The relay
+//     responds with a status byte.
+//   - requestAccept, followed by a circuit ID received in a
+//     notificationCircuit: the relay responds with a status byte.
+//
+// On statusOK, the relay splices the connect and accept connections together.
+// The peers then run ragep2p's knock and TLS handshake end to end, so the
+// relay only ever forwards ciphertext.
+const protocolMagic = "ragep2p relay v1"
+
+const (
+	_ byte = iota
+	requestReserve
+	requestConnect
+	requestAccept
+)
+
+const (
+	notificationPing byte = iota
+	notificationCircuit
+)
+
+const (
+	statusOK byte = iota
+	statusNoReservation
+	statusRejected
+	statusTimeout
+)
+
+const circuitIDSize = 16
+
+type circuitID [circuitIDSize]byte
+
+const (
+	// Timeout for handshakes and individual writes
+	netTimeout = 10 * time.Second
+	// How often the relay pings reservations. Clients consider a reservation
+	// dead if they don't hear from the relay for reservationTimeout.
+	pingInterval       = 15 * time.Second
+	reservationTimeout = 3 * pingInterval
+	// How long the relay waits for the target to accept a circuit
+	circuitAcceptTimeout = 10 * time.Second
+)
+
+func statusError(status byte) error {
+	switch status {
+	case statusOK:
+		return nil
+	case statusNoReservation:
+		return fmt.Errorf("relay has no reservation for target")
+	case statusRejected:
+		return fmt.Errorf("relay rejected request")
+	case statusTimeout:
+		return fmt.Errorf("target did not accept circuit in time")
+	default:
+		return fmt.Errorf("relay responded with unknown status %d", status)
+	}
+}
+
+func writeRequest(conn net.Conn, requestType byte, payload []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(netTimeout)); err != nil {
+		return err
+	}
+	msg := make([]byte, 0, len(protocolMagic)+1+len(payload))
+	msg = append(msg, protocolMagic...)
+	msg = append(msg, requestType)
+	msg = append(msg, payload...)
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+	return conn.SetWriteDeadline(time.Time{})
+}
+
+func writeStatus(conn net.Conn, status byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(netTimeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write([]byte{status}); err != nil {
+		return err
+	}
+	return conn.SetWriteDeadline(time.Time{})
+}
+
+// readStatus reads a status byte, waiting at most until deadline.
+func readStatus(conn net.Conn, deadline time.Time) error {
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	var status [1]byte
+	if _, err := io.ReadFull(conn, status[:]); err != nil {
+		return fmt.Errorf("failed to read relay status: %w", err)
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+	return statusError(status[0])
+}
+
+func newTLSConfig(cert tls.Certificate, verifyPeerCertificate func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+
+		// Since peers use self-signed certs, we skip verification here.
+		// Instead, we use VerifyPeerCertificate for our own check
+		InsecureSkipVerify: true,
+
+		MaxVersion: tls.VersionTLS13,
+		MinVersion: tls.VersionTLS13,
+
+		VerifyPeerCertificate: verifyPeerCertificate,
+	}
+}
+
+// splice forwards data between a and b until either side fails, then closes
+// both.
+func splice(a, b net.Conn) {
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			_ = a.Close()
+			_ = b.Close()
+		})
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(a, b)
+		closeBoth()
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(b, a)
+		closeBoth()
+	}()
+	wg.Wait()
+}
+
+func dialContext(ctx context.Context, dialer types.Dialer, address string) (net.Conn, error) {
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	return dialer.DialContext(ctx, "tcp", address)
+}
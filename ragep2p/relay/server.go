@@ -0,0 +1,377 @@
+package relay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/smartcontractkit/libocr/commontypes"
+	"github.com/smartcontractkit/libocr/internal/loghelper"
+	"github.com/smartcontractkit/libocr/ragep2p/internal/mtls"
+	"github.com/smartcontractkit/libocr/ragep2p/types"
+	"github.com/smartcontractkit/libocr/subprocesses"
+)
+
+const (
+	maxReservations           = 1000
+	maxCircuitsPerReservation = 32
+)
+
+// Server relays connections to peers that hold a reservation with it. It only
+// splices TCP connections together and never sees plaintext ragep2p traffic.
+type Server struct {
+	keyring         types.PeerKeyring
+	listenAddresses []string
+	logger          loghelper.LoggerWithContext
+
+	tlsCert tls.Certificate
+
+	mu           sync.Mutex
+	started      bool
+	reservations map[types.PeerID]*reservation
+	pending      map[circuitID]chan<- net.Conn
+
+	subprocesses subprocesses.Subprocesses
+	ctx          context.Context
+	cancel       context.CancelFunc
+}
+
+type reservation struct {
+	circuits int
+	chNotify chan circuitID
+	cancel   context.CancelFunc
+}
+
+// NewServer creates a relay Server that will listen on listenAddresses once
+// started. The relay identifies itself to peers holding reservations with the
+// key in keyring.
+func NewServer(keyring types.PeerKeyring, listenAddresses []string, logger commontypes.Logger) (*Server, error) {
+	if len(listenAddresses) == 0 {
+		return nil, fmt.Errorf("no listen addresses provided")
+	}
+
+	tlsCert, err := mtls.NewMinimalX509CertFromKeyring(keyring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate from keyring for relay: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		keyring,
+		listenAddresses,
+		loghelper.MakeRootLoggerWithContext(logger).MakeChild(commontypes.LogFields{
+			"id":     "RelayServer",
+			"peerID": types.PeerIDFromKeyring(keyring),
+		}),
+
+		tlsCert,
+
+		sync.Mutex{},
+		false,
+		map[types.PeerID]*reservation{},
+		map[circuitID]chan<- net.Conn{},
+
+		subprocesses.Subprocesses{},
+		ctx,
+		cancel,
+	}, nil
+}
+
+// Start listening on the network interfaces.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("cannot Start() relay server that has already been started")
+	}
+	s.started = true
+
+	var listeners []net.Listener
+	for _, addr := range s.listenAddresses {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, ln := range listeners {
+				_ = ln.Close()
+			}
+			return fmt.Errorf("net.Listen(%q) failed: %w", addr, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	for _, ln := range listeners {
+		s.subprocesses.Go(func() {
+			s.listenLoop(ln)
+		})
+	}
+	s.logger.Info("Relay server started", commontypes.LogFields{"listenAddresses": s.listenAddresses})
+	return nil
+}
+
+// Close stops listening and closes all reservations and relayed connections.
+func (s *Server) Close() error {
+	s.cancel()
+	s.subprocesses.Wait()
+	return nil
+}
+
+func (s *Server) listenLoop(ln net.Listener) {
+	s.subprocesses.Go(func() {
+		<-s.ctx.Done()
+		if err := ln.Close(); err != nil {
+			s.logger.Warn("Failed to close listener", commontypes.LogFields{"error": err})
+		}
+	})
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.logger.Info("Exiting Server.listenLoop due to error while Accepting", commontypes.LogFields{"error": err})
+			return
+		}
+		s.subprocesses.Go(func() {
+			s.handleConnection(conn)
+		})
+	}
+}
+
+func (s *Server) handleConnection(conn net.Conn) {
+	logger := s.logger.MakeChild(commontypes.LogFields{"remoteAddr": conn.RemoteAddr()})
+	shouldClose := true
+	stop := context.AfterFunc(s.ctx, func() {
+		_ = conn.Close()
+	})
+	defer func() {
+		stop()
+		if shouldClose {
+			_ = conn.Close()
+		}
+	}()
+
+	if err := conn.SetReadDeadline(time.Now().Add(netTimeout)); err != nil {
+		logger.Warn("Closing connection, error during SetReadDeadline", commontypes.LogFields{"error": err})
+		return
+	}
+	header := make([]byte, len(protocolMagic)+1)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		logger.Debug("Error while reading request", commontypes.LogFields{"error": err})
+		return
+	}
+	if string(header[:len(protocolMagic)]) != protocolMagic {
+		logger.Debug("Invalid request magic, closing connection", nil)
+		return
+	}
+
+	switch header[len(protocolMagic)] {
+	case requestReserve:
+		if err := conn.SetReadDeadline(time.Time{}); err != nil {
+			return
+		}
+		s.handleReserve(conn, logger)
+	case requestConnect:
+		var target types.PeerPublicKey
+		if _, err := io.ReadFull(conn, target[:]); err != nil {
+			logger.Debug("Error while reading connect request", commontypes.LogFields{"error": err})
+			return
+		}
+		if err := conn.SetReadDeadline(time.Time{}); err != nil {
+			return
+		}
+		s.handleConnect(conn, types.PeerIDFromPeerPublicKey(target), logger)
+	case requestAccept:
+		var id circuitID
+		if _, err := io.ReadFull(conn, id[:]); err != nil {
+			logger.Debug("Error while reading accept request", commontypes.LogFields{"error": err})
+			return
+		}
+		if err := conn.SetReadDeadline(time.Time{}); err != nil {
+			return
+		}
+		// On success, ownership of conn passes to the handleConnect call that
+		// is waiting for it
+		shouldClose = !s.handleAccept(conn, id)
+	default:
+		logger.Debug("Unknown request type, closing connection", commontypes.LogFields{"type": header[len(protocolMagic)]})
+	}
+}
+
+func (s *Server) handleReserve(conn net.Conn, logger loghelper.LoggerWithContext) {
+	var clientPublicKey types.PeerPublicKey
+	tlsConn := tls.Server(conn, newTLSConfig(s.tlsCert, func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) != 1 {
+			return fmt.Errorf("required exactly one client certificate")
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		clientPublicKey, err = mtls.PubKeyFromCert(cert)
+		return err
+	}))
+	handshakeCtx, cancelHandshake := context.WithTimeout(s.ctx, netTimeout)
+	err := tlsConn.HandshakeContext(handshakeCtx)
+	cancelHandshake()
+	if err != nil {
+		logger.Debug("Reservation TLS handshake failed", commontypes.LogFields{"error": err})
+		return
+	}
+
+	peerID := types.PeerIDFromPeerPublicKey(clientPublicKey)
+	logger = logger.MakeChild(commontypes.LogFields{"remotePeerID": peerID})
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	r := &reservation{0, make(chan circuitID, maxCircuitsPerReservation), cancel}
+
+	s.mu.Lock()
+	if old, ok := s.reservations[peerID]; ok {
+		// The peer has likely reconnected, the new reservation supersedes the old one
+		old.cancel()
+	} else if len(s.reservations) >= maxReservations {
+		s.mu.Unlock()
+		logger.Warn("Too many reservations, rejecting reservation", commontypes.LogFields{"maxReservations": maxReservations})
+		return
+	}
+	s.reservations[peerID] = r
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if s.reservations[peerID] == r {
+			delete(s.reservations, peerID)
+		}
+		s.mu.Unlock()
+	}()
+
+	logger.Info("Reservation established", nil)
+	defer logger.Info("Reservation ended", nil)
+
+	// The client never sends anything after the handshake, reading only
+	// serves to detect when it goes away.
+	s.subprocesses.Go(func() {
+		_, _ = io.Copy(io.Discard, tlsConn)
+		cancel()
+	})
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		var notification []byte
+		select {
+		case <-ticker.C:
+			notification = []byte{notificationPing}
+		case id := <-r.chNotify:
+			notification = append([]byte{notificationCircuit}, id[:]...)
+		case <-ctx.Done():
+			return
+		}
+		if err := tlsConn.SetWriteDeadline(time.Now().Add(netTimeout)); err != nil {
+			return
+		}
+		if _, err := tlsConn.Write(notification); err != nil {
+			logger.Debug("Error while writing notification", commontypes.LogFields{"error": err})
+			return
+		}
+	}
+}
+
+func (s *Server) handleConnect(conn net.Conn, target types.PeerID, logger loghelper.LoggerWithContext) {
+	logger = logger.MakeChild(commontypes.LogFields{"targetPeerID": target})
+
+	var id circuitID
+	if _, err := rand.Read(id[:]); err != nil {
+		logger.Error("Failed to generate circuit ID", commontypes.LogFields{"error": err})
+		return
+	}
+	chAccepted := make(chan net.Conn, 1)
+
+	s.mu.Lock()
+	r, ok := s.reservations[target]
+	if !ok {
+		s.mu.Unlock()
+		_ = writeStatus(conn, statusNoReservation)
+		return
+	}
+	if r.circuits >= maxCircuitsPerReservation {
+		s.mu.Unlock()
+		logger.Debug("Too many circuits for target, rejecting", nil)
+		_ = writeStatus(conn, statusRejected)
+		return
+	}
+	select {
+	case r.chNotify <- id:
+	default:
+		s.mu.Unlock()
+		_ = writeStatus(conn, statusRejected)
+		return
+	}
+	r.circuits++
+	s.pending[id] = chAccepted
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		r.circuits--
+		s.mu.Unlock()
+	}()
+
+	var other net.Conn
+	timer := time.NewTimer(circuitAcceptTimeout)
+	defer timer.Stop()
+	select {
+	case other = <-chAccepted:
+	case <-timer.C:
+	case <-s.ctx.Done():
+	}
+	if other == nil {
+		s.mu.Lock()
+		_, stillPending := s.pending[id]
+		delete(s.pending, id)
+		s.mu.Unlock()
+		if stillPending {
+			_ = writeStatus(conn, statusTimeout)
+			return
+		}
+		// handleAccept won the race, chAccepted is ready
+		other = <-chAccepted
+	}
+
+	stop := context.AfterFunc(s.ctx, func() {
+		_ = other.Close()
+	})
+	defer stop()
+
+	if err := writeStatus(other, statusOK); err != nil {
+		_ = other.Close()
+		return
+	}
+	if err := writeStatus(conn, statusOK); err != nil {
+		_ = other.Close()
+		return
+	}
+
+	logger.Debug("Relaying circuit", nil)
+	splice(conn, other)
+	logger.Debug("Circuit closed", nil)
+}
+
+// handleAccept returns true if conn has been handed off to the matching
+// handleConnect call.
+func (s *Server) handleAccept(conn net.Conn, id circuitID) bool {
+	s.mu.Lock()
+	chAccepted, ok := s.pending[id]
+	delete(s.pending, id)
+	if ok {
+		// Never blocks, chAccepted has capacity 1 and is only sent to once
+		chAccepted <- conn
+	}
+	s.mu.Unlock()
+	if !ok {
+		_ = writeStatus(conn, statusRejected)
+	}
+	return ok
+}
@@ -2,10 +2,13 @@ package types
 
 import (
 	"bytes"
+	"context"
 	"crypto"
 	"crypto/ed25519"
 	"encoding"
 	"fmt"
+	"net"
+	"time"
 
 	"github.com/mr-tron/base58"
 )
@@ -111,6 +114,15 @@ func PeerIDFromKeyring(keyring PeerKeyring) PeerID {
 	return PeerIDFromPeerPublicKey(keyring.PublicKey())
 }
 
+// A KeyRotation records that the peer known as OldPeerID has moved to the key
+// of NewPeerID. Until ValidUntil, the peer may authenticate with either key.
+// Hosts connect to both PeerIDs over a single connection.
+type KeyRotation struct {
+	OldPeerID  PeerID
+	NewPeerID  PeerID
+	ValidUntil time.Time
+}
+
 type PeerInfo struct {
 	ID    PeerID
 	Addrs []Address
@@ -140,6 +152,13 @@ type TokenBucketParams struct {
 	Capacity uint32
 }
 
+// Dialer establishes outgoing connections to other peers. *net.Dialer
+// implements Dialer. See package proxydialer for Dialers that connect through
+// proxies.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
 // The maximum number of peers we can have across all concurrent streams on a host.
 // The exact number is chosen arbitrarily. Better to have an arbitrary limit
 // than no limit.
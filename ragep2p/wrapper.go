@@ -1,6 +1,8 @@
 package ragep2p
 
 import (
+	"fmt"
+
 	"github.com/smartcontractkit/libocr/networking/ragep2pwrapper"
 	"github.com/smartcontractkit/libocr/ragep2p/types"
 )
@@ -53,6 +55,55 @@ func (h *hostWrapper) RawWrappee() any {
 	return h.host
 }
 
+// WrappedPreviousIdentity returns a view of host in which we identify
+// ourselves by the previous PeerID from HostConfig.PreviousKeyring. Streams
+// created through it share their peers and connections with the streams of
+// host. Start and Close are no-ops, host must be started and closed directly.
+func WrappedPreviousIdentity(host *Host) ragep2pwrapper.Host {
+	return &previousIdentityHostWrapper{host}
+}
+
+var _ ragep2pwrapper.Host = &previousIdentityHostWrapper{}
+
+type previousIdentityHostWrapper struct {
+	host *Host
+}
+
+func (h *previousIdentityHostWrapper) Start() error {
+	return nil
+}
+
+func (h *previousIdentityHostWrapper) Close() error {
+	return nil
+}
+
+func (h *previousIdentityHostWrapper) ID() types.PeerID {
+	return h.host.previousID
+}
+
+func (h *previousIdentityHostWrapper) NewStream(
+	other types.PeerID,
+	streamName string,
+	outgoingBufferSize int,
+	incomingBufferSize int,
+	maxMessageLength int,
+	messagesLimit types.TokenBucketParams,
+	bytesLimit types.TokenBucketParams,
+) (ragep2pwrapper.Stream, error) {
+	if h.host.config.PreviousKeyring == nil {
+		return nil, fmt.Errorf("host has no previous identity")
+	}
+	stream, err := h.host.newStream(h.host.previousID, other, streamName, outgoingBufferSize, incomingBufferSize, maxMessageLength, messagesLimit, bytesLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &streamWrapper{stream}, nil
+}
+
+func (h *previousIdentityHostWrapper) RawWrappee() any {
+	return h.host
+}
+
 func (s *streamWrapper) Other() types.PeerID {
 	return s.stream.Other()
 }